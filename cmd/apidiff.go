@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/apidiff"
+	"github.com/disconnekt/goreview/internal/blame"
+	"github.com/disconnekt/goreview/internal/reviewer"
+	"github.com/disconnekt/goreview/internal/scanner"
+)
+
+// fileAPIChanges holds the exported API changes attributed to each file path for
+// --profile api. It is filled before the review starts and read-only after.
+var fileAPIChanges map[string][]apidiff.Change
+
+// loadAPIDiff prepares --profile api: it compares the exported API of each package
+// with the merge base of --base and HEAD, the revision policy rules with changed_lines
+// compare against, attaches the changes to the files declaring them and returns only
+// those files. Unless --since or --author already selected lines, the lines of the
+// changed declarations are selected like theirs, so --context-lines and
+// --full-function-context apply and findings elsewhere are dropped.
+func loadAPIDiff(ctx context.Context, files []scanner.FileInfo) ([]scanner.FileInfo, error) {
+	fileAPIChanges = nil
+	if cfg.Profile != "api" {
+		return files, nil
+	}
+	fileAPIChanges = make(map[string][]apidiff.Change)
+	root := projectRoot(cfg.ProjectPath)
+	// Submodules resolve --base in their own history
+	bases := make(map[string]string)
+
+	byDir := make(map[string][]scanner.FileInfo)
+	var dirs []string
+	for _, f := range files {
+		dir := filepath.Dir(f.Path)
+		if _, ok := byDir[dir]; !ok {
+			dirs = append(dirs, dir)
+		}
+		byDir[dir] = append(byDir[dir], f)
+	}
+	sort.Strings(dirs)
+
+	breaking, total := 0, 0
+	for _, dir := range dirs {
+		pkgFiles := byDir[dir]
+		repo := repoDir(root, pkgFiles[0])
+		base, ok := bases[repo]
+		if !ok {
+			var err error
+			if base, err = blame.MergeBase(ctx, repo, cfg.Base); err != nil {
+				return nil, err
+			}
+			bases[repo] = base
+		}
+		before, err := apidiff.AtRevision(ctx, dir, base)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s at %s: %w", relPath(root, dir), cfg.Base, err)
+		}
+		oldAPI, err := apidiff.Parse(before)
+		if err != nil {
+			warnf("skipping API of %s at %s: %v\n", relPath(root, dir), cfg.Base, err)
+			continue
+		}
+		after, err := currentSources(dir)
+		if err != nil {
+			return nil, err
+		}
+		newAPI, err := apidiff.Parse(after)
+		if err != nil {
+			warnf("skipping API of %s: %v\n", relPath(root, dir), err)
+			continue
+		}
+
+		pkg := packagePath(pkgFiles[0], root)
+		if pkg == "" {
+			pkg = relPath(root, dir)
+		}
+		for _, c := range apidiff.Compare(pkg, oldAPI, newAPI) {
+			path := apiChangeFile(c, dir, pkgFiles)
+			if path == "" {
+				continue
+			}
+			fileAPIChanges[path] = append(fileAPIChanges[path], c)
+			total++
+			if c.Breaking {
+				breaking++
+			}
+		}
+	}
+
+	selectLines := authoredLines == nil
+	if selectLines {
+		authoredLines = make(map[string]map[int]bool)
+		authoredExcerpts = make(map[string][]reviewer.LineRange)
+	}
+	var out []scanner.FileInfo
+	for _, f := range files {
+		changes := fileAPIChanges[f.Path]
+		if len(changes) == 0 {
+			continue
+		}
+		section := apiSection(changes)
+		// Removed symbols have no line in the file, which is then reviewed whole
+		if numbers := changedLines(f.Path, changes); selectLines && len(numbers) > 0 {
+			set := make(map[int]bool, len(numbers))
+			for _, n := range numbers {
+				set[n] = true
+			}
+			authoredLines[f.Path] = set
+			section += "\nOnly report findings on lines " + blame.Ranges(numbers) + "; use the other lines as context only."
+			if ranges := excerpt(f, numbers); ranges != nil {
+				authoredExcerpts[f.Path] = ranges
+				section += ` Only parts of the file are shown; "..." marks the lines left out.`
+			}
+		}
+		addFileContext(f.Path, section)
+		out = append(out, f)
+	}
+	logf("Exported API since %s: %d changes (%d breaking) in %d files\n", cfg.Base, total, breaking, len(out))
+	return out, nil
+}
+
+// currentSources reads the Go files of the package in dir from the working tree.
+func currentSources(dir string) (map[string][]byte, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	sources := make(map[string][]byte, len(matches))
+	for _, m := range matches {
+		data, err := os.ReadFile(m)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", m, err)
+		}
+		sources[filepath.Base(m)] = data
+	}
+	return sources, nil
+}
+
+// changedLines returns the sorted lines of a file declaring the added and changed
+// symbols of its changes.
+func changedLines(path string, changes []apidiff.Change) []int {
+	seen := make(map[int]bool)
+	var numbers []int
+	for _, c := range changes {
+		if c.Kind == apidiff.Removed || c.Line == 0 || filepath.Base(path) != c.File || seen[c.Line] {
+			continue
+		}
+		seen[c.Line] = true
+		numbers = append(numbers, c.Line)
+	}
+	sort.Ints(numbers)
+	return numbers
+}
+
+// apiChangeFile returns the scanned file a change belongs to: the file declaring the
+// symbol, or for a symbol removed with its file, the first scanned file of the package.
+// It returns "" when the declaring file is not scanned, e.g. because it is excluded.
+func apiChangeFile(c apidiff.Change, dir string, pkgFiles []scanner.FileInfo) string {
+	path := filepath.Join(dir, c.File)
+	for _, f := range pkgFiles {
+		if f.Path == path {
+			return f.Path
+		}
+	}
+	if c.Kind == apidiff.Removed {
+		if _, err := os.Stat(path); err != nil {
+			return pkgFiles[0].Path
+		}
+	}
+	return ""
+}
+
+// apiSection describes the API changes of one file for the prompt.
+func apiSection(changes []apidiff.Change) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Exported API changes of this file since %s. Assess the backward compatibility of each for "+
+		"callers and implementers in other modules, and include [API-BREAK] in findings about breaking changes:\n", cfg.Base)
+	for _, c := range changes {
+		label := "compatible"
+		if c.Breaking {
+			label = "breaking"
+		}
+		fmt.Fprintf(&b, "- %s (%s)\n", c, label)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/architecture"
+	"github.com/disconnekt/goreview/internal/findings"
+	"github.com/disconnekt/goreview/internal/languages"
+	"github.com/disconnekt/goreview/internal/scanner"
+)
+
+// architectureRulePrefix starts the rule IDs of architecture violations, which end
+// with the number of the broken rule, e.g. ARCH-2.
+const architectureRulePrefix = "ARCH-"
+
+// checkArchitecture checks the imports of every Go file against the architecture rules
+// of the config. Violations are reported as findings on the import and given to the
+// model, which is asked how to remove the dependency.
+func checkArchitecture(files []scanner.FileInfo) {
+	if len(cfg.Architecture) == 0 {
+		return
+	}
+	root := projectRoot(cfg.ProjectPath)
+	total, affected := 0, 0
+	for _, f := range files {
+		if languages.Detect(f.Path) != "go" || f.ModulePath == "" {
+			continue
+		}
+		pkg := relPath(filepath.Join(root, filepath.FromSlash(f.Module)), filepath.Dir(f.Path))
+		violations := architecture.Check(cfg.Architecture, pkg, architecture.Imports(fileText(f), f.ModulePath))
+		if len(violations) == 0 {
+			continue
+		}
+		affected++
+		total += len(violations)
+
+		var b strings.Builder
+		b.WriteString("These imports violate the project's declared architecture and are reported separately. " +
+			"Suggest how to remove each dependency, e.g. by moving code, inverting it with an interface " +
+			"or passing data instead:\n")
+		for _, v := range violations {
+			msg := v.Message(cfg.Architecture, pkg)
+			fmt.Fprintf(&b, "- line %d: %s\n", v.Import.Line, msg)
+			toolFindings[f.Path] = append(toolFindings[f.Path], findings.Finding{
+				Line:     v.Import.Line,
+				Severity: findings.SeverityMedium,
+				RuleID:   fmt.Sprintf("%s%d", architectureRulePrefix, v.Rule+1),
+				Message:  "Architecture violation: " + msg,
+			})
+		}
+		addFileContext(f.Path, strings.TrimRight(b.String(), "\n"))
+	}
+	logf("Architecture rules: %d violations in %d files\n", total, affected)
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/disconnekt/goreview/internal/repocontext"
+	"github.com/disconnekt/goreview/internal/retrieval"
+	"github.com/disconnekt/goreview/internal/reviewer"
+)
+
+var askOpts struct {
+	top    int
+	budget int
+}
+
+var askCmd = &cobra.Command{
+	Use:   `ask "question"`,
+	Short: "Answer a question about the codebase",
+	Long: `Ask selects the files most relevant to a question, packs them into the prompt up to
+--budget tokens and prints the model's answer with references to files and lines.
+Files are ranked by how often the question's terms appear in their path and code,
+rare terms weighing more, so naming identifiers in the question helps. With
+--context similar they are ranked by embedding similarity to the question instead.`,
+	Example: `  aireview ask "where is retry logic implemented?"
+  aireview ask "how are API keys rotated after a 401?" --top 4`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAsk,
+}
+
+func init() {
+	askCmd.Flags().IntVar(&askOpts.top, "top", 8, "Maximum number of files sent as context")
+	askCmd.Flags().IntVar(&askOpts.budget, "budget", 12000, "Approximate token budget for the files sent as context")
+	addReviewFlags(askCmd.Flags())
+	rootCmd.AddCommand(askCmd)
+}
+
+const askPrompt = `You are a very experienced Go developer who knows this codebase. Answer the question using the
+	files below, which were selected as the most relevant to it. Refer to files by path and to code by
+	line number ("N| " prefixes), and quote short snippets where they make the answer clearer. If the
+	files do not contain the answer, say so and suggest where else to look instead of guessing.`
+
+func runAsk(cmd *cobra.Command, args []string) error {
+	question := strings.TrimSpace(args[0])
+	if question == "" {
+		return errors.New("the question is empty")
+	}
+	if err := prepareConfig(cmd); err != nil {
+		return err
+	}
+	files, err := scanProject(newFileScanner())
+	if err != nil {
+		return fmt.Errorf("failed to scan files: %w", err)
+	}
+	if len(files) == 0 {
+		return errors.New("no Go files found to answer from")
+	}
+	root := projectRoot(cfg.ProjectPath)
+	var matches []retrieval.Match
+	if containsString(cfg.Context, "similar") {
+		if matches, err = similarFiles(context.Background(), question, files, askOpts.top); err != nil {
+			return err
+		}
+		if len(matches) == 0 {
+			return errors.New("the embedding index holds no file to compare the question with")
+		}
+	} else {
+		matches = retrieval.Keyword(question, root, files)
+		if len(matches) == 0 {
+			return errors.New("no file matches the terms of the question; name identifiers, packages or files")
+		}
+	}
+
+	out := cmd.OutOrStdout()
+	selected, packed := packFiles(matches, root, askOpts.top, askOpts.budget)
+	fmt.Fprintf(out, "Context: %s\n\n", strings.Join(selected, ", "))
+
+	service, err := reviewer.NewService(cfg)
+	if err != nil {
+		return err
+	}
+	answer, err := service.Generate(context.Background(), askPrompt,
+		fmt.Sprintf("%s\nQuestion: %s", packed, question), reviewer.Options{})
+	if err != nil {
+		return fmt.Errorf("failed to answer: %w", err)
+	}
+	fmt.Fprintln(out, strings.TrimSpace(answer))
+	return nil
+}
+
+// packFiles renders the best matches as numbered code blocks until top files or the
+// token budget are reached; the first file is truncated rather than left out. It
+// returns the relative paths of the files included.
+func packFiles(matches []retrieval.Match, root string, top, budget int) ([]string, string) {
+	limit := budget * repocontext.CharsPerToken
+	var paths []string
+	var b strings.Builder
+	for _, m := range matches {
+		if len(paths) == top {
+			break
+		}
+		rel := relPath(root, m.File.Path)
+		content := fileText(m.File)
+		if b.Len()+len(content) > limit {
+			if len(paths) > 0 {
+				continue
+			}
+			content = strings.ToValidUTF8(content[:limit], "")
+		}
+		paths = append(paths, rel)
+		fmt.Fprintf(&b, "File %s:\n```go\n%s```\n\n", rel, reviewer.NumberLines(content, 1))
+	}
+	return paths, b.String()
+}
@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+
+	"github.com/disconnekt/goreview/internal/blame"
+	"github.com/disconnekt/goreview/internal/codegen"
+	"github.com/disconnekt/goreview/internal/findings"
+	"github.com/disconnekt/goreview/internal/languages"
+	"github.com/disconnekt/goreview/internal/reviewer"
+	"github.com/disconnekt/goreview/internal/scanner"
+)
+
+// authoredLines holds, per file path, the lines selected by --since and --author. It is
+// nil when neither is set. authoredExcerpts holds the parts of those files sent to the
+// model when --context-lines or --full-function-context is set.
+var (
+	authoredLines    map[string]map[int]bool
+	authoredExcerpts map[string][]reviewer.LineRange
+)
+
+// loadAuthorship blames each file with git and keeps only the files with lines from
+// commits matching --since and --author; the review of those files is told to
+// report findings on the matching lines only. Unless --include-trivial-changes is set,
+// whitespace changes are blamed on the commit before them, and comment lines of Go
+// files and import lines of commits that kept the import paths are not selected, so
+// gofmt and goimports churn is not reviewed.
+func loadAuthorship(ctx context.Context, files []scanner.FileInfo) ([]scanner.FileInfo, error) {
+	authoredLines, authoredExcerpts = nil, nil
+	if cfg.Since == "" && cfg.Author == "" {
+		return files, nil
+	}
+	since, err := cfg.SinceTime()
+	if err != nil {
+		return nil, err
+	}
+	filter := blame.Filter{Since: since, Author: cfg.Author}
+
+	authoredLines = make(map[string]map[int]bool)
+	authoredExcerpts = make(map[string][]reviewer.LineRange)
+	var kept []scanner.FileInfo
+	// Files moved with "git mv" are blamed through their old path, so only the lines the
+	// move changed are selected instead of the whole file
+	root := projectRoot(cfg.ProjectPath)
+	renames := make(map[string]map[string]string)
+
+	failed, total, trivial := 0, 0, 0
+	for _, f := range files {
+		dir := repoDir(root, f)
+		moved, ok := renames[dir]
+		if !ok {
+			if moved, err = blame.Renames(ctx, dir); err != nil {
+				warnf("%v\n", err)
+			}
+			renames[dir] = moved
+		}
+		opts := blame.Options{IgnoreWhitespace: !cfg.IncludeTrivialChanges}
+		if old, ok := moved[relPath(dir, f.Path)]; ok {
+			opts.RenamedFrom = filepath.Join(dir, filepath.FromSlash(old))
+		}
+		lines, err := blame.Run(ctx, f.Path, opts)
+		if err != nil {
+			if failed == 0 {
+				warnf("%v\n", err)
+			}
+			failed++
+			continue
+		}
+		var skip, imports map[int]bool
+		if !cfg.IncludeTrivialChanges && languages.Detect(f.Path) == "go" {
+			// A file that does not parse is reviewed on all its selected lines
+			skip, _ = codegen.TrivialLines(f.Path, fileText(f))
+			imports, _ = codegen.ImportLines(f.Path, fileText(f))
+		}
+		sameImports := make(map[string]bool) // by commit
+		var numbers []int
+		for _, l := range lines {
+			if !filter.Match(l) {
+				continue
+			}
+			if skip[l.Number] {
+				trivial++
+				continue
+			}
+			if imports[l.Number] {
+				same, ok := sameImports[l.Commit]
+				if !ok {
+					same = importsKept(ctx, dir, f, l)
+					sameImports[l.Commit] = same
+				}
+				if same {
+					trivial++
+					continue
+				}
+			}
+			numbers = append(numbers, l.Number)
+		}
+		if len(numbers) == 0 {
+			continue
+		}
+		set := make(map[int]bool, len(numbers))
+		for _, n := range numbers {
+			set[n] = true
+		}
+		authoredLines[f.Path] = set
+		total += len(numbers)
+		section := "This review audits selected changes. Only report findings on lines " +
+			blame.Ranges(numbers) + "; use the other lines as context only."
+		if ranges := excerpt(f, numbers); ranges != nil {
+			authoredExcerpts[f.Path] = ranges
+			section += ` Only parts of the file are shown; "..." marks the lines left out.`
+		}
+		addFileContext(f.Path, section)
+		kept = append(kept, f)
+	}
+	if failed > 0 {
+		warnf("%d files could not be blamed (not committed or not in a git repository) and are skipped\n", failed)
+	}
+	if trivial > 0 {
+		logf("Authorship filter: %d lines in %d of %d files, %d comment, import or blank lines ignored\n", total, len(kept), len(files), trivial)
+	} else {
+		logf("Authorship filter: %d lines in %d of %d files\n", total, len(kept), len(files))
+	}
+	return kept, nil
+}
+
+// importsKept reports whether the commit of an import line left the import paths of the
+// file as they were, so the line only moved, regrouped or renamed imports. Uncommitted
+// lines are compared with HEAD; when either version cannot be read the line counts as a
+// change.
+func importsKept(ctx context.Context, dir string, f scanner.FileInfo, l blame.Line) bool {
+	if l.Filename == "" {
+		return false
+	}
+	var before, after string
+	var err error
+	if l.Uncommitted() {
+		before, err = blame.Show(ctx, dir, "HEAD", l.Filename)
+		after = fileText(f)
+	} else {
+		// A file added by the commit has no earlier version, so its imports all changed
+		if before, err = blame.Show(ctx, dir, l.Commit+"^", l.Filename); err == nil {
+			after, err = blame.Show(ctx, dir, l.Commit, l.Filename)
+		}
+	}
+	if err != nil {
+		return false
+	}
+	old, err := codegen.ImportPaths(l.Filename, before)
+	if err != nil {
+		return false
+	}
+	cur, err := codegen.ImportPaths(l.Filename, after)
+	return err == nil && slices.Equal(old, cur)
+}
+
+// repoDir returns the directory of the git repository of a file: its submodule or
+// nested repository, or the project root.
+func repoDir(root string, f scanner.FileInfo) string {
+	if f.Repo == "" {
+		return root
+	}
+	return filepath.Join(root, filepath.FromSlash(f.Repo))
+}
+
+// excerpt returns the parts of a file to send for its selected lines: each line with
+// --context-lines lines around it, after widening it to the enclosing Go declaration
+// with --full-function-context. It returns nil to send the whole file.
+func excerpt(f scanner.FileInfo, numbers []int) []reviewer.LineRange {
+	if cfg.ContextLines < 0 && !cfg.FullFunctionContext {
+		return nil
+	}
+	var decls [][2]int
+	if cfg.FullFunctionContext && languages.Detect(f.Path) == "go" {
+		var err error
+		if decls, err = codegen.DeclLines(f.Path, fileText(f)); err != nil {
+			warnf("%v; sending the selected lines without their functions\n", err)
+		}
+	}
+	around := max(cfg.ContextLines, 0)
+	var ranges []reviewer.LineRange
+	for _, n := range numbers {
+		r := reviewer.LineRange{First: n, Last: n}
+		for _, d := range decls {
+			if d[0] <= n && n <= d[1] {
+				r = reviewer.LineRange{First: d[0], Last: d[1]}
+				break
+			}
+		}
+		r.First, r.Last = max(r.First-around, 1), r.Last+around
+		// Lines come in order, so a range touching the previous one extends it
+		if last := len(ranges) - 1; last >= 0 && r.First <= ranges[last].Last+1 {
+			ranges[last].Last = max(ranges[last].Last, r.Last)
+			continue
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+// onAuthoredLines drops the findings of a file that are not on lines selected by
+// --since and --author, or by --profile api. Findings about the whole file are kept,
+// as are all findings of a file without selected lines.
+func onAuthoredLines(path string, list []findings.Finding) []findings.Finding {
+	lines, ok := authoredLines[path]
+	if !ok {
+		return list
+	}
+	var kept []findings.Finding
+	for _, f := range list {
+		if f.Line == 0 || lines[f.Line] || (f.EndLine > f.Line && anyLine(lines, f.Line, f.EndLine)) {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+func anyLine(lines map[int]bool, from, to int) bool {
+	for n := from; n <= to; n++ {
+		if lines[n] {
+			return true
+		}
+	}
+	return false
+}
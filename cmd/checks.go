@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/findings"
+	"github.com/disconnekt/goreview/internal/github"
+	"github.com/disconnekt/goreview/internal/report"
+)
+
+// startCheckRun creates an in-progress check run when --github-check is set. Failures
+// are reported as warnings so an API problem never blocks the review itself.
+func startCheckRun() *github.CheckRun {
+	if !cfg.GitHubCheck {
+		return nil
+	}
+	client, err := github.NewClientFromEnv(cfg.GitHubToken)
+	if err != nil {
+		warnf("GitHub check run disabled: %v\n", err)
+		return nil
+	}
+	sha := github.HeadSHA()
+	if sha == "" {
+		warnf("GitHub check run disabled: GITHUB_SHA is not set\n")
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	run, err := client.CreateCheckRun(ctx, cfg.GitHubCheckName, sha)
+	if err != nil {
+		warnf("failed to create GitHub check run: %v\n", err)
+		return nil
+	}
+	logf("Created GitHub check run: %s\n", run.HTMLURL)
+	return run
+}
+
+// completeCheckRun publishes the results on the check run created by startCheckRun.
+func completeCheckRun(run *github.CheckRun, outcome *runOutcome, runErr error) {
+	if run == nil {
+		return
+	}
+	conclusion, output := checkRunOutput(outcome, runErr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	if err := run.Complete(ctx, conclusion, output); err != nil {
+		warnf("failed to complete GitHub check run: %v\n", err)
+	}
+}
+
+// checkRunOutput derives the conclusion and annotations. The check fails when the run
+// failed or reported critical or high findings, and is neutral for lesser findings.
+func checkRunOutput(outcome *runOutcome, runErr error) (string, github.CheckOutput) {
+	root := workspaceRoot()
+	var annotations []github.Annotation
+	counts := make(map[findings.Severity]int)
+	files := 0
+	if outcome != nil {
+		files = len(outcome.results)
+		for _, r := range outcome.results {
+			path := report.RelativeTo(root, r.Path)
+			for _, f := range r.Findings {
+				counts[f.Severity]++
+				annotations = append(annotations, checkAnnotation(path, f))
+			}
+		}
+	}
+
+	conclusion := "success"
+	switch {
+	case runErr != nil || counts[findings.SeverityCritical]+counts[findings.SeverityHigh] > 0:
+		conclusion = "failure"
+	case len(annotations) > 0:
+		conclusion = "neutral"
+	}
+
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "Reviewed %d files.\n\n| Severity | Findings |\n|---|---|\n", files)
+	for _, sev := range findings.Severities {
+		fmt.Fprintf(&summary, "| %s | %d |\n", sev, counts[sev])
+	}
+	if runErr != nil {
+		fmt.Fprintf(&summary, "\n**Run failed:** %s\n", runErr)
+	}
+	if cfg.ReportURL != "" {
+		fmt.Fprintf(&summary, "\n[Full report](%s)\n", cfg.ReportURL)
+	}
+
+	return conclusion, github.CheckOutput{
+		Title:       fmt.Sprintf("%d findings", len(annotations)),
+		Summary:     summary.String(),
+		Annotations: annotations,
+	}
+}
+
+func checkAnnotation(path string, f findings.Finding) github.Annotation {
+	level := "notice"
+	switch f.Severity {
+	case findings.SeverityCritical, findings.SeverityHigh:
+		level = "failure"
+	case findings.SeverityMedium:
+		level = "warning"
+	}
+	start := max(f.Line, 1)
+	end := max(f.EndLine, start)
+	title := strings.ToUpper(string(f.Severity))
+	if f.RuleID != "" {
+		title += " [" + f.RuleID + "]"
+	}
+	return github.Annotation{
+		Path:            path,
+		StartLine:       start,
+		EndLine:         end,
+		AnnotationLevel: level,
+		Message:         f.Message,
+		Title:           title,
+	}
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/disconnekt/goreview/internal/policy"
+)
+
+const (
+	// ciMountPoint is where container images mount the workspace by convention.
+	ciMountPoint = "/workspace"
+	// ciReportDir is the directory, relative to the project, that --ci writes reports to.
+	ciReportDir = "aireview-reports"
+)
+
+// applyCIMode enables --ci from AIREVIEW_CI and, unless --path or AIREVIEW_PATH is
+// given, points the run at the CI workspace.
+func applyCIMode(cmd *cobra.Command) error {
+	if v := os.Getenv("AIREVIEW_CI"); v != "" && !cmd.Flags().Changed("ci") {
+		ci, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid AIREVIEW_CI %q: %w", v, err)
+		}
+		cfg.CI = ci
+	}
+	if !cfg.CI || cmd.Flags().Changed("path") || os.Getenv("AIREVIEW_PATH") != "" {
+		return nil
+	}
+	if ws := ciWorkspace(); ws != "" {
+		cfg.ProjectPath = ws
+		return nil
+	}
+	if info, err := os.Stat(ciMountPoint); err == nil && info.IsDir() {
+		cfg.ProjectPath = ciMountPoint
+	}
+	return nil
+}
+
+// setCIReports writes the primary report to ciReportDir when no --report-file is set,
+// as Markdown instead of text, and adds JSON and SARIF copies unless already requested.
+func setCIReports() {
+	dir := filepath.Join(projectRoot(cfg.ProjectPath), ciReportDir)
+	if cfg.ReportFile == "" {
+		if cfg.Format == "text" {
+			cfg.Format = "markdown"
+		}
+		cfg.ReportFile = filepath.Join(dir, "aireview"+reportExtension(cfg.Format))
+	}
+	for _, format := range []string{"json", "sarif"} {
+		if cfg.Format == format || hasOutput(format) {
+			continue
+		}
+		cfg.Outputs = append(cfg.Outputs, format+"="+filepath.Join(dir, "aireview."+format))
+	}
+}
+
+func hasOutput(format string) bool {
+	for _, spec := range cfg.Outputs {
+		if name, _, _ := strings.Cut(spec, "="); strings.TrimSpace(name) == format {
+			return true
+		}
+	}
+	return false
+}
+
+func reportExtension(format string) string {
+	switch format {
+	case "markdown":
+		return ".md"
+	case "json", "sarif":
+		return "." + format
+	case "warnings-ng":
+		return ".warnings.json"
+	case "heatmap":
+		return ".html"
+	}
+	return ".txt"
+}
+
+// printCISummary prints the single line pipelines grep for, e.g.
+// "aireview: completed, 8 files reviewed, 0 failed, findings critical: 0, ..., policy PASS".
+func printCISummary(outcome *runOutcome, pol *policy.Policy, runErr error, elapsed time.Duration) {
+	var exit *exitError
+	policyFailed := errors.As(runErr, &exit) && exit.code == policyFailedStatus
+	if policyFailed {
+		runErr = nil
+	}
+	sum := buildSummary(outcome, runErr, elapsed)
+	parts := []string{
+		sum.Status,
+		fmt.Sprintf("%d files reviewed", sum.FilesReviewed),
+		fmt.Sprintf("%d failed", sum.FilesFailed),
+		"findings " + sum.SeverityLine(),
+	}
+	switch {
+	case policyFailed:
+		parts = append(parts, "policy FAIL")
+	case pol != nil && runErr == nil:
+		parts = append(parts, "policy PASS")
+	}
+	parts = append(parts, "reports in "+filepath.Dir(cfg.ReportFile), roundDuration(elapsed).String())
+	fmt.Printf("aireview: %s\n", strings.Join(parts, ", "))
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+
+	"github.com/disconnekt/goreview/internal/profiles"
+	"github.com/disconnekt/goreview/internal/report"
+	"github.com/disconnekt/goreview/internal/reviewer"
+)
+
+// modelCompletionTimeout keeps shell completion responsive when an endpoint is slow.
+const modelCompletionTimeout = 3 * time.Second
+
+var manDir string
+
+var manCmd = &cobra.Command{
+	Use:   "man",
+	Short: "Generate man pages",
+	Long: `Man writes a man page for aireview and every subcommand into --dir, ready to be
+installed under a man1 directory.`,
+	Example: `  aireview man --dir /usr/local/share/man/man1`,
+	Args:    cobra.NoArgs,
+	RunE:    runMan,
+}
+
+func init() {
+	manCmd.Flags().StringVar(&manDir, "dir", "man", "Directory the man pages are written to")
+	rootCmd.AddCommand(manCmd)
+}
+
+func runMan(cmd *cobra.Command, args []string) error {
+	if err := os.MkdirAll(manDir, 0755); err != nil {
+		return fmt.Errorf("failed to create man page directory: %w", err)
+	}
+	v, _, _ := buildInfo()
+	header := &doc.GenManHeader{
+		Title:   "AIREVIEW",
+		Section: "1",
+		Source:  "aireview " + v,
+		Manual:  "aireview manual",
+	}
+	if err := doc.GenManTree(rootCmd, header, manDir); err != nil {
+		return fmt.Errorf("failed to generate man pages: %w", err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Wrote man pages to %s\n", manDir)
+	return nil
+}
+
+// registerCompletions adds dynamic flag completion to every command that accepts the
+// review flags. Completion functions are bound per flag, so each command needs its own.
+func registerCompletions(cmd *cobra.Command) {
+	static := map[string][]string{
+		"profile":    profiles.Names(),
+		"provider":   reviewer.ProviderNames(),
+		"format":     report.FormatNames(),
+		"path-style": {"relative", "absolute"},
+	}
+	for name, values := range static {
+		if cmd.LocalFlags().Lookup(name) != nil {
+			_ = cmd.RegisterFlagCompletionFunc(name, cobra.FixedCompletions(values, cobra.ShellCompDirectiveNoFileComp))
+		}
+	}
+	if cmd.LocalFlags().Lookup("model") != nil {
+		_ = cmd.RegisterFlagCompletionFunc("model", completeModels)
+	}
+	for _, sub := range cmd.Commands() {
+		registerCompletions(sub)
+	}
+}
+
+// completeModels suggests the models served by the configured endpoints. Errors leave
+// the completion empty rather than printing into the user's shell.
+func completeModels(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if _, err := mergeConfig(cmd); err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	if cfg.APIKey == "" {
+		cfg.APIKey = os.Getenv("AIREVIEW_API_KEY")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), modelCompletionTimeout)
+	defer cancel()
+	listings, err := reviewer.ListModels(ctx, cfg)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	seen := make(map[string]bool)
+	var models []string
+	for _, l := range listings {
+		for _, m := range l.Models {
+			if !seen[m] {
+				seen[m] = true
+				models = append(models, m)
+			}
+		}
+	}
+	return models, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/concurrency"
+	"github.com/disconnekt/goreview/internal/findings"
+	"github.com/disconnekt/goreview/internal/languages"
+	"github.com/disconnekt/goreview/internal/scanner"
+)
+
+// dataRaceRule is the rule ID of findings for data races the race detector reported.
+const dataRaceRule = "DATA-RACE"
+
+// loadConcurrencyContext prepares --profile concurrency: it lists the goroutines,
+// channels and locks of each file in its prompt, attaches the data races of
+// --race-log to the files on their stacks and returns only the files with either;
+// the other files have nothing for the profile to review.
+func loadConcurrencyContext(files []scanner.FileInfo) ([]scanner.FileInfo, error) {
+	if cfg.Profile != "concurrency" {
+		return files, nil
+	}
+	var races []concurrency.Race
+	if cfg.RaceLog != "" {
+		var err error
+		if races, err = concurrency.LoadRaces(cfg.RaceLog); err != nil {
+			return nil, err
+		}
+	}
+
+	root := projectRoot(cfg.ProjectPath)
+	racesByPath := make(map[string][]concurrency.Race)
+	for _, r := range races {
+		for _, name := range r.Files() {
+			if path, ok := resolveSourcePath(name, root, files); ok {
+				racesByPath[path] = append(racesByPath[path], r)
+			}
+		}
+	}
+
+	var out []scanner.FileInfo
+	for _, f := range files {
+		var sites []concurrency.Site
+		if languages.Detect(f.Path) == "go" {
+			sites = concurrency.Sites(fileText(f))
+		}
+		fileRaces := racesByPath[f.Path]
+		if len(sites) == 0 && len(fileRaces) == 0 {
+			continue
+		}
+		if section := concurrency.PromptSection(sites); section != "" {
+			addFileContext(f.Path, section)
+		}
+		if len(fileRaces) > 0 {
+			addFileContext(f.Path, raceSection(fileRaces))
+			toolFindings[f.Path] = append(toolFindings[f.Path], raceFinding(fileRaces, f.Path, root, files))
+		}
+		out = append(out, f)
+	}
+
+	if cfg.RaceLog != "" {
+		logf("Race detector: %d data races, involving %d scanned files\n", len(races), len(racesByPath))
+	}
+	logf("Concurrency: %d of %d files use goroutines, channels, locks or atomics\n", len(out), len(files))
+	return out, nil
+}
+
+// raceSection quotes the race reports involving one file for the prompt.
+func raceSection(races []concurrency.Race) string {
+	var b strings.Builder
+	b.WriteString("The race detector (go test -race) reported these data races involving this file. " +
+		"Explain the cause of each and how to fix it:\n")
+	for _, r := range races {
+		fmt.Fprintf(&b, "```\n%s\n```\n", r.Text)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// raceFinding reports the races of a file at the first line of the file on their
+// stacks, so they are reported even when the review does not mention them.
+func raceFinding(races []concurrency.Race, path, root string, files []scanner.FileInfo) findings.Finding {
+	f := findings.Finding{Severity: findings.SeverityHigh, RuleID: dataRaceRule}
+	var accesses []string
+	for _, a := range races[0].Accesses {
+		if len(a.Stack) == 0 {
+			continue
+		}
+		top := a.Stack[0]
+		accesses = append(accesses, fmt.Sprintf("%s in %s (%s:%d)",
+			describeAccess(a.Description), top.Function, filepath.Base(top.File), top.Line))
+		for _, fr := range a.Stack {
+			if p, ok := resolveSourcePath(fr.File, root, files); f.Line == 0 && ok && p == path {
+				f.Line = fr.Line
+			}
+		}
+	}
+	f.Message = "Data race reported by the race detector: " + strings.Join(accesses, " conflicts with ")
+	if len(races) > 1 {
+		f.Message += fmt.Sprintf(" (and %d more races involving this file)", len(races)-1)
+	}
+	return f
+}
+
+var raceAddress = regexp.MustCompile(` at 0x[0-9a-f]+`)
+
+// describeAccess shortens an access header for a message, e.g. "Previous write at
+// 0x00c0000a4010 by goroutine 9" to "previous write by goroutine 9".
+func describeAccess(header string) string {
+	header = raceAddress.ReplaceAllString(header, "")
+	if header == "" {
+		return header
+	}
+	return strings.ToLower(header[:1]) + header[1:]
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/disconnekt/goreview/internal/config"
+	"github.com/disconnekt/goreview/internal/reviewer"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Create, inspect and validate the configuration",
+	Args:  cobra.NoArgs,
+}
+
+var configInitForce bool
+
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Write a commented starter config file",
+	Long: `Init writes a commented starter config to .aireview/config.yaml in the project,
+or to the path given with --config. An existing file is only replaced with --force.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigInit,
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the effective configuration and where each setting comes from",
+	Long: `Show merges the defaults, the config file, environment variables and the given
+flags exactly like a review run and prints the result as YAML, annotating each
+setting with its source. Webhook URLs are masked.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigShow,
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the configuration without running a review",
+	Long: `Validate loads the configuration like a review run, resolves the API key and
+checks the settings, the rules and policy files, the report formats and the provider
+setup.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigValidate,
+}
+
+func init() {
+	configInitCmd.Flags().BoolVar(&configInitForce, "force", false, "Overwrite an existing config file")
+	addReviewFlags(configShowCmd.Flags())
+	addReviewFlags(configValidateCmd.Flags())
+	configCmd.AddCommand(configInitCmd, configShowCmd, configValidateCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+func runConfigInit(cmd *cobra.Command, args []string) error {
+	path := cfg.ConfigFile
+	if path == "" {
+		path = filepath.Join(cfg.ProjectPath, config.DefaultFilePaths[0])
+	}
+	if _, err := os.Stat(path); err == nil && !configInitForce {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", path)
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to check config file: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(config.Starter), 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Wrote %s\n", path)
+	return nil
+}
+
+func runConfigShow(cmd *cobra.Command, args []string) error {
+	src, err := mergeConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	for _, s := range cfg.AsFile().Settings() {
+		source := "default"
+		from, ok := src.settings[s.Key]
+		switch flag := config.FlagName(s.Key); {
+		case from == orgLockedSource:
+			source = from
+		case flag != "" && cmd.Flags().Changed(flag):
+			source = "flag --" + flag
+		case ok:
+			source = from
+		}
+		value := s.Value
+		if str, ok := value.(string); ok && isWebhookKey(s.Key) {
+			value = maskURL(str)
+		}
+
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: s.Key}
+		val := &yaml.Node{}
+		if err := val.Encode(value); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", s.Key, err)
+		}
+		if val.Kind == yaml.ScalarNode || len(val.Content) == 0 {
+			val.LineComment = source
+		} else {
+			key.LineComment = source
+		}
+		doc.Content = append(doc.Content, key, val)
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintln(out, "# Effective configuration (precedence: flags, environment, config file, user config, defaults)")
+	if src.userFile != "" {
+		fmt.Fprintf(out, "# User config file: %s\n", src.userFile)
+	}
+	if src.file != "" {
+		fmt.Fprintf(out, "# Config file: %s\n", src.file)
+	}
+	enc := yaml.NewEncoder(out)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write configuration: %w", err)
+	}
+	return enc.Close()
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	if err := prepareConfig(cmd); err != nil {
+		return err
+	}
+	if _, err := loadRules(cfg); err != nil {
+		return err
+	}
+	if _, err := loadPolicy(); err != nil {
+		return err
+	}
+	if _, err := newReportOutputs(""); err != nil {
+		return err
+	}
+	if _, err := reviewer.NewProvider(cfg); err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), "Configuration is valid")
+	return nil
+}
+
+func isWebhookKey(key string) bool {
+	return key == "slack_webhook" || key == "teams_webhook" || key == "notify_webhook"
+}
+
+// maskURL keeps only the scheme and host of a URL whose path may embed a token.
+func maskURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "***"
+	}
+	return u.Scheme + "://" + u.Host + "/***"
+}
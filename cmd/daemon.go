@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/disconnekt/goreview/internal/schedule"
+)
+
+var daemonSchedule string
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Run full-project reviews on a cron schedule",
+	Long: `Daemon runs a full-project review every time the cron schedule fires, records each
+run in the history and sends the configured notifications. It accepts the same
+review flags as the root command and stops on SIGINT or SIGTERM.`,
+	Example: `  aireview daemon --schedule "0 2 * * *" --path ./my-project --notify slack`,
+	Args:    cobra.NoArgs,
+	RunE:    runDaemon,
+}
+
+func init() {
+	daemonCmd.Flags().StringVar(&daemonSchedule, "schedule", "@daily",
+		`Cron expression (minute hour day-of-month month day-of-week) or macro such as "@hourly"`)
+	addReviewFlags(daemonCmd.Flags())
+	rootCmd.AddCommand(daemonCmd)
+}
+
+func runDaemon(cmd *cobra.Command, args []string) error {
+	sched, err := schedule.Parse(daemonSchedule)
+	if err != nil {
+		return err
+	}
+	if err := prepareConfig(cmd); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logf("Daemon started with schedule %q\n", daemonSchedule)
+	for {
+		next := sched.Next(time.Now())
+		if next.IsZero() {
+			return fmt.Errorf("schedule %q never fires", daemonSchedule)
+		}
+		logf("Next review at %s\n", next.Format(time.RFC3339))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			logf("Daemon stopped\n")
+			return nil
+		case <-timer.C:
+		}
+
+		start := time.Now()
+		outcome, err := executeReview(ctx)
+		finishRun(outcome, err, start)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Scheduled review failed: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/disconnekt/goreview/internal/config"
+	"github.com/disconnekt/goreview/internal/reviewer"
+)
+
+// doctorDialTimeout bounds the TCP and TLS checks, which should be quick.
+const doctorDialTimeout = 5 * time.Second
+
+// certExpiryWarning is how close to expiry a server certificate is reported.
+const certExpiryWarning = 14 * 24 * time.Hour
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose connectivity and credentials of the configured endpoints",
+	Long: `Doctor checks every configured endpoint before a run: TCP reachability, the TLS
+handshake, whether the credentials are accepted and whether a tiny test completion
+succeeds with the selected model. It reports latencies and the detected server type
+and prints a suggested fix for every failed check.`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
+}
+
+func init() {
+	addReviewFlags(doctorCmd.Flags())
+	rootCmd.AddCommand(doctorCmd)
+}
+
+// doctorReport prints check results and counts failures.
+type doctorReport struct {
+	out      io.Writer
+	failed   int
+	warnings int
+}
+
+func (r *doctorReport) ok(format string, args ...any) {
+	fmt.Fprintf(r.out, "  [ok]   %s\n", fmt.Sprintf(format, args...))
+}
+
+func (r *doctorReport) warn(fix, format string, args ...any) {
+	r.warnings++
+	fmt.Fprintf(r.out, "  [warn] %s\n", fmt.Sprintf(format, args...))
+	if fix != "" {
+		fmt.Fprintf(r.out, "         fix: %s\n", fix)
+	}
+}
+
+func (r *doctorReport) fail(fix, format string, args ...any) {
+	r.failed++
+	fmt.Fprintf(r.out, "  [FAIL] %s\n", fmt.Sprintf(format, args...))
+	if fix != "" {
+		fmt.Fprintf(r.out, "         fix: %s\n", fix)
+	}
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	if err := prepareConfig(cmd); err != nil {
+		return err
+	}
+	r := &doctorReport{out: cmd.OutOrStdout()}
+	ctx := context.Background()
+
+	switch cfg.Provider {
+	case "bedrock", "vertex", "exec":
+		fmt.Fprintf(r.out, "Provider %s\n", cfg.Provider)
+		checkCompletion(ctx, r, *cfg)
+	default:
+		for _, ep := range cfg.EffectiveAPIURLs() {
+			checkEndpoint(ctx, r, ep)
+		}
+	}
+
+	fmt.Fprintln(r.out)
+	if r.failed > 0 {
+		return fmt.Errorf("%d checks failed", r.failed)
+	}
+	if r.warnings > 0 {
+		fmt.Fprintf(r.out, "All checks passed with %d warnings\n", r.warnings)
+		return nil
+	}
+	fmt.Fprintln(r.out, "All checks passed")
+	return nil
+}
+
+// checkEndpoint runs the network, credential and completion checks of one endpoint,
+// stopping at the first failure the later checks depend on.
+func checkEndpoint(ctx context.Context, r *doctorReport, endpoint string) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		fmt.Fprintf(r.out, "Endpoint %s\n", endpoint)
+		r.fail("pass a full URL such as http://127.0.0.1:1234/v1/chat/completions to --url", "invalid endpoint URL")
+		return
+	}
+	fmt.Fprintf(r.out, "Endpoint %s (%s)\n", endpoint, detectServer(u))
+
+	host := u.Host
+	if u.Port() == "" {
+		port := "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", host, doctorDialTimeout)
+	if err != nil {
+		r.fail(dialFix(u), "TCP connect to %s: %v", host, err)
+		return
+	}
+	conn.Close()
+	r.ok("TCP connect to %s (%s)", host, roundDuration(time.Since(start)))
+
+	if u.Scheme == "https" {
+		if !checkTLS(r, host, u.Hostname()) {
+			return
+		}
+	} else if !isLoopback(u.Hostname()) {
+		r.warn("use https unless the network between you and the endpoint is trusted",
+			"plain HTTP to a remote host sends code and credentials unencrypted")
+	}
+
+	// Narrow a copy of the configuration down to this endpoint
+	single := *cfg
+	single.APIURLs = []string{endpoint}
+	checkModels(ctx, r, single)
+	checkCompletion(ctx, r, single)
+}
+
+func checkTLS(r *doctorReport, host, serverName string) bool {
+	start := time.Now()
+	dialer := &net.Dialer{Timeout: doctorDialTimeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", host, &tls.Config{ServerName: serverName})
+	if err != nil {
+		r.fail("if a corporate proxy intercepts TLS, add its CA certificate to the system trust store or point SSL_CERT_FILE at it",
+			"TLS handshake: %v", err)
+		return false
+	}
+	defer conn.Close()
+	state := conn.ConnectionState()
+	r.ok("TLS %s handshake (%s)", tls.VersionName(state.Version), roundDuration(time.Since(start)))
+	if len(state.PeerCertificates) > 0 {
+		if left := time.Until(state.PeerCertificates[0].NotAfter); left < certExpiryWarning {
+			r.warn("renew the server certificate", "certificate expires %s",
+				state.PeerCertificates[0].NotAfter.Format(time.RFC3339))
+		}
+	}
+	return true
+}
+
+// checkModels uses the models listing to verify the credentials and that the endpoint
+// serves the selected model.
+func checkModels(ctx context.Context, r *doctorReport, c config.Config) {
+	ctx, cancel := context.WithTimeout(ctx, c.RequestTimeout)
+	defer cancel()
+	start := time.Now()
+	listings, err := reviewer.ListModels(ctx, &c)
+	if err != nil {
+		r.warn("", "models listing: %v", err)
+		return
+	}
+	l := listings[0]
+	switch code := reviewer.StatusCode(l.Err); {
+	case l.Err == nil:
+		r.ok("credentials accepted, %d models listed (%s)", len(l.Models), roundDuration(time.Since(start)))
+		if !l.Has() {
+			r.warn(`run "aireview models" to see the available identifiers and pass one to --model`,
+				"model %q is not in the endpoint's listing", l.Requested)
+		}
+	case code == 401 || code == 403:
+		r.fail("set a valid key with --api-key, AIREVIEW_API_KEY, --api-key-command or --api-key-keychain",
+			"credentials rejected: %v", l.Err)
+	case code == 404:
+		r.warn("", "the endpoint does not offer a models listing; relying on the test completion")
+	default:
+		r.warn("", "models listing: %v", l.Err)
+	}
+}
+
+// checkCompletion sends a tiny chat completion with the selected model.
+func checkCompletion(ctx context.Context, r *doctorReport, c config.Config) {
+	provider, err := reviewer.NewProvider(&c)
+	if err != nil {
+		r.fail("", "provider setup: %v", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.RequestTimeout)
+	defer cancel()
+	start := time.Now()
+	reply, err := provider.Complete(ctx, reviewer.ReviewRequest{
+		Model:     c.Model,
+		Messages:  []reviewer.Message{{Role: "user", Content: "Reply with the single word OK."}},
+		MaxTokens: 16,
+	})
+	elapsed := roundDuration(time.Since(start))
+	if err != nil {
+		r.fail(completionFix(err), "test completion with %s after %s: %v", c.Model, elapsed, err)
+		return
+	}
+	reply = strings.TrimSpace(reply)
+	if len(reply) > 40 {
+		reply = reply[:40] + "..."
+	}
+	r.ok("test completion with %s (%s): %q", c.Model, elapsed, reply)
+}
+
+func completionFix(err error) string {
+	switch reviewer.StatusCode(err) {
+	case 400, 404:
+		return `check the model name with "aireview models" and pass it to --model`
+	case 401, 403:
+		return "set a valid key with --api-key or AIREVIEW_API_KEY"
+	case 429:
+		return "the key is rate limited; wait, add keys with --api-key-file or lower --concurrency"
+	case 500, 502, 503:
+		return "the server failed; check its logs or try again later"
+	}
+	if errors.Is(err, context.DeadlineExceeded) || strings.Contains(err.Error(), "Client.Timeout") {
+		return "the model did not answer in time; raise --timeout or load a smaller model"
+	}
+	return ""
+}
+
+func dialFix(u *url.URL) string {
+	switch u.Port() {
+	case "1234":
+		return "start the LM Studio local server (Developer tab) or correct --url"
+	case "11434":
+		return `start Ollama with "ollama serve" or correct --url`
+	case "4000":
+		return "start the LiteLLM proxy or correct --url"
+	}
+	return "check that the server is running and the host and port in --url are right; a firewall, VPN or proxy may block the connection"
+}
+
+// detectServer guesses the kind of server behind an endpoint from its host and port.
+func detectServer(u *url.URL) string {
+	host := u.Hostname()
+	switch {
+	case strings.HasSuffix(host, "openai.azure.com"):
+		return "Azure OpenAI"
+	case host == "api.openai.com":
+		return "OpenAI"
+	case host == "openrouter.ai":
+		return "OpenRouter"
+	case host == "api.anthropic.com":
+		return "Anthropic OpenAI compatibility"
+	case host == "generativelanguage.googleapis.com":
+		return "Google Gemini OpenAI compatibility"
+	case strings.HasSuffix(host, "aiplatform.googleapis.com"):
+		return "Vertex AI"
+	}
+	switch u.Port() {
+	case "1234":
+		return "probably LM Studio"
+	case "11434":
+		return "probably Ollama"
+	case "4000":
+		return "probably LiteLLM"
+	case "8000":
+		return "probably vLLM"
+	}
+	return "OpenAI-compatible server"
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/findings"
+	"github.com/disconnekt/goreview/internal/report"
+	"github.com/disconnekt/goreview/internal/scanner"
+)
+
+// findDuplicates reports code blocks duplicated across the files for --duplicates. Both
+// copies get a low-severity finding with a shared DUP-<n> rule; in review mode the model
+// also sees the duplicates and is asked how to extract the shared code.
+func findDuplicates(files []scanner.FileInfo) {
+	if cfg.Duplicates == "" {
+		return
+	}
+	root := projectRoot(cfg.ProjectPath)
+	dups, full, err := scanner.FindDuplicates(files, cfg.DuplicateMinTokens)
+	if err != nil {
+		warnf("duplicate code: files left out: %v\n", err)
+	}
+	if full {
+		warnf("duplicate code: the comparison index is full, later files are only compared with earlier ones\n")
+	}
+	sections := make(map[string][]string)
+	for i, d := range dups {
+		rule := fmt.Sprintf("%s%d", report.DuplicateRulePrefix, i+1)
+		for _, pair := range [][2]scanner.Block{{d.A, d.B}, {d.B, d.A}} {
+			self, other := pair[0], pair[1]
+			where := fmt.Sprintf("%s lines %d-%d", relPath(root, other.Path), other.StartLine, other.EndLine)
+			if other.Path == self.Path {
+				where = fmt.Sprintf("lines %d-%d of this file", other.StartLine, other.EndLine)
+			}
+			toolFindings[self.Path] = append(toolFindings[self.Path], findings.Finding{
+				Line:     self.StartLine,
+				EndLine:  self.EndLine,
+				Severity: findings.SeverityLow,
+				RuleID:   rule,
+				Message: fmt.Sprintf("Duplicate code: lines %d-%d repeat %s (%d tokens); extract the shared code",
+					self.StartLine, self.EndLine, where, d.Tokens),
+			})
+			sections[self.Path] = append(sections[self.Path],
+				fmt.Sprintf("- [%s] lines %d-%d duplicate %s", rule, self.StartLine, self.EndLine, where))
+		}
+	}
+	if cfg.Duplicates == "review" {
+		for path, lines := range sections {
+			addFileContext(path, "These blocks of this file are near-identical to other code, found by token comparison. "+
+				"For each, suggest how to extract the shared code (a function, method or generic helper) and where it "+
+				"should live, and include its tag, e.g. [DUP-1], in the finding:\n"+strings.Join(lines, "\n"))
+		}
+	}
+	logf("Duplicate code: %d duplicated blocks in %d files\n", len(dups), len(sections))
+}
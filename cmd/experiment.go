@@ -0,0 +1,287 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/disconnekt/goreview/internal/experiment"
+	"github.com/disconnekt/goreview/internal/findings"
+	"github.com/disconnekt/goreview/internal/history"
+	"github.com/disconnekt/goreview/internal/profiles"
+	"github.com/disconnekt/goreview/internal/reviewer"
+	"github.com/disconnekt/goreview/internal/rules"
+	"github.com/disconnekt/goreview/internal/scanner"
+)
+
+// experimentsDir is the state subdirectory experiment results are recorded in.
+const experimentsDir = "experiments"
+
+var experimentOpts struct {
+	models  []string
+	prompts []string
+	sample  int
+	seed    int64
+	out     string
+}
+
+var experimentCmd = &cobra.Command{
+	Use:   "experiment --models a,b [--prompts p1,p2] [--sample 20]",
+	Short: "Compare models and prompts on the same sample of files",
+	Long: `Experiment reviews a random sample of the project's files with every combination
+of --models and --prompts and compares the variants: findings by severity, findings
+other variants also reported, tokens, latency and the precision recorded with
+"aireview feedback". A prompt is a review profile name or the path of a file whose
+text is appended to the system prompt.
+
+Every variant reviews the same files; --sample-seed picks another sample. The full results,
+including all findings, are recorded in the experiments directory of the state
+directory.`,
+	Example: `  aireview experiment --models gpt-4o-mini,qwen2.5-coder:32b --sample 20
+  aireview experiment --models gpt-4o --prompts general,security,prompts/strict.md --out experiment.md`,
+	Args: cobra.NoArgs,
+	RunE: runExperiment,
+}
+
+func init() {
+	flags := experimentCmd.Flags()
+	flags.StringSliceVar(&experimentOpts.models, "models", nil, "Models to compare (default: --model)")
+	flags.StringSliceVar(&experimentOpts.prompts, "prompts", nil, "Profiles or prompt files to compare (default: --profile)")
+	flags.IntVar(&experimentOpts.sample, "sample", 20, "Number of files reviewed by each variant (0 = all)")
+	flags.Int64Var(&experimentOpts.seed, "sample-seed", 1, "Seed of the random file sample")
+	flags.StringVar(&experimentOpts.out, "out", "", "Write the comparison to this file instead of stdout, as JSON for a .json file")
+	addReviewFlags(flags)
+	rootCmd.AddCommand(experimentCmd)
+}
+
+// experimentVariant is a model and prompt combination to review with.
+type experimentVariant struct {
+	model, prompt string
+	// profile is the review profile; extra the prompt file text, if any.
+	profile, extra string
+}
+
+func runExperiment(cmd *cobra.Command, args []string) error {
+	if err := prepareConfig(cmd); err != nil {
+		return err
+	}
+	write := experiment.WriteMarkdown
+	if strings.EqualFold(filepath.Ext(experimentOpts.out), ".json") {
+		write = experiment.WriteJSON
+	}
+	if experimentOpts.sample < 0 {
+		return errors.New("--sample must not be negative")
+	}
+	variants, err := experimentVariants()
+	if err != nil {
+		return err
+	}
+
+	files, err := scanProject(newFileScanner())
+	if err != nil {
+		return fmt.Errorf("failed to scan project: %w", err)
+	}
+	files = sampleFiles(files, experimentOpts.sample, experimentOpts.seed)
+	if len(files) == 0 {
+		return errors.New("no files to review")
+	}
+	projectRules, err := loadRules(cfg)
+	if err != nil {
+		return err
+	}
+	repoContext, err := loadRepoContext(cfg)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	root := projectRoot(cfg.ProjectPath)
+	rep := experiment.Report{ID: history.NewRunID(start), StartedAt: start.UTC()}
+	for _, f := range files {
+		rep.Files = append(rep.Files, relPath(root, f.Path))
+	}
+	verdicts, err := history.Open(cfg.StatePath(history.FileName)).Verdicts()
+	if err != nil {
+		warnf("%v\n", err)
+	}
+	precision := history.PrecisionByModel(verdicts)
+
+	logf("Experiment: %d variants on %d files\n", len(variants), len(files))
+	for _, v := range variants {
+		logf("Reviewing with %s / %s\n", v.model, v.prompt)
+		result, err := runVariant(context.Background(), v, files, root, projectRules, repoContext)
+		if err != nil {
+			return err
+		}
+		for i, p := range precision {
+			if p.Model == result.Model && p.Prompt == result.PromptID {
+				result.Precision = &precision[i]
+			}
+		}
+		rep.Variants = append(rep.Variants, result)
+	}
+
+	if err := recordExperiment(rep); err != nil {
+		warnf("%v\n", err)
+	}
+	var out io.Writer = cmd.OutOrStdout()
+	if experimentOpts.out != "" {
+		file, err := os.Create(experimentOpts.out)
+		if err != nil {
+			return fmt.Errorf("failed to create experiment report: %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+	if err := write(out, rep); err != nil {
+		return fmt.Errorf("failed to write experiment report: %w", err)
+	}
+	if experimentOpts.out != "" {
+		logf("Wrote the comparison of %d variants to %s\n", len(rep.Variants), experimentOpts.out)
+	}
+	return nil
+}
+
+// experimentVariants builds the model and prompt matrix, defaulting to the configured
+// model and profile.
+func experimentVariants() ([]experimentVariant, error) {
+	models := experimentOpts.models
+	if len(models) == 0 {
+		models = []string{cfg.Model}
+	}
+	prompts := experimentOpts.prompts
+	if len(prompts) == 0 {
+		prompts = []string{cfg.Profile}
+	}
+	var variants []experimentVariant
+	for _, p := range prompts {
+		profile, extra := p, ""
+		if _, err := profiles.Get(p); err != nil {
+			data, readErr := os.ReadFile(p)
+			if readErr != nil {
+				return nil, fmt.Errorf("prompt %q is neither a profile (%s) nor a readable file: %w",
+					p, strings.Join(profiles.Names(), ", "), readErr)
+			}
+			profile, extra = cfg.Profile, strings.TrimSpace(string(data))
+		}
+		for _, m := range models {
+			variants = append(variants, experimentVariant{model: m, prompt: p, profile: profile, extra: extra})
+		}
+	}
+	if len(variants) < 2 {
+		return nil, errors.New("an experiment needs at least two variants; pass several --models or --prompts")
+	}
+	return variants, nil
+}
+
+// sampleFiles picks n files at random with a fixed seed, in path order; n = 0 keeps all.
+func sampleFiles(files []scanner.FileInfo, n int, seed int64) []scanner.FileInfo {
+	if n == 0 || n >= len(files) {
+		return files
+	}
+	picked := append([]scanner.FileInfo(nil), files...)
+	rand.New(rand.NewSource(seed)).Shuffle(len(picked), func(i, j int) { picked[i], picked[j] = picked[j], picked[i] })
+	picked = picked[:n]
+	sort.Slice(picked, func(i, j int) bool { return picked[i].Path < picked[j].Path })
+	return picked
+}
+
+// runVariant reviews the files with one variant's model and prompt.
+func runVariant(ctx context.Context, v experimentVariant, files []scanner.FileInfo, root string,
+	projectRules []rules.Rule, repoContext string) (experiment.Variant, error) {
+	vcfg := *cfg
+	vcfg.Model = v.model
+	vcfg.Profile = v.profile
+	vcfg.Consensus = false
+	service, err := reviewer.NewService(&vcfg)
+	if err != nil {
+		return experiment.Variant{}, err
+	}
+	service.SetRules(projectRules)
+	service.SetContext(repoContext)
+
+	idPrompt := orgPrompt()
+	if v.extra != "" {
+		idPrompt += "\n\n" + v.extra
+	}
+	result := experiment.Variant{Model: v.model, Prompt: v.prompt, PromptID: service.PromptID(idPrompt)}
+	info := vcfg.ModelInfo(v.model)
+	result.Priced = info.Priced()
+	var mu sync.Mutex
+	service.AddObserver(func(a reviewer.Attempt) {
+		mu.Lock()
+		defer mu.Unlock()
+		result.Requests++
+		result.PromptTokens += a.PromptTokens
+		result.CompletionTokens += a.CompletionTokens
+		result.Cost += info.Cost(a.PromptTokens, a.CachedTokens, a.CompletionTokens)
+		result.Latency += a.Latency
+	})
+
+	sem := make(chan struct{}, max(cfg.MaxConcurrency, 1))
+	var wg sync.WaitGroup
+	for _, f := range files {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(f scanner.FileInfo) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			opts := moduleReviewOptions(f)
+			// The variant's model replaces per-module and routed models
+			opts.Model = ""
+			opts.File = relPath(root, f.Path)
+			if v.extra != "" {
+				opts.Prompt = strings.TrimSpace(opts.Prompt + "\n\n" + v.extra)
+			}
+			code, err := f.Text()
+			var review *reviewer.Result
+			if err == nil {
+				review, err = service.ReviewCode(ctx, code, opts)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			result.Files++
+			if err != nil {
+				result.Failed++
+				warnf("%s / %s: failed to review %s: %v\n", v.model, v.prompt, opts.File, err)
+				return
+			}
+			for _, finding := range review.Findings {
+				finding.File = opts.File
+				result.Findings = append(result.Findings, finding)
+			}
+		}(f)
+	}
+	wg.Wait()
+	findings.Sort(result.Findings)
+	return result, nil
+}
+
+// recordExperiment keeps the full results in the state directory.
+func recordExperiment(rep experiment.Report) error {
+	path := cfg.StatePath(filepath.Join(experimentsDir, rep.ID+".json"))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create experiments directory: %w", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to record the experiment: %w", err)
+	}
+	defer file.Close()
+	if err := experiment.WriteJSON(file, rep); err != nil {
+		return fmt.Errorf("failed to record the experiment: %w", err)
+	}
+	logf("Recorded the experiment in %s\n", path)
+	return nil
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/disconnekt/goreview/internal/codegen"
+	"github.com/disconnekt/goreview/internal/reviewer"
+)
+
+var explainOut string
+
+var explainCmd = &cobra.Command{
+	Use:   "explain path/to/file.go[:FuncName]",
+	Short: "Explain in plain language what a file or function does",
+	Long: `Explain asks the model for a plain-language explanation of a Go file, or of one
+function or method when its name follows the path (Type.Method for methods): what the
+code is for, how it works, its inputs, outputs and side effects, and the edge cases
+a newcomer should know about. The explanation is printed as Markdown.`,
+	Example: `  aireview explain internal/scanner/scanner.go
+  aireview explain internal/scanner/scanner.go:Scanner.ScanGoFiles --out scan.md`,
+	Args: cobra.ExactArgs(1),
+	RunE: runExplain,
+}
+
+func init() {
+	explainCmd.Flags().StringVar(&explainOut, "out", "", "Write the explanation to this file instead of stdout")
+	addReviewFlags(explainCmd.Flags())
+	rootCmd.AddCommand(explainCmd)
+}
+
+const explainPrompt = `You are a very experienced Go developer explaining code to a colleague who is new to the
+	codebase. Explain in plain language:
+	- what the code is for and where it fits,
+	- how it works, step by step,
+	- its inputs, outputs, side effects and error handling,
+	- edge cases and surprising behavior worth knowing before changing it.
+
+	The code is prefixed with line numbers ("N| "); refer to them where it helps. Answer in Markdown
+	with short sections, and do not suggest changes unless something is clearly a bug.`
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	path, name := splitFuncTarget(args[0])
+	if err := prepareConfig(cmd); err != nil {
+		return err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	code, first := string(content), 1
+	if name != "" {
+		if code, first, err = codegen.FuncSource(path, code, name); err != nil {
+			return err
+		}
+	}
+	if int64(len(code)) > cfg.MaxFileSize {
+		return fmt.Errorf("%s is %d bytes, over the %d byte limit; explain one function with %s:FuncName or raise --max-size",
+			path, len(code), cfg.MaxFileSize, path)
+	}
+
+	service, err := reviewer.NewService(cfg)
+	if err != nil {
+		return err
+	}
+	repoContext, err := loadRepoContext(cfg)
+	if err != nil {
+		return err
+	}
+	service.SetContext(repoContext)
+
+	rel := filepath.ToSlash(path)
+	if abs, err := filepath.Abs(path); err == nil {
+		rel = relPath(projectRoot(cfg.ProjectPath), abs)
+	}
+	subject := "File " + rel
+	if name != "" {
+		subject = fmt.Sprintf("%s in %s", name, rel)
+	}
+	explanation, err := service.Generate(context.Background(), explainPrompt,
+		fmt.Sprintf("%s:\n```go\n%s```", subject, reviewer.NumberLines(code, first)), reviewer.Options{File: rel})
+	if err != nil {
+		return fmt.Errorf("failed to explain %s: %w", args[0], err)
+	}
+
+	text := fmt.Sprintf("# %s\n\n%s\n", strings.TrimPrefix(subject, "File "), strings.TrimSpace(explanation))
+	if explainOut == "" {
+		fmt.Fprint(cmd.OutOrStdout(), text)
+		return nil
+	}
+	if err := os.WriteFile(explainOut, []byte(text), 0644); err != nil {
+		return fmt.Errorf("failed to write explanation: %w", err)
+	}
+	fmt.Printf("Wrote explanation to %s\n", explainOut)
+	return nil
+}
+
+// splitFuncTarget splits "file.go:FuncName" into the path and the function name.
+func splitFuncTarget(arg string) (string, string) {
+	if i := strings.LastIndex(arg, ".go:"); i >= 0 {
+		return arg[:i+3], arg[i+4:]
+	}
+	return arg, ""
+}
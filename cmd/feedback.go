@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/disconnekt/goreview/internal/findings"
+	"github.com/disconnekt/goreview/internal/history"
+	"github.com/disconnekt/goreview/internal/report"
+)
+
+var feedbackOpts struct {
+	verdict string
+	stats   bool
+}
+
+var feedbackCmd = &cobra.Command{
+	Use:   "feedback <finding-id> --verdict useful|wrong",
+	Short: "Record whether a finding was useful, to measure the precision of models and prompts",
+	Long: `Feedback records a human verdict on a finding of a recorded run. The finding is
+selected by its fingerprint (or a prefix of it), as shown in SARIF, Warnings NG,
+tickets and pull request comments, or by its file:line in the most recent run that
+reported it.
+
+Verdicts are kept in the state directory next to the run history and credited to the
+model and prompt that produced the finding. --stats prints the precision of each
+model and prompt, which the summary format also includes.`,
+	Example: `  aireview feedback 3f9c2a1b --verdict useful
+  aireview feedback internal/cache/lru.go:42 --verdict wrong
+  aireview feedback --stats`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runFeedback,
+}
+
+func init() {
+	feedbackCmd.Flags().StringVar(&feedbackOpts.verdict, "verdict", "", "Verdict on the finding: useful or wrong")
+	feedbackCmd.Flags().BoolVar(&feedbackOpts.stats, "stats", false, "Print the precision of each model and prompt")
+	rootCmd.AddCommand(feedbackCmd)
+}
+
+func runFeedback(cmd *cobra.Command, args []string) error {
+	store := history.Open(cfg.StatePath(history.FileName))
+	out := cmd.OutOrStdout()
+	if len(args) == 0 {
+		if !feedbackOpts.stats {
+			return errors.New("a finding ID is required unless --stats is set")
+		}
+		return printPrecision(out, store)
+	}
+	switch feedbackOpts.verdict {
+	case history.Useful, history.Wrong:
+	default:
+		return fmt.Errorf("invalid --verdict %q (use useful or wrong)", feedbackOpts.verdict)
+	}
+	// Past this point errors are about the recorded runs, not the command line
+	cmd.SilenceUsage = true
+
+	runs, err := store.Runs()
+	if err != nil {
+		return err
+	}
+	run, f, err := findRecordedFinding(runs, args[0])
+	if err != nil {
+		return err
+	}
+	// Consensus findings are credited to each model that reported them
+	models := f.Models
+	if len(models) == 0 {
+		models = []string{run.Model}
+	}
+	now := time.Now().UTC()
+	for _, model := range models {
+		v := history.Verdict{
+			Fingerprint: f.Fingerprint(),
+			RunID:       run.ID,
+			Model:       model,
+			Prompt:      run.Prompt,
+			Verdict:     feedbackOpts.verdict,
+			RecordedAt:  now,
+			Finding:     f,
+		}
+		if err := store.RecordVerdict(v); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(out, "Recorded %s: [%s] %s: %s\n", feedbackOpts.verdict,
+		strings.ToUpper(string(f.Severity)), f.Location(), f.Message)
+	if feedbackOpts.stats {
+		return printPrecision(out, store)
+	}
+	return nil
+}
+
+// findRecordedFinding returns the most recent run reporting a finding selected by
+// fingerprint prefix or file:line, and the finding. A selector matching several
+// findings of that run is an error.
+func findRecordedFinding(runs []history.Run, selector string) (history.Run, findings.Finding, error) {
+	for i := len(runs) - 1; i >= 0; i-- {
+		var matches []findings.Finding
+		for _, f := range runs[i].Findings {
+			if strings.HasPrefix(f.Fingerprint(), selector) || f.Location() == selector {
+				matches = append(matches, f)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			continue
+		case 1:
+			return runs[i], matches[0], nil
+		}
+		var b strings.Builder
+		fmt.Fprintf(&b, "%q matches %d findings of run %s; use a longer fingerprint:", selector, len(matches), runs[i].ID)
+		for _, f := range matches {
+			fmt.Fprintf(&b, "\n  %s  %s: %s", f.Fingerprint(), f.Location(), f.Message)
+		}
+		return history.Run{}, findings.Finding{}, errors.New(b.String())
+	}
+	return history.Run{}, findings.Finding{}, fmt.Errorf("no recorded finding matches %q", selector)
+}
+
+func printPrecision(w io.Writer, store *history.Store) error {
+	verdicts, err := store.Verdicts()
+	if err != nil {
+		return err
+	}
+	list := history.PrecisionByModel(verdicts)
+	if len(list) == 0 {
+		fmt.Fprintln(w, "No verdicts recorded yet.")
+		return nil
+	}
+	report.WritePrecision(w, list)
+	return nil
+}
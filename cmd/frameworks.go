@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/frameworks"
+	"github.com/disconnekt/goreview/internal/languages"
+	"github.com/disconnekt/goreview/internal/scanner"
+)
+
+// loadFrameworkContext adds the review guidance for the frameworks each Go file
+// imports when --context includes frameworks.
+func loadFrameworkContext(files []scanner.FileInfo) {
+	counts := make(frameworkCounts)
+	for _, f := range files {
+		counts.add(f)
+	}
+	counts.log()
+}
+
+// frameworkCounts counts the files importing each framework as their contexts are added.
+type frameworkCounts map[string]int
+
+// add adds the guidance for the frameworks f imports to its context.
+func (c frameworkCounts) add(f scanner.FileInfo) {
+	if !containsString(cfg.Context, "frameworks") || languages.Detect(f.Path) != "go" {
+		return
+	}
+	list := frameworks.Detect(fileText(f))
+	if section := frameworks.PromptSection(list); section != "" {
+		addFileContext(f.Path, section)
+	}
+	for _, fw := range list {
+		c[fw.Name]++
+	}
+}
+
+func (c frameworkCounts) log() {
+	var parts []string
+	for _, name := range frameworks.Names() {
+		if n := c[name]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%s (%d)", name, n))
+		}
+	}
+	if len(parts) > 0 {
+		logf("Frameworks detected (files): %s\n", strings.Join(parts, ", "))
+	}
+}
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/disconnekt/goreview/internal/codegen"
+	"github.com/disconnekt/goreview/internal/patch"
+	"github.com/disconnekt/goreview/internal/reviewer"
+	"github.com/disconnekt/goreview/internal/scanner"
+)
+
+var genDocsOpts struct {
+	files []string
+	patch string
+	apply bool
+}
+
+var genDocsCmd = &cobra.Command{
+	Use:   "gen-docs",
+	Short: "Draft missing GoDoc comments for exported identifiers",
+	Long: `Gen-docs finds exported functions, methods, types, constants and variables without
+a doc comment, asks the model to write the comments and prints them as a unified
+diff that "git apply" accepts. With --apply the comments are written to the files
+directly. Without --file every file the review would scan is covered.`,
+	Example: `  aireview gen-docs --path ./internal/billing > godoc.patch
+  aireview gen-docs --file internal/billing/invoice.go --apply`,
+	Args: cobra.NoArgs,
+	RunE: runGenDocs,
+}
+
+func init() {
+	flags := genDocsCmd.Flags()
+	flags.StringArrayVar(&genDocsOpts.files, "file", nil, "Go file to document (repeatable; default: all scanned files)")
+	flags.StringVar(&genDocsOpts.patch, "patch", "-", `File the patch is written to ("-" for stdout)`)
+	flags.BoolVar(&genDocsOpts.apply, "apply", false, "Write the comments to the files instead of printing a patch")
+	addReviewFlags(flags)
+	rootCmd.AddCommand(genDocsCmd)
+}
+
+const genDocsPrompt = `You are a very experienced Go developer documenting a package. Write a GoDoc comment for each
+	listed identifier, following Go conventions: complete sentences starting with the identifier's
+	name (the method name for methods), describing what it does and what callers must know rather
+	than how it works, in one to three lines.
+
+	Reply with a JSON object that maps each listed name to its comment text, without comment markers,
+	and nothing else.`
+
+func runGenDocs(cmd *cobra.Command, args []string) error {
+	if err := prepareConfig(cmd); err != nil {
+		return err
+	}
+	service, err := reviewer.NewService(cfg)
+	if err != nil {
+		return err
+	}
+	repoContext, err := loadRepoContext(cfg)
+	if err != nil {
+		return err
+	}
+	service.SetContext(repoContext)
+
+	files, err := genDocsFiles()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	root := projectRoot(cfg.ProjectPath)
+	var patches []*patch.File
+	comments := 0
+	for _, f := range files {
+		p, err := genDocs(ctx, service, root, f)
+		if err != nil {
+			warnf("%v\n", err)
+			continue
+		}
+		if p != nil {
+			patches = append(patches, p)
+			comments += len(p.Edits)
+		}
+	}
+	if len(patches) == 0 {
+		fmt.Fprintln(os.Stderr, "No missing doc comments")
+		return nil
+	}
+
+	if genDocsOpts.apply {
+		if err := patch.Apply(patches); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Added %d doc comments to %d files\n", comments, len(patches))
+		return nil
+	}
+	var out io.Writer = cmd.OutOrStdout()
+	if genDocsOpts.patch != "-" {
+		file, err := os.Create(genDocsOpts.patch)
+		if err != nil {
+			return fmt.Errorf("failed to create patch file: %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+	for _, p := range patches {
+		if _, err := io.WriteString(out, p.Unified()); err != nil {
+			return fmt.Errorf("failed to write patch: %w", err)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Drafted %d doc comments in %d files\n", comments, len(patches))
+	return nil
+}
+
+// genDocsFiles returns the files named with --file, or the files a review would scan.
+func genDocsFiles() ([]scanner.FileInfo, error) {
+	if len(genDocsOpts.files) == 0 {
+		files, err := scanProject(newFileScanner())
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan files: %w", err)
+		}
+		return files, nil
+	}
+	var files []scanner.FileInfo
+	for _, path := range genDocsOpts.files {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		files = append(files, scanner.FileInfo{Path: path, Content: string(content)})
+	}
+	return files, nil
+}
+
+// genDocs drafts the missing comments of one file; it returns nil when none are missing.
+func genDocs(ctx context.Context, service *reviewer.Service, root string, f scanner.FileInfo) (*patch.File, error) {
+	content, err := f.Text()
+	if err != nil {
+		return nil, err
+	}
+	missing, err := codegen.FindUndocumented(f.Path, content)
+	if err != nil || len(missing) == 0 {
+		return nil, err
+	}
+	abs, err := filepath.Abs(f.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", f.Path, err)
+	}
+	rel := relPath(root, abs)
+
+	// The patch applies to the bytes on disk, which keep their byte order mark and
+	// CRLF line endings
+	raw, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", f.Path, err)
+	}
+	if unix := strings.ReplaceAll(strings.TrimPrefix(string(raw), "\uFEFF"), "\r\n", "\n"); unix != content {
+		return nil, fmt.Errorf("cannot patch %s: only UTF-8 files with LF or CRLF line endings are supported", rel)
+	}
+
+	var task strings.Builder
+	fmt.Fprintf(&task, "File %s:\n```go\n%s\n```\n\nIdentifiers without a doc comment:\n", rel, content)
+	for _, u := range missing {
+		fmt.Fprintf(&task, "- %s (%s, line %d)\n", u.Name, u.Kind, u.Line)
+	}
+	fmt.Fprintf(os.Stderr, "Documenting %d identifiers in %s\n", len(missing), rel)
+	reply, err := service.Generate(ctx, genDocsPrompt, task.String(), reviewer.Options{File: rel})
+	if err != nil {
+		return nil, fmt.Errorf("failed to draft comments for %s: %w", rel, err)
+	}
+	drafted, err := parseDocReply(reply)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read comments for %s: %w", rel, err)
+	}
+
+	p := &patch.File{Path: f.Path, Name: rel, Old: string(raw)}
+	for _, u := range missing {
+		text := drafted[u.Name]
+		if strings.TrimSpace(text) == "" {
+			fmt.Fprintf(os.Stderr, "  no comment drafted for %s\n", u.Name)
+			continue
+		}
+		p.Edits = append(p.Edits, patch.Edit{Start: u.Line, End: u.Line, Lines: codegen.CommentLines(text, u.Indent)})
+	}
+	if len(p.Edits) == 0 {
+		return nil, nil
+	}
+	if _, err := format.Source([]byte(p.New())); err != nil {
+		return nil, fmt.Errorf("comments drafted for %s do not leave valid Go: %w", rel, err)
+	}
+	return p, nil
+}
+
+// parseDocReply reads the JSON object of the reply, ignoring text or a code fence
+// around it.
+func parseDocReply(reply string) (map[string]string, error) {
+	start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("reply contains no JSON object")
+	}
+	var drafted map[string]string
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &drafted); err != nil {
+		return nil, fmt.Errorf("failed to parse reply: %w", err)
+	}
+	return drafted, nil
+}
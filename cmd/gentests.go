@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/disconnekt/goreview/internal/codegen"
+	"github.com/disconnekt/goreview/internal/reviewer"
+)
+
+var genTestsOpts struct {
+	files    []string
+	funcs    []string
+	out      string
+	attempts int
+	verify   bool
+}
+
+var genTestsCmd = &cobra.Command{
+	Use:   "gen-tests",
+	Short: "Draft table-driven tests for files or functions",
+	Long: `Gen-tests asks the model to draft table-driven tests for the given files, or only
+for the functions named with --func, and writes them under --out for human review.
+Each draft is compiled with "go vet" against its package (without touching the
+package on disk); drafts that fail are sent back to the model with the compiler
+errors, and those still failing after --attempts are written with the errors in a
+leading comment.`,
+	Example: `  aireview gen-tests --file internal/billing/invoice.go
+  aireview gen-tests --file parse.go --func ParseDuration,ParseSize --out drafts`,
+	Args: cobra.NoArgs,
+	RunE: runGenTests,
+}
+
+func init() {
+	flags := genTestsCmd.Flags()
+	flags.StringArrayVar(&genTestsOpts.files, "file", nil, "Go file to draft tests for (repeatable)")
+	flags.StringSliceVar(&genTestsOpts.funcs, "func", nil, "Only draft tests for these functions or methods")
+	flags.StringVar(&genTestsOpts.out, "out", "generated-tests", "Directory the drafts are written to, mirroring the file paths")
+	flags.IntVar(&genTestsOpts.attempts, "attempts", 2, "Drafts per file when the previous one does not compile")
+	flags.BoolVar(&genTestsOpts.verify, "verify", true, "Check that each draft compiles with go vet")
+	genTestsCmd.MarkFlagRequired("file")
+	addReviewFlags(flags)
+	rootCmd.AddCommand(genTestsCmd)
+}
+
+const genTestsPrompt = `You are a very experienced Go developer writing unit tests. Write table-driven tests with the
+	standard testing package for the code you are given: cover the normal behavior, boundary values
+	and error paths, use t.Run with descriptive case names, and only call identifiers that exist in
+	the code. Do not add third-party dependencies. The tests live in the same package as the code.
+
+	Reply with the complete test file in a single go code block and nothing else.`
+
+func runGenTests(cmd *cobra.Command, args []string) error {
+	if err := prepareConfig(cmd); err != nil {
+		return err
+	}
+	if genTestsOpts.attempts < 1 {
+		return fmt.Errorf("--attempts must be at least 1")
+	}
+	service, err := reviewer.NewService(cfg)
+	if err != nil {
+		return err
+	}
+	repoContext, err := loadRepoContext(cfg)
+	if err != nil {
+		return err
+	}
+	service.SetContext(repoContext)
+
+	ctx := context.Background()
+	root := projectRoot(cfg.ProjectPath)
+	failed := 0
+	for _, file := range genTestsOpts.files {
+		ok, err := genTests(ctx, service, root, file)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d drafts do not compile; fix them before use", failed, len(genTestsOpts.files))
+	}
+	return nil
+}
+
+// genTests drafts tests for one file and writes them under --out. It reports whether
+// the draft compiles (or was not verified).
+func genTests(ctx context.Context, service *reviewer.Service, root, file string) (bool, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	parsed, err := parser.ParseFile(token.NewFileSet(), file, content, parser.PackageClauseOnly)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve %s: %w", file, err)
+	}
+	rel := relPath(root, abs)
+	if strings.HasPrefix(rel, "..") {
+		rel = filepath.Base(abs)
+	}
+	name := strings.TrimSuffix(filepath.Base(abs), ".go") + "_gen_test.go"
+
+	var task strings.Builder
+	fmt.Fprintf(&task, "File %s (package %s):\n```go\n%s\n```\n", rel, parsed.Name.Name, content)
+	if len(genTestsOpts.funcs) > 0 {
+		fmt.Fprintf(&task, "\nOnly write tests for: %s.\n", strings.Join(genTestsOpts.funcs, ", "))
+	}
+	existing := strings.TrimSuffix(abs, ".go") + "_test.go"
+	if tests, err := os.ReadFile(existing); err == nil {
+		fmt.Fprintf(&task, "\nThe package already has these tests; do not repeat them or reuse their names:\n```go\n%s\n```\n", tests)
+	}
+
+	fmt.Printf("Drafting tests for %s\n", rel)
+	var draft string
+	var verifyErr error
+	prompt := task.String()
+	for attempt := 1; attempt <= genTestsOpts.attempts; attempt++ {
+		reply, err := service.Generate(ctx, genTestsPrompt, prompt, reviewer.Options{File: rel})
+		if err != nil {
+			return false, fmt.Errorf("failed to draft tests for %s: %w", rel, err)
+		}
+		draft, verifyErr = codegen.ExtractGo(reply)
+		if verifyErr != nil {
+			draft = reply
+		} else if genTestsOpts.verify {
+			verifyErr = codegen.Verify(ctx, filepath.Dir(abs), name, draft)
+		}
+		if verifyErr == nil {
+			break
+		}
+		fmt.Printf("  draft %d does not compile\n", attempt)
+		prompt = fmt.Sprintf("%s\nYour previous draft:\n```go\n%s\n```\nfailed with:\n%v\n\nReply with a corrected test file.",
+			task.String(), draft, verifyErr)
+	}
+
+	if verifyErr != nil {
+		var note strings.Builder
+		note.WriteString("// This draft does not compile yet:\n")
+		for _, line := range strings.Split(verifyErr.Error(), "\n") {
+			note.WriteString("// " + line + "\n")
+		}
+		draft = note.String() + "\n" + draft
+	}
+	out := filepath.Join(genTestsOpts.out, filepath.Dir(filepath.FromSlash(rel)), name)
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		return false, fmt.Errorf("failed to create %s: %w", filepath.Dir(out), err)
+	}
+	if err := os.WriteFile(out, []byte(draft), 0644); err != nil {
+		return false, fmt.Errorf("failed to write %s: %w", out, err)
+	}
+	switch {
+	case verifyErr != nil:
+		fmt.Printf("  wrote %s (does not compile)\n", out)
+	case genTestsOpts.verify:
+		fmt.Printf("  wrote %s (compiles)\n", out)
+	default:
+		fmt.Printf("  wrote %s (not verified)\n", out)
+	}
+	return verifyErr == nil, nil
+}
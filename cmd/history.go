@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/disconnekt/goreview/internal/findings"
+	"github.com/disconnekt/goreview/internal/history"
+	"github.com/disconnekt/goreview/internal/notify"
+)
+
+// recordHistory appends the run to the project's history store.
+func recordHistory(outcome *runOutcome, sum notify.Summary, start time.Time) error {
+	run := history.Run{
+		ID:            history.NewRunID(start),
+		StartedAt:     start.UTC(),
+		Duration:      sum.Duration,
+		Status:        sum.Status,
+		Model:         cfg.Model,
+		FilesReviewed: sum.FilesReviewed,
+		FilesFailed:   sum.FilesFailed,
+		Error:         sum.Error,
+	}
+	if outcome != nil {
+		run.Prompt = outcome.prompt
+		for _, r := range outcome.results {
+			run.Findings = append(run.Findings, r.Findings...)
+		}
+		findings.Sort(run.Findings)
+	}
+	return history.Open(cfg.StatePath(history.FileName)).Append(run)
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/disconnekt/goreview/internal/findings"
+	"github.com/disconnekt/goreview/internal/rules"
+	"github.com/disconnekt/goreview/internal/scanner"
+)
+
+// licenseHeaderRule is the rule ID of findings for files missing the license header.
+const licenseHeaderRule = "LICENSE-HEADER"
+
+// loadLicenseCheck prepares --profile license: it checks every file for the header
+// required by the rules file and gives the model the license policy to judge copied code.
+func loadLicenseCheck(files []scanner.FileInfo) error {
+	if cfg.Profile != "license" {
+		return nil
+	}
+	var policy *rules.License
+	if path := rulesPath(cfg); path != "" {
+		var err error
+		if policy, err = rules.LoadLicense(path); err != nil {
+			return fmt.Errorf("failed to load license policy: %w", err)
+		}
+	}
+	if policy == nil {
+		warnf("no license section in a YAML rules file; only copied code is checked\n")
+		return nil
+	}
+
+	root := projectRoot(cfg.ProjectPath)
+	missing := 0
+	for _, f := range files {
+		addFileContext(f.Path, policy.PromptSection())
+		if isLicenseExcluded(policy, relPath(root, f.Path)) || policy.HasHeader(fileText(f)) {
+			continue
+		}
+		missing++
+		toolFindings[f.Path] = append(toolFindings[f.Path], findings.Finding{
+			Line:     1,
+			Severity: findings.SeverityMedium,
+			RuleID:   licenseHeaderRule,
+			Message:  "File is missing the required license header",
+		})
+	}
+	logf("License header missing in %d of %d files\n", missing, len(files))
+	return nil
+}
+
+func isLicenseExcluded(policy *rules.License, rel string) bool {
+	for _, pattern := range policy.Exclude {
+		if scanner.MatchGlob(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+// quiet mirrors cfg.Quiet once the settings are merged. The server and worker call
+// logf and warnf from goroutines while a job points the shared configuration at its
+// project, so they must not read cfg.
+var quiet atomic.Bool
+
+// logf prints progress and informational messages of a run, which --quiet silences.
+func logf(format string, args ...interface{}) {
+	if quiet.Load() {
+		return
+	}
+	fmt.Printf(format, args...)
+}
+
+// warnf prints a warning to stderr unless --quiet is set.
+func warnf(format string, args ...interface{}) {
+	if quiet.Load() {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Warning: "+format, args...)
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"github.com/disconnekt/goreview/internal/languages"
+	"github.com/disconnekt/goreview/internal/metrics"
+	"github.com/disconnekt/goreview/internal/scanner"
+)
+
+// fileMetrics holds the function metrics of each Go file path, reported in the JSON
+// report.
+var fileMetrics map[string][]metrics.Function
+
+// loadComplexityMetrics measures the functions of every Go file and points out the
+// complex ones in the file's prompt.
+func loadComplexityMetrics(files []scanner.FileInfo) {
+	resetComplexityMetrics()
+	notable := 0
+	for _, f := range files {
+		notable += addComplexityMetrics(f)
+	}
+	logNotableFunctions(notable)
+}
+
+func resetComplexityMetrics() {
+	fileDataMu.Lock()
+	fileMetrics = make(map[string][]metrics.Function)
+	fileDataMu.Unlock()
+}
+
+// addComplexityMetrics measures the functions of f and returns how many of them were
+// pointed out to the model.
+func addComplexityMetrics(f scanner.FileInfo) int {
+	if languages.Detect(f.Path) != "go" {
+		return 0
+	}
+	list := metrics.Analyze(fileText(f))
+	fileDataMu.Lock()
+	fileMetrics[f.Path] = list
+	fileDataMu.Unlock()
+	if cfg.ComplexityThreshold == 0 {
+		return 0
+	}
+	top := metrics.Notable(list, cfg.ComplexityThreshold)
+	if section := metrics.PromptSection(top); section != "" {
+		addFileContext(f.Path, section)
+	}
+	return len(top)
+}
+
+func logNotableFunctions(n int) {
+	if n > 0 {
+		logf("Complex functions pointed out to the model: %d\n", n)
+	}
+}
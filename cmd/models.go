@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/disconnekt/goreview/internal/modelinfo"
+	"github.com/disconnekt/goreview/internal/reviewer"
+)
+
+var modelsCheck bool
+
+var modelsCmd = &cobra.Command{
+	Use:   "models",
+	Short: "List the models served by the configured endpoints",
+	Long: `Models queries the /models listing of every configured endpoint and prints the
+model identifiers with the context window, output limit and prices known for them,
+marking the one selected with --model. With --check it fails
+unless every reachable endpoint serves that model, so a pipeline can stop before
+an expensive run.`,
+	Example: `  aireview models --url http://127.0.0.1:1234/v1/chat/completions
+  aireview models --model gpt-4o --check`,
+	Args: cobra.NoArgs,
+	RunE: runModels,
+}
+
+func init() {
+	modelsCmd.Flags().BoolVar(&modelsCheck, "check", false,
+		"Exit with an error when the --model is not served by the endpoints")
+	addReviewFlags(modelsCmd.Flags())
+	rootCmd.AddCommand(modelsCmd)
+}
+
+func runModels(cmd *cobra.Command, args []string) error {
+	if err := prepareConfig(cmd); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	defer cancel()
+	listings, err := reviewer.ListModels(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	reachable, missing := 0, 0
+	for _, l := range listings {
+		fmt.Fprintf(out, "%s\n", l.Endpoint)
+		if l.Err != nil {
+			fmt.Fprintf(out, "  error: %v\n", l.Err)
+			continue
+		}
+		reachable++
+		for _, m := range l.Models {
+			marker := " "
+			if m == l.Requested {
+				marker = "*"
+			}
+			fmt.Fprintf(out, "  %s %s%s\n", marker, m, modelLimits(m))
+		}
+		if !l.Has() {
+			missing++
+			fmt.Fprintf(out, "  model %q is not served by this endpoint\n", l.Requested)
+		}
+	}
+
+	if reachable == 0 {
+		return errors.New("no endpoint returned a model listing")
+	}
+	if modelsCheck && missing > 0 {
+		return fmt.Errorf("model %q is not available on %d of %d endpoints", cfg.Model, missing, len(listings))
+	}
+	return nil
+}
+
+// modelLimits describes the metadata known for a model, or "" for models outside the
+// table and the models config.
+func modelLimits(model string) string {
+	info, ok := modelinfo.Lookup(model, cfg.Models)
+	if !ok {
+		return ""
+	}
+	s := fmt.Sprintf(" (context %d, output %d tokens", info.ContextWindow, info.MaxOutput)
+	if info.Priced() {
+		s += fmt.Sprintf(", $%.2f/$%.2f per 1M tokens", info.InputPrice, info.OutputPrice)
+	}
+	return s + ")"
+}
@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/disconnekt/goreview/internal/config"
+	"github.com/disconnekt/goreview/internal/findings"
+	"github.com/disconnekt/goreview/internal/languages"
+	"github.com/disconnekt/goreview/internal/repocontext"
+	"github.com/disconnekt/goreview/internal/reviewer"
+	"github.com/disconnekt/goreview/internal/routing"
+	"github.com/disconnekt/goreview/internal/scanner"
+)
+
+// normalizeModule turns a user-supplied module directory into the slash-separated form
+// used by scanner.FileInfo.Module.
+func normalizeModule(dir string) string {
+	dir = filepath.ToSlash(filepath.Clean(dir))
+	if dir == "" {
+		return "."
+	}
+	return dir
+}
+
+// applyModuleSettings restricts files to --module and applies per-module excludes and
+// file limits from the config.
+func applyModuleSettings(files []scanner.FileInfo) ([]scanner.FileInfo, error) {
+	filter := newModuleFilter()
+	var out []scanner.FileInfo
+	for _, f := range files {
+		if filter.keep(f) {
+			out = append(out, f)
+		}
+	}
+	filter.logModules()
+	if filter.selected != "" {
+		if _, ok := filter.modules[filter.selected]; !ok {
+			return nil, fmt.Errorf("module %q not found (no reviewable files in a go.mod directory there)", cfg.Module)
+		}
+	}
+	filter.logTruncated()
+	return out, nil
+}
+
+// moduleFilter applies --module and the per-module excludes and file limits to files
+// one at a time, as a streaming scan finds them.
+type moduleFilter struct {
+	selected  string
+	modules   map[string]int
+	kept      map[string]int
+	truncated map[string]int
+}
+
+func newModuleFilter() *moduleFilter {
+	m := &moduleFilter{
+		modules:   make(map[string]int),
+		kept:      make(map[string]int),
+		truncated: make(map[string]int),
+	}
+	if cfg.Module != "" {
+		m.selected = normalizeModule(cfg.Module)
+	}
+	return m
+}
+
+// keep reports whether f is reviewed. Files are counted against max_files in the order
+// they come.
+func (m *moduleFilter) keep(f scanner.FileInfo) bool {
+	m.modules[f.Module]++
+	if m.selected != "" && f.Module != m.selected {
+		return false
+	}
+	mc := moduleConfig(f.Module)
+	if isModuleExcluded(f, mc) {
+		return false
+	}
+	if mc.MaxFiles > 0 && m.kept[f.Module] >= mc.MaxFiles {
+		m.truncated[f.Module]++
+		return false
+	}
+	m.kept[f.Module]++
+	return true
+}
+
+// logModules lists the modules of the files seen when there are several.
+func (m *moduleFilter) logModules() {
+	if len(m.modules) < 2 {
+		return
+	}
+	names := make([]string, 0, len(m.modules))
+	for name := range m.modules {
+		names = append(names, displayModule(name))
+	}
+	sort.Strings(names)
+	logf("Detected %d Go modules: %s\n", len(names), strings.Join(names, ", "))
+}
+
+// logTruncated reports the files left out by the modules' max_files.
+func (m *moduleFilter) logTruncated() {
+	for name, n := range m.truncated {
+		logf("Module %s: skipped %d files over its max_files limit of %d\n", displayModule(name), n, moduleConfig(name).MaxFiles)
+	}
+}
+
+// moduleConfig returns the overrides configured for a module directory.
+func moduleConfig(module string) config.ModuleConfig {
+	if mc, ok := cfg.Modules[module]; ok {
+		return mc
+	}
+	for key, mc := range cfg.Modules {
+		if normalizeModule(key) == module {
+			return mc
+		}
+	}
+	return config.ModuleConfig{}
+}
+
+// isModuleExcluded matches the module's exclude patterns against the path relative to the module.
+func isModuleExcluded(f scanner.FileInfo, mc config.ModuleConfig) bool {
+	if len(mc.Exclude) == 0 {
+		return false
+	}
+	rel := relPath(projectRoot(cfg.ProjectPath), f.Path)
+	if f.Module != "" && f.Module != "." {
+		rel = strings.TrimPrefix(rel, f.Module+"/")
+	}
+	for _, pattern := range mc.Exclude {
+		if scanner.MatchGlob(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// moduleGoModContext holds the go.mod summary of each module directory when
+// --context includes gomod.
+var moduleGoModContext map[string]string
+
+// loadGoModContext summarizes the go.mod of every module the files belong to.
+func loadGoModContext(files []scanner.FileInfo) {
+	fileDataMu.Lock()
+	moduleGoModContext = make(map[string]string)
+	fileDataMu.Unlock()
+	for _, f := range files {
+		addGoModContext(f)
+	}
+}
+
+// addGoModContext summarizes the go.mod of f's module, unless it already is.
+func addGoModContext(f scanner.FileInfo) {
+	if !containsString(cfg.Context, "gomod") || f.Module == "" {
+		return
+	}
+	fileDataMu.RLock()
+	_, ok := moduleGoModContext[f.Module]
+	fileDataMu.RUnlock()
+	if ok {
+		return
+	}
+	root := projectRoot(cfg.ProjectPath)
+	section, err := repocontext.GoMod(filepath.Join(root, filepath.FromSlash(f.Module), "go.mod"))
+	if err != nil {
+		warnf("no go.mod context for module %s: %v\n", displayModule(f.Module), err)
+	}
+	fileDataMu.Lock()
+	moduleGoModContext[f.Module] = section
+	fileDataMu.Unlock()
+}
+
+// fileContexts holds prompt sections for single files and toolFindings the findings
+// external tools reported for them, both keyed by file path. They are filled before the
+// review starts and read-only after, except that a streaming scan adds the contexts of
+// each file as it comes.
+var (
+	fileContexts map[string]string
+	toolFindings map[string][]findings.Finding
+)
+
+// fileDataMu guards the per-file maps a streaming scan fills while the reviews of
+// earlier files are reported: fileContexts, fileOwners, fileMetrics and
+// moduleGoModContext.
+var fileDataMu sync.RWMutex
+
+// addFileContext appends a prompt section for a single file.
+func addFileContext(path, section string) {
+	fileDataMu.Lock()
+	defer fileDataMu.Unlock()
+	if prev := fileContexts[path]; prev != "" {
+		section = prev + "\n\n" + section
+	}
+	fileContexts[path] = section
+}
+
+// moduleReviewOptions maps a file's module overrides, model route, go.mod context and
+// file context to reviewer options.
+func moduleReviewOptions(f scanner.FileInfo) reviewer.Options {
+	mc := moduleConfig(f.Module)
+	language := languages.Detect(f.Path)
+	model := mc.Model
+	if model == "" {
+		model = routeModel(f, language)
+	}
+	prompt := mc.Prompt
+	fileDataMu.RLock()
+	if section := moduleGoModContext[f.Module]; section != "" {
+		prompt = strings.TrimSpace(section + "\n" + prompt)
+	}
+	if section := fileContexts[f.Path]; section != "" {
+		prompt = strings.TrimSpace(prompt + "\n\n" + section)
+	}
+	fileDataMu.RUnlock()
+	if org := orgPrompt(); org != "" {
+		prompt = strings.TrimSpace(prompt + "\n\n" + org)
+	}
+	return reviewer.Options{Model: model, Prompt: prompt, Language: language, Excerpt: authoredExcerpts[f.Path]}
+}
+
+// routeModel returns the model of the first route matching the file, or "" to use
+// --model.
+func routeModel(f scanner.FileInfo, language string) string {
+	r, ok := routing.Select(cfg.Routes, routing.File{
+		Path:     relPath(projectRoot(cfg.ProjectPath), f.Path),
+		Language: language,
+		Size:     f.Size,
+		Profile:  cfg.Profile,
+	})
+	if !ok {
+		return ""
+	}
+	return r.Model
+}
+
+// reviewModel returns the model of opts that replaces --model, or "" when there is none
+// or consensus reviews ignore it.
+func reviewModel(opts reviewer.Options) string {
+	if cfg.Consensus {
+		return ""
+	}
+	return opts.Model
+}
+
+// orgPrompt returns the prompt the organization config adds to every file, if any.
+func orgPrompt() string {
+	if orgConfig == nil {
+		return ""
+	}
+	return orgConfig.Prompt
+}
+
+func displayModule(m string) string {
+	if m == "" {
+		return "(no module)"
+	}
+	return path.Clean(m)
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/disconnekt/goreview/internal/findings"
+	"github.com/disconnekt/goreview/internal/notify"
+)
+
+// notifyTimeout bounds how long delivering all notifications may take.
+const notifyTimeout = 30 * time.Second
+
+func buildNotifiers() []notify.Notifier {
+	var notifiers []notify.Notifier
+	for _, target := range cfg.Notify {
+		switch target {
+		case "slack":
+			notifiers = append(notifiers, notify.NewSlack(cfg.SlackWebhook))
+		case "teams":
+			notifiers = append(notifiers, notify.NewTeams(cfg.TeamsWebhook))
+		case "webhook":
+			notifiers = append(notifiers, notify.NewWebhook(cfg.NotifyWebhook))
+		}
+	}
+	return notifiers
+}
+
+func buildSummary(outcome *runOutcome, runErr error, elapsed time.Duration) notify.Summary {
+	sum := notify.Summary{
+		Project:   projectRoot(cfg.ProjectPath),
+		Status:    notify.StatusCompleted,
+		Findings:  make(map[findings.Severity]int),
+		ReportURL: cfg.ReportURL,
+		Duration:  elapsed,
+	}
+	if outcome != nil {
+		sum.FilesReviewed = len(outcome.results)
+		sum.FilesFailed = outcome.filesFound - len(outcome.results)
+		for _, r := range outcome.results {
+			for sev, n := range findings.CountBySeverity(r.Findings) {
+				sum.Findings[sev] += n
+			}
+		}
+	}
+	if runErr != nil {
+		sum.Status = notify.StatusFailed
+		sum.Error = runErr.Error()
+	}
+	return sum
+}
+
+// sendNotifications delivers the summary to every configured target. Delivery
+// failures are reported as warnings and never change the run's outcome.
+func sendNotifications(sum notify.Summary) {
+	notifiers := buildNotifiers()
+	if len(notifiers) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	for _, n := range notifiers {
+		if err := n.Notify(ctx, sum); err != nil {
+			warnf("%s notification failed: %v\n", n.Name(), err)
+		}
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
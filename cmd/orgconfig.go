@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/disconnekt/goreview/internal/config"
+	"github.com/disconnekt/goreview/internal/policy"
+)
+
+// orgLockedSource is the source of settings locked by the organization config.
+const orgLockedSource = "config url (locked)"
+
+// orgConfig is the organization config of --config-url, fetched once per process.
+var orgConfig *config.Org
+
+// loadOrgConfig fetches the config named by --config-url; it returns nil when there is
+// none.
+func loadOrgConfig() (*config.Org, error) {
+	if cfg.ConfigURL == "" || orgConfig != nil {
+		return orgConfig, nil
+	}
+	org, sum, err := config.FetchOrg(context.Background(), cfg.ConfigURL, cfg.ConfigSHA256)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ConfigSHA256 == "" {
+		warnf("%s is not pinned; use --config-sha256 %s\n", cfg.ConfigURL, sum)
+	}
+	logf("Loaded organization config from %s (sha256 %s)\n", cfg.ConfigURL, sum)
+	orgConfig = org
+	return org, nil
+}
+
+// enforceOrgConfig restores the locked settings of the organization config over any
+// project config, environment variable or flag, and adds its excludes to the project's.
+func enforceOrgConfig(cmd *cobra.Command, org *config.Org, src *configSources) {
+	for _, key := range org.Locked {
+		if flag := config.FlagName(key); flag != "" && cmd.Flags().Changed(flag) {
+			warnf("--%s is ignored; %s is locked by the organization config\n", flag, key)
+		} else if from := src.settings[key]; from != "config url" {
+			warnf("%s from %s is ignored; it is locked by the organization config\n", key, from)
+		}
+		src.settings[key] = orgLockedSource
+	}
+	cfg.Apply(org.LockedSettings(), func(string) bool { return false })
+
+	for _, pattern := range org.Exclude {
+		if !containsString(cfg.Exclude, pattern) {
+			cfg.Exclude = append(cfg.Exclude, pattern)
+		}
+	}
+	if _, ok := src.settings["exclude"]; !ok && len(org.Exclude) > 0 {
+		src.settings["exclude"] = "config url"
+	}
+}
+
+// orgPolicy returns the policy of the organization config, or nil when it has none.
+func orgPolicy() (*policy.Policy, error) {
+	org, err := loadOrgConfig()
+	if err != nil || org == nil || org.Policy.Kind == 0 {
+		return nil, err
+	}
+	data, err := yaml.Marshal(&org.Policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the organization policy: %w", err)
+	}
+	return policy.Parse(data, cfg.ConfigURL)
+}
@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/disconnekt/goreview/internal/console"
+	"github.com/disconnekt/goreview/internal/history"
+	"github.com/disconnekt/goreview/internal/report"
+)
+
+// reportOutput is one rendered copy of the report.
+type reportOutput struct {
+	formatter report.Formatter
+	// path is the destination file; empty means stdout.
+	path string
+	w    io.Writer
+	file *os.File
+}
+
+// reportOutputs renders the same in-memory results into every configured output: the
+// primary --format/--report-file pair followed by each --output.
+type reportOutputs []*reportOutput
+
+// newReportOutputs builds the formatters up front so a bad format or template fails the
+// run before any file is reviewed. Files are created later by open.
+func newReportOutputs(prompt string) (reportOutputs, error) {
+	opts := report.Options{
+		Root:        workspaceRoot(),
+		PathRoot:    reportPathRoot(),
+		Template:    cfg.ReportTemplate,
+		TestBacklog: cfg.Profile == "testgap",
+		ByOwner:     cfg.GroupByOwner,
+		Run:         runInfo(prompt),
+	}
+	// Only the summary shows the feedback, read from next to the history. It is
+	// optional; a broken feedback file must not stop the review
+	if wantsSummary() {
+		if verdicts, err := history.Open(cfg.StatePath(history.FileName)).Verdicts(); err != nil {
+			warnf("%v\n", err)
+		} else {
+			opts.Precision = history.PrecisionByModel(verdicts)
+		}
+	}
+	primaryOpts := opts
+	primaryOpts.Color = strings.TrimSpace(cfg.ReportFile) == "" && useColor()
+	primary, err := report.NewFormatter(cfg.Format, primaryOpts)
+	if err != nil {
+		return nil, err
+	}
+	outputs := reportOutputs{{formatter: primary, path: strings.TrimSpace(cfg.ReportFile)}}
+	if cfg.SummaryOnly {
+		// The roll-up replaces the report on stdout, or follows the report files
+		summary, _ := report.NewFormatter("summary", opts)
+		if outputs[0].path == "" {
+			outputs[0].formatter = summary
+		} else {
+			outputs = append(outputs, &reportOutput{formatter: summary})
+		}
+	}
+	for _, spec := range cfg.Outputs {
+		name, path, _ := strings.Cut(spec, "=")
+		f, err := report.NewFormatter(strings.TrimSpace(name), opts)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --output %q: %w", spec, err)
+		}
+		outputs = append(outputs, &reportOutput{formatter: f, path: strings.TrimSpace(path)})
+	}
+	return outputs, nil
+}
+
+// wantsSummary reports whether one of the outputs is the summary format.
+func wantsSummary() bool {
+	if cfg.SummaryOnly || strings.TrimSpace(cfg.Format) == "summary" {
+		return true
+	}
+	for _, spec := range cfg.Outputs {
+		if name, _, _ := strings.Cut(spec, "="); strings.TrimSpace(name) == "summary" {
+			return true
+		}
+	}
+	return false
+}
+
+// useColor reports whether the report printed to stdout may use colors: stdout is a
+// terminal, or a pager showing them on one, and neither --no-color, NO_COLOR
+// (https://no-color.org) nor --ci is set.
+func useColor() bool {
+	if cfg.NoColor || cfg.CI || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if pagerTerminal != nil {
+		return console.Enable(pagerTerminal)
+	}
+	return console.Enable(os.Stdout)
+}
+
+// reportPathRoot is the directory report paths are relative to under --path-style;
+// "" keeps them absolute.
+func reportPathRoot() string {
+	if cfg.PathStyle == "absolute" {
+		return ""
+	}
+	return projectRoot(cfg.ProjectPath)
+}
+
+// open creates the report files, expanding date placeholders in their paths and
+// pruning reports beyond --keep-reports, and writes the headers of the formats that
+// have one; outputs without a path write to stdout.
+func (o reportOutputs) open() error {
+	now := time.Now()
+	for _, out := range o {
+		if out.path == "" {
+			out.w = os.Stdout
+			continue
+		}
+		path := report.ExpandPath(out.path, now)
+		if dir := filepath.Dir(path); dir != "." && dir != "" {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return fmt.Errorf("failed to create report directory: %w", err)
+			}
+		}
+		flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if cfg.ReportAppend {
+			flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		}
+		f, err := os.OpenFile(path, flags, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open report file: %w", err)
+		}
+		out.file = f
+		out.w = f
+		logf("Writing report to: %s\n", path)
+
+		removed, err := report.PruneReports(out.path, cfg.KeepReports)
+		for _, r := range removed {
+			logf("Removed old report: %s\n", r)
+		}
+		if err != nil {
+			warnf("%v\n", err)
+		}
+	}
+	for _, out := range o {
+		if h, ok := out.formatter.(report.HeaderWriter); ok {
+			if err := h.WriteHeader(out.w); err != nil {
+				return fmt.Errorf("failed to write report header: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// runInfo describes the model and decoding parameters of the run for the reports.
+func runInfo(prompt string) report.RunInfo {
+	temperature, seed := cfg.Sampling()
+	if cfg.Provider == "bedrock" {
+		// The Converse API takes no seed, so none was sent
+		seed = 0
+	}
+	model := cfg.Model
+	maxTokens := cfg.ModelInfo(cfg.Model).MaxOutput
+	if cfg.Consensus {
+		model = strings.Join(cfg.ConsensusModels, ", ")
+		maxTokens = 0
+		for _, m := range cfg.ConsensusModels {
+			maxTokens = max(maxTokens, cfg.ModelInfo(m).MaxOutput)
+		}
+	}
+	return report.RunInfo{
+		Model:         model,
+		Provider:      cfg.Provider,
+		Profile:       cfg.Profile,
+		Prompt:        prompt,
+		Temperature:   temperature,
+		Seed:          seed,
+		MaxTokens:     maxTokens,
+		Passes:        cfg.Passes,
+		Deterministic: cfg.Deterministic,
+	}
+}
+
+func (o reportOutputs) writeEntry(fr report.FileReview) error {
+	for _, out := range o {
+		if err := out.formatter.WriteEntry(out.w, fr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o reportOutputs) writeSkipped(skipped []report.SkippedFile) error {
+	for _, out := range o {
+		if err := out.formatter.WriteSkipped(out.w, skipped); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+	}
+	return nil
+}
+
+func (o reportOutputs) finish(all []report.FileReview) error {
+	for _, out := range o {
+		if err := out.formatter.Finish(out.w, all); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+	}
+	return nil
+}
+
+// close closes the report files, reporting the first error so a failed flush of a
+// report the CI pipeline depends on does not go unnoticed.
+func (o reportOutputs) close() error {
+	var first error
+	for _, out := range o {
+		if out.file == nil {
+			continue
+		}
+		if err := out.file.Close(); err != nil && first == nil {
+			first = fmt.Errorf("failed to close report file: %w", err)
+		}
+		out.file = nil
+	}
+	return first
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/disconnekt/goreview/internal/owners"
+	"github.com/disconnekt/goreview/internal/scanner"
+)
+
+// fileOwners holds the CODEOWNERS owners of each file of the run, keyed by path.
+var fileOwners map[string][]string
+
+// loadOwners attributes the files to their CODEOWNERS owners and, with --owner, keeps
+// only the files of that owner.
+func loadOwners(files []scanner.FileInfo) ([]scanner.FileInfo, error) {
+	attr, err := newOwnerAttribution()
+	if err != nil {
+		return nil, err
+	}
+	var kept []scanner.FileInfo
+	for _, f := range files {
+		ok, err := attr.keep(f)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			kept = append(kept, f)
+		}
+	}
+	attr.logOwner()
+	return kept, nil
+}
+
+// ownerAttribution attributes files to their CODEOWNERS owners one at a time, as a
+// streaming scan finds them.
+type ownerAttribution struct {
+	co          *owners.Codeowners
+	kept, total int
+}
+
+// newOwnerAttribution loads the CODEOWNERS file, if any, and resets fileOwners.
+func newOwnerAttribution() (*ownerAttribution, error) {
+	fileDataMu.Lock()
+	fileOwners = make(map[string][]string)
+	fileDataMu.Unlock()
+	co, err := owners.Find(projectRoot(cfg.ProjectPath))
+	if err != nil {
+		return nil, err
+	}
+	if co == nil {
+		if cfg.Owner != "" {
+			return nil, fmt.Errorf("--owner needs a CODEOWNERS file (looked in %v of the repository)", owners.Locations)
+		}
+		return &ownerAttribution{}, nil
+	}
+	logf("Attributing findings to owners from %s\n", co.Path)
+	return &ownerAttribution{co: co}, nil
+}
+
+// keep records the owners of f and reports whether f belongs to --owner, if set.
+func (a *ownerAttribution) keep(f scanner.FileInfo) (bool, error) {
+	if a.co == nil {
+		return true, nil
+	}
+	a.total++
+	abs, err := filepath.Abs(f.Path)
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve %s: %w", f.Path, err)
+	}
+	list := a.co.Owners(abs)
+	if cfg.Owner != "" && !owners.Match(list, cfg.Owner) {
+		return false, nil
+	}
+	fileDataMu.Lock()
+	fileOwners[f.Path] = list
+	fileDataMu.Unlock()
+	a.kept++
+	return true, nil
+}
+
+// logOwner reports how many of the files belong to --owner.
+func (a *ownerAttribution) logOwner() {
+	if a.co != nil && cfg.Owner != "" {
+		logf("Owner %s: %d of %d files\n", cfg.Owner, a.kept, a.total)
+	}
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/console"
+)
+
+// pagerTerminal is the terminal the pager writes to while startPager has replaced
+// os.Stdout, or nil when no pager runs.
+var pagerTerminal *os.File
+
+// pagerCommand returns the pager the terminal report is shown in, or "" when the report
+// is printed directly: no report goes to stdout, stdout is not a terminal, or
+// --no-pager, --ci or a pager of "cat" is set. Like git, AIREVIEW_PAGER wins over PAGER
+// and less is the default.
+func pagerCommand() string {
+	if cfg.NoPager || cfg.CI || !reportsToStdout() || !console.IsTerminal(os.Stdout) {
+		return ""
+	}
+	pager := "less"
+	for _, env := range []string{"AIREVIEW_PAGER", "PAGER"} {
+		if v, ok := os.LookupEnv(env); ok {
+			pager = strings.TrimSpace(v)
+			break
+		}
+	}
+	if pager == "cat" {
+		return ""
+	}
+	return pager
+}
+
+// reportsToStdout reports whether one of the configured reports is printed to stdout.
+func reportsToStdout() bool {
+	if strings.TrimSpace(cfg.ReportFile) == "" || cfg.SummaryOnly {
+		return true
+	}
+	for _, spec := range cfg.Outputs {
+		if _, path, _ := strings.Cut(spec, "="); strings.TrimSpace(path) == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// startPager starts the pager and, like git, points os.Stdout (and os.Stderr when it is
+// the terminal too) at it, so the logs and the report stream into the pager as the run
+// goes. Unless LESS is set, less quits by itself when the output fits on one screen and
+// keeps colors. The returned function restores the terminal and waits for the pager to
+// exit. It returns nil when there is no pager or it cannot be started.
+func startPager() func() {
+	pager := pagerCommand()
+	if pager == "" {
+		return nil
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		warnf("failed to start pager %q: %v\n", pager, err)
+		return nil
+	}
+	args := strings.Fields(pager)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = r
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		cmd.Env = append(cmd.Env, "LESS=FRX")
+	}
+	if err := cmd.Start(); err != nil {
+		r.Close()
+		w.Close()
+		warnf("failed to run pager %q: %v\n", pager, err)
+		return nil
+	}
+	r.Close()
+
+	stdout, stderr := os.Stdout, os.Stderr
+	pagerTerminal = stdout
+	os.Stdout = w
+	if console.IsTerminal(stderr) {
+		os.Stderr = w
+	}
+	return func() {
+		os.Stdout, os.Stderr = stdout, stderr
+		pagerTerminal = nil
+		w.Close()
+		// The pager's exit status only says how the user left it
+		_ = cmd.Wait()
+	}
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/perf"
+	"github.com/disconnekt/goreview/internal/scanner"
+)
+
+// maxHotspotsPerFile bounds the hot functions listed in one file's prompt.
+const maxHotspotsPerFile = 15
+
+var benchmarkDecl = regexp.MustCompile(`(?m)^func (Benchmark\w*)\(`)
+
+// loadPerfContext adds the hot functions of the --pprof profiles and the benchmark
+// results of each file's package to the prompts of --profile performance.
+func loadPerfContext(files []scanner.FileInfo) error {
+	if len(cfg.Pprof) == 0 {
+		return nil
+	}
+	root := projectRoot(cfg.ProjectPath)
+	for _, path := range cfg.Pprof {
+		profile, benchmarks, err := perf.Load(path)
+		if err != nil {
+			return err
+		}
+		if profile != nil {
+			addHotspotContext(files, root, filepath.Base(path), profile)
+		} else {
+			addBenchmarkContext(files, root, filepath.Base(path), benchmarks)
+		}
+	}
+	return nil
+}
+
+// addHotspotContext lists the hot functions of a profile in the files defining them.
+func addHotspotContext(files []scanner.FileInfo, root, name string, p *perf.Profile) {
+	byPath := make(map[string][]perf.Hotspot)
+	for _, h := range p.Hotspots {
+		if path, ok := resolveSourcePath(h.File, root, files); ok {
+			byPath[path] = append(byPath[path], h)
+		}
+	}
+	for path, list := range byPath {
+		var b strings.Builder
+		fmt.Fprintf(&b, "Profile data for this file (%s from %s). Focus performance findings on these hot "+
+			"functions and skip optimizations elsewhere that would not be measurable:\n", p.SampleType, name)
+		for i, h := range list {
+			if i == maxHotspotsPerFile {
+				break
+			}
+			fmt.Fprintf(&b, "- %s", shortFunction(h.Function))
+			if h.Line > 0 {
+				fmt.Fprintf(&b, " (line %d)", h.Line)
+			}
+			fmt.Fprintf(&b, ": %.1f%% flat, %.1f%% cumulative\n", h.Flat*100, h.Cum*100)
+		}
+		addFileContext(path, strings.TrimRight(b.String(), "\n"))
+	}
+
+	logf("Profile %s (%s): %d hot functions, %d of them in %d scanned files\n",
+		name, p.SampleType, len(p.Hotspots), countHotspots(byPath), len(byPath))
+	if len(p.Hotspots) > 0 && len(byPath) == 0 {
+		warnf("no hot function of %s is in a scanned file; was the profile taken from this code?\n", name)
+	}
+}
+
+func countHotspots(byPath map[string][]perf.Hotspot) int {
+	n := 0
+	for _, list := range byPath {
+		n += len(list)
+	}
+	return n
+}
+
+// shortFunction drops the import path of a pprof function name, e.g.
+// "github.com/a/b/pkg.(*T).M" becomes "pkg.(*T).M".
+func shortFunction(name string) string {
+	return name[strings.LastIndex(name, "/")+1:]
+}
+
+// addBenchmarkContext adds benchmark results to the files of the package declaring
+// the benchmarks, found in the package's _test.go files.
+func addBenchmarkContext(files []scanner.FileInfo, root, name string, benchmarks []perf.Benchmark) {
+	declared := make(map[string]map[string]bool)
+	matched := 0
+	for _, f := range files {
+		dir := filepath.Dir(f.Path)
+		if _, ok := declared[dir]; !ok {
+			declared[dir] = benchmarksIn(dir)
+		}
+		pkg := packagePath(f, root)
+		var lines []string
+		for _, bm := range benchmarks {
+			if !declared[dir][bm.Function()] {
+				continue
+			}
+			// Benchmarks of the same name in other packages are told apart by import path
+			if bm.Package != "" && pkg != "" && bm.Package != pkg {
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("- %s: %s", bm.Name, strings.Join(bm.Metrics, ", ")))
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		matched++
+		addFileContext(f.Path, fmt.Sprintf("Benchmark results for this file's package (from %s). Use them to "+
+			"judge which code is hot and whether allocations matter:\n%s", name, strings.Join(lines, "\n")))
+	}
+	logf("Benchmarks %s: %d results, added to %d files\n", name, len(benchmarks), matched)
+}
+
+// packagePath returns the import path of a file's package, or "" outside a module.
+func packagePath(f scanner.FileInfo, root string) string {
+	if f.ModulePath == "" {
+		return ""
+	}
+	rel := relPath(filepath.Join(root, filepath.FromSlash(f.Module)), filepath.Dir(f.Path))
+	if rel == "." {
+		return f.ModulePath
+	}
+	return f.ModulePath + "/" + rel
+}
+
+// benchmarksIn returns the benchmark functions declared in the _test.go files of dir.
+func benchmarksIn(dir string) map[string]bool {
+	names := make(map[string]bool)
+	matches, _ := filepath.Glob(filepath.Join(dir, "*_test.go"))
+	for _, m := range matches {
+		data, err := os.ReadFile(m)
+		if err != nil {
+			continue
+		}
+		for _, sub := range benchmarkDecl.FindAllStringSubmatch(string(data), -1) {
+			names[sub[1]] = true
+		}
+	}
+	return names
+}
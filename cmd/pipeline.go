@@ -0,0 +1,702 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/disconnekt/goreview/internal/findings"
+	"github.com/disconnekt/goreview/internal/report"
+	"github.com/disconnekt/goreview/internal/reviewer"
+	"github.com/disconnekt/goreview/internal/scanner"
+)
+
+// A run's files are reviewed by a pipeline of stages connected by channels: scan reads
+// the files, prepare groups identical files and reads the content of each group's
+// first file, chunk splits a file too large for one request into excerpts, a pool of
+// reviewers sends each to the model, and report puts the reviews of a file's chunks
+// together into its report entries as they arrive. A channel holds at most one unit per
+// reviewer and the scan reads only a few files ahead of the one it passes on, so a slow
+// model holds back the reads of the file system instead of letting contents pile up in
+// memory.
+
+// fileSource starts the scan feeding a pipeline. It returns the channel of files, closed
+// when the scan ends or ctx is done, and a function returning the error that ended the
+// scan once the channel is closed.
+type fileSource func(ctx context.Context) (<-chan scanner.FileInfo, func() error)
+
+// sliceSource feeds files scanned beforehand to a pipeline.
+func sliceSource(files []scanner.FileInfo) fileSource {
+	return func(ctx context.Context) (<-chan scanner.FileInfo, func() error) {
+		out := make(chan scanner.FileInfo)
+		go func() {
+			defer close(out)
+			for _, f := range files {
+				select {
+				case out <- f:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return out, func() error { return nil }
+	}
+}
+
+// reviewUnit is a file on its way to the reviews: the first file of a group of
+// identical files, with its content or the error reading it, or a later file of the
+// group, which reuses the review of the first.
+type reviewUnit struct {
+	file  scanner.FileInfo
+	group int
+	dup   bool
+	opts  reviewer.Options
+	code  string
+	err   error
+	// weight is the share of the memory budget the unit holds until its group settles
+	weight int64
+}
+
+// reviewChunk is a part of a unit sent to the model in one review: the excerpt of the
+// file in opts, index of count chunks. A unit within the budget of one request is a
+// single chunk with its excerpt unchanged; later files of a group pass as one chunk.
+// kept is the review of the chunk from an earlier failed attempt at the file, which
+// passes through the review stage instead of being resent.
+type reviewChunk struct {
+	unit  *reviewUnit
+	index int
+	count int
+	opts  reviewer.Options
+	kept  *reviewer.Result
+}
+
+// reviewOutcome is a chunk leaving the review stage: its review or the error, and the
+// time the review took.
+type reviewOutcome struct {
+	chunk  reviewChunk
+	review *reviewer.Result
+	err    error
+	took   time.Duration
+}
+
+// reviewGroup is a group of identical files as the report stage sees it: the outcomes of
+// the chunks of its first file as they arrive, their merged outcome once all did, and
+// the later files waiting for it until then.
+type reviewGroup struct {
+	chunks  []*reviewOutcome
+	arrived int
+	outcome *reviewOutcome
+	// failure is the group's index in pipeline.failures when its review failed
+	failure int
+	// dropped is set when the review was cut short by the end of the run
+	dropped bool
+	waiting []scanner.FileInfo
+}
+
+// memoryBudget bounds the bytes of file content in flight between the prepare and the
+// report stages for --max-memory: a file's content is read only once its size fits
+// next to the files whose reviews have not arrived yet. A file larger than the whole
+// budget waits until nothing else is in flight and then passes alone. It is safe for
+// concurrent use; a nil budget bounds nothing.
+type memoryBudget struct {
+	limit int64
+
+	mu   sync.Mutex
+	cond *sync.Cond
+	used int64
+}
+
+// newMemoryBudget returns the budget of limit bytes, whose waits end with ctx, or nil
+// for no limit.
+func newMemoryBudget(ctx context.Context, limit int64) *memoryBudget {
+	if limit <= 0 {
+		return nil
+	}
+	b := &memoryBudget{limit: limit}
+	b.cond = sync.NewCond(&b.mu)
+	context.AfterFunc(ctx, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.cond.Broadcast()
+	})
+	return b
+}
+
+// acquire waits until size more bytes fit and returns the weight to release, or false
+// when ctx ended first.
+func (b *memoryBudget) acquire(ctx context.Context, size int64) (int64, bool) {
+	if b == nil {
+		return 0, true
+	}
+	n := min(size, b.limit)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for b.used > 0 && b.used+n > b.limit && ctx.Err() == nil {
+		b.cond.Wait()
+	}
+	if ctx.Err() != nil {
+		return 0, false
+	}
+	b.used += n
+	return n, true
+}
+
+// release returns a weight taken with acquire.
+func (b *memoryBudget) release(n int64) {
+	if b == nil || n == 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.used -= n
+	b.cond.Broadcast()
+}
+
+// stageStats measures a pipeline stage: the units it handled, the time spent on them,
+// and the time spent waiting for units from the stage before it and for room in the
+// stage after it. It is safe for concurrent use.
+type stageStats struct {
+	name    string
+	workers int
+
+	mu      sync.Mutex
+	units   int
+	busy    time.Duration
+	idle    time.Duration
+	blocked time.Duration
+}
+
+func (s *stageStats) add(idle, busy, blocked time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.units++
+	s.idle += idle
+	s.busy += busy
+	s.blocked += blocked
+}
+
+// pipeline holds the stages of a review run. The fields after stats are owned by the
+// stage named in their comment, and read by others once process returned.
+type pipeline struct {
+	ctx     context.Context
+	abort   context.CancelFunc
+	run     *reviewRun
+	workers int
+	// analyses, when set, runs the per-file analyses of a streaming scan in the prepare
+	// stage
+	analyses *streamAnalyses
+	// kept holds the chunk outcomes of the failed reviews being retried, by the path of
+	// the group's first file, for the chunk stage to reuse those that succeeded
+	kept map[string][]*reviewOutcome
+	// memory bounds the content in flight with --max-memory; taken by prepare and
+	// returned by report
+	memory *memoryBudget
+
+	scan, prepare, chunk, review, report *stageStats
+
+	// scan: the repositories the files came from, and the error ending the scan early
+	repos   []string
+	scanErr error
+
+	// prepare: the files passed on, also read by report for the progress total, and
+	// how many of them reuse the review of an identical file
+	fed  atomic.Int64
+	dups int
+
+	// report
+	groups      map[int]*reviewGroup
+	results     []report.FileReview
+	failures    []reviewFailure
+	errors      []error
+	failedFiles int
+	aborted     bool
+	// unfinished counts files left unreviewed because the run was cancelled, timed out
+	// or aborted; done the files handled and total the files of the run, for
+	// reviewProgress
+	unfinished int
+	done       int
+	total      int
+}
+
+func newPipeline(ctx context.Context, abort context.CancelFunc, run *reviewRun, workers, total int) *pipeline {
+	return &pipeline{
+		ctx:     ctx,
+		abort:   abort,
+		run:     run,
+		workers: workers,
+		total:   total,
+		scan:    &stageStats{name: "scan", workers: 1},
+		prepare: &stageStats{name: "prepare", workers: 1},
+		chunk:   &stageStats{name: "chunk", workers: 1},
+		review:  &stageStats{name: "review", workers: workers},
+		report:  &stageStats{name: "report", workers: 1},
+	}
+}
+
+// process runs the files of source through the stages and returns when all of them are
+// done or the run ends.
+func (p *pipeline) process(source fileSource) {
+	scanned := make(chan scanner.FileInfo, p.workers)
+	units := make(chan reviewUnit, p.workers)
+	chunks := make(chan reviewChunk, p.workers)
+	reviewed := make(chan reviewOutcome, p.workers)
+	p.fed.Store(0)
+	p.groups = make(map[int]*reviewGroup)
+	if !cfg.Batch {
+		// A batch holds every request until it is submitted, so it cannot be bounded
+		p.memory = newMemoryBudget(p.ctx, cfg.MaxMemory)
+	}
+
+	go p.scanStage(source, scanned)
+	go p.prepareStage(scanned, units)
+	var reviewers sync.WaitGroup
+	startReviewers := func(n int) {
+		for i := 0; i < n; i++ {
+			reviewers.Add(1)
+			go func() {
+				defer reviewers.Done()
+				p.reviewStage(chunks, reviewed)
+			}()
+		}
+	}
+	chunked := make(chan struct{})
+	go func() {
+		defer close(chunked)
+		p.chunkStage(units, chunks, startReviewers)
+	}()
+	if !cfg.Batch {
+		startReviewers(p.workers)
+	}
+	go func() {
+		<-chunked
+		reviewers.Wait()
+		close(reviewed)
+	}()
+
+	handled := p.reportStage(reviewed)
+	fed := int(p.fed.Load())
+	p.unfinished += fed - handled
+	p.total = max(p.total, fed)
+}
+
+// scanStage passes on the files of source as the scan reads them. Its busy time is the
+// time spent waiting for the scan. A scan failing part-way aborts the run.
+func (p *pipeline) scanStage(source fileSource, out chan<- scanner.FileInfo) {
+	defer close(out)
+	files, wait := source(p.ctx)
+	for {
+		started := time.Now()
+		f, ok := <-files
+		if !ok {
+			break
+		}
+		ready := time.Now()
+		if f.Repo != "" && !slices.Contains(p.repos, f.Repo) {
+			p.repos = append(p.repos, f.Repo)
+		}
+		select {
+		case out <- f:
+		case <-p.ctx.Done():
+		}
+		p.scan.add(0, ready.Sub(started), time.Since(ready))
+	}
+	if err := wait(); err != nil && p.ctx.Err() == nil {
+		p.scanErr = err
+		p.abort()
+	}
+}
+
+// prepareStage runs the analyses of a streaming scan on each file, groups identical
+// files and reads the content of each group's first file, which the scan may have left
+// on disk.
+func (p *pipeline) prepareStage(in <-chan scanner.FileInfo, out chan<- reviewUnit) {
+	defer close(out)
+	index := make(map[string]int)
+	waiting := time.Now()
+	for f := range in {
+		started := time.Now()
+		idle := started.Sub(waiting)
+		if p.ctx.Err() != nil {
+			return
+		}
+		unit, ok := p.prepareFile(f, index)
+		ready := time.Now()
+		if ok {
+			p.fed.Add(1)
+			select {
+			case out <- unit:
+			case <-p.ctx.Done():
+				return
+			}
+		}
+		waiting = time.Now()
+		p.prepare.add(idle, ready.Sub(started), waiting.Sub(ready))
+	}
+	if p.analyses != nil && p.ctx.Err() == nil {
+		p.analyses.logSummary()
+		if n := p.fed.Load(); n > 0 {
+			logf("Found %d Go files to review\n", n)
+		}
+	}
+}
+
+// prepareFile turns f into a unit, unless the analyses of a streaming scan leave it out.
+// index maps the content and review options of each group's first file to the group.
+func (p *pipeline) prepareFile(f scanner.FileInfo, index map[string]int) (reviewUnit, bool) {
+	if p.analyses != nil {
+		ok, err := p.analyses.admit(f)
+		if err != nil {
+			group := len(index)
+			index["path\x00"+f.Path] = group
+			return reviewUnit{file: f, group: group, err: err}, true
+		}
+		if !ok {
+			return reviewUnit{}, false
+		}
+	}
+	opts := moduleReviewOptions(f)
+	opts.File = relPath(p.run.projectRoot, f.Path)
+	key := f.Hash + "\x00" + opts.Model + "\x00" + opts.Prompt
+	if f.Hash == "" {
+		key = "path\x00" + f.Path
+	}
+	if group, ok := index[key]; ok {
+		p.dups++
+		return reviewUnit{file: f, group: group, dup: true}, true
+	}
+	weight, ok := p.memory.acquire(p.ctx, f.Size)
+	if !ok {
+		return reviewUnit{}, false
+	}
+	group := len(index)
+	index[key] = group
+	code, err := f.Text()
+	return reviewUnit{file: f, group: group, opts: opts, code: code, err: err, weight: weight}, true
+}
+
+// chunkStage splits the content of each unit into the chunks reviewed one request at a
+// time, within the budget of reviewer.Service.ChunkChars; the chunks of a file share
+// its request ID. With --batch, the chunks are held until the scan ends and a reviewer
+// is started for each, so every request waits for the batch; otherwise the reviewers
+// are already running.
+func (p *pipeline) chunkStage(in <-chan reviewUnit, out chan<- reviewChunk, startReviewers func(n int)) {
+	defer close(out)
+	var held []reviewChunk
+	waiting := time.Now()
+	for unit := range in {
+		started := time.Now()
+		idle := started.Sub(waiting)
+		chunks := p.chunkUnit(unit)
+		ready := time.Now()
+		if cfg.Batch {
+			held = append(held, chunks...)
+		} else {
+			for _, c := range chunks {
+				select {
+				case out <- c:
+				case <-p.ctx.Done():
+					return
+				}
+			}
+		}
+		waiting = time.Now()
+		p.chunk.add(idle, ready.Sub(started), waiting.Sub(ready))
+	}
+	if !cfg.Batch {
+		return
+	}
+
+	reviews := 0
+	for _, c := range held {
+		if !c.unit.dup && c.kept == nil {
+			reviews++
+		}
+	}
+	p.run.service.ExpectReviews(reviews)
+	p.review.workers = max(p.workers, len(held))
+	startReviewers(p.review.workers)
+	for _, c := range held {
+		select {
+		case out <- c:
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+// chunkUnit returns the chunks of u, which share it. When the file is being retried
+// and is split as before, the chunks reviewed in the failed attempt carry their review.
+func (p *pipeline) chunkUnit(u reviewUnit) []reviewChunk {
+	unit := &u
+	if unit.dup || unit.err != nil {
+		return []reviewChunk{{unit: unit, count: 1, opts: unit.opts}}
+	}
+	opts := unit.opts
+	opts.RequestID = reviewer.NewRequestID()
+	excerpts := reviewer.Chunk(unit.code, opts.Excerpt, p.run.service.ChunkChars(opts))
+	kept := p.kept[unit.file.Path]
+	if len(kept) != len(excerpts) {
+		kept = nil
+	}
+	chunks := make([]reviewChunk, len(excerpts))
+	for i, excerpt := range excerpts {
+		chunks[i] = reviewChunk{unit: unit, index: i, count: len(excerpts), opts: opts}
+		chunks[i].opts.Excerpt = excerpt
+		if kept != nil && kept[i].err == nil && slices.Equal(kept[i].chunk.opts.Excerpt, excerpt) {
+			chunks[i].kept = kept[i].review
+		}
+	}
+	return chunks
+}
+
+// reviewStage reviews chunks until the chunk stage is done. Chunks arriving after the
+// run ended, and reviews cut short by it, are dropped and count as unfinished. Later
+// files of a group and chunks kept from a failed attempt pass through.
+func (p *pipeline) reviewStage(in <-chan reviewChunk, out chan<- reviewOutcome) {
+	waiting := time.Now()
+	for c := range in {
+		if c.unit.dup || c.kept != nil {
+			out <- reviewOutcome{chunk: c, review: c.kept}
+			continue
+		}
+		started := time.Now()
+		idle := started.Sub(waiting)
+		if p.ctx.Err() != nil {
+			p.run.service.SkipReview()
+			waiting = time.Now()
+			continue
+		}
+		f, opts := c.unit.file, c.opts
+		if !cfg.CI {
+			logf("Reviewing: %s%s\n", f.Path, reviewLabel(c))
+		}
+
+		var review *reviewer.Result
+		err := c.unit.err
+		if err == nil {
+			review, err = p.run.service.ReviewCode(p.ctx, c.unit.code, opts)
+		} else {
+			p.run.service.SkipReview()
+		}
+		if err != nil && p.ctx.Err() != nil {
+			waiting = time.Now()
+			continue
+		}
+		finished := time.Now()
+		out <- reviewOutcome{chunk: c, review: review, err: err, took: finished.Sub(started)}
+		waiting = time.Now()
+		p.review.add(idle, finished.Sub(started), waiting.Sub(finished))
+	}
+}
+
+// reviewLabel describes the chunk and the request of a review for its log line.
+func reviewLabel(c reviewChunk) string {
+	label := ""
+	if c.count > 1 {
+		excerpt := c.opts.Excerpt
+		label = fmt.Sprintf(" lines %d-%d, chunk %d of %d", excerpt[0].First, excerpt[len(excerpt)-1].Last, c.index+1, c.count)
+	}
+	label += " (request " + c.opts.RequestID
+	if model := reviewModel(c.opts); model != "" {
+		label += ", model " + model
+	}
+	return label + ")"
+}
+
+// reportStage puts the reviews of each file's chunks together, turns them into report
+// entries and records failures, aborting the run once --max-errors files have failed.
+// It returns how many files it handled.
+func (p *pipeline) reportStage(in <-chan reviewOutcome) int {
+	handled := 0
+	waiting := time.Now()
+	for o := range in {
+		started := time.Now()
+		idle := started.Sub(waiting)
+		unit := o.chunk.unit
+		g := p.groups[unit.group]
+		if g == nil {
+			g = &reviewGroup{}
+			p.groups[unit.group] = g
+		}
+		switch {
+		case unit.dup:
+			handled += p.reuse(g, unit.file)
+		case o.err != nil && p.ctx.Err() != nil:
+			// Reviews failing once the run was aborted count as unfinished, with their group
+			g.dropped = true
+		default:
+			if g.chunks == nil {
+				g.chunks = make([]*reviewOutcome, o.chunk.count)
+			}
+			outcome := o
+			g.chunks[o.chunk.index] = &outcome
+			if g.arrived++; g.arrived == o.chunk.count {
+				handled += p.settle(g, mergeOutcomes(g.chunks))
+			}
+		}
+		waiting = time.Now()
+		p.report.add(idle, waiting.Sub(started), 0)
+	}
+	return handled
+}
+
+// mergeOutcomes puts the outcomes of the chunks of a file together. The file fails with
+// the error of its first failed chunk; its review counts the chunks kept from a failed
+// attempt and carries the request ID of this one.
+func mergeOutcomes(chunks []*reviewOutcome) reviewOutcome {
+	merged := reviewOutcome{chunk: chunks[0].chunk}
+	merged.chunk.opts.Excerpt = chunks[0].chunk.unit.opts.Excerpt
+	results := make([]*reviewer.Result, len(chunks))
+	excerpts := make([][]reviewer.LineRange, len(chunks))
+	reused := 0
+	for i, o := range chunks {
+		merged.took += o.took
+		if o.err != nil && merged.err == nil {
+			merged.err = o.err
+		}
+		if o.chunk.kept != nil {
+			reused++
+		}
+		results[i], excerpts[i] = o.review, o.chunk.opts.Excerpt
+	}
+	if merged.err == nil {
+		merged.review = reviewer.MergeChunks(results, excerpts)
+		if reused > 0 {
+			merged.review.RequestID = merged.chunk.opts.RequestID
+			merged.review.Metadata.Reused += reused
+		}
+	}
+	return merged
+}
+
+// settle records the outcome of the review of a group's first file for it and the
+// files of the group waiting for it, and returns how many files it handled. The
+// content of the file leaves the memory budget; a retry reads it again.
+func (p *pipeline) settle(g *reviewGroup, o reviewOutcome) int {
+	g.outcome = &o
+	unit := o.chunk.unit
+	unit.code = ""
+	p.memory.release(unit.weight)
+	first := unit.file
+	files := append([]scanner.FileInfo{first}, g.waiting...)
+	g.waiting = nil
+	if o.err != nil {
+		g.failure = len(p.failures)
+		p.failures = append(p.failures, reviewFailure{group: files, err: o.err, chunks: g.chunks})
+		p.fail(len(files))
+	} else {
+		p.run.stats.recordFile(o.chunk.opts.File, first.Size, o.took)
+		for _, f := range files {
+			p.addEntry(o, f)
+		}
+	}
+	p.progress(len(files))
+	return len(files)
+}
+
+// reuse gives f, a later file of a group, the outcome of the group's review, or holds
+// it until that arrives. It returns how many files it handled.
+func (p *pipeline) reuse(g *reviewGroup, f scanner.FileInfo) int {
+	switch {
+	case g.dropped:
+		return 0
+	case g.outcome == nil:
+		g.waiting = append(g.waiting, f)
+		return 0
+	case g.outcome.err != nil:
+		rf := &p.failures[g.failure]
+		rf.group = append(rf.group, f)
+		p.fail(1)
+	default:
+		p.addEntry(*g.outcome, f)
+	}
+	p.progress(1)
+	return 1
+}
+
+// fail counts n failed files and aborts the run once --max-errors files have failed.
+func (p *pipeline) fail(n int) {
+	p.failedFiles += n
+	if cfg.MaxErrors > 0 && p.failedFiles >= cfg.MaxErrors && !p.aborted {
+		p.aborted = true
+		p.abort()
+	}
+}
+
+// addEntry adds the report entry of file g of a reviewed group and writes it to the
+// report outputs.
+func (p *pipeline) addEntry(o reviewOutcome, g scanner.FileInfo) {
+	review := o.review
+	// Each copy gets its own findings so locations and baseline decisions stay per path
+	list := append([]findings.Finding(nil), review.Findings...)
+	rel := relPath(p.run.projectRoot, g.Path)
+	for i := range list {
+		list[i].File = rel
+		list[i].Module = g.ModulePath
+	}
+	fileDataMu.RLock()
+	defer fileDataMu.RUnlock()
+	list, todos := triageTodos(list, fileTodos[g.Path])
+	list = mergeToolFindings(list, toolFindings[g.Path], rel, g.ModulePath)
+	for i := range list {
+		list[i].Owners = fileOwners[g.Path]
+	}
+	list = onAuthoredLines(g.Path, list)
+	kept, suppressed := p.run.baseline.Filter(list)
+	result := report.FileReview{
+		Path:        g.Path,
+		Module:      g.ModulePath,
+		Owners:      fileOwners[g.Path],
+		Size:        g.Size,
+		Review:      review.Review,
+		Findings:    kept,
+		Suppressed:  suppressed,
+		Truncated:   review.Truncated,
+		APIChanges:  fileAPIChanges[g.Path],
+		Functions:   fileMetrics[g.Path],
+		Todos:       todos,
+		Churn:       fileChurn[g.Path],
+		Model:       reviewModel(o.chunk.opts),
+		Prefiltered: review.Prefiltered,
+
+		RequestID:          review.RequestID,
+		ProviderRequestIDs: review.ProviderRequestIDs,
+	}
+	if first := o.chunk.unit.file; first.Path != g.Path {
+		result.DuplicateOf = first.Path
+	} else {
+		result.Usage = reviewUsage(review.Metadata)
+	}
+
+	p.results = append(p.results, result)
+	if reviewFindings != nil {
+		reviewFindings(rel, kept)
+	}
+	if err := p.run.outputs.writeEntry(result); err != nil {
+		p.errors = append(p.errors, fmt.Errorf("failed to write report for %s: %w", g.Path, err))
+	}
+}
+
+// stopReason describes why the run ended before all its files were reviewed.
+func (p *pipeline) stopReason() string {
+	switch {
+	case p.aborted:
+		return fmt.Sprintf("aborted after %d failed files (--max-errors)", p.failedFiles)
+	case p.run.ctx.Err() == context.DeadlineExceeded:
+		return fmt.Sprintf("run timeout of %s reached", cfg.RunTimeout)
+	}
+	return "run was cancelled"
+}
+
+// progress tells reviewProgress that n more files were handled.
+func (p *pipeline) progress(n int) {
+	if reviewProgress == nil {
+		return
+	}
+	total := max(p.total, int(p.fed.Load()))
+	p.done = min(p.done+n, total)
+	reviewProgress(p.done, total)
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/disconnekt/goreview/internal/blame"
+	"github.com/disconnekt/goreview/internal/findings"
+	"github.com/disconnekt/goreview/internal/policy"
+)
+
+// policyFailedStatus is the exit status of a run whose policy failed, distinct from
+// the status 1 of runs that could not complete.
+const policyFailedStatus = 2
+
+// loadPolicy reads the policy named by --policy or found in the project, extended with
+// the rules of the organization config; it returns nil when there is none.
+func loadPolicy() (*policy.Policy, error) {
+	pol, err := orgPolicy()
+	if err != nil {
+		return nil, err
+	}
+	path := cfg.PolicyFile
+	if path == "" {
+		path = policy.Discover(cfg.ProjectPath)
+	}
+	if path == "" {
+		return pol, nil
+	}
+	local, err := policy.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if pol == nil {
+		return local, nil
+	}
+	// The organization's rules come first and keep their base revision
+	pol.Extend(local)
+	return pol, nil
+}
+
+// addSubmoduleChanges adds the lines of the submodule repo changed since the commit it
+// was at in base to changed, keyed by path relative to root.
+func addSubmoduleChanges(changed map[string]map[int]bool, root, base, repo string) error {
+	ctx := context.Background()
+	commit, err := blame.Gitlink(ctx, root, base, repo)
+	if err != nil {
+		return err
+	}
+	lines, err := blame.Changed(ctx, filepath.Join(root, filepath.FromSlash(repo)), commit)
+	if err != nil {
+		return err
+	}
+	for file, set := range lines {
+		changed[repo+"/"+file] = set
+	}
+	return nil
+}
+
+// enforcePolicy evaluates the policy against the run's findings, prints the decision
+// and fails the command with policyFailedStatus when a rule fails.
+func enforcePolicy(cmd *cobra.Command, pol *policy.Policy, outcome *runOutcome) error {
+	if pol == nil || outcome == nil {
+		return nil
+	}
+	run := policy.Run{Profile: cfg.Profile}
+	for _, r := range outcome.results {
+		run.Findings = append(run.Findings, r.Findings...)
+	}
+	if pol.NeedsChangedLines() {
+		root := projectRoot(cfg.ProjectPath)
+		changed, err := blame.Changed(context.Background(), root, pol.Base)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate the policy: %w", err)
+		}
+		// A submodule is compared against the commit it was at in the base revision
+		for _, repo := range outcome.repos {
+			if err := addSubmoduleChanges(changed, root, pol.Base, repo); err != nil {
+				warnf("changed lines leave out %s: %v\n", repo, err)
+			}
+		}
+		run.Changed = func(file string, line int) bool {
+			lines, ok := changed[file]
+			// Findings about a whole file count when the file changed
+			return ok && (line == 0 || lines[line])
+		}
+	}
+
+	decision := pol.Evaluate(run)
+	if cfg.Quiet && decision.Passed() {
+		return nil
+	}
+	verdict := "PASS"
+	if !decision.Passed() {
+		verdict = "FAIL"
+	}
+	fmt.Printf("\nPolicy: %s\n", verdict)
+	for _, res := range decision.Results {
+		mark := "ok  "
+		if res.Failed() {
+			mark = "FAIL"
+		}
+		name := res.Rule.Name
+		if res.Rule.Description != "" {
+			name += " (" + res.Rule.Description + ")"
+		}
+		fmt.Printf("  %s %s: %d matching findings, %d allowed\n", mark, name, len(res.Matched), res.Rule.Max)
+		if !res.Failed() {
+			continue
+		}
+		findings.Sort(res.Matched)
+		for _, f := range res.Matched {
+			fmt.Printf("         [%s] %s: %s\n", strings.ToUpper(string(f.Severity)), f.Location(), f.Message)
+		}
+	}
+	if decision.Passed() {
+		return nil
+	}
+	cmd.SilenceUsage = true
+	return &exitError{code: policyFailedStatus, err: errors.New("the policy failed")}
+}
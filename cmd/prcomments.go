@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/disconnekt/goreview/internal/github"
+	"github.com/disconnekt/goreview/internal/gitlab"
+	"github.com/disconnekt/goreview/internal/prcomment"
+	"github.com/disconnekt/goreview/internal/report"
+)
+
+// postPRComments syncs findings to the pull/merge request selected by --pr-comments.
+// Errors are warnings: commenting is a side channel and must not fail the review.
+func postPRComments(outcome *runOutcome, runErr error) {
+	if cfg.PRComments == "" || outcome == nil {
+		return
+	}
+	platform, err := prCommentPlatform()
+	if err != nil {
+		warnf("PR comments disabled: %v\n", err)
+		return
+	}
+
+	root := workspaceRoot()
+	var want []prcomment.Comment
+	for _, r := range outcome.results {
+		path := report.RelativeTo(root, r.Path)
+		for _, f := range r.Findings {
+			want = append(want, prcomment.FromFinding(path, f))
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*notifyTimeout)
+	defer cancel()
+	stats, err := prcomment.Sync(ctx, platform, want)
+	if err != nil {
+		warnf("failed to sync PR comments: %v\n", err)
+		return
+	}
+
+	sum := buildSummary(outcome, runErr, 0)
+	headline := fmt.Sprintf("Reviewed %d files. Findings: %s", sum.FilesReviewed, sum.SeverityLine())
+	if err := platform.UpsertSummary(ctx, prcomment.SummaryBody(headline, stats.Unplaced)); err != nil {
+		warnf("failed to update PR summary comment: %v\n", err)
+	}
+	logf("PR comments: %d created, %d updated, %d unchanged, %d resolved, %d outside the diff\n",
+		stats.Created, stats.Updated, stats.Unchanged, stats.Resolved, len(stats.Unplaced))
+}
+
+func prCommentPlatform() (prcomment.Platform, error) {
+	switch cfg.PRComments {
+	case "github":
+		client, err := github.NewClientFromEnv(cfg.GitHubToken)
+		if err != nil {
+			return nil, err
+		}
+		number, err := github.PullRequestNumber()
+		if err != nil {
+			return nil, err
+		}
+		return client.PullRequestComments(number, github.HeadSHA()), nil
+	case "gitlab":
+		client, err := gitlab.NewClientFromEnv(cfg.GitLabToken)
+		if err != nil {
+			return nil, err
+		}
+		iid, err := gitlab.MergeRequestIID()
+		if err != nil {
+			return nil, err
+		}
+		return client.MergeRequestComments(iid), nil
+	}
+	return nil, fmt.Errorf("unknown platform %q", cfg.PRComments)
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/disconnekt/goreview/internal/blame"
+	"github.com/disconnekt/goreview/internal/scanner"
+)
+
+// fileChurn holds the recent churn of each file path for the risk and heatmap
+// formats. It is filled before the review starts and read-only after.
+var fileChurn map[string]int
+
+// loadChurn reads from git how much each file changed since --churn-since, when a risk
+// or heatmap report is requested. Outside a git repository churn is left out of the
+// score with a warning.
+func loadChurn(ctx context.Context, files []scanner.FileInfo) {
+	fileChurn = nil
+	if cfg.Format != "risk" && cfg.Format != "heatmap" && !hasOutput("risk") && !hasOutput("heatmap") {
+		return
+	}
+	root := projectRoot(cfg.ProjectPath)
+	// Submodules and nested repositories have their own history
+	churn := make(map[string]map[string]int)
+	fileChurn = make(map[string]int)
+	for _, f := range files {
+		dir := repoDir(root, f)
+		counts, ok := churn[dir]
+		if !ok {
+			var err error
+			if counts, err = blame.Churn(ctx, dir, cfg.ChurnSince); err != nil {
+				warnf("risk scores leave out churn: %v\n", err)
+			}
+			churn[dir] = counts
+		}
+		if n := counts[relPath(dir, f.Path)]; n > 0 {
+			fileChurn[f.Path] = n
+		}
+	}
+}
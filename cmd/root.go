@@ -1,171 +1,1111 @@
 package cmd
 
 import (
-    "context"
-    "fmt"
-    "io"
-    "os"
-    "path/filepath"
-    "sync"
-    "strings"
-
-    "github.com/spf13/cobra"
-    "github.com/disconnekt/goreview/internal/config"
-    "github.com/disconnekt/goreview/internal/reviewer"
-    "github.com/disconnekt/goreview/internal/scanner"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/disconnekt/goreview/internal/audit"
+	"github.com/disconnekt/goreview/internal/baseline"
+	"github.com/disconnekt/goreview/internal/config"
+	"github.com/disconnekt/goreview/internal/console"
+	"github.com/disconnekt/goreview/internal/findings"
+	"github.com/disconnekt/goreview/internal/frameworks"
+	"github.com/disconnekt/goreview/internal/profiles"
+	"github.com/disconnekt/goreview/internal/repocontext"
+	"github.com/disconnekt/goreview/internal/report"
+	"github.com/disconnekt/goreview/internal/reviewer"
+	"github.com/disconnekt/goreview/internal/rules"
+	"github.com/disconnekt/goreview/internal/scanner"
+	"github.com/disconnekt/goreview/internal/secrets"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
-var cfg *config.Config
+// cfg is initialized at declaration so every command's init can bind flags to it,
+// regardless of file initialization order.
+var cfg = config.DefaultConfig()
 
 var rootCmd = &cobra.Command{
-    Use:   "aireview",
-    Short: "AI-powered code review tool for Go projects",
-    Long: `AIReview is a command-line tool that analyzes Go code files 
+	Use:   "aireview",
+	Short: "AI-powered code review tool for Go projects",
+	Long: `AIReview is a command-line tool that analyzes Go code files 
 and provides intelligent code review suggestions using AI.`,
-    RunE: runReview,
+	PersistentPreRunE: applyGlobalEnv,
+	RunE:              runReview,
 }
 
 func Execute() {
-    if err := rootCmd.Execute(); err != nil {
-        fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-        os.Exit(1)
-    }
+	// Windows consoles render UTF-8 and escape sequences only once enabled
+	console.Enable(os.Stdout)
+	console.Enable(os.Stderr)
+	registerCompletions(rootCmd)
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		var exit *exitError
+		if errors.As(err, &exit) {
+			os.Exit(exit.code)
+		}
+		os.Exit(1)
+	}
+}
+
+// exitError makes the process exit with a specific status instead of 1.
+type exitError struct {
+	code int
+	err  error
 }
 
+func (e *exitError) Error() string { return e.err.Error() }
+
+func (e *exitError) Unwrap() error { return e.err }
+
 func init() {
-    cfg = config.DefaultConfig()
-    
-    rootCmd.Flags().StringVarP(&cfg.ProjectPath, "path", "p", cfg.ProjectPath, 
-        "Path to the project directory for review")
-    rootCmd.Flags().StringVarP(&cfg.APIURL, "url", "u", cfg.APIURL, 
-        "URL to the AI API endpoint")
-    // Multiple endpoints override single --url. Accepts comma-separated values or repeated flags.
-    rootCmd.Flags().StringSliceVar(&cfg.APIURLs, "urls", nil, 
-        "Comma-separated list of AI API endpoints (overrides --url)")
-    rootCmd.Flags().StringVarP(&cfg.APIKey, "api-key", "k", cfg.APIKey, 
-        "API key for authentication (can also use AIREVIEW_API_KEY env var)")
-    rootCmd.Flags().StringVarP(&cfg.Model, "model", "m", cfg.Model, 
-        "AI model to use for code review")
-    rootCmd.Flags().Int64Var(&cfg.MaxFileSize, "max-size", cfg.MaxFileSize, 
-        "Maximum file size in bytes to process")
-    rootCmd.Flags().IntVarP(&cfg.MaxConcurrency, "concurrency", "c", cfg.MaxConcurrency, 
-        "Maximum number of concurrent reviews")
-    rootCmd.Flags().StringVar(&cfg.ReportFile, "report-file", "", 
-        "Path to write the review report (Markdown). If empty, prints to stdout")
+	rootCmd.PersistentFlags().StringVarP(&cfg.ProjectPath, "path", "p", cfg.ProjectPath,
+		"Path to the project directory for review")
+	rootCmd.PersistentFlags().StringVar(&cfg.ConfigFile, "config", "",
+		"Path to a YAML config file (default: .aireview/config.{yaml,yml} in the project)")
+	rootCmd.PersistentFlags().StringVar(&cfg.ConfigURL, "config-url", "",
+		"URL of an organization config whose locked settings, prompt, excludes and policy apply to every project")
+	rootCmd.PersistentFlags().StringVar(&cfg.ConfigSHA256, "config-sha256", "",
+		"Expected SHA-256 checksum of the --config-url document")
+	rootCmd.PersistentFlags().StringVar(&cfg.StateDir, "state-dir", cfg.StateDir,
+		"Directory for run state and the findings baseline (relative to the project path)")
+	addReviewFlags(rootCmd.Flags())
+}
+
+// applyGlobalEnv reads the environment variables of the persistent flags, which locate
+// the config file and the state directory and so apply before any config is loaded.
+func applyGlobalEnv(cmd *cobra.Command, args []string) error {
+	for flag, target := range map[string]*string{
+		"path":          &cfg.ProjectPath,
+		"config":        &cfg.ConfigFile,
+		"config-url":    &cfg.ConfigURL,
+		"config-sha256": &cfg.ConfigSHA256,
+		"state-dir":     &cfg.StateDir,
+	} {
+		name := config.EnvName(strings.ReplaceAll(flag, "-", "_"))
+		if v := os.Getenv(name); v != "" && !cmd.Flags().Changed(flag) {
+			*target = v
+		}
+	}
+	quiet.Store(cfg.Quiet)
+	return nil
+}
+
+// addReviewFlags registers the flags that configure a review run. They are shared by
+// every command that runs reviews so all of them accept the same options.
+func addReviewFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&cfg.Provider, "provider", cfg.Provider,
+		"Model backend: "+strings.Join(reviewer.ProviderNames(), ", "))
+	flags.StringVar(&cfg.ProviderCommand, "provider-command", "",
+		"Command run by the exec provider; it reads a JSON request on stdin and writes a JSON response to stdout")
+	flags.StringVar(&cfg.AWSRegion, "aws-region", "",
+		"AWS region of the bedrock provider (default: AWS_REGION)")
+	flags.StringVar(&cfg.GCPProject, "gcp-project", "",
+		"Google Cloud project of the vertex provider (default: GOOGLE_CLOUD_PROJECT or the credentials' project)")
+	flags.StringVar(&cfg.GCPRegion, "gcp-region", "",
+		"Google Cloud region of the vertex provider (default: GOOGLE_CLOUD_REGION or us-central1)")
+	flags.StringVarP(&cfg.APIURL, "url", "u", cfg.APIURL,
+		"URL to the AI API endpoint")
+	// Multiple endpoints override single --url. Accepts comma-separated values or repeated flags.
+	flags.StringSliceVar(&cfg.APIURLs, "urls", nil,
+		"Comma-separated list of AI API endpoints (overrides --url)")
+	flags.StringSliceVar(&cfg.Gzip, "gzip", nil,
+		"Gzip request bodies sent to these endpoints (comma-separated URLs or hosts, or \"all\") for gateways that accept Content-Encoding: gzip")
+	flags.StringVarP(&cfg.APIKey, "api-key", "k", cfg.APIKey,
+		"API key for authentication (can also use AIREVIEW_API_KEY env var)")
+	flags.StringVar(&cfg.APIKeyCommand, "api-key-command", "",
+		"Shell command that prints the API key, e.g. \"vault kv get -field=key secret/aireview\"")
+	flags.StringVar(&cfg.APIKeyKeychain, "api-key-keychain", "",
+		"Read the API key from the OS keychain item SERVICE[/ACCOUNT]")
+	flags.StringSliceVar(&cfg.APIKeys, "api-keys", nil,
+		"Comma-separated API keys to rotate through on rate limits (can also use AIREVIEW_API_KEYS env var)")
+	flags.StringVar(&cfg.APIKeyFile, "api-key-file", "",
+		"File with API keys to rotate through, one per line")
+	flags.StringVarP(&cfg.Model, "model", "m", cfg.Model,
+		"AI model to use for code review")
+	flags.StringVar(&cfg.Module, "module", "",
+		"Restrict the run to the Go module in this directory (for monorepos)")
+	flags.StringVar(&cfg.Owner, "owner", "",
+		"Restrict the run to files owned by this CODEOWNERS owner, e.g. @org/platform-team")
+	flags.BoolVar(&cfg.GroupByOwner, "group-by-owner", false,
+		"Add the findings grouped by CODEOWNERS owner to the report")
+	flags.StringVar(&cfg.Since, "since", "",
+		"Only review lines introduced in commits authored on or after this date (YYYY-MM-DD), per git blame")
+	flags.StringVar(&cfg.Author, "author", "",
+		"Only review lines introduced in commits whose author name or email contains this text, per git blame")
+	flags.IntVar(&cfg.ContextLines, "context-lines", cfg.ContextLines,
+		"With --since or --author, send only the selected lines and this many lines around them instead of whole files (-1 sends whole files)")
+	flags.BoolVar(&cfg.FullFunctionContext, "full-function-context", false,
+		"With --since or --author, widen the selected lines to the Go functions and declarations enclosing them")
+	flags.BoolVar(&cfg.IncludeTrivialChanges, "include-trivial-changes", false,
+		"With --since or --author, also review lines whose change only touched whitespace, comments or imports")
+	flags.BoolVar(&cfg.IgnoreWorkspace, "ignore-go-work", false,
+		"Scan the project directory as-is instead of the member modules listed in go.work")
+	flags.BoolVar(&cfg.FollowSymlinks, "follow-symlinks", false,
+		"Follow symlinked files and directories (with cycle detection) instead of skipping them")
+	flags.BoolVar(&cfg.IncludeSubmodules, "include-submodules", false,
+		"Scan git submodules and nested repositories instead of skipping them")
+	flags.IntVar(&cfg.ScanWorkers, "scan-workers", 0,
+		"Number of directories and files the scan reads at once (0 = number of CPUs, at least 4)")
+	flags.StringSliceVar(&cfg.Exclude, "exclude", nil,
+		"Glob patterns of files to skip, relative to the project (supports **)")
+	flags.Int64Var(&cfg.MaxFileSize, "max-size", cfg.MaxFileSize,
+		"Maximum file size in bytes to process")
+	flags.IntVar(&cfg.MaxReviewChars, "max-review-chars", 0,
+		"Length budget of each file's review in characters; the model is asked to stay within it and longer reviews are truncated (0 = unlimited)")
+	flags.IntVar(&cfg.ChunkTokens, "chunk-tokens", 0,
+		"Most code sent in one review request, in approximate tokens; larger files are reviewed in chunks split between declarations (0 = half of what the model's context window leaves after its reply and the repository context)")
+	flags.IntVar(&cfg.ComplexityThreshold, "complexity-threshold", cfg.ComplexityThreshold,
+		"Cyclomatic complexity from which functions are pointed out in the prompt, along with long and deeply nested ones (0 = none)")
+	flags.StringVar(&cfg.Duplicates, "duplicates", cfg.Duplicates,
+		"Detect code duplicated across files: report (list the duplicated blocks) or review (also ask the model how to extract them)")
+	flags.IntVar(&cfg.DuplicateMinTokens, "duplicate-min-tokens", cfg.DuplicateMinTokens,
+		"Smallest duplicated block reported by --duplicates, in tokens")
+	flags.StringVar(&cfg.Todos, "todos", cfg.Todos,
+		"Collect TODO, FIXME, HACK and XXX comments into the report: report (list them) or triage (also ask the model for the priority of each)")
+	flags.StringVar(&cfg.ChurnSince, "churn-since", cfg.ChurnSince,
+		"How far back git history counts toward the churn of the risk and heatmap formats, e.g. \"6 months ago\" or 2024-01-01")
+	flags.IntVar(&cfg.MaxFiles, "max-files", cfg.MaxFiles,
+		"Maximum number of files to review in one run (0 = unlimited)")
+	flags.Int64Var(&cfg.MaxTotalBytes, "max-total-bytes", cfg.MaxTotalBytes,
+		"Maximum total size in bytes of the files reviewed in one run (0 = unlimited)")
+	flags.Int64Var(&cfg.MaxMemory, "max-memory", 0,
+		"Maximum bytes of file content the scan keeps in memory, and of files being reviewed at once; files past it are read from disk when needed, and a larger file is reviewed alone (not with --batch; 0 = unlimited)")
+	flags.DurationVar(&cfg.RunTimeout, "run-timeout", 0,
+		"Deadline for the whole run, e.g. 30m; unfinished files are cancelled and the partial report is written (0 = none)")
+	flags.BoolVar(&cfg.RetryFailed, "retry-failed", cfg.RetryFailed,
+		"Re-attempt files that failed once more at the end of the run")
+	flags.IntVar(&cfg.MaxErrors, "max-errors", 0,
+		"Abort the run after this many files failed to review (0 = never abort)")
+	flags.BoolVar(&cfg.ContinueOnError, "continue-on-error", false,
+		"Do not fail the run when individual files fail to review")
+	flags.IntVarP(&cfg.MaxConcurrency, "concurrency", "c", cfg.MaxConcurrency,
+		"Maximum number of concurrent reviews")
+	flags.BoolVar(&cfg.Batch, "batch", false,
+		"Submit all review requests through the provider's asynchronous batch API for its discount, waiting up to 24h for results")
+	flags.DurationVar(&cfg.BatchPollInterval, "batch-poll-interval", cfg.BatchPollInterval,
+		"How often --batch polls the batch for completion")
+	flags.BoolVar(&cfg.PromptCache, "prompt-cache", false,
+		"Mark the system prompt shared by all requests as cacheable: cache_control for Claude models, cache points on Bedrock")
+	flags.StringVar(&cfg.AuditLog, "audit-log", "",
+		"Append every API request (endpoint, model, prompt hash, size, status, tokens, latency) to this JSON lines file")
+	flags.StringVar(&cfg.ReportFile, "report-file", "",
+		"Path to write the review report (Markdown). If empty, prints to stdout")
+	flags.StringVar(&cfg.Format, "format", cfg.Format,
+		"Report format: text, markdown, json, sarif, warnings-ng (Jenkins), summary, github-actions (workflow annotation commands) or template (see --report-template)")
+	flags.BoolVar(&cfg.ReportAppend, "report-append", false,
+		"Append to existing report files instead of overwriting them")
+	flags.IntVar(&cfg.KeepReports, "keep-reports", 0,
+		"Keep only the newest N reports matching a date-templated report path such as report-{{date}}.md (0 = keep all)")
+	flags.BoolVarP(&cfg.Quiet, "quiet", "q", false,
+		"Print errors only; progress, statistics and warnings are silenced")
+	flags.BoolVar(&cfg.SummaryOnly, "summary-only", false,
+		"Print a roll-up of the findings instead of the per-file report to stdout; report files still get the full report")
+	flags.BoolVar(&cfg.NoPager, "no-pager", false,
+		"Print the report directly instead of through $PAGER (less) when stdout is a terminal")
+	flags.BoolVar(&cfg.NoColor, "no-color", false,
+		"Disable colors in the report printed to the terminal (also disabled by NO_COLOR)")
+	flags.StringVar(&cfg.PathStyle, "path-style", cfg.PathStyle,
+		"File paths in text, markdown, json and template reports: relative (to the project root) or absolute")
+	flags.StringArrayVar(&cfg.Outputs, "output", nil,
+		"Additional report as format=path, e.g. sarif=report.sarif; repeatable, all rendered from the same results")
+	flags.StringVar(&cfg.ReportTemplate, "report-template", "",
+		"Go text/template file used to render the report (implies --format template)")
+	flags.StringVar(&cfg.Profile, "profile", cfg.Profile,
+		"Review profile focusing the review: "+strings.Join(profiles.Names(), ", "))
+	flags.StringVar(&cfg.Govulncheck, "govulncheck", "",
+		"Saved \"govulncheck -json\" report for --profile vuln (default: run govulncheck in each module)")
+	flags.StringVar(&cfg.RaceLog, "race-log", "",
+		"Saved \"go test -race\" output whose data races --profile concurrency adds to the review")
+	flags.StringVar(&cfg.Base, "base", "",
+		"Git revision whose merge base with HEAD --profile api compares the exported API against, e.g. origin/main or v1.4.0")
+	flags.StringSliceVar(&cfg.Pprof, "pprof", nil,
+		"pprof profiles or saved \"go test -bench\" output whose hot paths --profile performance adds to the review")
+	flags.StringSliceVar(&cfg.Context, "context", cfg.Context,
+		"Repository context added to the prompt: gomod (module path, Go version, direct dependencies), "+
+			"frameworks (guidance for "+strings.Join(frameworks.Names(), ", ")+" when imported), "+
+			"docs (README.md, ARCHITECTURE.md, CONTRIBUTING.md) and similar (related files found by embeddings)")
+	flags.IntVar(&cfg.ContextBudget, "context-budget", cfg.ContextBudget,
+		"Approximate token budget for the repository context")
+	flags.StringVar(&cfg.EmbeddingModel, "embedding-model", cfg.EmbeddingModel,
+		"Embedding model used by --context similar and ask")
+	flags.StringVar(&cfg.PolicyFile, "policy", "",
+		"Pass/fail policy evaluated after the run; a failing policy exits with status 2 (default: .aireview/policy.yaml in the project)")
+	flags.StringVar(&cfg.RulesFile, "rules", "",
+		"Path to a Markdown or YAML rules file (default: .aireview/rules.{yaml,yml,md} in the project)")
+	flags.IntVar(&cfg.Passes, "passes", cfg.Passes,
+		"Number of review passes; extra passes verify findings against the code to drop hallucinated ones")
+	flags.Float64Var(&cfg.Temperature, "temperature", cfg.Temperature,
+		"Sampling temperature of review requests")
+	flags.Int64Var(&cfg.Seed, "seed", cfg.Seed,
+		"Sampling seed sent to providers that support it, for reproducible reviews (0 = none)")
+	flags.BoolVar(&cfg.Deterministic, "deterministic", false,
+		fmt.Sprintf("Send temperature 0 and a fixed seed (--seed, default %d) so runs can be reproduced", config.DeterministicSeed))
+	flags.BoolVar(&cfg.Consensus, "consensus", false,
+		"Review each file with every model in --consensus-models and merge their findings")
+	flags.StringSliceVar(&cfg.ConsensusModels, "consensus-models", nil,
+		"Comma-separated list of 2-3 models used by --consensus")
+	flags.StringVar(&cfg.TriageModel, "triage-model", "",
+		"Small model that screens every file first; only files it flags are sent to the review model")
+	flags.StringVar(&cfg.TriageURL, "triage-url", "",
+		"OpenAI-compatible endpoint serving --triage-model, e.g. a local server (default: the review endpoints)")
+	flags.StringSliceVar(&cfg.Notify, "notify", nil,
+		"Send a run summary to these targets when the run completes or fails (slack, teams, webhook)")
+	flags.StringVar(&cfg.SlackWebhook, "slack-webhook", "",
+		"Slack incoming webhook URL (can also use AIREVIEW_SLACK_WEBHOOK env var)")
+	flags.StringVar(&cfg.TeamsWebhook, "teams-webhook", "",
+		"Microsoft Teams incoming webhook URL (can also use AIREVIEW_TEAMS_WEBHOOK env var)")
+	flags.StringVar(&cfg.NotifyWebhook, "notify-webhook", "",
+		"URL that receives the JSON run summary as a POST (implies --notify webhook)")
+	flags.BoolVar(&cfg.GitHubCheck, "github-check", false,
+		"Publish results as a GitHub Check Run with line annotations (uses GITHUB_TOKEN and GITHUB_REPOSITORY)")
+	flags.StringVar(&cfg.GitHubCheckName, "github-check-name", cfg.GitHubCheckName,
+		"Name of the GitHub Check Run")
+	flags.StringVar(&cfg.PRComments, "pr-comments", "",
+		"Post findings as pull/merge request comments, updating earlier ones in place (github or gitlab)")
+	flags.StringVar(&cfg.CreateTickets, "create-tickets", "",
+		"Open a ticket in this tracker (jira or github) for each finding at or above --ticket-severity; reruns skip findings that have one")
+	flags.StringVar(&cfg.TicketSeverity, "ticket-severity", cfg.TicketSeverity,
+		"Lowest severity that gets a ticket with --create-tickets")
+	flags.BoolVar(&cfg.TicketConsolidate, "ticket-consolidate", false,
+		"File one GitHub issue per run listing all new findings instead of one per finding")
+	flags.StringVar(&cfg.JiraURL, "jira-url", "", "Base URL of the Jira site, e.g. https://example.atlassian.net")
+	flags.StringVar(&cfg.JiraProject, "jira-project", "", "Key of the Jira project tickets are filed in")
+	flags.StringVar(&cfg.JiraIssueType, "jira-issue-type", cfg.JiraIssueType, "Issue type of Jira tickets")
+	flags.StringVar(&cfg.JiraUser, "jira-user", "",
+		"Account email for a Jira Cloud API token in JIRA_API_TOKEN (empty: the token is a personal access token)")
+	flags.StringVar(&cfg.ReportURL, "report-url", "",
+		"URL of the published report (e.g. CI artifact) linked from notifications")
+	flags.BoolVar(&cfg.CI, "ci", false,
+		"Single-shot pipeline mode: review the mounted workspace, write Markdown, JSON and SARIF reports to "+ciReportDir+"/ and print a summary line (can also use AIREVIEW_CI)")
 }
 
 func runReview(cmd *cobra.Command, args []string) error {
-    if cfg.APIKey == "" {
-        if envKey := os.Getenv("AIREVIEW_API_KEY"); envKey != "" {
-            cfg.APIKey = envKey
-        }
-    }
-	
-	if cfg.RequiresAPIKey() && cfg.APIKey == "" {
+	if err := applyCIMode(cmd); err != nil {
+		return err
+	}
+	if err := prepareConfig(cmd); err != nil {
+		return err
+	}
+	if cfg.CI {
+		setCIReports()
+	}
+	// Load the policy up front so a broken policy fails before any file is reviewed
+	pol, err := loadPolicy()
+	if err != nil {
+		return err
+	}
+
+	// Only this one-shot run pages its output; daemon and server runs must not wait
+	// for someone to leave the pager
+	if stop := startPager(); stop != nil {
+		defer stop()
+	}
+	check := startCheckRun()
+	start := time.Now()
+	outcome, err := executeReview(context.Background())
+	finishRun(outcome, err, start)
+	completeCheckRun(check, outcome, err)
+	postPRComments(outcome, err)
+	createTickets(outcome)
+	if err == nil {
+		err = enforcePolicy(cmd, pol, outcome)
+	}
+	if cfg.CI {
+		printCISummary(outcome, pol, err, time.Since(start))
+	}
+	return err
+}
+
+// prepareConfig loads the config file, applies environment fallbacks, warns about likely
+// missing credentials and validates the configuration before a review run.
+func prepareConfig(cmd *cobra.Command) error {
+	src, err := mergeConfig(cmd)
+	if err != nil {
+		return err
+	}
+	if src.userFile != "" {
+		logf("Loaded user config from %s\n", src.userFile)
+	}
+	if src.file != "" {
+		logf("Loaded config from %s\n", src.file)
+	}
+
+	if cfg.APIKey == "" {
+		if err := resolveAPIKey(); err != nil {
+			return err
+		}
+	}
+
+	if len(cfg.APIKeys) == 0 {
+		if envKeys := os.Getenv("AIREVIEW_API_KEYS"); envKeys != "" {
+			cfg.APIKeys = strings.Split(envKeys, ",")
+		}
+	}
+	if cfg.APIKeyFile != "" {
+		keys, err := config.ReadKeyFile(cfg.APIKeyFile)
+		if err != nil {
+			return err
+		}
+		cfg.APIKeys = append(cfg.APIKeys, keys...)
+	}
+
+	if cfg.RequiresAPIKey() && len(cfg.EffectiveAPIKeys()) == 0 {
 		endpoints := strings.Join(cfg.EffectiveAPIURLs(), ", ")
-		fmt.Fprintf(os.Stderr, "Warning: One or more API endpoints (%s) likely require an API key.\n", endpoints)
-		fmt.Fprintf(os.Stderr, "Use --api-key flag or set AIREVIEW_API_KEY environment variable.\n\n")
+		warnf("One or more API endpoints (%s) likely require an API key.\n"+
+			"Use --api-key flag or set AIREVIEW_API_KEY environment variable.\n\n", endpoints)
 	}
 
 	if err := cfg.Validate(); err != nil {
 		return fmt.Errorf("configuration error: %w", err)
 	}
+	return nil
+}
+
+// configSources records where merged settings that did not come from a flag or a
+// default were taken from, keyed by config file key.
+type configSources struct {
+	file     string
+	userFile string
+	settings map[string]string
+}
+
+// mergeConfig layers the config file and AIREVIEW_* environment variables under the
+// command-line flags and derives the settings implied by others.
+func mergeConfig(cmd *cobra.Command) (*configSources, error) {
+	src := &configSources{settings: make(map[string]string)}
+	org, err := loadOrgConfig()
+	if err != nil {
+		return nil, err
+	}
+	if org != nil {
+		// Organization settings are defaults under the project's config file
+		cfg.Apply(&org.Settings, cmd.Flags().Changed)
+		for _, s := range org.Settings.Settings() {
+			if s.Present {
+				src.settings[s.Key] = "config url"
+			}
+		}
+	}
+	if err := loadUserConfigFile(cmd, src); err != nil {
+		return nil, err
+	}
+	if err := loadConfigFile(cmd, src); err != nil {
+		return nil, err
+	}
+
+	// Environment variables override the config file but not the flags
+	env, err := config.FromEnv(os.LookupEnv)
+	if err != nil {
+		return nil, fmt.Errorf("configuration error: %w", err)
+	}
+	cfg.Apply(env, cmd.Flags().Changed)
+	for _, s := range env.Settings() {
+		if s.Present {
+			src.settings[s.Key] = "env " + config.EnvName(s.Key)
+		}
+	}
 
-	    fileScanner := scanner.NewScanner(cfg.MaxFileSize)
-    reviewService := reviewer.NewService(cfg)
-
-    fmt.Printf("Scanning directory: %s\n", cfg.ProjectPath)
-    urls := cfg.EffectiveAPIURLs()
-    if len(urls) > 1 {
-        fmt.Printf("Using %d AI endpoints (round-robin): %s\n", len(urls), strings.Join(urls, ", "))
-    } else if len(urls) == 1 {
-        fmt.Printf("Using AI endpoint: %s\n", urls[0])
-    }
-    files, err := fileScanner.ScanGoFiles(cfg.ProjectPath)
-    if err != nil {
-        return fmt.Errorf("failed to scan files: %w", err)
-    }
-
-    if len(files) == 0 {
-        fmt.Println("No Go files found to review")
-        return nil
-    }
-
-    fmt.Printf("Found %d Go files to review\n", len(files))
-
-    // Prepare report writer (only for report content; logs continue to stdout/stderr)
-    var reportWriter io.Writer = os.Stdout
-    var reportFileHandle *os.File
-    if strings.TrimSpace(cfg.ReportFile) != "" {
-        dir := filepath.Dir(cfg.ReportFile)
-        if dir != "." && dir != "" {
-            if err := os.MkdirAll(dir, 0755); err != nil {
-                return fmt.Errorf("failed to create report directory: %w", err)
-            }
-        }
-        f, err := os.Create(cfg.ReportFile)
-        if err != nil {
-            return fmt.Errorf("failed to open report file: %w", err)
-        }
-        reportFileHandle = f
-        reportWriter = f
-        fmt.Fprintf(os.Stdout, "Writing report to: %s\n", cfg.ReportFile)
-    }
-    defer func() {
-        if reportFileHandle != nil {
-            _ = reportFileHandle.Close()
-        }
-    }()
-
-    return processFilesWithConcurrency(reviewService, files, cfg.MaxConcurrency, reportWriter)
-}
-
-func processFilesWithConcurrency(reviewService *reviewer.Service, files []scanner.FileInfo, maxConcurrency int, reportWriter io.Writer) error {
-    ctx := context.Background()
-    
-    semaphore := make(chan struct{}, maxConcurrency)
-    var wg sync.WaitGroup
-    var mu sync.Mutex
-    var errors []error
-
-	for _, file := range files {
-		wg.Add(1)
-		go func(f scanner.FileInfo) {
-			defer wg.Done()
-			
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
-
-			fmt.Printf("Reviewing: %s\n", f.Path)
-			
-			review, err := reviewService.ReviewCode(ctx, f.Content)
+	if cfg.ReportTemplate != "" && !cmd.Flags().Changed("format") {
+		cfg.Format = "template"
+		src.settings["format"] = "implied by report_template"
+	}
+	if cfg.NotifyWebhook != "" && !containsString(cfg.Notify, "webhook") {
+		cfg.Notify = append(cfg.Notify, "webhook")
+		src.settings["notify"] = "implied by notify_webhook"
+	}
+	if org != nil {
+		enforceOrgConfig(cmd, org, src)
+	}
+	quiet.Store(cfg.Quiet)
+	return src, nil
+}
+
+// resolveAPIKey fills in the API key when --api-key is not given, from the secret
+// command, the OS keychain or AIREVIEW_API_KEY, in that order.
+func resolveAPIKey() error {
+	switch {
+	case cfg.APIKeyCommand != "":
+		key, err := secrets.FromCommand(context.Background(), cfg.APIKeyCommand)
+		if err != nil {
+			return fmt.Errorf("failed to get API key from --api-key-command: %w", err)
+		}
+		cfg.APIKey = key
+	case cfg.APIKeyKeychain != "":
+		key, err := secrets.FromKeychain(context.Background(), cfg.APIKeyKeychain)
+		if err != nil {
+			return fmt.Errorf("failed to get API key from keychain: %w", err)
+		}
+		cfg.APIKey = key
+	default:
+		cfg.APIKey = os.Getenv("AIREVIEW_API_KEY")
+	}
+	return nil
+}
+
+// loadConfigFile applies the YAML config file; flags given on the command line win.
+func loadConfigFile(cmd *cobra.Command, src *configSources) error {
+	path := cfg.ConfigFile
+	if path == "" {
+		path = config.DiscoverFile(cfg.ProjectPath)
+		if path == "" {
+			return nil
+		}
+	}
+	file, err := config.LoadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := file.CheckProject(); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	cfg.Apply(file, cmd.Flags().Changed)
+	src.file = path
+	for _, s := range file.Settings() {
+		if s.Present {
+			src.settings[s.Key] = "config file"
+		}
+	}
+	return nil
+}
+
+// loadUserConfigFile applies the user's own config file, if there is one. It ranks
+// below the project config file but may also set the keys projects cannot.
+func loadUserConfigFile(cmd *cobra.Command, src *configSources) error {
+	path := config.UserFilePath()
+	if path == "" {
+		return nil
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	file, err := config.LoadFile(path)
+	if err != nil {
+		return err
+	}
+	cfg.Apply(file, cmd.Flags().Changed)
+	src.userFile = path
+	for _, s := range file.Settings() {
+		if s.Present {
+			src.settings[s.Key] = "user config"
+		}
+	}
+	return nil
+}
+
+// finishRun records the run in the history and sends notifications.
+func finishRun(outcome *runOutcome, runErr error, start time.Time) {
+	sum := buildSummary(outcome, runErr, time.Since(start))
+	if err := recordHistory(outcome, sum, start); err != nil {
+		warnf("%v\n", err)
+	}
+	sendNotifications(sum)
+}
+
+// runOutcome summarizes a review run for notifications.
+type runOutcome struct {
+	filesFound int
+	results    []report.FileReview
+	// prompt is the PromptID of the review service, recorded in the history.
+	prompt string
+	// repos lists the submodules and nested repositories scanned, relative to the
+	// project root.
+	repos []string
+}
+
+// executeReview scans the project, reviews every file and writes the report. When
+// ctx ends (or --run-timeout expires) outstanding requests are cancelled and the
+// partial report is still written.
+func executeReview(ctx context.Context) (*runOutcome, error) {
+	outcome := &runOutcome{}
+	if cfg.RunTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.RunTimeout)
+		defer cancel()
+	}
+
+	fileScanner := newFileScanner()
+	reviewService, err := reviewer.NewService(cfg)
+	if err != nil {
+		return outcome, err
+	}
+	if cfg.AuditLog != "" {
+		auditLog, err := audit.Open(cfg.AuditLog)
+		if err != nil {
+			return outcome, err
+		}
+		defer auditLog.Close()
+		reviewService.AddObserver(auditObserver(auditLog))
+	}
+
+	projectRules, err := loadRules(cfg)
+	if err != nil {
+		return outcome, err
+	}
+	if len(projectRules) > 0 {
+		logf("Loaded %d project rules\n", len(projectRules))
+		reviewService.SetRules(projectRules)
+	}
+	outcome.prompt = reviewService.PromptID(orgPrompt())
+	repoContext, err := loadRepoContext(cfg)
+	if err != nil {
+		return outcome, err
+	}
+	reviewService.SetContext(repoContext)
+	if cfg.Profile != profiles.Default {
+		logf("Review profile: %s\n", cfg.Profile)
+	}
+	if cfg.Consensus {
+		logf("Consensus review across models: %s\n", strings.Join(cfg.ConsensusModels, ", "))
+	}
+	if cfg.TriageModel != "" {
+		logf("Triage: %s screens files before the review model\n", cfg.TriageModel)
+	}
+	if cfg.Passes > 1 {
+		logf("Multi-pass review enabled: %d passes per file\n", cfg.Passes)
+	}
+	if cfg.Batch {
+		logf("Batch mode: requests are submitted through the batch API; results may take up to 24h\n")
+	}
+
+	store, err := baseline.Load(cfg.StatePath(baseline.FileName))
+	if err != nil {
+		return outcome, err
+	}
+	outputs, err := newReportOutputs(outcome.prompt)
+	if err != nil {
+		return outcome, err
+	}
+
+	logf("Scanning directory: %s\n", cfg.ProjectPath)
+	urls := cfg.EffectiveAPIURLs()
+	if cfg.Provider != "openai" {
+		logf("Using provider: %s\n", cfg.Provider)
+	} else if len(urls) > 1 {
+		logf("Using %d AI endpoints (round-robin): %s\n", len(urls), strings.Join(urls, ", "))
+	} else if len(urls) == 1 {
+		logf("Using AI endpoint: %s\n", urls[0])
+	}
+	// Unless an analysis needs the whole file list first, files go to the reviews as the
+	// scan reads them and the per-file analyses run on each one in the pipeline
+	var input reviewInput
+	if streamScan() {
+		input.stream, err = streamProject(fileScanner)
+		if err != nil {
+			return outcome, fmt.Errorf("failed to scan files: %w", err)
+		}
+		if input.analyses, err = newStreamAnalyses(); err != nil {
+			return outcome, err
+		}
+	} else {
+		files, err := scanProject(fileScanner)
+		if err != nil {
+			return outcome, fmt.Errorf("failed to scan files: %w", err)
+		}
+		for _, f := range files {
+			if f.Repo != "" && !slices.Contains(outcome.repos, f.Repo) {
+				outcome.repos = append(outcome.repos, f.Repo)
+			}
+		}
+		reportScan(fileScanner)
+		if input.files, err = analyzeFiles(ctx, files); err != nil {
+			return outcome, err
+		}
+		if len(input.files) == 0 {
+			if err := outputs.open(); err != nil {
+				return outcome, err
+			}
+			defer outputs.close()
+			return outcome, writeEmptyReport(outputs, skippedFiles(fileScanner))
+		}
+		outcome.filesFound = len(input.files)
+		logf("Found %d Go files to review\n", len(input.files))
+	}
+
+	// Report content goes to the outputs; logs continue to stdout/stderr
+	if err := outputs.open(); err != nil {
+		return outcome, err
+	}
+	defer outputs.close()
+
+	stats := newRunStats()
+	reviewService.AddObserver(stats.observe)
+	run := &reviewRun{
+		ctx:         ctx,
+		stats:       stats,
+		service:     reviewService,
+		baseline:    store,
+		outputs:     outputs,
+		projectRoot: projectRoot(cfg.ProjectPath),
+	}
+	results, reviewErr := processFilesWithConcurrency(run, input, cfg.MaxConcurrency)
+	skipped := skippedFiles(fileScanner)
+	if input.stream != nil {
+		reportScan(fileScanner)
+		outcome.filesFound, outcome.repos = run.files, run.repos
+		if run.files == 0 && reviewErr == nil {
+			return outcome, writeEmptyReport(outputs, skipped)
+		}
+	}
+	printTriage(results)
+	stats.print()
+	printKeyUsage(reviewService.KeyUsage())
+	outcome.results = results
+	if err := outputs.writeSkipped(skipped); err != nil {
+		return outcome, err
+	}
+	if err := outputs.finish(results); err != nil {
+		return outcome, err
+	}
+	if err := outputs.close(); err != nil {
+		return outcome, err
+	}
+
+	var all []findings.Finding
+	for _, r := range results {
+		all = append(all, r.Findings...)
+	}
+	if err := report.SaveLastRun(cfg.StatePath(report.LastRunFile), all); err != nil {
+		warnf("%v\n", err)
+	}
+	return outcome, reviewErr
+}
+
+// reviewRun carries the shared state of one review run across worker goroutines.
+type reviewRun struct {
+	ctx         context.Context
+	stats       *runStats
+	service     *reviewer.Service
+	baseline    *baseline.Store
+	outputs     reportOutputs
+	projectRoot string
+
+	// files counts the files fed to the reviews and repos lists the repositories they
+	// came from; processFilesWithConcurrency sets them once the scan ended.
+	files int
+	repos []string
+}
+
+// reviewInput is what a run reviews: the files of a finished scan, or a streaming scan
+// and the analyses to run on each of its files.
+type reviewInput struct {
+	files    []scanner.FileInfo
+	stream   fileSource
+	analyses *streamAnalyses
+}
+
+// analyzeFiles runs the analyses of the run on the scanned files and returns the files
+// to review.
+func analyzeFiles(ctx context.Context, files []scanner.FileInfo) ([]scanner.FileInfo, error) {
+	scanned := files
+	files, err := applyModuleSettings(files)
+	if err != nil {
+		return nil, err
+	}
+	files, err = loadOwners(files)
+	if err != nil {
+		return nil, err
+	}
+	loadGoModContext(files)
+	fileDataMu.Lock()
+	fileContexts = make(map[string]string)
+	toolFindings = make(map[string][]findings.Finding)
+	fileDataMu.Unlock()
+	loadFrameworkContext(files)
+	checkArchitecture(files)
+	loadComplexityMetrics(files)
+	findDuplicates(files)
+	loadTodos(files)
+	loadChurn(ctx, files)
+	files, err = loadAuthorship(ctx, files)
+	if err != nil {
+		return nil, err
+	}
+	files, err = loadVulnContext(ctx, files)
+	if err != nil {
+		return nil, err
+	}
+	files, err = loadAPIDiff(ctx, files)
+	if err != nil {
+		return nil, err
+	}
+	files, err = loadConcurrencyContext(files)
+	if err != nil {
+		return nil, err
+	}
+	if err := loadPerfContext(files); err != nil {
+		return nil, err
+	}
+	if err := loadLicenseCheck(files); err != nil {
+		return nil, err
+	}
+	loadTestContext(files)
+	loadSimilarContext(ctx, scanned, files)
+	return files, nil
+}
+
+// reportScan warns about the files the scan skipped or left out.
+func reportScan(fileScanner *scanner.Scanner) {
+	if n := len(fileScanner.Skipped()); n > 0 {
+		warnf("skipped %d files during scan (listed in the report)\n", n)
+	}
+	if n, size := fileScanner.Truncated(); n > 0 {
+		warnf("scan limits reached (--max-files %d, --max-total-bytes %d): "+
+			"%d files (%d bytes) were not included.\n"+
+			"Raise the limits, set them to 0 to disable, or narrow the run with --path, --module or --exclude.\n",
+			cfg.MaxFiles, cfg.MaxTotalBytes, n, size)
+	}
+	if n := fileScanner.Lazy(); n > 0 {
+		logf("Memory limit: %d files are read from disk when needed (--max-memory %d)\n", n, cfg.MaxMemory)
+	}
+}
+
+// writeEmptyReport writes the report of a run that found no files to review, listing
+// the files the scan skipped.
+func writeEmptyReport(outputs reportOutputs, skipped []report.SkippedFile) error {
+	logf("No Go files found to review\n")
+	if err := outputs.writeSkipped(skipped); err != nil {
+		return err
+	}
+	if err := outputs.finish(nil); err != nil {
+		return err
+	}
+	return outputs.close()
+}
+
+// projectRoot resolves the project path to an absolute directory for relative finding paths.
+func projectRoot(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	return abs
+}
+
+// workspaceRoot is the directory report paths are relative to: the CI workspace when
+// running in GitHub Actions, GitLab CI or Jenkins, otherwise the current directory.
+func workspaceRoot() string {
+	if ws := ciWorkspace(); ws != "" {
+		return ws
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	return wd
+}
+
+// ciWorkspace returns the checkout directory announced by the CI system, or "".
+func ciWorkspace() string {
+	for _, env := range []string{"GITHUB_WORKSPACE", "CI_PROJECT_DIR"} {
+		if ws := os.Getenv(env); ws != "" {
+			return ws
+		}
+	}
+	// WORKSPACE is too generic a name to trust outside Jenkins
+	if os.Getenv("JENKINS_URL") != "" {
+		return os.Getenv("WORKSPACE")
+	}
+	return ""
+}
+
+// relPath returns path relative to root using forward slashes, or path unchanged if that fails.
+func relPath(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// resolveSourcePath maps a source path reported by a Go tool, such as the race detector
+// or pprof, to a scanned file. Tools report absolute paths, which differ from the
+// scanned ones when they ran in another checkout, so paths are also matched by their
+// suffix relative to the project.
+func resolveSourcePath(name, root string, files []scanner.FileInfo) (string, bool) {
+	name = filepath.Clean(name)
+	for _, f := range files {
+		if f.Path == name || projectRoot(f.Path) == name {
+			return f.Path, true
+		}
+	}
+	slashed := filepath.ToSlash(name)
+	for _, f := range files {
+		if strings.HasSuffix(slashed, "/"+relPath(root, f.Path)) {
+			return f.Path, true
+		}
+	}
+	return "", false
+}
+
+// newFileScanner returns a scanner honoring the configured module, size and memory
+// limits, excludes, symlink and submodule settings and scan workers.
+func newFileScanner() *scanner.Scanner {
+	s := scanner.NewScanner(cfg.MaxFileSize)
+	s.SetExcludes(cfg.Exclude)
+	s.SetFollowSymlinks(cfg.FollowSymlinks)
+	s.SetIncludeSubmodules(cfg.IncludeSubmodules)
+	if cfg.Module != "" {
+		s.SetModule(normalizeModule(cfg.Module))
+	}
+	s.SetLimits(cfg.MaxFiles, cfg.MaxTotalBytes)
+	s.SetMaxMemory(cfg.MaxMemory)
+	s.SetWorkers(cfg.ScanWorkers)
+	return s
+}
+
+// fileText returns the content of a scanned file, reading it again when --max-memory
+// left it on disk. Analyses skip a file that cannot be read anymore; its review fails.
+func fileText(f scanner.FileInfo) string {
+	text, err := f.Text()
+	if err != nil {
+		warnf("%v\n", err)
+	}
+	return text
+}
+
+// scanProject scans the member modules of go.work when the project has one, otherwise
+// the whole project directory.
+func scanProject(fileScanner *scanner.Scanner) ([]scanner.FileInfo, error) {
+	if !cfg.IgnoreWorkspace {
+		files, members, ok, err := fileScanner.ScanWorkspace(cfg.ProjectPath)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			logf("Using go.work with %d member modules: %s\n", len(members), strings.Join(members, ", "))
+			return files, nil
+		}
+	}
+	return fileScanner.ScanGoFiles(cfg.ProjectPath)
+}
+
+// rulesPath returns the configured rules file, falling back to the project's default
+// location, or "" when there is none.
+func rulesPath(cfg *config.Config) string {
+	if cfg.RulesFile != "" {
+		return cfg.RulesFile
+	}
+	return rules.Discover(cfg.ProjectPath)
+}
+
+// loadRules reads the project rules file.
+func loadRules(cfg *config.Config) ([]rules.Rule, error) {
+	path := rulesPath(cfg)
+	if path == "" {
+		return nil, nil
+	}
+	r, err := rules.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rules: %w", err)
+	}
+	return r, nil
+}
+
+// loadRepoContext builds the repository context selected with --context.
+func loadRepoContext(cfg *config.Config) (string, error) {
+	var sections []string
+	for _, source := range cfg.Context {
+		switch source {
+		case "docs":
+			docs, err := repocontext.Docs(cfg.ProjectPath, cfg.ContextBudget)
 			if err != nil {
-				mu.Lock()
-				errors = append(errors, fmt.Errorf("failed to review %s: %w", f.Path, err))
-				mu.Unlock()
-				return
+				return "", fmt.Errorf("failed to load documentation context: %w", err)
+			}
+			if docs == "" {
+				warnf("--context docs found no README.md, ARCHITECTURE.md or CONTRIBUTING.md\n")
+				continue
 			}
+			logf("Added project documentation to the review context (%d bytes)\n", len(docs))
+			sections = append(sections, docs)
+		}
+	}
+	return strings.Join(sections, "\n\n"), nil
+}
 
-			if review != "" {
-				mu.Lock()
-				fmt.Fprintf(reportWriter, "\n=== Review for %s ===\n", f.Path)
-				fmt.Fprintf(reportWriter, "File size: %d bytes\n", f.Size)
-				fmt.Fprintf(reportWriter, "Review:\n%s\n\n", review)
-				mu.Unlock()
+// reviewProgress, when set, is told how many of the files of a run have been reviewed
+// whenever a file finishes, e.g. to report the progress of server jobs.
+var reviewProgress func(done, total int)
+
+// reviewFindings, when set, is given the findings of each file as it finishes, e.g. to
+// stream the findings of server jobs.
+var reviewFindings func(file string, list []findings.Finding)
+
+// reviewFailure is a group of identical files whose review failed, with the outcomes
+// of the chunks of its first file.
+type reviewFailure struct {
+	group  []scanner.FileInfo
+	err    error
+	chunks []*reviewOutcome
+}
+
+func processFilesWithConcurrency(run *reviewRun, input reviewInput, maxConcurrency int) ([]report.FileReview, error) {
+	// abort stops the remaining reviews once --max-errors files have failed
+	ctx, abort := context.WithCancel(run.ctx)
+	defer abort()
+	p := newPipeline(ctx, abort, run, maxConcurrency, len(input.files))
+	defer run.stats.addStages(p.scan, p.prepare, p.chunk, p.review, p.report)
+	p.progress(0)
+
+	source := input.stream
+	if source == nil {
+		source = sliceSource(input.files)
+	}
+	p.analyses = input.analyses
+	p.process(source)
+	run.files, run.repos = p.total, p.repos
+	if p.scanErr != nil {
+		return p.results, fmt.Errorf("failed to scan files: %w", p.scanErr)
+	}
+	if p.total == 0 {
+		if run.ctx.Err() != nil {
+			return nil, fmt.Errorf("%s before any file was scanned", p.stopReason())
+		}
+		return nil, nil
+	}
+	if p.dups > 0 {
+		logf("Skipped %d duplicate files with identical content; their reviews were reused\n", p.dups)
+	}
+
+	// Re-attempt failed files once, after transient endpoint issues may have cleared
+	if cfg.RetryFailed && len(p.failures) > 0 && !p.aborted && ctx.Err() == nil {
+		retry := p.failures
+		p.failures, p.failedFiles = nil, 0
+		// Only the failed chunks of a large file are sent again
+		var files []scanner.FileInfo
+		p.kept = make(map[string][]*reviewOutcome)
+		for _, rf := range retry {
+			files = append(files, rf.group...)
+			if len(rf.chunks) > 1 {
+				p.kept[rf.group[0].Path] = rf.chunks
 			}
-		}(file)
-    }
+		}
+		logf("\nRetrying %d failed files\n", len(files))
+		before := len(p.results)
+		p.analyses = nil
+		p.process(sliceSource(files))
+		p.kept = nil
+		reportRetry(len(files), p.results[before:])
+	}
 
-	wg.Wait()
+	results, errors := p.results, p.errors
+	for _, rf := range p.failures {
+		for _, g := range rf.group {
+			errors = append(errors, fmt.Errorf("failed to review %s: %w", g.Path, rf.err))
+		}
+	}
+
+	if unfinished := p.unfinished; unfinished > 0 {
+		reason := p.stopReason()
+		fmt.Fprintf(os.Stderr, "\n%s: %d of %d files were not reviewed; writing the partial report\n",
+			reason, unfinished, p.total)
+		if len(errors) > 0 {
+			fmt.Fprintf(os.Stderr, "Encountered %d errors before that:\n", len(errors))
+			for _, err := range errors {
+				fmt.Fprintf(os.Stderr, "- %v\n", err)
+			}
+		}
+		return results, fmt.Errorf("%s: %d of %d files not reviewed", reason, unfinished, p.total)
+	}
 
 	if len(errors) > 0 {
 		fmt.Fprintf(os.Stderr, "\nEncountered %d errors during review:\n", len(errors))
 		for _, err := range errors {
 			fmt.Fprintf(os.Stderr, "- %v\n", err)
 		}
-		return fmt.Errorf("review completed with %d errors", len(errors))
+		if cfg.ContinueOnError {
+			fmt.Fprintf(os.Stderr, "Continuing despite %d failed files (--continue-on-error)\n", len(errors))
+			return results, nil
+		}
+		return results, fmt.Errorf("review completed with %d errors", len(errors))
 	}
 
-	fmt.Printf("\nReview completed successfully for %d files\n", len(files))
-	return nil
+	logf("\nReview completed successfully for %d files\n", p.total)
+	return results, nil
+}
+
+// reportRetry prints which retried files recovered, i.e. were reviewed by the retry.
+// The others failed again or were cut short by the end of the run.
+func reportRetry(retried int, reviewed []report.FileReview) {
+	logf("Retry recovered %d files, %d still not reviewed\n", len(reviewed), retried-len(reviewed))
+	for _, r := range reviewed {
+		logf("- recovered: %s\n", r.Path)
+	}
+}
+
+// skippedFiles converts the scanner's skip list for the report.
+func skippedFiles(s *scanner.Scanner) []report.SkippedFile {
+	var out []report.SkippedFile
+	for _, sf := range s.Skipped() {
+		out = append(out, report.SkippedFile{Path: sf.Path, Reason: sf.Reason})
+	}
+	return out
+}
+
+// printTriage reports how many files --triage-model kept from the review model.
+func printTriage(results []report.FileReview) {
+	if cfg.TriageModel == "" || len(results) == 0 {
+		return
+	}
+	skipped := 0
+	for _, fr := range results {
+		if fr.Prefiltered {
+			skipped++
+		}
+	}
+	logf("Triage: %d of %d files sent to the review model, %d screened out by %s\n",
+		len(results)-skipped, len(results), skipped, cfg.TriageModel)
+}
+
+// printKeyUsage shows how requests were spread when several API keys are configured.
+func printKeyUsage(usage []reviewer.KeyUsage) {
+	if len(usage) < 2 {
+		return
+	}
+	logf("API key usage:\n")
+	for _, u := range usage {
+		line := fmt.Sprintf("- %s: %d requests, %d rate limited", u.Key, u.Requests, u.RateLimited)
+		if u.Rejected {
+			line += ", rejected"
+		}
+		logf("%s\n", line)
+	}
+}
+
+// auditObserver converts provider attempts into audit log entries.
+func auditObserver(l *audit.Log) func(reviewer.Attempt) {
+	return func(a reviewer.Attempt) {
+		e := audit.Entry{
+			Time:              time.Now().UTC(),
+			Provider:          cfg.Provider,
+			Endpoint:          a.Endpoint,
+			Model:             a.Model,
+			File:              a.File,
+			RequestID:         a.RequestID,
+			ProviderRequestID: a.ProviderRequestID,
+			PromptSHA256:      a.PromptSHA256,
+			RequestBytes:      a.RequestBytes,
+			Status:            a.Status,
+			PromptTokens:      a.PromptTokens,
+			CompletionTokens:  a.CompletionTokens,
+			CachedTokens:      a.CachedTokens,
+			LatencyMS:         a.Latency.Milliseconds(),
+		}
+		if a.SentBytes > 0 && a.SentBytes != a.RequestBytes {
+			e.SentBytes = a.SentBytes
+		}
+		if a.Err != nil {
+			e.Error = a.Err.Error()
+		}
+		if err := l.Write(e); err != nil {
+			warnf("%v\n", err)
+		}
+	}
 }
@@ -0,0 +1,426 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+
+	"github.com/disconnekt/goreview/internal/config"
+	"github.com/disconnekt/goreview/internal/profiles"
+	"github.com/disconnekt/goreview/internal/report"
+	"github.com/disconnekt/goreview/internal/server"
+)
+
+var (
+	serveListen         string
+	serveQueueSize      int
+	serveCallbackSecret string
+	serveTenants        string
+	serveStore          string
+	serveRetention      time.Duration
+	serveGRPCListen     string
+	serveShardSize      int
+	serveWorkerToken    string
+	serveLeaseTimeout   time.Duration
+	serveDrainTimeout   time.Duration
+	serveAllowProfiles  []string
+	serveAllowModels    []string
+	serveCallbackAllow  []string
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Review files submitted over HTTP as asynchronous jobs",
+	Long: `Serve starts an HTTP server that accepts review jobs. POST /v1/jobs with the files
+to review returns a job ID at once; the jobs are reviewed one after another, each
+with the usual concurrency, and GET /v1/jobs/{id} returns the progress and, once
+done, the report. It accepts the same review flags as the root command, which
+apply to every job. On SIGINT or SIGTERM it stops taking jobs and gives the running
+job up to --drain-timeout to finish.`,
+	Example: `  aireview serve --listen :8080 --model gpt-4o
+  curl -s localhost:8080/v1/jobs -d '{"files":[{"path":"main.go","content":"package main\n"}]}'
+  curl -s localhost:8080/v1/jobs/job-5f0c2a9e41d3b7a6`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveListen, "listen", "127.0.0.1:8080", "Address the server listens on")
+	serveCmd.Flags().StringVar(&serveGRPCListen, "grpc-listen", "",
+		"Also serve the gRPC ReviewService (api/review/v1), with streamed findings, on this address")
+	serveCmd.Flags().IntVar(&serveQueueSize, "queue-size", 100, "Maximum number of jobs waiting to be reviewed")
+	serveCmd.Flags().IntVar(&serveShardSize, "shard-size", 0,
+		"Coordinate workers: split each job into shards of this many files for \"aireview worker\" processes instead of reviewing locally (0 = review locally)")
+	serveCmd.Flags().StringVar(&serveWorkerToken, "worker-token", "",
+		"Bearer token workers must present; required with --shard-size (can also use AIREVIEW_WORKER_TOKEN)")
+	serveCmd.Flags().DurationVar(&serveLeaseTimeout, "lease-timeout", 2*time.Minute,
+		"How long a worker may go without a heartbeat before its shard goes to another worker")
+	serveCmd.Flags().StringVar(&serveCallbackSecret, "callback-secret", "",
+		"Sign job callbacks with an HMAC-SHA256 of the body in the X-Aireview-Signature header (can also use AIREVIEW_CALLBACK_SECRET)")
+	serveCmd.Flags().StringSliceVar(&serveCallbackAllow, "callback-allow-networks", nil,
+		"CIDR networks, e.g. 10.1.0.0/16, that job callbacks may reach besides public addresses")
+	serveCmd.Flags().StringVar(&serveTenants, "tenants", "",
+		"YAML file of tenants with their tokens, job limits and daily token budgets; requests then need a tenant's bearer token")
+	serveCmd.Flags().StringVar(&serveStore, "store", "",
+		"Keep jobs and tenant usage across restarts in a PostgreSQL database (postgres://...), a SQLite file (sqlite:<file>) or a directory; unfinished jobs are queued again on start")
+	serveCmd.Flags().DurationVar(&serveRetention, "job-retention", 24*time.Hour, "How long finished jobs stay queryable")
+	serveCmd.Flags().DurationVar(&serveDrainTimeout, "drain-timeout", time.Minute,
+		"How long the running job may go on after SIGINT or SIGTERM before it is cancelled")
+	serveCmd.Flags().StringSliceVar(&serveAllowProfiles, "allow-profiles", nil,
+		"Profiles a job may choose (default: any built-in profile)")
+	serveCmd.Flags().StringSliceVar(&serveAllowModels, "allow-models", nil,
+		"Models a job may choose (default: none, jobs use --model)")
+	addReviewFlags(serveCmd.Flags())
+	rootCmd.AddCommand(serveCmd)
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	if serveQueueSize < 1 {
+		return errors.New("--queue-size must be at least 1")
+	}
+	if serveRetention <= 0 {
+		return errors.New("--job-retention must be positive")
+	}
+	if serveShardSize < 0 {
+		return errors.New("--shard-size must not be negative")
+	}
+	if serveLeaseTimeout < 4*time.Second {
+		return errors.New("--lease-timeout must be at least 4s")
+	}
+	if serveDrainTimeout < 0 {
+		return errors.New("--drain-timeout must not be negative")
+	}
+	var callbackNetworks []*net.IPNet
+	for _, cidr := range serveCallbackAllow {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("invalid --callback-allow-networks: %w", err)
+		}
+		callbackNetworks = append(callbackNetworks, n)
+	}
+	for _, name := range serveAllowProfiles {
+		if _, err := profiles.Get(name); err != nil {
+			return fmt.Errorf("invalid --allow-profiles: %w", err)
+		}
+	}
+	// The flags alone, which a reload merges the config files and environment into again
+	flags := *cfg
+	if err := prepareConfig(cmd); err != nil {
+		return err
+	}
+	base := *cfg
+	jobs := &serveConfig{base: base}
+	// Submissions carry the sources as JSON, which escaping can double in size
+	maxBytes := int64(256 << 20)
+	if base.MaxTotalBytes > 0 {
+		maxBytes = 2*base.MaxTotalBytes + 1<<20
+	}
+	secret := serveCallbackSecret
+	if secret == "" {
+		secret = os.Getenv("AIREVIEW_CALLBACK_SECRET")
+	}
+	opts := server.Options{
+		QueueSize:        serveQueueSize,
+		MaxBytes:         maxBytes,
+		CallbackSecret:   secret,
+		CallbackNetworks: callbackNetworks,
+		Store:            serveStore,
+		Retention:        serveRetention,
+		DrainTimeout:     serveDrainTimeout,
+		Profiles:         serveAllowProfiles,
+		Models:           serveAllowModels,
+		Logf:             warnf,
+	}
+	if serveTenants != "" {
+		var err error
+		if opts.Tenants, err = server.LoadTenants(serveTenants); err != nil {
+			return err
+		}
+		logf("Loaded %d tenants\n", len(opts.Tenants.Tenants))
+	}
+	var srv *server.Server
+	opts.Reload = func() error {
+		var tenants *server.Tenants
+		if serveTenants != "" {
+			var err error
+			if tenants, err = server.LoadTenants(serveTenants); err != nil {
+				return err
+			}
+		}
+		if err := jobs.reload(cmd, flags); err != nil {
+			return err
+		}
+		if tenants != nil {
+			srv.SetTenants(tenants)
+		}
+		logf("Reloaded the configuration\n")
+		return nil
+	}
+	runner := jobRunner(jobs)
+	handler := http.NewServeMux()
+	if serveShardSize > 0 {
+		token := serveWorkerToken
+		if token == "" {
+			token = os.Getenv("AIREVIEW_WORKER_TOKEN")
+		}
+		// Anyone who can lease shards reads the jobs' sources and writes their reviews
+		if token == "" {
+			return errors.New("--shard-size requires --worker-token (or AIREVIEW_WORKER_TOKEN)")
+		}
+		dispatcher := server.NewDispatcher(server.DispatcherOptions{Token: token, LeaseTimeout: serveLeaseTimeout, MaxBytes: maxBytes})
+		runner = distributedRunner(jobs, dispatcher, serveShardSize)
+		handler.Handle("/v1/worker/", dispatcher.Handler())
+		logf("Coordinating workers in shards of %d files\n", serveShardSize)
+	}
+	srv, err := server.New(runner, opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := srv.Close(); err != nil {
+			warnf("failed to close the job store: %v\n", err)
+		}
+	}()
+	handler.Handle("/", srv.Handler())
+	requeued, err := srv.Restore()
+	if err != nil {
+		return err
+	}
+	if requeued > 0 {
+		logf("Queued %d unfinished jobs again\n", requeued)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				if err := opts.Reload(); err != nil {
+					warnf("failed to reload the configuration: %v\n", err)
+				}
+			}
+		}
+	}()
+	working := make(chan struct{})
+	go func() {
+		srv.Work(ctx)
+		close(working)
+	}()
+
+	httpServer := &http.Server{Addr: serveListen, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	errc := make(chan error, 2)
+	go func() { errc <- httpServer.ListenAndServe() }()
+	logf("Serving review jobs on %s\n", serveListen)
+	var grpcServer *grpc.Server
+	if serveGRPCListen != "" {
+		lis, err := net.Listen("tcp", serveGRPCListen)
+		if err != nil {
+			return fmt.Errorf("failed to listen for gRPC: %w", err)
+		}
+		grpcServer = srv.GRPCServer()
+		go func() { errc <- grpcServer.Serve(lis) }()
+		logf("Serving gRPC on %s\n", serveGRPCListen)
+	}
+
+	select {
+	case err := <-errc:
+		return fmt.Errorf("failed to serve: %w", err)
+	case <-ctx.Done():
+	}
+	shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdown); err != nil {
+		warnf("failed to shut down the server: %v\n", err)
+	}
+	if grpcServer != nil {
+		// Findings streams of running jobs end with the jobs; cut them off at the deadline
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-shutdown.Done():
+			grpcServer.Stop()
+		}
+	}
+	<-working
+	logf("Server stopped\n")
+	return nil
+}
+
+// serveConfig is the configuration the server's jobs run with. A job holds mu while it
+// runs, so a reload takes effect from the next job on.
+type serveConfig struct {
+	mu   sync.Mutex
+	base config.Config
+}
+
+// reload merges the config files, the organization config and the environment into
+// the flags again, waiting for the running job. When that fails the previous
+// configuration stays in effect.
+func (sc *serveConfig) reload(cmd *cobra.Command, flags config.Config) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	org := orgConfig
+	*cfg, orgConfig = flags, nil // fetch the organization config again
+	if err := prepareConfig(cmd); err != nil {
+		*cfg, orgConfig = sc.base, org
+		return err
+	}
+	sc.base = *cfg
+	return nil
+}
+
+// jobRunner reviews the files of a job with the server's configuration: the files are
+// reviewed like a project and the report is read back, also when some files failed.
+func jobRunner(sc *serveConfig) server.Runner {
+	return func(ctx context.Context, req server.Request, progress server.Progress) (server.Outcome, error) {
+		sc.mu.Lock()
+		defer sc.mu.Unlock()
+		var out server.Outcome
+		err := inJobProject(sc.base, req, req.Files, func() error {
+			reviewProgress, reviewFindings = progress.Files, progress.Findings
+			defer func() { reviewProgress, reviewFindings = nil, nil }()
+
+			// A run with failed files still writes the report of the others
+			run, runErr := executeReview(ctx)
+			out.Tokens = runTokens(run.results)
+			var err error
+			out.Report, err = os.ReadFile(cfg.ReportFile)
+			if err != nil && runErr == nil {
+				return fmt.Errorf("failed to read the report: %w", err)
+			}
+			return runErr
+		})
+		return out, err
+	}
+}
+
+// distributedRunner has the files of a job reviewed by workers in shards of size files
+// and renders the report of their reviews with the server's configuration.
+func distributedRunner(sc *serveConfig, d *server.Dispatcher, size int) server.Runner {
+	return func(ctx context.Context, req server.Request, progress server.Progress) (server.Outcome, error) {
+		results, tokens, runErr := d.Run(ctx, req, size, progress)
+		out := server.Outcome{Tokens: tokens}
+		if ctx.Err() != nil {
+			return out, runErr
+		}
+		sc.mu.Lock()
+		defer sc.mu.Unlock()
+		err := inJobProject(sc.base, req, nil, func() error {
+			var err error
+			out.Report, err = renderReport(results)
+			return err
+		})
+		if err != nil {
+			return out, err
+		}
+		return out, runErr
+	}
+}
+
+// inJobProject writes files to a temporary project directory and, while fn runs, points
+// the shared configuration at it, with base adjusted to the format, profile and model
+// of req. Jobs and shards run one at a time, so each may set the shared configuration.
+func inJobProject(base config.Config, req server.Request, files []server.File, fn func() error) error {
+	dir, err := os.MkdirTemp("", "aireview-job-")
+	if err != nil {
+		return fmt.Errorf("failed to create job directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	project := filepath.Join(dir, "project")
+	if err := os.Mkdir(project, 0755); err != nil {
+		return fmt.Errorf("failed to create job directory: %w", err)
+	}
+	for _, f := range files {
+		path := filepath.Join(project, filepath.FromSlash(f.Path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.Path, err)
+		}
+		if err := os.WriteFile(path, []byte(f.Content), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.Path, err)
+		}
+	}
+
+	*cfg = base
+	defer func() { *cfg = base }()
+	cfg.ProjectPath = project
+	cfg.ReportFile = filepath.Join(dir, "report")
+	cfg.Format = req.Format
+	cfg.Outputs = nil
+	cfg.SummaryOnly = false
+	cfg.PathStyle = "relative"
+	if req.Profile != "" {
+		cfg.Profile = req.Profile
+	}
+	if req.Model != "" {
+		cfg.Model = req.Model
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	return fn()
+}
+
+// renderReport writes reviews made elsewhere, by path relative to the project, to the
+// report file as executeReview would and returns the report.
+func renderReport(results []report.FileReview) ([]byte, error) {
+	outputs, err := newReportOutputs("")
+	if err != nil {
+		return nil, err
+	}
+	if err := outputs.open(); err != nil {
+		return nil, err
+	}
+	defer outputs.close()
+	root := projectRoot(cfg.ProjectPath)
+	for i := range results {
+		fr := &results[i]
+		fr.Path = filepath.Join(root, filepath.FromSlash(fr.Path))
+		if fr.DuplicateOf != "" {
+			fr.DuplicateOf = filepath.Join(root, filepath.FromSlash(fr.DuplicateOf))
+		}
+		if err := outputs.writeEntry(*fr); err != nil {
+			return nil, fmt.Errorf("failed to write report for %s: %w", fr.Path, err)
+		}
+	}
+	if err := outputs.finish(results); err != nil {
+		return nil, err
+	}
+	if err := outputs.close(); err != nil {
+		return nil, err
+	}
+	out, err := os.ReadFile(cfg.ReportFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the report: %w", err)
+	}
+	return out, nil
+}
+
+// runTokens sums the prompt and completion tokens of a run's reviews.
+func runTokens(results []report.FileReview) int {
+	u := report.TotalUsage(results)
+	if u == nil {
+		return 0
+	}
+	return u.PromptTokens + u.CompletionTokens
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/disconnekt/goreview/internal/retrieval"
+	"github.com/disconnekt/goreview/internal/reviewer"
+	"github.com/disconnekt/goreview/internal/scanner"
+)
+
+const (
+	// similarNeighbors is how many related files are added to each file's prompt.
+	similarNeighbors = 3
+	// minSimilarity leaves out neighbors too unrelated to be worth their tokens.
+	minSimilarity = 0.3
+	// embedChars caps the text embedded per file; embedding models accept a few
+	// thousand tokens at most.
+	embedChars = 8000
+)
+
+// embedText is the text embedded for a file: its path and the start of its content.
+func embedText(rel, content string) string {
+	if len(content) > embedChars {
+		content = content[:embedChars]
+	}
+	return "File " + rel + "\n" + content
+}
+
+// updateIndex brings the embedding index in the state directory up to date with files:
+// only new and changed files are embedded, and entries of files deleted from disk are
+// dropped. Switching the embedding model rebuilds the index.
+func updateIndex(ctx context.Context, embedder *reviewer.Embedder, files []scanner.FileInfo) (*retrieval.Index, error) {
+	path := cfg.StatePath(retrieval.IndexFile)
+	ix, err := retrieval.LoadIndex(path)
+	if err != nil {
+		return nil, err
+	}
+	if ix.Model != embedder.Model() {
+		if len(ix.Files) > 0 {
+			logf("Embedding model changed from %s to %s; rebuilding the index\n", ix.Model, embedder.Model())
+		}
+		ix = &retrieval.Index{Model: embedder.Model(), Files: make(map[string]retrieval.Entry)}
+	}
+
+	root := projectRoot(cfg.ProjectPath)
+	hashes := make(map[string]string, len(files))
+	byPath := make(map[string]scanner.FileInfo, len(files))
+	for _, f := range files {
+		rel := relPath(root, f.Path)
+		hashes[rel] = f.Hash
+		byPath[rel] = f
+	}
+	removed := ix.Prune(func(rel string) bool {
+		if _, ok := hashes[rel]; ok {
+			return true
+		}
+		_, err := os.Stat(filepath.Join(root, filepath.FromSlash(rel)))
+		return err == nil
+	})
+	stale := ix.Stale(hashes)
+	if len(stale) > 0 {
+		inputs := make([]string, len(stale))
+		for i, rel := range stale {
+			inputs[i] = embedText(rel, fileText(byPath[rel]))
+		}
+		logf("Embedding %d new or changed files with %s\n", len(stale), embedder.Model())
+		vectors, err := embedder.Embed(ctx, inputs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed files: %w", err)
+		}
+		for i, rel := range stale {
+			ix.Files[rel] = retrieval.Entry{Hash: hashes[rel], Vector: vectors[i]}
+		}
+	}
+	logf("Embedding index: %d files, %d updated, %d removed\n", len(ix.Files), len(stale), removed)
+	if len(stale) > 0 || removed > 0 {
+		if err := ix.Save(path); err != nil {
+			return nil, err
+		}
+	}
+	return ix, nil
+}
+
+// loadSimilarContext adds the files most similar to each reviewed file to its prompt
+// when --context includes similar. The index covers every scanned file, so neighbors
+// may come from outside the reviewed set. Failures only cost the context.
+func loadSimilarContext(ctx context.Context, scanned, files []scanner.FileInfo) {
+	if !containsString(cfg.Context, "similar") {
+		return
+	}
+	embedder, err := reviewer.NewEmbedder(cfg)
+	if err != nil {
+		warnf("no similar-file context: %v\n", err)
+		return
+	}
+	ix, err := updateIndex(ctx, embedder, scanned)
+	if err != nil {
+		warnf("no similar-file context: %v\n", err)
+		return
+	}
+
+	root := projectRoot(cfg.ProjectPath)
+	byRel := make(map[string]scanner.FileInfo, len(scanned))
+	for _, f := range scanned {
+		byRel[relPath(root, f.Path)] = f
+	}
+	for _, f := range files {
+		rel := relPath(root, f.Path)
+		var matches []retrieval.Match
+		eligible := func(p string) bool {
+			_, ok := byRel[p]
+			return ok && p != rel
+		}
+		for _, n := range ix.Nearest(ix.Files[rel].Vector, similarNeighbors, eligible, minSimilarity) {
+			matches = append(matches, retrieval.Match{File: byRel[n.Path], Score: n.Similarity})
+		}
+		if len(matches) == 0 {
+			continue
+		}
+		_, packed := packFiles(matches, root, similarNeighbors, cfg.ContextBudget)
+		addFileContext(f.Path, "Related files from the repository, for cross-file context only; "+
+			"do not report findings in them:\n"+packed)
+	}
+}
+
+// similarFiles ranks the scanned files by embedding similarity to a question.
+func similarFiles(ctx context.Context, question string, files []scanner.FileInfo, top int) ([]retrieval.Match, error) {
+	embedder, err := reviewer.NewEmbedder(cfg)
+	if err != nil {
+		return nil, err
+	}
+	ix, err := updateIndex(ctx, embedder, files)
+	if err != nil {
+		return nil, err
+	}
+	vectors, err := embedder.Embed(ctx, []string{question})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed the question: %w", err)
+	}
+	root := projectRoot(cfg.ProjectPath)
+	byRel := make(map[string]scanner.FileInfo, len(files))
+	for _, f := range files {
+		byRel[relPath(root, f.Path)] = f
+	}
+	var matches []retrieval.Match
+	eligible := func(p string) bool {
+		_, ok := byRel[p]
+		return ok
+	}
+	for _, n := range ix.Nearest(vectors[0], top, eligible, 0) {
+		matches = append(matches, retrieval.Match{File: byRel[n.Path], Score: n.Similarity})
+	}
+	return matches, nil
+}
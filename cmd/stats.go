@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/disconnekt/goreview/internal/report"
+	"github.com/disconnekt/goreview/internal/reviewer"
+)
+
+// slowestFilesShown is how many of the slowest files the run statistics list.
+const slowestFilesShown = 5
+
+// fileTiming is how long one file took to review, including all passes.
+type fileTiming struct {
+	path     string
+	size     int64
+	duration time.Duration
+}
+
+// runStats collects per-file timings and per-request metrics to help tune concurrency
+// and chunk sizes. It is safe for concurrent use.
+type runStats struct {
+	mu       sync.Mutex
+	start    time.Time
+	files    []fileTiming
+	attempts []reviewer.Attempt
+	stages   []*stageStats
+}
+
+func newRunStats() *runStats {
+	return &runStats{start: time.Now()}
+}
+
+// observe records an outbound request; it is registered as a service observer.
+func (s *runStats) observe(a reviewer.Attempt) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts = append(s.attempts, a)
+}
+
+func (s *runStats) recordFile(path string, size int64, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files = append(s.files, fileTiming{path: path, size: size, duration: d})
+}
+
+// addStages records the measurements of the review pipeline's stages.
+func (s *runStats) addStages(stages ...*stageStats) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stages = append(s.stages, stages...)
+}
+
+// endpointStats aggregates the requests sent to one endpoint.
+type endpointStats struct {
+	requests int
+	failed   int
+	latency  time.Duration
+}
+
+// print writes the statistics section of the run summary.
+func (s *runStats) print() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.attempts) == 0 {
+		return
+	}
+	elapsed := time.Since(s.start)
+
+	var sentBytes, promptTokens, cachedTokens, completionTokens, failed int
+	latencies := make([]time.Duration, 0, len(s.attempts))
+	endpoints := make(map[string]*endpointStats)
+	for _, a := range s.attempts {
+		if a.SentBytes > 0 {
+			sentBytes += a.SentBytes
+		} else {
+			sentBytes += a.RequestBytes
+		}
+		promptTokens += a.PromptTokens
+		cachedTokens += a.CachedTokens
+		completionTokens += a.CompletionTokens
+		latencies = append(latencies, a.Latency)
+		es := endpoints[a.Endpoint]
+		if es == nil {
+			es = &endpointStats{}
+			endpoints[a.Endpoint] = es
+		}
+		es.requests++
+		es.latency += a.Latency
+		if a.Err != nil {
+			failed++
+			es.failed++
+		}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	var total time.Duration
+	for _, l := range latencies {
+		total += l
+	}
+
+	logf("\nRun statistics:\n")
+	logf("  Requests: %d (%d failed) in %s\n", len(s.attempts), failed, elapsed.Round(time.Millisecond))
+	logf("  Request latency: avg %s, p50 %s, p90 %s, p99 %s, max %s\n",
+		roundDuration(total/time.Duration(len(latencies))), roundDuration(percentile(latencies, 50)),
+		roundDuration(percentile(latencies, 90)), roundDuration(percentile(latencies, 99)),
+		roundDuration(latencies[len(latencies)-1]))
+	seconds := elapsed.Seconds()
+	logf("  Throughput: %.1f KB/s sent", float64(sentBytes)/1024/seconds)
+	if completionTokens > 0 {
+		cached := ""
+		if cachedTokens > 0 {
+			cached = fmt.Sprintf(" (%d cached)", cachedTokens)
+		}
+		logf(", %.1f tokens/s generated (%d prompt%s, %d completion tokens)",
+			float64(completionTokens)/seconds, promptTokens, cached, completionTokens)
+	}
+	logf("\n")
+	s.printCost()
+
+	if len(s.files) > 0 {
+		files := append([]fileTiming(nil), s.files...)
+		sort.Slice(files, func(i, j int) bool { return files[i].duration > files[j].duration })
+		var fileTotal time.Duration
+		for _, f := range files {
+			fileTotal += f.duration
+		}
+		logf("  Per-file latency: avg %s over %d files\n",
+			roundDuration(fileTotal/time.Duration(len(files))), len(files))
+		logf("  Slowest files:\n")
+		for _, f := range files[:min(slowestFilesShown, len(files))] {
+			logf("    %8s  %s (%d bytes)\n", roundDuration(f.duration), f.path, f.size)
+		}
+	}
+
+	if len(s.stages) > 0 {
+		logf("  Pipeline stages:\n")
+		for _, st := range s.stages {
+			st.mu.Lock()
+			name := st.name
+			if st.workers > 1 {
+				name = fmt.Sprintf("%s (%d workers)", name, st.workers)
+			}
+			logf("    %s: %d units, busy %s, waited %s for input and %s for the next stage\n", name, st.units,
+				roundDuration(st.busy), roundDuration(st.idle), roundDuration(st.blocked))
+			st.mu.Unlock()
+		}
+	}
+
+	if len(endpoints) > 0 {
+		names := make([]string, 0, len(endpoints))
+		for name := range endpoints {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		logf("  Endpoints:\n")
+		for _, name := range names {
+			es := endpoints[name]
+			logf("    %s: %d requests (%.0f%%), avg %s, %d failed\n", name, es.requests,
+				100*float64(es.requests)/float64(len(s.attempts)),
+				roundDuration(es.latency/time.Duration(es.requests)), es.failed)
+		}
+	}
+}
+
+// printCost estimates the price of the run's tokens from the prices of the models that
+// have them. The caller holds s.mu.
+func (s *runStats) printCost() {
+	costs := make(map[string]float64)
+	for _, a := range s.attempts {
+		if info := cfg.ModelInfo(a.Model); info.Priced() {
+			costs[a.Model] += info.Cost(a.PromptTokens, a.CachedTokens, a.CompletionTokens)
+		}
+	}
+	if len(costs) == 0 {
+		return
+	}
+	models := make([]string, 0, len(costs))
+	var total float64
+	for m, c := range costs {
+		models = append(models, m)
+		total += c
+	}
+	sort.Strings(models)
+	logf("  Estimated cost: $%.4f", total)
+	if len(models) > 1 {
+		parts := make([]string, len(models))
+		for i, m := range models {
+			parts[i] = fmt.Sprintf("%s $%.4f", m, costs[m])
+		}
+		logf(" (%s)", strings.Join(parts, ", "))
+	}
+	logf("\n")
+}
+
+// percentile returns the nearest-rank percentile p of sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
+
+func roundDuration(d time.Duration) time.Duration {
+	if d >= time.Second {
+		return d.Round(10 * time.Millisecond)
+	}
+	return d.Round(time.Millisecond)
+}
+
+// reviewUsage converts the metadata of a review's requests for the report.
+func reviewUsage(m reviewer.Metadata) *report.Usage {
+	if m.Requests == 0 && m.Reused == 0 {
+		return nil
+	}
+	return &report.Usage{
+		Requests:          m.Requests,
+		ReusedChunks:      m.Reused,
+		PromptTokens:      m.PromptTokens,
+		CompletionTokens:  m.CompletionTokens,
+		CachedTokens:      m.CachedTokens,
+		LatencyMS:         m.Latency.Milliseconds(),
+		RateLimitRequests: m.RateLimitRequests,
+		RateLimitTokens:   m.RateLimitTokens,
+		ModelVersions:     m.ModelVersions,
+	}
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/findings"
+	"github.com/disconnekt/goreview/internal/scanner"
+)
+
+// streamScan reports whether the files of the run can go to the reviews as the scan
+// reads them. Analyses comparing files with each other or needing the whole file list
+// before the first review make the scan finish first: --duplicates, --todos,
+// architecture rules, --since and --author, --pprof, the similar-code context, churn
+// for the risk and heatmap reports, the profiles with project-wide context, --module
+// (to tell a missing module apart) and --batch (to submit every request at once).
+func streamScan() bool {
+	switch {
+	case cfg.Batch, cfg.Module != "", cfg.Duplicates != "", cfg.Todos != "", len(cfg.Architecture) > 0,
+		cfg.Since != "", cfg.Author != "", len(cfg.Pprof) > 0, containsString(cfg.Context, "similar"):
+		return false
+	case cfg.Format == "risk" || cfg.Format == "heatmap" || hasOutput("risk") || hasOutput("heatmap"):
+		return false
+	}
+	switch cfg.Profile {
+	case "vuln", "api", "concurrency", "license", "testgap":
+		return false
+	}
+	return true
+}
+
+// streamProject returns the streaming scan of the member modules of go.work when the
+// project has one, otherwise of the whole project directory.
+func streamProject(fileScanner *scanner.Scanner) (fileSource, error) {
+	var members []string
+	if !cfg.IgnoreWorkspace {
+		var err error
+		members, err = scanner.WorkspaceMembers(cfg.ProjectPath)
+		if err != nil {
+			return nil, err
+		}
+		if members != nil {
+			logf("Using go.work with %d member modules: %s\n", len(members), strings.Join(members, ", "))
+		}
+	}
+	return func(ctx context.Context) (<-chan scanner.FileInfo, func() error) {
+		return fileScanner.ScanStream(ctx, cfg.ProjectPath, members)
+	}, nil
+}
+
+// streamAnalyses runs the analyses that look at one file at a time on each file of a
+// streaming scan as it comes: the module settings, CODEOWNERS, the go.mod and framework
+// context and the complexity metrics. It is used by the prepare stage alone.
+type streamAnalyses struct {
+	modules    *moduleFilter
+	owners     *ownerAttribution
+	frameworks frameworkCounts
+	notable    int
+}
+
+// newStreamAnalyses resets the per-file data of the run. The analyses streaming leaves
+// out have nothing to add.
+func newStreamAnalyses() (*streamAnalyses, error) {
+	attr, err := newOwnerAttribution()
+	if err != nil {
+		return nil, err
+	}
+	fileDataMu.Lock()
+	moduleGoModContext = make(map[string]string)
+	fileContexts = make(map[string]string)
+	toolFindings = make(map[string][]findings.Finding)
+	fileDataMu.Unlock()
+	resetComplexityMetrics()
+	fileTodos, fileChurn, fileAPIChanges = nil, nil, nil
+	authoredLines, authoredExcerpts = nil, nil
+	return &streamAnalyses{
+		modules:    newModuleFilter(),
+		owners:     attr,
+		frameworks: make(frameworkCounts),
+	}, nil
+}
+
+// admit runs the analyses on f and reports whether it is reviewed.
+func (a *streamAnalyses) admit(f scanner.FileInfo) (bool, error) {
+	if !a.modules.keep(f) {
+		return false, nil
+	}
+	if ok, err := a.owners.keep(f); !ok || err != nil {
+		return false, err
+	}
+	addGoModContext(f)
+	a.frameworks.add(f)
+	a.notable += addComplexityMetrics(f)
+	return true, nil
+}
+
+// logSummary prints what the analyses found once the scan is done.
+func (a *streamAnalyses) logSummary() {
+	a.modules.logModules()
+	a.modules.logTruncated()
+	a.owners.logOwner()
+	a.frameworks.log()
+	logNotableFunctions(a.notable)
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/scanner"
+)
+
+// loadTestContext pairs every file with its _test.go for --profile testgap, so the model
+// sees which paths the existing tests exercise.
+func loadTestContext(files []scanner.FileInfo) {
+	if cfg.Profile != "testgap" {
+		return
+	}
+	paired := 0
+	for _, f := range files {
+		testPath := strings.TrimSuffix(f.Path, ".go") + "_test.go"
+		name := filepath.Base(testPath)
+		info, err := os.Stat(testPath)
+		switch {
+		case err != nil:
+			addFileContext(f.Path, fmt.Sprintf("There is no %s; tests in other files of the package may still cover this code.", name))
+		case cfg.MaxFileSize > 0 && info.Size() > cfg.MaxFileSize:
+			addFileContext(f.Path, fmt.Sprintf("%s exists but is too large to include (%d bytes).", name, info.Size()))
+		default:
+			content, err := os.ReadFile(testPath)
+			if err != nil {
+				warnf("failed to read %s: %v\n", testPath, err)
+				continue
+			}
+			paired++
+			addFileContext(f.Path, fmt.Sprintf("Existing tests in %s:\n```go\n%s\n```", name, strings.TrimRight(string(content), "\n")))
+		}
+	}
+	logf("Paired %d of %d files with their tests\n", paired, len(files))
+}
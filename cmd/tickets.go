@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/findings"
+	"github.com/disconnekt/goreview/internal/github"
+	"github.com/disconnekt/goreview/internal/jira"
+	"github.com/disconnekt/goreview/internal/tickets"
+)
+
+// createTickets files tickets for the run's findings at or above --ticket-severity.
+// Errors are warnings: like PR comments, tickets must not fail the review.
+func createTickets(outcome *runOutcome) {
+	if cfg.CreateTickets == "" || outcome == nil {
+		return
+	}
+	tracker, err := ticketTracker()
+	if err != nil {
+		warnf("ticket creation disabled: %v\n", err)
+		return
+	}
+	threshold, _ := findings.ParseSeverity(cfg.TicketSeverity)
+
+	root := projectRoot(cfg.ProjectPath)
+	var want []tickets.Ticket
+	for _, r := range outcome.results {
+		content, _ := os.ReadFile(r.Path)
+		for _, f := range r.Findings {
+			if f.Severity.Rank() < threshold.Rank() {
+				continue
+			}
+			if f.File == "" {
+				f.File = relPath(root, r.Path)
+			}
+			want = append(want, tickets.Ticket{Finding: f, Excerpt: tickets.Excerpt(string(content), f.Line)})
+		}
+	}
+	if len(want) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*notifyTimeout)
+	defer cancel()
+	stats, err := tickets.Sync(ctx, tracker, want, cfg.TicketConsolidate)
+	if len(stats.Created) > 0 {
+		logf("Tickets: %d created for %d findings (%s), %d already filed\n",
+			len(stats.Created), stats.Filed, strings.Join(stats.Created, ", "), stats.Existing)
+	} else if err == nil {
+		logf("Tickets: none created, %d already filed\n", stats.Existing)
+	}
+	if err != nil {
+		warnf("failed to create tickets: %v\n", err)
+	}
+}
+
+func ticketTracker() (tickets.Tracker, error) {
+	switch cfg.CreateTickets {
+	case "jira":
+		return jira.NewClient(cfg.JiraURL, cfg.JiraUser, cfg.JiraToken, cfg.JiraProject, cfg.JiraIssueType)
+	case "github":
+		client, err := github.NewClientFromEnv(cfg.GitHubToken)
+		if err != nil {
+			return nil, err
+		}
+		return client.Issues(), nil
+	}
+	return nil, fmt.Errorf("unknown tracker %q", cfg.CreateTickets)
+}
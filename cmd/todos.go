@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/findings"
+	"github.com/disconnekt/goreview/internal/scanner"
+	"github.com/disconnekt/goreview/internal/todo"
+)
+
+// todoRulePrefix tags the model's assessment of a TODO in --todos triage, followed by
+// the TODO's number within the file, e.g. TODO-2.
+const todoRulePrefix = "TODO-"
+
+// fileTodos holds the TODO comments of each file path for --todos. It is filled before
+// the review starts and read-only after.
+var fileTodos map[string][]todo.Todo
+
+// loadTodos collects the TODO comments of the files for --todos; in triage mode each
+// file's TODOs are also given to the model to assess.
+func loadTodos(files []scanner.FileInfo) {
+	fileTodos = nil
+	if cfg.Todos == "" {
+		return
+	}
+	fileTodos = make(map[string][]todo.Todo)
+	total := 0
+	for _, f := range files {
+		todos := todo.Find(f.Path, fileText(f))
+		if len(todos) == 0 {
+			continue
+		}
+		fileTodos[f.Path] = todos
+		total += len(todos)
+		if cfg.Todos == "triage" {
+			addFileContext(f.Path, todoSection(todos))
+		}
+	}
+	logf("TODO comments: %d in %d files\n", total, len(fileTodos))
+}
+
+// todoSection asks the model to triage the TODOs of one file.
+func todoSection(todos []todo.Todo) string {
+	var b strings.Builder
+	b.WriteString("The TODO comments of this file are tracked as technical debt. Report one finding per TODO, " +
+		"tagged with its ID, e.g. [TODO-1]. Its severity is the priority of resolving the TODO, and its " +
+		"description the risk of leaving it as is:\n")
+	for i, t := range todos {
+		fmt.Fprintf(&b, "- [%s%d] line %d: %s %s\n", todoRulePrefix, i+1, t.Line, t.Tag, t.Text)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// triageTodos moves the model's TODO assessments out of the findings into a copy of
+// the file's TODOs.
+func triageTodos(list []findings.Finding, todos []todo.Todo) ([]findings.Finding, []todo.Todo) {
+	if len(todos) == 0 {
+		return list, nil
+	}
+	todos = append([]todo.Todo(nil), todos...)
+	var kept []findings.Finding
+	for _, f := range list {
+		n, err := strconv.Atoi(strings.TrimPrefix(f.RuleID, todoRulePrefix))
+		if !strings.HasPrefix(f.RuleID, todoRulePrefix) || err != nil || n < 1 || n > len(todos) {
+			kept = append(kept, f)
+			continue
+		}
+		todos[n-1].Priority = string(f.Severity)
+		todos[n-1].Assessment = strings.TrimSpace(strings.ReplaceAll(f.Message, "["+f.RuleID+"]", ""))
+	}
+	return kept, todos
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/disconnekt/goreview/internal/transcript"
+)
+
+var transcriptsOpts struct {
+	format string
+	out    string
+}
+
+var transcriptsCmd = &cobra.Command{
+	Use:   "transcripts [fingerprint | file[:line]]...",
+	Short: "Export the follow-up conversations about findings",
+	Long: `Transcripts exports the follow-up conversations held during triage, for attaching
+to tickets. Each conversation is printed with its finding, as Markdown or JSON.
+Arguments select conversations by finding fingerprint (or a prefix of it), file or
+file:line; without arguments every conversation is exported.`,
+	Example: `  aireview transcripts --out followups.md
+  aireview transcripts internal/cache/lru.go:42 --format json`,
+	RunE: runTranscripts,
+}
+
+func init() {
+	transcriptsCmd.Flags().StringVar(&transcriptsOpts.format, "format", "markdown", "Output format: markdown or json")
+	transcriptsCmd.Flags().StringVar(&transcriptsOpts.out, "out", "", "Write the transcripts to this file instead of stdout")
+	rootCmd.AddCommand(transcriptsCmd)
+}
+
+func runTranscripts(cmd *cobra.Command, args []string) error {
+	write := transcript.WriteMarkdown
+	switch transcriptsOpts.format {
+	case "markdown", "md":
+	case "json":
+		write = transcript.WriteJSON
+	default:
+		return fmt.Errorf("unknown transcript format %q (use markdown or json)", transcriptsOpts.format)
+	}
+	store, err := transcript.Load(cfg.StatePath(transcript.FileName))
+	if err != nil {
+		return err
+	}
+	threads := store.All()
+	if len(args) > 0 {
+		threads = store.Select(args)
+	}
+	if len(threads) == 0 {
+		return errors.New("no follow-up conversations found; ask about findings with [f] in aireview triage")
+	}
+
+	var out io.Writer = cmd.OutOrStdout()
+	if transcriptsOpts.out != "" {
+		file, err := os.Create(transcriptsOpts.out)
+		if err != nil {
+			return fmt.Errorf("failed to create transcript file: %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+	if err := write(out, threads); err != nil {
+		return fmt.Errorf("failed to write transcripts: %w", err)
+	}
+	if transcriptsOpts.out != "" {
+		fmt.Printf("Wrote %d conversations to %s\n", len(threads), transcriptsOpts.out)
+	}
+	return nil
+}
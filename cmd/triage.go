@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/disconnekt/goreview/internal/baseline"
+	"github.com/disconnekt/goreview/internal/findings"
+	"github.com/disconnekt/goreview/internal/report"
+	"github.com/disconnekt/goreview/internal/reviewer"
+	"github.com/disconnekt/goreview/internal/transcript"
+)
+
+var triageCmd = &cobra.Command{
+	Use:   "triage",
+	Short: "Step through the last run's findings and accept or dismiss each one",
+	Long: `Triage walks through the findings of the most recent review run. Dismissed
+findings are written to the baseline in the state directory and are suppressed
+from future runs; accepted findings keep being reported.
+
+Before deciding, [f]ollow-up asks the model a question about the finding ("why is
+this a race?"). The finding and its file are sent with the question, and further
+questions continue the same conversation. Conversations are kept in the state
+directory, so they resume in later triage sessions and can be exported with
+"aireview transcripts".`,
+	Args: cobra.NoArgs,
+	RunE: runTriage,
+}
+
+func init() {
+	addReviewFlags(triageCmd.Flags())
+	rootCmd.AddCommand(triageCmd)
+}
+
+const followUpPrompt = `You are the very experienced Go developer who reviewed the code below and reported the finding
+	below. Answer the developer's follow-up questions about the finding: explain your reasoning with
+	reference to line numbers ("N| " prefixes), show how to fix it when asked, and say plainly if, on
+	reflection, the finding is wrong. Be concise.`
+
+// followUps creates the review service on the first follow-up question, so triage
+// without questions needs no endpoint or credentials.
+type followUps struct {
+	cmd     *cobra.Command
+	root    string
+	store   *transcript.Store
+	service *reviewer.Service
+	threads map[string]*reviewer.Thread
+}
+
+// ask continues the conversation about f with a question.
+func (fu *followUps) ask(f findings.Finding, question string) (string, error) {
+	if fu.service == nil {
+		if err := prepareConfig(fu.cmd); err != nil {
+			return "", err
+		}
+		service, err := reviewer.NewService(cfg)
+		if err != nil {
+			return "", err
+		}
+		fu.service = service
+	}
+	key := f.Fingerprint()
+	thread, ok := fu.threads[key]
+	if !ok {
+		opening := findingOpening(filepath.Join(fu.root, filepath.FromSlash(f.File)), f)
+		thread = fu.service.NewThread(followUpPrompt, opening, fu.store.Messages(f))
+		fu.threads[key] = thread
+	}
+	answer, err := thread.Ask(context.Background(), question)
+	if err != nil {
+		return "", err
+	}
+	fu.store.Record(f, cfg.Model, thread.Messages)
+	if err := fu.store.Save(); err != nil {
+		warnf("%v\n", err)
+	}
+	return answer, nil
+}
+
+// findingOpening describes a finding and the code it is about for a follow-up thread.
+func findingOpening(path string, f findings.Finding) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Finding in %s:\n%s\n", f.File, findings.FormatBullet(f))
+	if data, err := os.ReadFile(path); err == nil && int64(len(data)) <= cfg.MaxFileSize {
+		fmt.Fprintf(&b, "\nFile %s:\n```go\n%s```", f.File, reviewer.NumberLines(string(data), 1))
+	} else if lines := strings.Split(string(data), "\n"); err == nil && f.Line > 0 && f.Line <= len(lines) {
+		// Too large to send whole: the surroundings of the finding have to do. A line
+		// past the end of the file is stale and has none
+		from, to := max(f.Line-40, 1), min(f.Line+40, len(lines))
+		fmt.Fprintf(&b, "\nLines %d-%d of %s:\n```go\n%s```", from, to, f.File,
+			reviewer.NumberLines(strings.Join(lines[from-1:to], "\n"), from))
+	}
+	return b.String()
+}
+
+func runTriage(cmd *cobra.Command, args []string) error {
+	lastRun, err := report.LoadLastRun(cfg.StatePath(report.LastRunFile))
+	if err != nil {
+		return fmt.Errorf("%w (run a review first)", err)
+	}
+	store, err := baseline.Load(cfg.StatePath(baseline.FileName))
+	if err != nil {
+		return err
+	}
+	transcripts, err := transcript.Load(cfg.StatePath(transcript.FileName))
+	if err != nil {
+		return err
+	}
+
+	var pending []findings.Finding
+	for _, f := range lastRun.Findings {
+		if !store.Decided(f) {
+			pending = append(pending, f)
+		}
+	}
+	if len(pending) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "No findings left to triage.")
+		return nil
+	}
+	findings.Sort(pending)
+
+	out := cmd.OutOrStdout()
+	in := bufio.NewReader(cmd.InOrStdin())
+	root := projectRoot(cfg.ProjectPath)
+	accepted, dismissed := 0, 0
+	fu := &followUps{cmd: cmd, root: root, store: transcripts, threads: make(map[string]*reviewer.Thread)}
+
+loop:
+	for i, f := range pending {
+		fmt.Fprintf(out, "\n[%d/%d] %s %s\n", i+1, len(pending), strings.ToUpper(string(f.Severity)), f.Location())
+		fmt.Fprintf(out, "  %s\n", f.Message)
+		printExcerpt(out, filepath.Join(root, filepath.FromSlash(f.File)), f.Line)
+		if n := len(transcripts.Messages(f)) / 2; n > 0 {
+			fmt.Fprintf(out, "  %d earlier follow-up questions; [f] continues the conversation\n", n)
+		}
+
+		for {
+			answer, err := prompt(out, in, "  [a]ccept, [d]ismiss, [f]ollow-up, [s]kip, [q]uit? ")
+			if err != nil {
+				break loop
+			}
+			switch strings.ToLower(answer) {
+			case "a", "accept":
+				reason, _ := prompt(out, in, "  Reason (optional): ")
+				store.Record(f, baseline.Accepted, reason)
+				accepted++
+			case "d", "dismiss":
+				reason, _ := prompt(out, in, "  Reason: ")
+				store.Record(f, baseline.Dismissed, reason)
+				dismissed++
+			case "f", "follow-up", "followup":
+				question, _ := prompt(out, in, "  Question: ")
+				if question == "" {
+					continue
+				}
+				reply, err := fu.ask(f, question)
+				if err != nil {
+					fmt.Fprintf(out, "  Follow-up failed: %v\n", err)
+					continue
+				}
+				for _, line := range strings.Split(strings.TrimSpace(reply), "\n") {
+					fmt.Fprintf(out, "  | %s\n", line)
+				}
+				continue
+			case "s", "skip", "":
+			case "q", "quit":
+				break loop
+			default:
+				continue
+			}
+			break
+		}
+	}
+
+	if accepted+dismissed == 0 {
+		fmt.Fprintln(out, "\nNo decisions recorded.")
+		return nil
+	}
+	if err := store.Save(); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "\nRecorded %d accepted and %d dismissed findings in %s\n",
+		accepted, dismissed, cfg.StatePath(baseline.FileName))
+	return nil
+}
+
+// prompt prints a question and reads one trimmed line of input.
+func prompt(out io.Writer, in *bufio.Reader, question string) (string, error) {
+	fmt.Fprint(out, question)
+	line, err := in.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// printExcerpt shows the lines around a finding so it can be judged without opening the file.
+func printExcerpt(out io.Writer, path string, line int) {
+	if line <= 0 {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	lines := strings.Split(string(data), "\n")
+	from, to := max(line-2, 1), min(line+2, len(lines))
+	for n := from; n <= to; n++ {
+		marker := " "
+		if n == line {
+			marker = ">"
+		}
+		fmt.Fprintf(out, "  %s %4d| %s\n", marker, n, lines[n-1])
+	}
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/disconnekt/goreview/internal/github"
+)
+
+// Build metadata, set at link time:
+//
+//	go build -ldflags "-X github.com/disconnekt/goreview/cmd.version=v1.4.0 \
+//	  -X github.com/disconnekt/goreview/cmd.commit=$(git rev-parse HEAD) \
+//	  -X github.com/disconnekt/goreview/cmd.date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	version = "dev"
+	commit  = ""
+	date    = ""
+)
+
+// releaseRepository is where update checks look for new releases.
+const releaseRepository = "disconnekt/goreview"
+
+var versionCheckUpdate bool
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the version and build information",
+	Long: `Version prints the version, commit and build date of the binary. With
+--check-update it also asks GitHub for the latest release; set update_check: false
+in the config (or AIREVIEW_UPDATE_CHECK=false) to disable the check, e.g. in
+air-gapped environments.`,
+	Args: cobra.NoArgs,
+	RunE: runVersion,
+}
+
+func init() {
+	versionCmd.Flags().BoolVar(&versionCheckUpdate, "check-update", false,
+		"Check GitHub for a newer release")
+	rootCmd.AddCommand(versionCmd)
+}
+
+func runVersion(cmd *cobra.Command, args []string) error {
+	v, c, d := buildInfo()
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "aireview %s\n", v)
+	if c != "" {
+		fmt.Fprintf(out, "  commit: %s\n", c)
+	}
+	if d != "" {
+		fmt.Fprintf(out, "  date:   %s\n", d)
+	}
+	fmt.Fprintf(out, "  go:     %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+
+	if !versionCheckUpdate {
+		return nil
+	}
+	if _, err := mergeConfig(cmd); err != nil {
+		return err
+	}
+	if !cfg.UpdateCheck {
+		return errors.New("update checks are disabled by update_check in the configuration")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	defer cancel()
+	rel, err := github.LatestRelease(ctx, github.DefaultAPIURL, releaseRepository)
+	if err != nil {
+		return fmt.Errorf("failed to check for updates: %w", err)
+	}
+	switch {
+	case !isRelease(v):
+		fmt.Fprintf(out, "Development build; the latest release is %s: %s\n", rel.TagName, rel.HTMLURL)
+	case newerVersion(rel.TagName, v):
+		fmt.Fprintf(out, "A newer release is available: %s (%s)\n", rel.TagName, rel.HTMLURL)
+	default:
+		fmt.Fprintln(out, "aireview is up to date")
+	}
+	return nil
+}
+
+// buildInfo returns the link-time metadata, falling back to what the Go toolchain
+// records for "go install" and VCS builds.
+func buildInfo() (v, c, d string) {
+	v, c, d = version, commit, date
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return v, c, d
+	}
+	if v == "dev" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		v = info.Main.Version
+	}
+	for _, s := range info.Settings {
+		switch {
+		case s.Key == "vcs.revision" && c == "":
+			c = s.Value
+		case s.Key == "vcs.time" && d == "":
+			d = s.Value
+		}
+	}
+	return v, c, d
+}
+
+// isRelease reports whether v looks like a tagged vMAJOR.MINOR.PATCH version.
+func isRelease(v string) bool {
+	_, ok := parseVersion(v)
+	return ok
+}
+
+// newerVersion reports whether version a is newer than b.
+func newerVersion(a, b string) bool {
+	va, okA := parseVersion(a)
+	vb, okB := parseVersion(b)
+	if !okA || !okB {
+		return false
+	}
+	for i := range va {
+		if va[i] != vb[i] {
+			return va[i] > vb[i]
+		}
+	}
+	return false
+}
+
+// parseVersion reads "v1.2.3", ignoring pre-release and build suffixes.
+func parseVersion(v string) ([3]int, bool) {
+	var out [3]int
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	parts := strings.Split(v, ".")
+	if len(parts) != 3 {
+		return out, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return out, false
+		}
+		out[i] = n
+	}
+	return out, true
+}
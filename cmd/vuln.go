@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/findings"
+	"github.com/disconnekt/goreview/internal/scanner"
+	"github.com/disconnekt/goreview/internal/vuln"
+)
+
+// callSite is a line of a scanned file on the call path to a vulnerable symbol.
+type callSite struct {
+	osv  string
+	line int
+	// call describes the call made at the line, e.g. "pkg.F calls dep.G".
+	call string
+	// finding is the govulncheck finding the call path belongs to.
+	finding vuln.Finding
+}
+
+// loadVulnContext runs or loads govulncheck for --profile vuln, attaches the reachable
+// vulnerabilities to the files that call them and returns only those files: the other
+// files have nothing for the profile to review.
+func loadVulnContext(ctx context.Context, files []scanner.FileInfo) ([]scanner.FileInfo, error) {
+	if cfg.Profile != "vuln" {
+		return files, nil
+	}
+	root := projectRoot(cfg.ProjectPath)
+
+	// Reports per module directory; a saved report covers the whole project
+	reports := make(map[string]*vuln.Report)
+	if cfg.Govulncheck != "" {
+		rep, err := vuln.Load(cfg.Govulncheck)
+		if err != nil {
+			return nil, err
+		}
+		reports["."] = rep
+	} else {
+		outside := 0
+		for _, f := range files {
+			if f.Module == "" {
+				outside++
+				continue
+			}
+			if _, ok := reports[f.Module]; ok {
+				continue
+			}
+			logf("Running govulncheck in %s\n", displayModule(f.Module))
+			rep, err := vuln.Run(ctx, filepath.Join(root, filepath.FromSlash(f.Module)))
+			if err != nil {
+				return nil, err
+			}
+			reports[f.Module] = rep
+		}
+		if outside > 0 {
+			warnf("govulncheck cannot scan %d files outside a Go module\n", outside)
+		}
+	}
+
+	byPath := make(map[string]string, len(files))
+	for _, f := range files {
+		byPath[projectRoot(f.Path)] = f.Path
+	}
+	sites := make(map[string][]callSite)
+	entries := make(map[string]vuln.Entry)
+	unreached := make(map[string]string)
+	var total, reachable int
+	for module, rep := range reports {
+		for id, e := range rep.Entries {
+			entries[id] = e
+		}
+		dirs := []string{filepath.Join(root, filepath.FromSlash(module)), root}
+		for _, fnd := range rep.Findings {
+			total++
+			if !fnd.Reachable() {
+				if len(fnd.Trace) > 0 {
+					unreached[fnd.OSV] = fnd.Trace[0].Module + "@" + fnd.Trace[0].Version
+				}
+				continue
+			}
+			reachable++
+			for i, fr := range fnd.Trace {
+				if fr.Position == nil {
+					continue
+				}
+				path, ok := resolveTracePath(fr.Position.Filename, dirs, byPath)
+				if !ok {
+					continue
+				}
+				// The position of a frame is where it calls the previous frame
+				site := callSite{osv: fnd.OSV, line: fr.Position.Line, finding: fnd}
+				if i > 0 {
+					site.call = fr.Symbol() + " calls " + fnd.Trace[i-1].Symbol()
+				}
+				sites[path] = append(sites[path], site)
+			}
+		}
+	}
+	// Symbol-level findings also appear at package and module level; count them once
+	for _, list := range sites {
+		for _, s := range list {
+			delete(unreached, s.osv)
+		}
+	}
+
+	logf("govulncheck: %d findings, %d reachable from %d files\n", total, reachable, len(sites))
+	if len(unreached) > 0 {
+		ids := make([]string, 0, len(unreached))
+		for id := range unreached {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		logf("Vulnerabilities in dependencies that the code does not call (upgrade when convenient):\n")
+		for _, id := range ids {
+			logf("- %s in %s: %s\n", id, unreached[id], entries[id].Summary)
+		}
+	}
+
+	var out []scanner.FileInfo
+	for _, f := range files {
+		list := sites[f.Path]
+		if len(list) == 0 {
+			continue
+		}
+		addFileContext(f.Path, vulnSection(list, entries))
+		toolFindings[f.Path] = vulnFindings(list, entries)
+		out = append(out, f)
+	}
+	if len(out) == 0 {
+		logf("No reachable vulnerabilities found\n")
+	}
+	return out, nil
+}
+
+// resolveTracePath maps a trace position to a scanned file. govulncheck reports
+// absolute paths or paths relative to the module it ran in.
+func resolveTracePath(name string, dirs []string, byPath map[string]string) (string, bool) {
+	if filepath.IsAbs(name) {
+		p, ok := byPath[filepath.Clean(name)]
+		return p, ok
+	}
+	for _, dir := range dirs {
+		if p, ok := byPath[filepath.Join(dir, filepath.FromSlash(name))]; ok {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// vulnSection describes the vulnerabilities reachable from one file for the prompt.
+func vulnSection(sites []callSite, entries map[string]vuln.Entry) string {
+	var b strings.Builder
+	b.WriteString("Known vulnerabilities reachable from this file (govulncheck):\n")
+	seen := make(map[string]bool)
+	for _, s := range sites {
+		if !seen[s.osv] {
+			seen[s.osv] = true
+			e := entries[s.osv]
+			sym := s.finding.Trace[0]
+			fmt.Fprintf(&b, "- %s%s: %s\n", s.osv, aliasList(e.Aliases), e.Summary)
+			fmt.Fprintf(&b, "  Vulnerable symbol: %s in %s@%s, %s\n", sym.Symbol(), sym.Module, sym.Version, fixedIn(s.finding))
+		}
+		fmt.Fprintf(&b, "  Line %d: %s\n", s.line, s.call)
+	}
+	return b.String()
+}
+
+// vulnFindings turns each reachable vulnerability into a finding at its first call site,
+// so it is reported even when the review does not mention it.
+func vulnFindings(sites []callSite, entries map[string]vuln.Entry) []findings.Finding {
+	var out []findings.Finding
+	seen := make(map[string]bool)
+	for _, s := range sites {
+		if seen[s.osv] {
+			continue
+		}
+		seen[s.osv] = true
+		sym := s.finding.Trace[0]
+		out = append(out, findings.Finding{
+			Line:     s.line,
+			Severity: findings.SeverityHigh,
+			RuleID:   s.osv,
+			Message: fmt.Sprintf("Reachable known vulnerability %s%s: %s; %s in %s@%s is called here, %s",
+				s.osv, aliasList(entries[s.osv].Aliases), entries[s.osv].Summary,
+				sym.Symbol(), sym.Module, sym.Version, fixedIn(s.finding)),
+		})
+	}
+	return out
+}
+
+func aliasList(aliases []string) string {
+	if len(aliases) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(aliases, ", ") + ")"
+}
+
+func fixedIn(f vuln.Finding) string {
+	if f.FixedVersion == "" {
+		return "no fixed version available"
+	}
+	return "fixed in " + f.FixedVersion
+}
+
+// mergeToolFindings adds the findings of external tools to a file's review findings,
+// skipping those whose rule the review already reported.
+func mergeToolFindings(list, extra []findings.Finding, file, module string) []findings.Finding {
+	// Several tool findings may share a rule; only the review's own findings replace them
+	reviewed := list
+	for _, tf := range extra {
+		reported := false
+		for _, f := range reviewed {
+			if f.RuleID == tf.RuleID {
+				reported = true
+				break
+			}
+		}
+		if !reported {
+			tf.File = file
+			tf.Module = module
+			list = append(list, tf)
+		}
+	}
+	return list
+}
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/disconnekt/goreview/internal/config"
+	"github.com/disconnekt/goreview/internal/server"
+)
+
+var (
+	workerCoordinator string
+	workerToken       string
+)
+
+var workerCmd = &cobra.Command{
+	Use:   "worker",
+	Short: "Review shards of server jobs leased from a coordinator",
+	Long: `Worker leases the shards of the jobs of an "aireview serve --shard-size" coordinator,
+reviews them with its own review flags, for instance a model endpoint on the same
+machine, and sends the reviews back. Several workers spread a large job across
+machines. On SIGINT or SIGTERM the worker drops its shard, which goes to another
+worker once the lease expires, and stops.`,
+	Example: `  aireview worker --coordinator http://review.internal:8080 --url http://127.0.0.1:1234/v1/chat/completions`,
+	Args:    cobra.NoArgs,
+	RunE:    runWorker,
+}
+
+func init() {
+	workerCmd.Flags().StringVar(&workerCoordinator, "coordinator", "", "Base URL of the coordinating review server")
+	workerCmd.Flags().StringVar(&workerToken, "worker-token", "",
+		"Bearer token of the coordinator's --worker-token (can also use AIREVIEW_WORKER_TOKEN)")
+	addReviewFlags(workerCmd.Flags())
+	rootCmd.AddCommand(workerCmd)
+}
+
+// errLeaseLost reports that the coordinator gave the shard to another worker.
+var errLeaseLost = errors.New("the lease of the shard was lost")
+
+func runWorker(cmd *cobra.Command, args []string) error {
+	if workerCoordinator == "" {
+		return errors.New("--coordinator is required")
+	}
+	if err := prepareConfig(cmd); err != nil {
+		return err
+	}
+	w := &workerClient{
+		url:    strings.TrimSuffix(workerCoordinator, "/"),
+		token:  workerToken,
+		client: &http.Client{Timeout: 2 * time.Minute},
+	}
+	if w.token == "" {
+		w.token = os.Getenv("AIREVIEW_WORKER_TOKEN")
+	}
+	base := *cfg
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	logf("Leasing shards from %s\n", w.url)
+	for ctx.Err() == nil {
+		var shard server.Shard
+		leased, err := w.call(ctx, "/v1/worker/lease", struct{}{}, &shard)
+		if err != nil {
+			if ctx.Err() == nil {
+				warnf("%v\n", err)
+				sleepCtx(ctx, 5*time.Second)
+			}
+			continue
+		}
+		if !leased {
+			continue
+		}
+		logf("Reviewing shard %s (%d files)\n", shard.ID, len(shard.Request.Files))
+		res, err := w.review(ctx, base, shard)
+		if err != nil {
+			if ctx.Err() == nil {
+				warnf("Dropped shard %s: %v\n", shard.ID, err)
+			}
+			continue
+		}
+		if _, err := w.call(ctx, "/v1/worker/shards/"+shard.ID, res, nil); err != nil {
+			warnf("failed to send shard %s: %v\n", shard.ID, err)
+		}
+	}
+	logf("Worker stopped\n")
+	return nil
+}
+
+type workerClient struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// review reviews the files of the shard and returns their reviews by path relative to
+// the project, sending heartbeats meanwhile. A review that fails is reported in the
+// result; an error means the shard must be dropped.
+func (w *workerClient) review(ctx context.Context, base config.Config, shard server.Shard) (server.ShardResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var done atomic.Int64
+	var lost atomic.Bool
+	go func() {
+		ticker := time.NewTicker(max(time.Duration(shard.LeaseSeconds)*time.Second/3, time.Second))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			hb := server.Heartbeat{FilesDone: int(done.Load())}
+			if _, err := w.call(ctx, "/v1/worker/shards/"+shard.ID+"/heartbeat", hb, nil); errors.Is(err, errLeaseLost) {
+				lost.Store(true)
+				cancel()
+				return
+			} else if err != nil && ctx.Err() == nil {
+				warnf("failed to send heartbeat of shard %s: %v\n", shard.ID, err)
+			}
+		}
+	}()
+
+	var res server.ShardResult
+	err := inJobProject(base, shard.Request, shard.Request.Files, func() error {
+		reviewProgress = func(d, total int) { done.Store(int64(d)) }
+		defer func() { reviewProgress = nil }()
+		run, runErr := executeReview(ctx)
+		root := projectRoot(cfg.ProjectPath)
+		for _, fr := range run.results {
+			fr.Path = relPath(root, fr.Path)
+			if fr.DuplicateOf != "" {
+				fr.DuplicateOf = relPath(root, fr.DuplicateOf)
+			}
+			res.Files = append(res.Files, fr)
+		}
+		res.Tokens = runTokens(run.results)
+		return runErr
+	})
+	switch {
+	case lost.Load():
+		return res, errLeaseLost
+	case ctx.Err() != nil:
+		return res, ctx.Err()
+	case err != nil:
+		res.Error = err.Error()
+	}
+	return res, nil
+}
+
+// call POSTs body as JSON to the coordinator and decodes a 200 response into out. It
+// reports whether the coordinator answered with content, which a lease does not when
+// no shard is queued.
+func (w *workerClient) call(ctx context.Context, path string, body, out any) (bool, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url+path, bytes.NewReader(data))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "aireview/1.0")
+	if w.token != "" {
+		req.Header.Set("Authorization", "Bearer "+w.token)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to reach the coordinator: %w", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if out == nil {
+			return true, nil
+		}
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return false, fmt.Errorf("failed to decode coordinator response: %w", err)
+		}
+		return true, nil
+	case http.StatusNoContent:
+		return false, nil
+	case http.StatusGone:
+		return false, errLeaseLost
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return false, fmt.Errorf("coordinator returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+}
+
+// sleepCtx waits for d or until ctx ends.
+func sleepCtx(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
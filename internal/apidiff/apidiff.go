@@ -0,0 +1,294 @@
+// Package apidiff compares the exported API of a Go package between two versions, so
+// a review can judge the backward compatibility of each change.
+package apidiff
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// Symbol is one exported identifier of a package: a function, method, type, struct
+// field, interface method, constant or variable.
+type Symbol struct {
+	// Decl renders the declaration without names that do not affect callers, e.g.
+	// "func Parse(string, int) (*File, error)".
+	Decl string
+	// File is the base name of the file declaring the symbol, Line its line.
+	File string
+	Line int
+	// Interface marks methods and embeds of interfaces, which implementations must add.
+	Interface bool
+}
+
+// API maps the symbols of a package by qualified name, e.g. "Parse" or "File.Close".
+type API map[string]Symbol
+
+// Change kinds.
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Change is a difference in a package's exported API.
+type Change struct {
+	Package string `json:"package"`
+	Symbol  string `json:"symbol"`
+	Kind    string `json:"kind"`
+	Before  string `json:"before,omitempty"`
+	After   string `json:"after,omitempty"`
+	// Breaking reports that code using the old API may no longer compile.
+	Breaking bool `json:"breaking"`
+	// File and Line locate the symbol in the new version, or in the old one when removed.
+	File string `json:"file"`
+	Line int    `json:"line,omitempty"`
+}
+
+// String renders the change for prompts and reports.
+func (c Change) String() string {
+	switch c.Kind {
+	case Removed:
+		return "removed " + c.Before
+	case Added:
+		return "added " + c.After
+	default:
+		return "changed " + c.Before + " to " + c.After
+	}
+}
+
+// Parse extracts the exported API from the sources of one package, keyed by file base
+// name. Test files and package main have no API; files that do not parse are an error.
+func Parse(sources map[string][]byte) (API, error) {
+	api := make(API)
+	fset := token.NewFileSet()
+	for name, src := range sources {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, name, src, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		if file.Name.Name == "main" {
+			continue
+		}
+		for _, decl := range file.Decls {
+			addDecl(api, fset, name, decl)
+		}
+	}
+	return api, nil
+}
+
+func addDecl(api API, fset *token.FileSet, name string, decl ast.Decl) {
+	add := func(key, text string, pos token.Pos, iface bool) {
+		api[key] = Symbol{Decl: text, File: name, Line: fset.Position(pos).Line, Interface: iface}
+	}
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if !d.Name.IsExported() {
+			return
+		}
+		if d.Recv == nil {
+			add(d.Name.Name, "func "+d.Name.Name+typeParams(d.Type.TypeParams)+signature(d.Type), d.Pos(), false)
+			return
+		}
+		recv := types.ExprString(d.Recv.List[0].Type)
+		base := receiverBase(d.Recv.List[0].Type)
+		if !ast.IsExported(base) {
+			return
+		}
+		add(base+"."+d.Name.Name, "func ("+recv+") "+d.Name.Name+signature(d.Type), d.Pos(), false)
+	case *ast.GenDecl:
+		for _, spec := range d.Specs {
+			switch s := spec.(type) {
+			case *ast.TypeSpec:
+				if s.Name.IsExported() {
+					addType(add, s)
+				}
+			case *ast.ValueSpec:
+				for i, n := range s.Names {
+					if !n.IsExported() {
+						continue
+					}
+					text := d.Tok.String() + " " + n.Name
+					if s.Type != nil {
+						text += " " + types.ExprString(s.Type)
+					}
+					// A new value does not break callers, but a literal of another kind
+					// changes the type of an untyped constant
+					if d.Tok == token.CONST && s.Type == nil && i < len(s.Values) {
+						if lit, ok := s.Values[i].(*ast.BasicLit); ok {
+							text += " (untyped " + untypedKinds[lit.Kind] + ")"
+						}
+					}
+					add(n.Name, text, n.Pos(), false)
+				}
+			}
+		}
+	}
+}
+
+// untypedKinds names the default types of untyped constant literals.
+var untypedKinds = map[token.Token]string{
+	token.INT:    "int",
+	token.FLOAT:  "float",
+	token.IMAG:   "complex",
+	token.CHAR:   "rune",
+	token.STRING: "string",
+}
+
+func addType(add func(key, text string, pos token.Pos, iface bool), s *ast.TypeSpec) {
+	name := s.Name.Name
+	head := "type " + name + typeParams(s.TypeParams)
+	if s.Assign.IsValid() {
+		add(name, head+" = "+types.ExprString(s.Type), s.Pos(), false)
+		return
+	}
+	switch t := s.Type.(type) {
+	case *ast.StructType:
+		add(name, head+" struct", s.Pos(), false)
+		for _, f := range t.Fields.List {
+			if len(f.Names) == 0 {
+				embedded := receiverBase(f.Type)
+				if ast.IsExported(embedded) {
+					add(name+"."+embedded, "field "+name+"."+embedded+" (embedded "+types.ExprString(f.Type)+")", f.Pos(), false)
+				}
+				continue
+			}
+			for _, n := range f.Names {
+				if n.IsExported() {
+					add(name+"."+n.Name, "field "+name+"."+n.Name+" "+types.ExprString(f.Type), n.Pos(), false)
+				}
+			}
+		}
+	case *ast.InterfaceType:
+		add(name, head+" interface", s.Pos(), false)
+		for _, m := range t.Methods.List {
+			if len(m.Names) == 0 {
+				embedded := types.ExprString(m.Type)
+				add(name+"."+embedded, "interface "+name+" embeds "+embedded, m.Pos(), true)
+				continue
+			}
+			ft, ok := m.Type.(*ast.FuncType)
+			if !ok {
+				continue
+			}
+			for _, n := range m.Names {
+				// Unexported methods also bind implementations, but to the package only
+				if n.IsExported() {
+					add(name+"."+n.Name, "method "+name+"."+n.Name+signature(ft), n.Pos(), true)
+				}
+			}
+		}
+	default:
+		add(name, head+" "+types.ExprString(s.Type), s.Pos(), false)
+	}
+}
+
+// signature renders parameter and result types without names, which callers do not
+// depend on.
+func signature(ft *ast.FuncType) string {
+	s := "(" + fieldTypes(ft.Params) + ")"
+	if ft.Results == nil || len(ft.Results.List) == 0 {
+		return s
+	}
+	results := fieldTypes(ft.Results)
+	if ft.Results.NumFields() == 1 {
+		return s + " " + results
+	}
+	return s + " (" + results + ")"
+}
+
+func fieldTypes(fields *ast.FieldList) string {
+	if fields == nil {
+		return ""
+	}
+	var parts []string
+	for _, f := range fields.List {
+		t := types.ExprString(f.Type)
+		for n := max(len(f.Names), 1); n > 0; n-- {
+			parts = append(parts, t)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func typeParams(fields *ast.FieldList) string {
+	if fields == nil || len(fields.List) == 0 {
+		return ""
+	}
+	var parts []string
+	for _, f := range fields.List {
+		var names []string
+		for _, n := range f.Names {
+			names = append(names, n.Name)
+		}
+		parts = append(parts, strings.Join(names, ", ")+" "+types.ExprString(f.Type))
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// receiverBase returns the type name of a receiver or embedded field, without pointer,
+// package qualifier and type arguments.
+func receiverBase(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return receiverBase(e.X)
+	case *ast.IndexExpr:
+		return receiverBase(e.X)
+	case *ast.IndexListExpr:
+		return receiverBase(e.X)
+	case *ast.SelectorExpr:
+		return e.Sel.Name
+	case *ast.Ident:
+		return e.Name
+	}
+	return ""
+}
+
+// Compare lists the changes from before to after, breaking changes first. Removals and
+// changed declarations are breaking; additions are not, except methods added to an
+// interface, which existing implementations lack.
+func Compare(pkg string, before, after API) []Change {
+	var changes []Change
+	for key, b := range before {
+		a, ok := after[key]
+		parent, _, member := strings.Cut(key, ".")
+		switch {
+		case !ok && member && !hasKey(after, parent):
+			// Removing a type removes its fields and methods; the type stands for them
+		case !ok:
+			changes = append(changes, Change{Package: pkg, Symbol: key, Kind: Removed, Before: b.Decl,
+				Breaking: true, File: b.File, Line: b.Line})
+		case a.Decl != b.Decl:
+			changes = append(changes, Change{Package: pkg, Symbol: key, Kind: Changed, Before: b.Decl, After: a.Decl,
+				Breaking: true, File: a.File, Line: a.Line})
+		}
+	}
+	for key, a := range after {
+		if _, ok := before[key]; !ok {
+			// A new interface has no implementations to break
+			parent, _, _ := strings.Cut(key, ".")
+			existed := hasKey(before, parent)
+			changes = append(changes, Change{Package: pkg, Symbol: key, Kind: Added, After: a.Decl,
+				Breaking: a.Interface && existed, File: a.File, Line: a.Line})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Breaking != changes[j].Breaking {
+			return changes[i].Breaking
+		}
+		return changes[i].Symbol < changes[j].Symbol
+	})
+	return changes
+}
+
+func hasKey(api API, key string) bool {
+	_, ok := api[key]
+	return ok
+}
@@ -0,0 +1,42 @@
+package apidiff
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// AtRevision reads the Go files of the package in dir as of a git revision, keyed by
+// base name. A package that did not exist at the revision has no files.
+func AtRevision(ctx context.Context, dir, rev string) (map[string][]byte, error) {
+	list, err := git(ctx, dir, "ls-tree", "--name-only", rev, "--", "./")
+	if err != nil {
+		return nil, err
+	}
+	sources := make(map[string][]byte)
+	for _, name := range strings.Split(strings.TrimSpace(string(list)), "\n") {
+		if !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") || strings.Contains(name, "/") {
+			continue
+		}
+		src, err := git(ctx, dir, "show", rev+":./"+name)
+		if err != nil {
+			return nil, err
+		}
+		sources[name] = src
+	}
+	return sources, nil
+}
+
+func git(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-c", "core.longpaths=true"}, args...)...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,148 @@
+// Package architecture checks the imports of Go packages against declared dependency
+// rules, such as "internal/scanner may not import internal/reviewer".
+package architecture
+
+import (
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/scanner"
+)
+
+// Rule restricts the imports of the packages matching Package. Packages of the module
+// are named by their directory relative to the module root, e.g. "internal/scanner";
+// other packages by import path.
+type Rule struct {
+	// Package is a glob of the packages the rule applies to; "**" matches any number of
+	// directories.
+	Package string `yaml:"package"`
+	// Allow lists globs of the module's packages the matching packages may import. When
+	// set, importing any other package of the module is a violation; packages outside
+	// the module are not restricted by Allow.
+	Allow []string `yaml:"allow,omitempty"`
+	// Deny lists globs of packages the matching packages must not import.
+	Deny []string `yaml:"deny,omitempty"`
+	// Reason explains the rule in violations.
+	Reason string `yaml:"reason,omitempty"`
+}
+
+// Validate checks that the rule has a package and well-formed globs.
+func (r Rule) Validate() error {
+	if r.Package == "" {
+		return errors.New("architecture rule without package")
+	}
+	for _, p := range append(append([]string{r.Package}, r.Allow...), r.Deny...) {
+		if _, err := path.Match(strings.ReplaceAll(p, "**", "*"), ""); err != nil {
+			return fmt.Errorf("architecture rule for %s: invalid pattern %q: %w", r.Package, p, err)
+		}
+	}
+	return nil
+}
+
+// Import is a package imported by a file.
+type Import struct {
+	// Path is the directory relative to the module root for packages of the module,
+	// otherwise the import path.
+	Path string
+	// Internal reports whether the package belongs to the module.
+	Internal bool
+	Line     int
+}
+
+// Imports reads the imports of Go source; modulePath is the path of the module the
+// file belongs to. Source that does not parse has no imports.
+func Imports(src, modulePath string) []Import {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ImportsOnly)
+	if err != nil {
+		return nil
+	}
+	var imports []Import
+	for _, spec := range file.Imports {
+		p, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		imp := Import{Path: p, Line: fset.Position(spec.Pos()).Line}
+		if modulePath != "" {
+			if p == modulePath {
+				imp.Path, imp.Internal = ".", true
+			} else if rel, ok := strings.CutPrefix(p, modulePath+"/"); ok {
+				imp.Path, imp.Internal = rel, true
+			}
+		}
+		imports = append(imports, imp)
+	}
+	return imports
+}
+
+// Violation is an import that breaks a rule.
+type Violation struct {
+	// Rule is the index of the broken rule.
+	Rule   int
+	Import Import
+	// Denied reports a Deny match; otherwise the import is missing from Allow.
+	Denied bool
+}
+
+// Message describes the violation of a rule by a package.
+func (v Violation) Message(rules []Rule, pkg string) string {
+	r := rules[v.Rule]
+	msg := fmt.Sprintf("%s imports %s, which the architecture rule for %s ", pkg, v.Import.Path, r.Package)
+	if v.Denied {
+		msg += "denies"
+	} else {
+		msg += "does not allow (allowed: " + strings.Join(r.Allow, ", ") + ")"
+	}
+	if r.Reason != "" {
+		msg += ": " + r.Reason
+	}
+	return msg
+}
+
+// Check returns the imports of the package pkg (its directory relative to the module
+// root) that break a rule.
+func Check(rules []Rule, pkg string, imports []Import) []Violation {
+	var violations []Violation
+	for i, r := range rules {
+		if !Match(r.Package, pkg) {
+			continue
+		}
+		for _, imp := range imports {
+			switch {
+			case matchAny(r.Deny, imp.Path):
+				violations = append(violations, Violation{Rule: i, Import: imp, Denied: true})
+			case len(r.Allow) > 0 && imp.Internal && imp.Path != pkg && !matchAny(r.Allow, imp.Path):
+				violations = append(violations, Violation{Rule: i, Import: imp})
+			}
+		}
+	}
+	return violations
+}
+
+// Match reports whether a package matches a glob. Unlike file excludes, a pattern
+// without a slash matches the whole path, so "errors" does not match "github.com/pkg/errors".
+func Match(pattern, pkg string) bool {
+	if pattern == "**" {
+		return true
+	}
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, pkg)
+		return ok
+	}
+	return scanner.MatchGlob(pattern, pkg)
+}
+
+func matchAny(patterns []string, pkg string) bool {
+	for _, p := range patterns {
+		if Match(p, pkg) {
+			return true
+		}
+	}
+	return false
+}
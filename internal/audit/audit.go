@@ -0,0 +1,72 @@
+// Package audit records every request sent to a model backend as JSON lines, so
+// compliance reviews can tell which code left the machine, where it went and when.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Entry is one outbound request and the metadata of its response.
+type Entry struct {
+	Time     time.Time `json:"time"`
+	Provider string    `json:"provider"`
+	Endpoint string    `json:"endpoint"`
+	Model    string    `json:"model"`
+	File     string    `json:"file,omitempty"`
+	// RequestID is the X-Request-ID sent with the request; ProviderRequestID the ID the
+	// provider returned for it.
+	RequestID         string `json:"request_id,omitempty"`
+	ProviderRequestID string `json:"provider_request_id,omitempty"`
+	// PromptSHA256 identifies the prompt without storing the code itself.
+	PromptSHA256 string `json:"prompt_sha256"`
+	RequestBytes int    `json:"request_bytes"`
+	// SentBytes is the compressed size when the body was gzip-compressed.
+	SentBytes        int    `json:"sent_bytes,omitempty"`
+	Status           int    `json:"status,omitempty"`
+	PromptTokens     int    `json:"prompt_tokens,omitempty"`
+	CompletionTokens int    `json:"completion_tokens,omitempty"`
+	CachedTokens     int    `json:"cached_tokens,omitempty"`
+	LatencyMS        int64  `json:"latency_ms"`
+	Error            string `json:"error,omitempty"`
+}
+
+// Log appends entries to a JSON lines file. It is safe for concurrent use.
+type Log struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+// Open opens path for appending, creating it and its directory if needed.
+func Open(path string) (*Log, error) {
+	if dir := filepath.Dir(path); dir != "." && dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+		}
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &Log{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+// Write appends one entry.
+func (l *Log) Write(e Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.enc.Encode(e); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying file.
+func (l *Log) Close() error {
+	return l.f.Close()
+}
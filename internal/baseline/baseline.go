@@ -0,0 +1,137 @@
+package baseline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/disconnekt/goreview/internal/findings"
+)
+
+// FileName is the name of the baseline file inside the state directory.
+const FileName = "baseline.json"
+
+// Decision is a human verdict on a finding.
+type Decision string
+
+const (
+	// Accepted findings are real issues; they keep being reported.
+	Accepted Decision = "accepted"
+	// Dismissed findings are suppressed from future runs.
+	Dismissed Decision = "dismissed"
+)
+
+// Entry records the decision taken for one finding fingerprint.
+type Entry struct {
+	Fingerprint string            `json:"fingerprint"`
+	File        string            `json:"file"`
+	Severity    findings.Severity `json:"severity"`
+	Message     string            `json:"message"`
+	Decision    Decision          `json:"decision"`
+	Reason      string            `json:"reason,omitempty"`
+	DecidedAt   time.Time         `json:"decided_at"`
+}
+
+// Store is the suppression store persisted as JSON.
+type Store struct {
+	path    string
+	entries map[string]Entry
+}
+
+type fileLayout struct {
+	Entries []Entry `json:"entries"`
+}
+
+// Load reads the baseline at path. A missing file yields an empty store.
+func Load(path string) (*Store, error) {
+	s := &Store{path: path, entries: make(map[string]Entry)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+	var layout fileLayout
+	if err := json.Unmarshal(data, &layout); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline %s: %w", path, err)
+	}
+	for _, e := range layout.Entries {
+		s.entries[e.Fingerprint] = e
+	}
+	return s, nil
+}
+
+// Save writes the store back to disk, sorted for stable diffs.
+func (s *Store) Save() error {
+	layout := fileLayout{Entries: make([]Entry, 0, len(s.entries))}
+	for _, e := range s.entries {
+		layout.Entries = append(layout.Entries, e)
+	}
+	sort.Slice(layout.Entries, func(i, j int) bool {
+		if layout.Entries[i].File != layout.Entries[j].File {
+			return layout.Entries[i].File < layout.Entries[j].File
+		}
+		return layout.Entries[i].Fingerprint < layout.Entries[j].Fingerprint
+	})
+
+	data, err := json.MarshalIndent(layout, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode baseline: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create baseline directory: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	return nil
+}
+
+// Record stores a decision for the finding, replacing any earlier one.
+func (s *Store) Record(f findings.Finding, d Decision, reason string) {
+	s.entries[f.Fingerprint()] = Entry{
+		Fingerprint: f.Fingerprint(),
+		File:        f.File,
+		Severity:    f.Severity,
+		Message:     f.Message,
+		Decision:    d,
+		Reason:      reason,
+		DecidedAt:   time.Now().UTC(),
+	}
+}
+
+// Decided reports whether a decision was already taken for the finding.
+func (s *Store) Decided(f findings.Finding) bool {
+	_, ok := s.entries[f.Fingerprint()]
+	return ok
+}
+
+// Suppressed reports whether the finding was dismissed.
+func (s *Store) Suppressed(f findings.Finding) bool {
+	e, ok := s.entries[f.Fingerprint()]
+	return ok && e.Decision == Dismissed
+}
+
+// Filter splits findings into the ones to report and the number suppressed.
+func (s *Store) Filter(list []findings.Finding) ([]findings.Finding, int) {
+	kept := list[:0:0]
+	suppressed := 0
+	for _, f := range list {
+		if s.Suppressed(f) {
+			suppressed++
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept, suppressed
+}
+
+// Len returns the number of recorded decisions.
+func (s *Store) Len() int {
+	return len(s.entries)
+}
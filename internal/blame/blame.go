@@ -0,0 +1,331 @@
+package blame
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// uncommitted is the commit git blame reports for lines not committed yet.
+const uncommitted = "0000000000000000000000000000000000000000"
+
+// Line is the commit that last changed one line of a file.
+type Line struct {
+	Number     int
+	Commit     string
+	Author     string
+	AuthorMail string
+	Time       time.Time
+	// Filename is the path of the file in Commit, relative to the top of the repository.
+	Filename string
+}
+
+// Uncommitted reports whether the line has local changes not committed yet.
+func (l Line) Uncommitted() bool {
+	return l.Commit == uncommitted
+}
+
+// Options tunes how Run attributes lines.
+type Options struct {
+	// IgnoreWhitespace attributes a line whose last change only touched whitespace to
+	// the commit before it.
+	IgnoreWhitespace bool
+	// RenamedFrom is the committed path of a file renamed but not committed yet; the
+	// file is blamed as the new content of that path.
+	RenamedFrom string
+}
+
+// Run blames every line of the file with git.
+func Run(ctx context.Context, path string, opts Options) ([]Line, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, errors.New("git not found in PATH")
+	}
+	var stdout, stderr bytes.Buffer
+	// Git for Windows needs core.longpaths for paths over 260 characters
+	args := []string{"-c", "core.longpaths=true", "blame", "--line-porcelain"}
+	if opts.IgnoreWhitespace {
+		args = append(args, "-w")
+	}
+	target := filepath.Base(path)
+	if opts.RenamedFrom != "" {
+		from, err := filepath.Rel(filepath.Dir(path), opts.RenamedFrom)
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate %s: %w", opts.RenamedFrom, err)
+		}
+		// git reads --contents relative to the top of the work tree, not the directory
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate %s: %w", path, err)
+		}
+		args = append(args, "--contents", abs)
+		target = from
+	}
+	cmd := exec.CommandContext(ctx, "git", append(args, "--", target)...)
+	cmd.Dir = filepath.Dir(path)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git blame failed for %s: %w: %s", path, err, strings.TrimSpace(stderr.String()))
+	}
+	return Parse(&stdout)
+}
+
+// Parse reads the output of "git blame --line-porcelain".
+func Parse(r io.Reader) ([]Line, error) {
+	var lines []Line
+	var cur Line
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	header := true
+	for sc.Scan() {
+		text := sc.Text()
+		if header {
+			// "<sha> <original line> <final line> [<lines in group>]"
+			fields := strings.Fields(text)
+			if len(fields) < 3 {
+				return nil, fmt.Errorf("unexpected blame header %q", text)
+			}
+			n, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("unexpected blame header %q", text)
+			}
+			cur = Line{Number: n, Commit: fields[0]}
+			header = false
+			continue
+		}
+		switch key, value, _ := strings.Cut(text, " "); key {
+		case "author":
+			cur.Author = value
+		case "filename":
+			cur.Filename = value
+		case "author-mail":
+			cur.AuthorMail = strings.Trim(value, "<>")
+		case "author-time":
+			secs, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("unexpected author-time %q", value)
+			}
+			cur.Time = time.Unix(secs, 0)
+		default:
+			if strings.HasPrefix(text, "\t") {
+				lines = append(lines, cur)
+				header = true
+			}
+		}
+	}
+	return lines, sc.Err()
+}
+
+// Filter selects lines by the commit that introduced them. Zero fields match any line.
+type Filter struct {
+	// Since keeps lines authored at or after this time; uncommitted lines are newer
+	// than any date.
+	Since time.Time
+	// Author keeps lines whose author name or email contains this text, ignoring case.
+	Author string
+}
+
+// Match reports whether the line passes the filter.
+func (f Filter) Match(l Line) bool {
+	if !f.Since.IsZero() && !l.Uncommitted() && l.Time.Before(f.Since) {
+		return false
+	}
+	if f.Author != "" {
+		if l.Uncommitted() {
+			return false
+		}
+		author := strings.ToLower(l.Author + " <" + l.AuthorMail + ">")
+		if !strings.Contains(author, strings.ToLower(f.Author)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Ranges renders sorted line numbers as compact ranges, e.g. "3-7, 12".
+func Ranges(numbers []int) string {
+	var parts []string
+	for i := 0; i < len(numbers); {
+		j := i
+		for j+1 < len(numbers) && numbers[j+1] == numbers[j]+1 {
+			j++
+		}
+		if j == i {
+			parts = append(parts, strconv.Itoa(numbers[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", numbers[i], numbers[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ", ")
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// Changed returns the lines of each file in dir that changed between the merge base
+// of base and HEAD, and HEAD, keyed by slash-separated path relative to dir.
+func Changed(ctx context.Context, dir, base string) (map[string]map[int]bool, error) {
+	var stdout, stderr bytes.Buffer
+	// Renamed files count only with the lines their move changed, whatever diff.renames says
+	cmd := exec.CommandContext(ctx, "git", "-c", "core.longpaths=true", "diff", "--unified=0", "--find-renames", "--no-color", "--no-ext-diff", "--relative", base+"...", "--", ".")
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git diff against %s failed: %w: %s", base, err, strings.TrimSpace(stderr.String()))
+	}
+	return ParseDiff(&stdout)
+}
+
+// MergeBase returns the commit that Changed compares against: the merge base of base
+// and HEAD in the repository of dir.
+func MergeBase(ctx context.Context, dir, base string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", "merge-base", base, "HEAD")
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git merge-base %s HEAD failed: %w: %s", base, err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// Show returns the content of the file at path, relative to the top of the repository
+// of dir, in revision rev.
+func Show(ctx context.Context, dir, rev, path string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", "-c", "core.longpaths=true", "show", rev+":"+path)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git show %s:%s failed: %w: %s", rev, path, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// Gitlink returns the commit the submodule at path, relative to dir, is at in revision
+// rev of the repository in dir.
+func Gitlink(ctx context.Context, dir, rev, path string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--verify", "--quiet", rev+":./"+path)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s is not a submodule in %s", path, rev)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// Renames returns the files of dir renamed since HEAD and not committed yet, such as by
+// "git mv", mapping the new path to the old one, both slash-separated and relative to
+// dir.
+func Renames(ctx context.Context, dir string) (map[string]string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", "-c", "core.longpaths=true", "diff", "--name-status", "-z", "--find-renames", "--no-ext-diff", "--relative", "HEAD", "--", ".")
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git diff against HEAD failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return ParseRenames(stdout.Bytes()), nil
+}
+
+// ParseRenames reads the renames from the output of "git diff --name-status -z".
+func ParseRenames(out []byte) map[string]string {
+	renames := make(map[string]string)
+	fields := strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00")
+	for i := 0; i < len(fields); i++ {
+		status := fields[i]
+		// Renames and copies list two paths, other changes one
+		if strings.HasPrefix(status, "R") && i+2 < len(fields) {
+			renames[fields[i+2]] = fields[i+1]
+		}
+		if strings.HasPrefix(status, "R") || strings.HasPrefix(status, "C") {
+			i += 2
+		} else {
+			i++
+		}
+	}
+	return renames
+}
+
+// ParseDiff reads the added and modified lines of each file from a unified diff.
+func ParseDiff(r io.Reader) (map[string]map[int]bool, error) {
+	changed := make(map[string]map[int]bool)
+	var current map[int]bool
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		text := sc.Text()
+		if name, ok := strings.CutPrefix(text, "+++ "); ok {
+			current = nil
+			if name != "/dev/null" {
+				current = make(map[int]bool)
+				changed[strings.TrimPrefix(name, "b/")] = current
+			}
+			continue
+		}
+		m := hunkHeader.FindStringSubmatch(text)
+		if m == nil || current == nil {
+			continue
+		}
+		start, _ := strconv.Atoi(m[1])
+		count := 1
+		if m[2] != "" {
+			count, _ = strconv.Atoi(m[2])
+		}
+		for n := start; n < start+count; n++ {
+			current[n] = true
+		}
+	}
+	return changed, sc.Err()
+}
+
+// Churn returns how many lines of each file in dir were added or deleted by the commits
+// since the given date, e.g. "6 months ago", keyed by slash-separated path relative to
+// dir.
+func Churn(ctx context.Context, dir, since string) (map[string]int, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", "-c", "core.longpaths=true", "log", "--since="+since, "--numstat", "--format=", "--no-renames", "--relative", "--", ".")
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git log since %s failed: %w: %s", since, err, strings.TrimSpace(stderr.String()))
+	}
+	return ParseNumstat(&stdout)
+}
+
+// ParseNumstat sums the added and deleted lines per file of git --numstat output.
+// Binary files, listed with "-" counts, have no churn.
+func ParseNumstat(r io.Reader) (map[string]int, error) {
+	churn := make(map[string]int)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		fields := strings.SplitN(sc.Text(), "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		added, err1 := strconv.Atoi(fields[0])
+		deleted, err2 := strconv.Atoi(fields[1])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		churn[fields[2]] += added + deleted
+	}
+	return churn, sc.Err()
+}
@@ -0,0 +1,81 @@
+// Package codegen extracts Go code drafted by the model and checks that it compiles.
+package codegen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/format"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// fencePattern matches a fenced code block, capturing its language and content.
+var fencePattern = regexp.MustCompile("(?s)```([a-zA-Z]*)[^\\n]*\\n(.*?)```")
+
+// ExtractGo returns the Go source in a model reply: the longest go-tagged code block,
+// or the whole reply when it has no code block. The result is gofmt-formatted.
+func ExtractGo(reply string) (string, error) {
+	src := ""
+	for _, m := range fencePattern.FindAllStringSubmatch(reply, -1) {
+		if (m[1] == "go" || m[1] == "") && len(m[2]) > len(src) {
+			src = m[2]
+		}
+	}
+	if src == "" {
+		src = reply
+	}
+	formatted, err := format.Source([]byte(src))
+	if err != nil {
+		return "", fmt.Errorf("generated code is not valid Go: %w", err)
+	}
+	return string(formatted), nil
+}
+
+// Verify checks that src compiles as the file name in the package directory dir by
+// running "go vet" with an overlay, so the package on disk is left untouched. The
+// returned error carries the compiler output.
+func Verify(ctx context.Context, dir, name, src string) error {
+	if _, err := exec.LookPath("go"); err != nil {
+		return errors.New("the go command is required to verify generated code")
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", dir, err)
+	}
+	tmp, err := os.MkdirTemp("", "aireview-verify-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	draft := filepath.Join(tmp, name)
+	if err := os.WriteFile(draft, []byte(src), 0644); err != nil {
+		return fmt.Errorf("failed to write draft: %w", err)
+	}
+	overlay, err := json.Marshal(map[string]map[string]string{
+		"Replace": {filepath.Join(absDir, name): draft},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode overlay: %w", err)
+	}
+	overlayPath := filepath.Join(tmp, "overlay.json")
+	if err := os.WriteFile(overlayPath, overlay, 0644); err != nil {
+		return fmt.Errorf("failed to write overlay: %w", err)
+	}
+
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, "go", "vet", "-overlay", overlayPath, ".")
+	cmd.Dir = absDir
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("go vet failed:\n%s", strings.TrimSpace(strings.ReplaceAll(out.String(), tmp+string(filepath.Separator), "")))
+	}
+	return nil
+}
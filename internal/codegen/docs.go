@@ -0,0 +1,115 @@
+package codegen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// Undocumented is an exported identifier without a doc comment.
+type Undocumented struct {
+	// Name is the identifier, or Type.Method for methods.
+	Name string
+	// Kind is "func", "method", "type", "const" or "var".
+	Kind string
+	// Line is where the doc comment goes: the line of the declaration, counted from 1.
+	Line int
+	// Indent is the indentation of the declaration inside a grouped declaration.
+	Indent string
+}
+
+// FindUndocumented lists the exported identifiers of a Go file that lack a doc comment.
+// Methods count only when their receiver type is exported too.
+func FindUndocumented(filename, src string) ([]Undocumented, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	line := func(p token.Pos) int { return fset.Position(p).Line }
+
+	var out []Undocumented
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Doc != nil || !d.Name.IsExported() {
+				continue
+			}
+			if d.Recv == nil {
+				out = append(out, Undocumented{Name: d.Name.Name, Kind: "func", Line: line(d.Pos())})
+				continue
+			}
+			if recv := receiverType(d.Recv.List[0].Type); ast.IsExported(recv) {
+				out = append(out, Undocumented{Name: recv + "." + d.Name.Name, Kind: "method", Line: line(d.Pos())})
+			}
+		case *ast.GenDecl:
+			// A comment on a grouped declaration documents the whole group
+			if d.Tok == token.IMPORT || d.Doc != nil {
+				continue
+			}
+			grouped := d.Lparen.IsValid()
+			for _, spec := range d.Specs {
+				name, doc := specName(spec)
+				if !ast.IsExported(name) || (grouped && doc != nil) {
+					continue
+				}
+				u := Undocumented{Name: name, Kind: d.Tok.String(), Line: line(d.Pos())}
+				if grouped {
+					u.Line = line(spec.Pos())
+					u.Indent = "\t"
+				}
+				out = append(out, u)
+			}
+		}
+	}
+	return out, nil
+}
+
+// specName returns the first exported name declared by a spec and its doc comment.
+func specName(spec ast.Spec) (string, *ast.CommentGroup) {
+	switch s := spec.(type) {
+	case *ast.TypeSpec:
+		return s.Name.Name, s.Doc
+	case *ast.ValueSpec:
+		for _, n := range s.Names {
+			if n.IsExported() {
+				return n.Name, s.Doc
+			}
+		}
+	}
+	return "", nil
+}
+
+// receiverType returns the type name of a method receiver.
+func receiverType(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// CommentLines formats doc comment text as // lines with the given indentation.
+func CommentLines(text, indent string) []string {
+	var out []string
+	for _, l := range strings.Split(strings.TrimSpace(text), "\n") {
+		l = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(l), "//"))
+		if l == "" {
+			out = append(out, indent+"//")
+			continue
+		}
+		out = append(out, indent+"// "+l)
+	}
+	return out
+}
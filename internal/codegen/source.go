@@ -0,0 +1,185 @@
+package codegen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FuncSource returns the source of the function or method name in a Go file, including
+// its doc comment, and the line it starts on. Methods are named Type.Method, or just
+// Method when no other declaration shares the name.
+func FuncSource(filename, src, name string) (string, int, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	var matches []*ast.FuncDecl
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		full := fn.Name.Name
+		if fn.Recv != nil {
+			full = receiverType(fn.Recv.List[0].Type) + "." + full
+		}
+		if full == name || fn.Name.Name == name {
+			matches = append(matches, fn)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", 0, fmt.Errorf("no function %s in %s", name, filename)
+	case 1:
+	default:
+		return "", 0, fmt.Errorf("%s is ambiguous in %s; name the method as Type.%s", name, filename, name)
+	}
+	fn := matches[0]
+	start := fn.Pos()
+	if fn.Doc != nil {
+		start = fn.Doc.Pos()
+	}
+	from, to := fset.Position(start), fset.Position(fn.End())
+	return src[from.Offset:to.Offset], from.Line, nil
+}
+
+// DeclLines returns the first and last line of each top-level declaration of a Go file,
+// including its doc comment.
+func DeclLines(filename, src string) ([][2]int, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	var spans [][2]int
+	for _, decl := range file.Decls {
+		start := decl.Pos()
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		case *ast.GenDecl:
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		}
+		spans = append(spans, [2]int{fset.Position(start).Line, fset.Position(decl.End()).Line})
+	}
+	return spans, nil
+}
+
+// TrivialLines returns the lines of a Go file that hold no code: blank lines and lines
+// holding only comments. Compiler directives such as //go:build and //go:embed, and the
+// cgo preamble above import "C", are code.
+func TrivialLines(filename, src string) (map[int]bool, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ImportsOnly|parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	code := make(map[int]bool)
+	markLines := func(from, to token.Pos) {
+		for n := fset.Position(from).Line; n <= fset.Position(to).Line; n++ {
+			code[n] = true
+		}
+	}
+	for _, decl := range file.Decls {
+		d, ok := decl.(*ast.GenDecl)
+		if !ok || d.Tok != token.IMPORT {
+			continue
+		}
+		for _, spec := range d.Specs {
+			imp := spec.(*ast.ImportSpec)
+			if imp.Path.Value != `"C"` {
+				continue
+			}
+			// A lone import "C" carries the preamble as the doc comment of the declaration
+			doc := imp.Doc
+			if doc == nil && !d.Lparen.IsValid() {
+				doc = d.Doc
+			}
+			if doc != nil {
+				markLines(doc.Pos(), doc.End())
+			}
+		}
+	}
+
+	var s scanner.Scanner
+	tf := fset.AddFile(filename, -1, len(src))
+	s.Init(tf, []byte(src), nil, scanner.ScanComments)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		// Semicolons inserted at line ends are not code of their own
+		if tok == token.SEMICOLON && lit == "\n" {
+			continue
+		}
+		if tok == token.COMMENT && !isDirective(lit) {
+			continue
+		}
+		first := tf.Line(pos)
+		for n := first; n <= first+strings.Count(lit, "\n"); n++ {
+			code[n] = true
+		}
+	}
+
+	trivial := make(map[int]bool)
+	for n := 1; n <= strings.Count(src, "\n")+1; n++ {
+		if !code[n] {
+			trivial[n] = true
+		}
+	}
+	return trivial, nil
+}
+
+// isDirective reports whether a comment is a directive to the toolchain rather than
+// prose: //go:..., //line and the legacy // +build constraints.
+func isDirective(comment string) bool {
+	return strings.HasPrefix(comment, "//go:") || strings.HasPrefix(comment, "//line ") ||
+		strings.HasPrefix(comment, "// +build")
+}
+
+// ImportLines returns the lines of the import declarations of a Go file.
+func ImportLines(filename, src string) (map[int]bool, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ImportsOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	lines := make(map[int]bool)
+	for _, decl := range file.Decls {
+		for n := fset.Position(decl.Pos()).Line; n <= fset.Position(decl.End()).Line; n++ {
+			lines[n] = true
+		}
+	}
+	return lines, nil
+}
+
+// ImportPaths returns the sorted import paths of a Go file.
+func ImportPaths(filename, src string) ([]string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ImportsOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	paths := make([]string, 0, len(file.Imports))
+	for _, spec := range file.Imports {
+		p, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid import path %s in %s", spec.Path.Value, filename)
+		}
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
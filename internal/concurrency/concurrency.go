@@ -0,0 +1,274 @@
+// Package concurrency finds goroutines, channels and locks in Go source and reads the
+// data race reports of the race detector, for reviews focused on concurrency.
+package concurrency
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// maxSites bounds the sites listed in a prompt; the model sees the whole file anyway.
+const maxSites = 60
+
+// Site is a line of a file that takes part in concurrency.
+type Site struct {
+	Line int
+	// Kind describes the construct, e.g. "goroutine started" or "mutex locked".
+	Kind string
+}
+
+// syncMethods maps the types of the sync package to the kind of the call sites of
+// their methods.
+var syncMethods = map[string]map[string]string{
+	"Mutex": {
+		"Lock":    "mutex locked",
+		"Unlock":  "mutex unlocked",
+		"TryLock": "mutex try-locked",
+	},
+	"RWMutex": {
+		"Lock":     "mutex locked",
+		"Unlock":   "mutex unlocked",
+		"TryLock":  "mutex try-locked",
+		"RLock":    "read lock taken",
+		"RUnlock":  "read lock released",
+		"TryRLock": "read lock try-taken",
+	},
+	"WaitGroup": {
+		"Add":  "WaitGroup add",
+		"Done": "WaitGroup done",
+		"Wait": "WaitGroup wait",
+	},
+	"Cond": {
+		"Wait":      "condition wait",
+		"Signal":    "condition signaled",
+		"Broadcast": "condition broadcast",
+	},
+	"Once": {
+		"Do": "sync.Once call",
+	},
+}
+
+// Sites returns the goroutine, channel, lock and atomic operations of Go source in
+// line order. Source that does not parse has no sites.
+func Sites(src string) []Site {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+	atomicName := importName(file, "sync/atomic")
+	syncTypes := syncVariables(file, importName(file, "sync"))
+
+	var sites []Site
+	add := func(pos token.Pos, kind string) {
+		sites = append(sites, Site{Line: fset.Position(pos).Line, Kind: kind})
+	}
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.GoStmt:
+			add(n.Pos(), "goroutine started")
+		case *ast.SelectStmt:
+			add(n.Pos(), "select")
+		case *ast.SendStmt:
+			add(n.Pos(), "channel send")
+		case *ast.UnaryExpr:
+			if n.Op == token.ARROW {
+				add(n.Pos(), "channel receive")
+			}
+		case *ast.CallExpr:
+			switch fn := n.Fun.(type) {
+			case *ast.Ident:
+				if fn.Name == "close" && len(n.Args) == 1 {
+					add(n.Pos(), "channel closed")
+				}
+				if fn.Name == "make" && len(n.Args) > 0 {
+					if _, ok := n.Args[0].(*ast.ChanType); ok {
+						add(n.Pos(), "channel created")
+					}
+				}
+			case *ast.SelectorExpr:
+				if pkg, ok := fn.X.(*ast.Ident); ok && atomicName != "" && pkg.Name == atomicName {
+					add(n.Pos(), "atomic "+fn.Sel.Name)
+				} else if kind, ok := syncMethods[syncTypes[receiverName(fn.X)]][fn.Sel.Name]; ok {
+					add(n.Pos(), kind)
+				}
+			}
+		}
+		return true
+	})
+	sort.SliceStable(sites, func(i, j int) bool { return sites[i].Line < sites[j].Line })
+	return sites
+}
+
+// syncVariables maps the names of variables, fields and parameters to the sync type
+// they hold, from their declarations in the file. Without type checking, names are not
+// told apart by scope. Values of struct types embedding a mutex count as that mutex.
+func syncVariables(file *ast.File, syncName string) map[string]string {
+	types := make(map[string]string)
+	if syncName == "" {
+		return types
+	}
+	syncType := func(expr ast.Expr) string {
+		for {
+			switch e := expr.(type) {
+			case *ast.StarExpr:
+				expr = e.X
+				continue
+			case *ast.ArrayType:
+				expr = e.Elt
+				continue
+			case *ast.MapType:
+				expr = e.Value
+				continue
+			case *ast.SelectorExpr:
+				if pkg, ok := e.X.(*ast.Ident); ok && pkg.Name == syncName && syncMethods[e.Sel.Name] != nil {
+					return e.Sel.Name
+				}
+			}
+			return ""
+		}
+	}
+
+	// Struct types embedding a mutex lock through their own values
+	lockers := make(map[string]string)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if spec, ok := n.(*ast.TypeSpec); ok {
+			if st, ok := spec.Type.(*ast.StructType); ok {
+				for _, f := range st.Fields.List {
+					if t := syncType(f.Type); len(f.Names) == 0 && (t == "Mutex" || t == "RWMutex") {
+						lockers[spec.Name.Name] = t
+					}
+				}
+			}
+		}
+		return true
+	})
+	typeOf := func(expr ast.Expr) string {
+		if t := syncType(expr); t != "" {
+			return t
+		}
+		for {
+			switch e := expr.(type) {
+			case *ast.StarExpr:
+				expr = e.X
+				continue
+			case *ast.Ident:
+				return lockers[e.Name]
+			}
+			return ""
+		}
+	}
+	// valueType is the type of an initializer such as sync.WaitGroup{}, &sync.Mutex{},
+	// new(sync.Once) or sync.NewCond(l).
+	valueType := func(expr ast.Expr) string {
+		if u, ok := expr.(*ast.UnaryExpr); ok && u.Op == token.AND {
+			expr = u.X
+		}
+		switch e := expr.(type) {
+		case *ast.CompositeLit:
+			return typeOf(e.Type)
+		case *ast.CallExpr:
+			if id, ok := e.Fun.(*ast.Ident); ok && id.Name == "new" && len(e.Args) == 1 {
+				return typeOf(e.Args[0])
+			}
+			if sel, ok := e.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "NewCond" {
+				if pkg, ok := sel.X.(*ast.Ident); ok && pkg.Name == syncName {
+					return "Cond"
+				}
+			}
+		}
+		return ""
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Field:
+			if t := typeOf(n.Type); t != "" {
+				for _, name := range n.Names {
+					types[name.Name] = t
+				}
+			}
+		case *ast.ValueSpec:
+			for i, name := range n.Names {
+				if t := typeOf(n.Type); n.Type != nil && t != "" {
+					types[name.Name] = t
+				} else if i < len(n.Values) {
+					if t := valueType(n.Values[i]); t != "" {
+						types[name.Name] = t
+					}
+				}
+			}
+		case *ast.AssignStmt:
+			if len(n.Lhs) != len(n.Rhs) {
+				return true
+			}
+			for i, lhs := range n.Lhs {
+				if t := valueType(n.Rhs[i]); t != "" {
+					if name := receiverName(lhs); name != "" {
+						types[name] = t
+					}
+				}
+			}
+		}
+		return true
+	})
+	return types
+}
+
+// receiverName returns the variable or field name a method is called on, e.g. "mu"
+// for s.mu.Lock() and s.locks[i].Lock(), or "" when there is none.
+func receiverName(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.Ident:
+			return e.Name
+		case *ast.SelectorExpr:
+			return e.Sel.Name
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		default:
+			return ""
+		}
+	}
+}
+
+// importName returns the name a file uses for an imported package, or "" when the
+// file does not import it.
+func importName(file *ast.File, path string) string {
+	for _, spec := range file.Imports {
+		if strings.Trim(spec.Path.Value, `"`) != path {
+			continue
+		}
+		if spec.Name != nil {
+			return spec.Name.Name
+		}
+		return path[strings.LastIndex(path, "/")+1:]
+	}
+	return ""
+}
+
+// PromptSection lists the sites of a file for the prompt, or returns "" when there
+// are none.
+func PromptSection(sites []Site) string {
+	if len(sites) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("Concurrency in this file; check each for data races, deadlocks and leaked goroutines:\n")
+	for i, s := range sites {
+		if i == maxSites {
+			fmt.Fprintf(&b, "- and %d more\n", len(sites)-maxSites)
+			break
+		}
+		fmt.Fprintf(&b, "- line %d: %s\n", s.Line, s.Kind)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
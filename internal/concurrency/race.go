@@ -0,0 +1,130 @@
+package concurrency
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// raceSeparator delimits the reports of the race detector.
+const raceSeparator = "=================="
+
+// Race is one "WARNING: DATA RACE" report of the race detector.
+type Race struct {
+	// Accesses are the conflicting memory accesses, the current one first.
+	Accesses []Access
+	// Text is the report as printed, for the prompt.
+	Text string
+}
+
+// Access is one side of a data race: the access and its call stack, innermost first.
+type Access struct {
+	// Description is the header of the access, e.g. "Write at 0x00c0000a4010 by goroutine 7".
+	Description string
+	Stack       []Frame
+}
+
+// Frame is one function of a call stack.
+type Frame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+var (
+	accessHeader = regexp.MustCompile(`^(?:Previous )?(?:[Rr]ead|[Ww]rite|Atomic read|Atomic write)\b.* by `)
+	stackFrame   = regexp.MustCompile(`^\s+(\S.*):(\d+)(?: \+0x[0-9a-f]+)?$`)
+)
+
+// LoadRaces reads the data race reports from saved "go test -race" output.
+func LoadRaces(file string) ([]Race, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open race report: %w", err)
+	}
+	defer f.Close()
+	races, err := ParseRaces(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read race report %s: %w", file, err)
+	}
+	return races, nil
+}
+
+// ParseRaces reads the data race reports from race detector output. Other output,
+// such as test results, is ignored.
+func ParseRaces(r io.Reader) ([]Race, error) {
+	var races []Race
+	var cur *Race
+	var text strings.Builder
+	var function string
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.TrimSpace(line) == "WARNING: DATA RACE" {
+			races = append(races, Race{})
+			cur = &races[len(races)-1]
+			text.Reset()
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		if strings.TrimSpace(line) == raceSeparator {
+			cur.Text = strings.TrimSpace(text.String())
+			cur = nil
+			continue
+		}
+		text.WriteString(line + "\n")
+
+		switch {
+		case accessHeader.MatchString(line):
+			cur.Accesses = append(cur.Accesses, Access{Description: strings.TrimSuffix(line, ":")})
+		case strings.HasPrefix(line, "Goroutine "):
+			// The creation stacks of the goroutines are context, not accesses
+			cur.Accesses = append(cur.Accesses, Access{})
+		case len(cur.Accesses) == 0:
+		case stackFrame.MatchString(line) && function != "":
+			m := stackFrame.FindStringSubmatch(line)
+			n, _ := strconv.Atoi(m[2])
+			a := &cur.Accesses[len(cur.Accesses)-1]
+			a.Stack = append(a.Stack, Frame{Function: function, File: m[1], Line: n})
+			function = ""
+		default:
+			function = strings.TrimSpace(line)
+		}
+	}
+	// A report cut off before its closing separator keeps what was read
+	if cur != nil {
+		cur.Text = strings.TrimSpace(text.String())
+	}
+	for i := range races {
+		var kept []Access
+		for _, a := range races[i].Accesses {
+			if a.Description != "" {
+				kept = append(kept, a)
+			}
+		}
+		races[i].Accesses = kept
+	}
+	return races, sc.Err()
+}
+
+// Files returns the files of the race's access stacks, each once.
+func (r Race) Files() []string {
+	var files []string
+	seen := make(map[string]bool)
+	for _, a := range r.Accesses {
+		for _, f := range a.Stack {
+			if !seen[f.File] {
+				seen[f.File] = true
+				files = append(files, f.File)
+			}
+		}
+	}
+	return files
+}
@@ -2,34 +2,305 @@ package config
 
 import (
 	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/disconnekt/goreview/internal/architecture"
+	"github.com/disconnekt/goreview/internal/findings"
+	"github.com/disconnekt/goreview/internal/languages"
+	"github.com/disconnekt/goreview/internal/modelinfo"
+	"github.com/disconnekt/goreview/internal/routing"
 )
 
 type Config struct {
-	ProjectPath    string
-	APIURL         string
+	ProjectPath string
+	// Provider selects the model backend: "openai" for OpenAI-compatible HTTP endpoints,
+	// a gateway preset ("openrouter", "litellm"), "bedrock" (AWS Bedrock), "vertex"
+	// (Google Vertex AI) or "exec" for an external command speaking JSON over stdin/stdout.
+	Provider string
+	// ProviderCommand is the command line run by the exec provider.
+	ProviderCommand string
+	// AWSRegion is the region of the bedrock provider; defaults to AWS_REGION.
+	AWSRegion string
+	// GCPProject and GCPRegion locate the vertex provider's endpoint.
+	GCPProject string
+	GCPRegion  string
+	APIURL     string
 	// APIURLs allows specifying multiple OpenAI-compatible endpoints.
 	// If provided, these take precedence over APIURL and will be used in a round-robin fashion.
-	APIURLs        []string
-	APIKey         string
-	Model          string
-	MaxFileSize    int64
+	APIURLs []string
+	// Gzip lists the endpoints, by URL or host, whose request bodies are gzip-compressed;
+	// "all" compresses for every endpoint.
+	Gzip   []string
+	APIKey string
+	// APIKeyCommand and APIKeyKeychain fetch the API key from a secret manager command
+	// or the OS keychain when APIKey is not set.
+	APIKeyCommand  string
+	APIKeyKeychain string
+	// APIKeys are additional keys requests rotate through; a key that is rate limited
+	// or rejected hands the request to the next one.
+	APIKeys []string
+	// APIKeyFile holds more keys, one per line.
+	APIKeyFile  string
+	Model       string
+	MaxFileSize int64
+	// MaxFiles and MaxTotalBytes guard against accidentally scanning huge trees (0 = unlimited).
+	MaxFiles      int
+	MaxTotalBytes int64
+	// MaxMemory caps the bytes of scanned file content kept in memory between the scan
+	// and the reviews, files past it being read again when needed, and separately the
+	// bytes of the files whose reviews are in flight, except with --batch (0 =
+	// unlimited). It does not bound the memory of the run as a whole.
+	MaxMemory int64
+	// MaxReviewChars is the length budget of each file's review; longer reviews are
+	// truncated (0 = unlimited).
+	MaxReviewChars int
+	// ChunkTokens is the most code, in approximate tokens, sent in one review request;
+	// larger files are reviewed in chunks (0 = derived from the model's context window).
+	ChunkTokens int
+	// ComplexityThreshold is the cyclomatic complexity from which functions are pointed
+	// out in the prompt (0 = none).
+	ComplexityThreshold int
+	// Duplicates enables duplicate code detection: "report" lists duplicated blocks,
+	// "review" also asks the model how to extract them.
+	Duplicates string
+	// DuplicateMinTokens is the smallest duplicated block reported, in tokens.
+	DuplicateMinTokens int
+	// Todos collects TODO comments into the report: "report" lists them, "triage" also
+	// asks the model for the priority of each.
+	Todos string
+	// ChurnSince is how far back git history counts toward the churn of the risk score,
+	// e.g. "6 months ago".
+	ChurnSince     string
 	RequestTimeout time.Duration
+	// RunTimeout bounds the whole run; 0 means no deadline.
+	RunTimeout time.Duration
+	// MaxErrors aborts the run after this many failed files (0 = never).
+	MaxErrors int
+	// ContinueOnError keeps individual file failures from failing the run.
+	ContinueOnError bool
+	// RetryFailed re-attempts failed files once at the end of the run.
+	RetryFailed    bool
 	MaxConcurrency int
+	// Batch submits the review requests through the provider's asynchronous batch API,
+	// polling every BatchPollInterval for the results.
+	Batch             bool
+	BatchPollInterval time.Duration
+	// PromptCache marks the system prompt shared by a run's requests as cacheable for
+	// providers that need explicit cache markers.
+	PromptCache bool
+	// AuditLog, if set, is a JSON lines file receiving a record of every API request.
+	AuditLog string
 	// ReportFile, if set, writes the review content (without logs) to the given file.
-	// When empty, the review content is printed to stdout as before.
-	ReportFile     string
+	// When empty, the review content is printed to stdout as before. Report paths may
+	// contain {{date}}, {{time}} and {{timestamp}} placeholders.
+	ReportFile string
+	// ReportAppend appends to existing report files instead of truncating them.
+	ReportAppend bool
+	// KeepReports prunes date-templated reports beyond the newest N (0 = keep all).
+	KeepReports int
+	// Format selects the report format, e.g. "text" or "github-actions".
+	Format string
+	// UpdateCheck allows "aireview version --check-update" to contact GitHub.
+	UpdateCheck bool
+	// ReportTemplate is a Go text/template file for the "template" format.
+	ReportTemplate string
+	// Outputs lists additional reports as "format=path", rendered from the same results.
+	Outputs []string
+	// Profile selects a built-in review profile that focuses the review on one concern.
+	Profile string
+	// Govulncheck is a saved "govulncheck -json" report used by the vuln profile
+	// instead of running govulncheck.
+	Govulncheck string
+	// RaceLog is saved "go test -race" output whose data races the concurrency profile
+	// adds to the prompts.
+	RaceLog string
+	// Base is the git revision whose merge base with HEAD the api profile compares the
+	// exported API against.
+	Base string
+	// Pprof lists pprof profiles and saved benchmark output whose hot paths the
+	// performance profile adds to the prompts.
+	Pprof []string
+	// Context lists repository context sources added to the prompt ("gomod", "frameworks", "docs").
+	Context []string
+	// EmbeddingModel is the model used for embeddings when --context includes similar.
+	EmbeddingModel string
+	// ContextBudget caps the repository context in (approximate) tokens.
+	ContextBudget int
+	// RulesFile points to a Markdown or YAML list of project rules injected into the prompt.
+	// When empty, .aireview/rules.{yaml,yml,md} inside ProjectPath is used if present.
+	RulesFile string
+	// PolicyFile holds the pass/fail rules evaluated after a run. When empty,
+	// .aireview/policy.yaml inside ProjectPath is used if present.
+	PolicyFile string
+	// Passes is the number of review passes per file. Passes beyond the first feed the
+	// previous review back to the model to verify each finding and drop hallucinated ones.
+	Passes int
+	// Temperature is the sampling temperature of review requests.
+	Temperature float64
+	// Seed asks providers that support it for reproducible sampling; 0 sends none.
+	Seed int64
+	// Deterministic sends temperature 0 and a seed (DeterministicSeed unless Seed is
+	// set) instead of Temperature, so repeated runs give the same reviews as far as the
+	// provider allows.
+	Deterministic bool
+	// Consensus sends each file to every model in ConsensusModels and merges their findings,
+	// flagging the ones reported by more than one model.
+	Consensus       bool
+	ConsensusModels []string
+	// TriageModel, when set, screens every file first; only files it flags go to the
+	// review model. TriageURL is an OpenAI-compatible endpoint serving it, such as a
+	// local server; by default the review endpoints serve it.
+	TriageModel string
+	TriageURL   string
+	// StateDir holds per-project state such as the last run's findings and the baseline
+	// of dismissed findings. Relative paths are resolved against ProjectPath.
+	StateDir string
+	// Notify lists the targets ("slack", "teams", "webhook") that receive a run summary
+	// when the run ends.
+	Notify       []string
+	SlackWebhook string
+	TeamsWebhook string
+	// NotifyWebhook receives the JSON run summary as a POST body.
+	NotifyWebhook string
+	// GitHubCheck publishes the run as a GitHub Check Run with line annotations.
+	GitHubCheck     bool
+	GitHubCheckName string
+	// GitHubToken authenticates GitHub API calls; defaults to GITHUB_TOKEN.
+	GitHubToken string
+	// PRComments posts findings as pull/merge request line comments ("github" or "gitlab"),
+	// updating earlier goreview comments in place instead of duplicating them.
+	PRComments  string
+	GitLabToken string
+	// CreateTickets files an issue in this tracker ("jira" or "github") for every finding
+	// at or above TicketSeverity that has none yet.
+	CreateTickets  string
+	TicketSeverity string
+	// TicketConsolidate files one ticket per run listing all new findings (GitHub only).
+	TicketConsolidate bool
+	// JiraURL, JiraProject and JiraIssueType locate where Jira tickets are filed.
+	JiraURL       string
+	JiraProject   string
+	JiraIssueType string
+	// JiraUser is the account of a Jira Cloud API token; leave it empty for a Data
+	// Center personal access token.
+	JiraUser string
+	// JiraToken authenticates Jira API calls; defaults to JIRA_API_TOKEN.
+	JiraToken string
+	// ReportURL is linked from notifications, e.g. the CI artifact URL of the report.
+	ReportURL string
+	// ConfigFile is the YAML config file to load. When empty, .aireview/config.{yaml,yml}
+	// inside ProjectPath is used if present.
+	ConfigFile string
+	// CI runs non-interactively for container pipelines: the project is the mounted
+	// workspace, reports are written to a known directory and a summary line is printed.
+	CI bool
+	// ConfigURL is an organization config fetched over HTTP(S) whose locked settings,
+	// prompt, excludes and policy rules cannot be weakened by the project.
+	ConfigURL string
+	// ConfigSHA256 pins the checksum of the organization config.
+	ConfigSHA256 string
+	// Exclude holds glob patterns, relative to the project root, of files to skip.
+	Exclude []string
+	// Modules holds per-module overrides keyed by module directory relative to the project root.
+	Modules map[string]ModuleConfig
+	// Languages overrides the built-in prompts per language, keyed by language name.
+	Languages map[string]LanguageConfig
+	// Architecture declares the allowed package dependencies, checked on every run.
+	Architecture []architecture.Rule
+	// Routes pick the model of each file by its path, language, size and the profile;
+	// the first matching route wins.
+	Routes []routing.Route
+	// Models overrides the built-in metadata of models, keyed by model name.
+	Models map[string]modelinfo.Info
+	// Module restricts the run to the Go module in this directory.
+	Module string
+	// Owner restricts the run to files owned by this CODEOWNERS owner.
+	Owner string
+	// Since and Author restrict findings to lines introduced in commits authored on or
+	// after a date (YYYY-MM-DD or RFC 3339) or by a matching author name or email.
+	Since  string
+	Author string
+	// ContextLines, when not negative, sends only the lines selected by Since and Author
+	// and this many lines around them to the model instead of the whole file.
+	ContextLines int
+	// FullFunctionContext widens the selected lines to the Go declarations enclosing them.
+	FullFunctionContext bool
+	// IncludeTrivialChanges keeps selected lines whose change only touched whitespace,
+	// comments or imports, which are dropped otherwise.
+	IncludeTrivialChanges bool
+	// Quiet silences everything but errors.
+	Quiet bool
+	// SummaryOnly prints a roll-up of the findings instead of the per-file report to
+	// stdout; report files still get the full report.
+	SummaryOnly bool
+	// NoPager prints the terminal report directly instead of through $PAGER.
+	NoPager bool
+	// NoColor disables ANSI colors in the report printed to the terminal.
+	NoColor bool
+	// PathStyle is how reports show file paths: "relative" to the project root or
+	// "absolute".
+	PathStyle string
+	// GroupByOwner adds the findings grouped by CODEOWNERS owner to the report.
+	GroupByOwner bool
+	// IgnoreWorkspace disables go.work handling; by default a go.work in ProjectPath
+	// makes the run scan all of its member modules.
+	IgnoreWorkspace bool
+	// FollowSymlinks makes the scanner follow symlinked files and directories
+	// (with cycle detection) instead of skipping them.
+	FollowSymlinks bool
+	// IncludeSubmodules makes the scanner descend into git submodules and nested
+	// repositories instead of skipping them.
+	IncludeSubmodules bool
+	// ScanWorkers is the number of directories and files the scanner reads at once
+	// (0 = by CPU count).
+	ScanWorkers int
+}
+
+// DefaultAPIURL is the endpoint used when none is configured: a local LM Studio server.
+const DefaultAPIURL = "http://127.0.0.1:1234/v1/chat/completions"
+
+// providerURLs are the endpoints of gateway presets, used instead of DefaultAPIURL
+// when the preset's provider is selected.
+var providerURLs = map[string]string{
+	"openrouter": "https://openrouter.ai/api/v1/chat/completions",
+	"litellm":    "http://127.0.0.1:4000/v1/chat/completions",
 }
 
 func DefaultConfig() *Config {
 	return &Config{
-		ProjectPath:    ".",
-		APIURL:         "http://127.0.0.1:1234/v1/chat/completions",
-		Model:          "devstral-small-2507-mlx",
-		MaxFileSize:    10 * 1024 * 1024, // 10MB
-		RequestTimeout: 720 * time.Second,
-		MaxConcurrency: 10,
+		ProjectPath:         ".",
+		Provider:            "openai",
+		APIURL:              DefaultAPIURL,
+		Model:               "devstral-small-2507-mlx",
+		MaxFileSize:         10 * 1024 * 1024, // 10MB
+		MaxFiles:            5000,
+		MaxTotalBytes:       100 * 1024 * 1024, // 100MB
+		ComplexityThreshold: 10,
+		DuplicateMinTokens:  100,
+		ChurnSince:          "6 months ago",
+		ContextLines:        -1,
+		RequestTimeout:      720 * time.Second,
+		MaxConcurrency:      10,
+		RetryFailed:         true,
+		BatchPollInterval:   30 * time.Second,
+		Passes:              1,
+		Temperature:         0.1,
+		StateDir:            ".aireview",
+		Format:              "text",
+		PathStyle:           "relative",
+		GitHubCheckName:     "goreview",
+		TicketSeverity:      "high",
+		JiraIssueType:       "Bug",
+		UpdateCheck:         true,
+		Profile:             "general",
+		Context:             []string{"gomod", "frameworks"},
+		ContextBudget:       4000,
+		EmbeddingModel:      "text-embedding-3-small",
 	}
 }
 
@@ -43,12 +314,176 @@ func (c *Config) Validate() error {
 			return errors.New("API URL cannot be empty")
 		}
 	}
+	if len(c.Gzip) > 0 && (c.Provider == "bedrock" || c.Provider == "exec") {
+		return fmt.Errorf("--gzip is not supported by the %s provider", c.Provider)
+	}
+	for _, g := range c.Gzip {
+		if g != "all" && !c.namesEndpoint(g) {
+			return fmt.Errorf("--gzip %q matches none of the configured endpoints (use a URL or host of --url, --urls or --triage-url, or \"all\")", g)
+		}
+	}
+	if c.Provider == "exec" && strings.TrimSpace(c.ProviderCommand) == "" {
+		return errors.New("the exec provider requires --provider-command")
+	}
 	if c.Model == "" {
 		return errors.New("model cannot be empty")
 	}
 	if c.MaxFileSize <= 0 {
 		return errors.New("max file size must be positive")
 	}
+	if c.MaxFiles < 0 || c.MaxTotalBytes < 0 {
+		return errors.New("max files and max total bytes must not be negative")
+	}
+	if c.MaxMemory < 0 {
+		return errors.New("max memory must not be negative")
+	}
+	if c.ScanWorkers < 0 {
+		return errors.New("scan workers must not be negative")
+	}
+	if c.Passes < 1 {
+		return errors.New("passes must be at least 1")
+	}
+	if c.Temperature < 0 || c.Temperature > 2 {
+		return fmt.Errorf("temperature must be between 0 and 2, got %g", c.Temperature)
+	}
+	if c.Consensus && (len(c.ConsensusModels) < 2 || len(c.ConsensusModels) > 3) {
+		return errors.New("consensus mode requires two or three models in --consensus-models")
+	}
+	if c.TriageURL != "" && c.TriageModel == "" {
+		return errors.New("--triage-url requires --triage-model")
+	}
+	for _, target := range c.Notify {
+		switch target {
+		case "slack":
+			if c.SlackWebhook == "" {
+				return errors.New("slack notifications require --slack-webhook or AIREVIEW_SLACK_WEBHOOK")
+			}
+		case "teams":
+			if c.TeamsWebhook == "" {
+				return errors.New("teams notifications require --teams-webhook or AIREVIEW_TEAMS_WEBHOOK")
+			}
+		case "webhook":
+			if c.NotifyWebhook == "" {
+				return errors.New("webhook notifications require --notify-webhook")
+			}
+		default:
+			return fmt.Errorf("unknown notification target %q", target)
+		}
+	}
+	if c.Govulncheck != "" && c.Profile != "vuln" {
+		return errors.New("--govulncheck requires --profile vuln")
+	}
+	if c.RaceLog != "" && c.Profile != "concurrency" {
+		return errors.New("--race-log requires --profile concurrency")
+	}
+	if (c.Base != "") != (c.Profile == "api") {
+		return errors.New("--profile api and --base must be used together")
+	}
+	if len(c.Pprof) > 0 && c.Profile != "performance" {
+		return errors.New("--pprof requires --profile performance")
+	}
+	for _, source := range c.Context {
+		switch source {
+		case "gomod", "frameworks", "docs", "similar":
+		default:
+			return fmt.Errorf("unknown context source %q (expected gomod, frameworks, docs or similar)", source)
+		}
+	}
+	if c.ContextBudget < 0 {
+		return errors.New("context budget must not be negative")
+	}
+	switch c.PRComments {
+	case "", "github", "gitlab":
+	default:
+		return fmt.Errorf("unknown --pr-comments platform %q (expected github or gitlab)", c.PRComments)
+	}
+	switch c.CreateTickets {
+	case "":
+	case "jira":
+		if c.JiraURL == "" || c.JiraProject == "" {
+			return errors.New("jira tickets require --jira-url and --jira-project")
+		}
+	case "github":
+	default:
+		return fmt.Errorf("unknown --create-tickets tracker %q (expected jira or github)", c.CreateTickets)
+	}
+	if c.TicketConsolidate && c.CreateTickets != "github" {
+		return errors.New("--ticket-consolidate requires --create-tickets github")
+	}
+	if _, err := findings.ParseSeverity(c.TicketSeverity); err != nil {
+		return fmt.Errorf("invalid --ticket-severity: %w", err)
+	}
+	if _, err := c.SinceTime(); err != nil {
+		return err
+	}
+	if c.ContextLines < -1 {
+		return errors.New("--context-lines must be -1 (send whole files) or more")
+	}
+	if c.PathStyle != "relative" && c.PathStyle != "absolute" {
+		return fmt.Errorf("invalid path style %q (expected relative or absolute)", c.PathStyle)
+	}
+	for _, r := range c.Architecture {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+	}
+	if err := routing.Validate(c.Routes); err != nil {
+		return err
+	}
+	for name, o := range c.Models {
+		if err := o.Validate(name); err != nil {
+			return err
+		}
+		// An override of one limit must still fit the built-in other
+		if err := c.ModelInfo(name).Validate(name); err != nil {
+			return err
+		}
+	}
+	for name := range c.Languages {
+		if _, ok := languages.Get(name); !ok {
+			return fmt.Errorf("unknown language %q in languages (available: %s)", name, strings.Join(languages.Names(), ", "))
+		}
+	}
+	if c.MaxReviewChars < 0 {
+		return errors.New("max review chars must not be negative")
+	}
+	if c.ChunkTokens < 0 {
+		return errors.New("chunk tokens must not be negative")
+	}
+	if c.ComplexityThreshold < 0 {
+		return errors.New("complexity threshold must not be negative")
+	}
+	switch c.Duplicates {
+	case "", "report", "review":
+	default:
+		return fmt.Errorf("unknown duplicates mode %q (expected report or review)", c.Duplicates)
+	}
+	switch c.Todos {
+	case "", "report", "triage":
+	default:
+		return fmt.Errorf("unknown todos mode %q (expected report or triage)", c.Todos)
+	}
+	if c.DuplicateMinTokens < 1 {
+		return errors.New("duplicate min tokens must be positive")
+	}
+	if c.KeepReports < 0 {
+		return errors.New("keep reports must not be negative")
+	}
+	for _, o := range c.Outputs {
+		name, path, ok := strings.Cut(o, "=")
+		if !ok || strings.TrimSpace(name) == "" || strings.TrimSpace(path) == "" {
+			return fmt.Errorf("invalid output %q (expected format=path, e.g. sarif=report.sarif)", o)
+		}
+	}
+	if c.MaxErrors < 0 {
+		return errors.New("max errors must not be negative")
+	}
+	if c.RunTimeout < 0 {
+		return errors.New("run timeout must not be negative")
+	}
+	if c.Batch && c.BatchPollInterval <= 0 {
+		return errors.New("batch poll interval must be positive")
+	}
 	if c.RequestTimeout <= 0 {
 		return errors.New("request timeout must be positive")
 	}
@@ -56,24 +491,121 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// Compresses reports whether request bodies sent to endpoint are gzip-compressed.
+func (c *Config) Compresses(endpoint string) bool {
+	for _, g := range c.Gzip {
+		if g == "all" || gzipMatches(g, endpoint) {
+			return true
+		}
+	}
+	return false
+}
+
+// namesEndpoint reports whether a --gzip entry names one of the review or triage
+// endpoints, so a typo does not silently leave requests uncompressed.
+func (c *Config) namesEndpoint(entry string) bool {
+	for _, e := range c.EffectiveAPIURLs() {
+		if gzipMatches(entry, e) {
+			return true
+		}
+	}
+	return c.TriageURL != "" && gzipMatches(entry, c.TriageURL)
+}
+
+// gzipMatches reports whether a --gzip entry names endpoint, by URL or host.
+func gzipMatches(entry, endpoint string) bool {
+	if entry == endpoint {
+		return true
+	}
+	u, err := url.Parse(endpoint)
+	return err == nil && (u.Host == entry || u.Hostname() == entry)
+}
+
 // EffectiveAPIURLs returns the list of API URLs to use. If APIURLs is set,
-// it takes precedence; otherwise, it falls back to the single APIURL value.
+// it takes precedence; otherwise, it falls back to the single APIURL value, or to the
+// provider preset's endpoint when APIURL was left at its default.
 func (c *Config) EffectiveAPIURLs() []string {
 	if len(c.APIURLs) > 0 {
 		return c.APIURLs
 	}
+	if u, ok := providerURLs[c.Provider]; ok && c.APIURL == DefaultAPIURL {
+		return []string{u}
+	}
 	if strings.TrimSpace(c.APIURL) != "" {
 		return []string{c.APIURL}
 	}
 	return nil
 }
 
+// EffectiveAPIKeys returns APIKey followed by APIKeys, without blanks and duplicates.
+func (c *Config) EffectiveAPIKeys() []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, k := range append([]string{c.APIKey}, c.APIKeys...) {
+		k = strings.TrimSpace(k)
+		if k == "" || seen[k] {
+			continue
+		}
+		seen[k] = true
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// ReadKeyFile reads API keys, one per line; blank lines and # comments are ignored.
+func ReadKeyFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API key file: %w", err)
+	}
+	var keys []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		keys = append(keys, line)
+	}
+	return keys, nil
+}
+
+// DeterministicSeed is the seed sent by --deterministic when no --seed is set.
+const DeterministicSeed = 42
+
+// Sampling returns the temperature and seed of review requests, applying
+// --deterministic; a seed of 0 is not sent.
+func (c *Config) Sampling() (temperature float64, seed int64) {
+	if !c.Deterministic {
+		return c.Temperature, c.Seed
+	}
+	if c.Seed != 0 {
+		return 0, c.Seed
+	}
+	return 0, DeterministicSeed
+}
+
+// ModelInfo returns the context window, output limit and prices of a model, from the
+// built-in table with the overrides under models applied.
+func (c *Config) ModelInfo(model string) modelinfo.Info {
+	info, _ := modelinfo.Lookup(model, c.Models)
+	return info
+}
+
+// StatePath returns the location of a file inside the project's state directory.
+func (c *Config) StatePath(name string) string {
+	if filepath.IsAbs(c.StateDir) {
+		return filepath.Join(c.StateDir, name)
+	}
+	return filepath.Join(c.ProjectPath, c.StateDir, name)
+}
+
 func (c *Config) RequiresAPIKey() bool {
 	onlineServices := []string{
 		"api.openai.com",
 		"openai.azure.com",
 		"api.anthropic.com",
 		"generativelanguage.googleapis.com",
+		"openrouter.ai",
 	}
 
 	urlList := c.EffectiveAPIURLs()
@@ -86,3 +618,18 @@ func (c *Config) RequiresAPIKey() bool {
 	}
 	return false
 }
+
+// SinceTime parses Since; it returns the zero time when Since is empty.
+func (c *Config) SinceTime() (time.Time, error) {
+	if c.Since == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", c.Since, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, c.Since)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since %q (expected YYYY-MM-DD or RFC 3339)", c.Since)
+	}
+	return t, nil
+}
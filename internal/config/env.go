@@ -0,0 +1,86 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix starts the name of every environment variable read by FromEnv.
+const EnvPrefix = "AIREVIEW_"
+
+// EnvName returns the environment variable that sets a config file key, e.g.
+// "max_errors" -> "AIREVIEW_MAX_ERRORS".
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(key)
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// FromEnv reads the AIREVIEW_<KEY> variables into a File so they can be applied like a
+// config file. Lists are comma-separated and empty variables are ignored; per-module
+// settings cannot be set from the environment.
+func FromEnv(lookup func(string) (string, bool)) (*File, error) {
+	var f File
+	v := reflect.ValueOf(&f).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		name := EnvName(key)
+		raw, ok := lookup(name)
+		raw = strings.TrimSpace(raw)
+		if !ok || raw == "" {
+			continue
+		}
+
+		field := v.Field(i)
+		switch {
+		case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+			var list []string
+			for _, item := range strings.Split(raw, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					list = append(list, item)
+				}
+			}
+			field.Set(reflect.ValueOf(list))
+		case field.Kind() == reflect.Pointer:
+			value, err := parseEnvValue(field.Type().Elem(), raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", name, err)
+			}
+			ptr := reflect.New(field.Type().Elem())
+			ptr.Elem().Set(value)
+			field.Set(ptr)
+		}
+	}
+	return &f, nil
+}
+
+func parseEnvValue(t reflect.Type, raw string) (reflect.Value, error) {
+	if t == durationType {
+		d, err := time.ParseDuration(raw)
+		return reflect.ValueOf(d), err
+	}
+	v := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return v, err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, t.Bits())
+		if err != nil {
+			return v, err
+		}
+		v.SetInt(n)
+	default:
+		return v, fmt.Errorf("unsupported type %s", t)
+	}
+	return v, nil
+}
@@ -0,0 +1,313 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/disconnekt/goreview/internal/architecture"
+	"github.com/disconnekt/goreview/internal/modelinfo"
+	"github.com/disconnekt/goreview/internal/routing"
+)
+
+// DefaultFilePaths lists the config files looked up (in order) inside the project directory.
+var DefaultFilePaths = []string{
+	filepath.Join(".aireview", "config.yaml"),
+	filepath.Join(".aireview", "config.yml"),
+}
+
+// File is the YAML layout of a config file. Pointer fields distinguish keys that are
+// absent from keys set to their zero value, so only keys present in the file apply.
+type File struct {
+	Provider            *string                 `yaml:"provider"`
+	ProviderCommand     *string                 `yaml:"provider_command"`
+	AWSRegion           *string                 `yaml:"aws_region"`
+	GCPProject          *string                 `yaml:"gcp_project"`
+	GCPRegion           *string                 `yaml:"gcp_region"`
+	APIKeyCommand       *string                 `yaml:"api_key_command"`
+	APIKeyKeychain      *string                 `yaml:"api_key_keychain"`
+	APIKeyFile          *string                 `yaml:"api_key_file"`
+	URL                 *string                 `yaml:"url"`
+	URLs                []string                `yaml:"urls"`
+	Gzip                []string                `yaml:"gzip"`
+	Model               *string                 `yaml:"model"`
+	MaxFileSize         *int64                  `yaml:"max_size"`
+	MaxFiles            *int                    `yaml:"max_files"`
+	MaxTotalBytes       *int64                  `yaml:"max_total_bytes"`
+	MaxMemory           *int64                  `yaml:"max_memory"`
+	MaxReviewChars      *int                    `yaml:"max_review_chars"`
+	ChunkTokens         *int                    `yaml:"chunk_tokens"`
+	ComplexityThreshold *int                    `yaml:"complexity_threshold"`
+	Duplicates          *string                 `yaml:"duplicates"`
+	DuplicateMinTokens  *int                    `yaml:"duplicate_min_tokens"`
+	Todos               *string                 `yaml:"todos"`
+	ChurnSince          *string                 `yaml:"churn_since"`
+	Concurrency         *int                    `yaml:"concurrency"`
+	Timeout             *time.Duration          `yaml:"timeout"`
+	RunTimeout          *time.Duration          `yaml:"run_timeout"`
+	MaxErrors           *int                    `yaml:"max_errors"`
+	ContinueOnError     *bool                   `yaml:"continue_on_error"`
+	RetryFailed         *bool                   `yaml:"retry_failed"`
+	Batch               *bool                   `yaml:"batch"`
+	BatchPollInterval   *time.Duration          `yaml:"batch_poll_interval"`
+	PromptCache         *bool                   `yaml:"prompt_cache"`
+	ContextLines        *int                    `yaml:"context_lines"`
+	FullFunctionContext *bool                   `yaml:"full_function_context"`
+	IncludeTrivial      *bool                   `yaml:"include_trivial_changes"`
+	ReportFile          *string                 `yaml:"report_file"`
+	ReportAppend        *bool                   `yaml:"report_append"`
+	KeepReports         *int                    `yaml:"keep_reports"`
+	AuditLog            *string                 `yaml:"audit_log"`
+	Format              *string                 `yaml:"format"`
+	ReportTemplate      *string                 `yaml:"report_template"`
+	Outputs             []string                `yaml:"outputs"`
+	PathStyle           *string                 `yaml:"path_style"`
+	Quiet               *bool                   `yaml:"quiet"`
+	SummaryOnly         *bool                   `yaml:"summary_only"`
+	Profile             *string                 `yaml:"profile"`
+	Govulncheck         *string                 `yaml:"govulncheck"`
+	RaceLog             *string                 `yaml:"race_log"`
+	Pprof               []string                `yaml:"pprof"`
+	Base                *string                 `yaml:"base"`
+	Context             []string                `yaml:"context"`
+	ContextBudget       *int                    `yaml:"context_budget"`
+	EmbeddingModel      *string                 `yaml:"embedding_model"`
+	Rules               *string                 `yaml:"rules"`
+	Policy              *string                 `yaml:"policy"`
+	Passes              *int                    `yaml:"passes"`
+	Temperature         *float64                `yaml:"temperature"`
+	Seed                *int64                  `yaml:"seed"`
+	Deterministic       *bool                   `yaml:"deterministic"`
+	Consensus           *bool                   `yaml:"consensus"`
+	ConsensusModels     []string                `yaml:"consensus_models"`
+	TriageModel         *string                 `yaml:"triage_model"`
+	TriageURL           *string                 `yaml:"triage_url"`
+	Notify              []string                `yaml:"notify"`
+	SlackWebhook        *string                 `yaml:"slack_webhook"`
+	TeamsWebhook        *string                 `yaml:"teams_webhook"`
+	NotifyWebhook       *string                 `yaml:"notify_webhook"`
+	ReportURL           *string                 `yaml:"report_url"`
+	GitHubCheck         *bool                   `yaml:"github_check"`
+	GitHubCheckName     *string                 `yaml:"github_check_name"`
+	PRComments          *string                 `yaml:"pr_comments"`
+	CreateTickets       *string                 `yaml:"create_tickets"`
+	TicketSeverity      *string                 `yaml:"ticket_severity"`
+	TicketConsolidate   *bool                   `yaml:"ticket_consolidate"`
+	JiraURL             *string                 `yaml:"jira_url"`
+	JiraProject         *string                 `yaml:"jira_project"`
+	JiraIssueType       *string                 `yaml:"jira_issue_type"`
+	JiraUser            *string                 `yaml:"jira_user"`
+	Exclude             []string                `yaml:"exclude"`
+	GroupByOwner        *bool                   `yaml:"group_by_owner"`
+	FollowSymlinks      *bool                   `yaml:"follow_symlinks"`
+	IncludeSubmodules   *bool                   `yaml:"include_submodules"`
+	ScanWorkers         *int                    `yaml:"scan_workers"`
+	UpdateCheck         *bool                   `yaml:"update_check"`
+	Modules             map[string]ModuleConfig `yaml:"modules"`
+	// Languages replaces the built-in prompt of a language, keyed by language name.
+	Languages map[string]LanguageConfig `yaml:"languages"`
+	// Architecture declares the allowed package dependencies.
+	Architecture []architecture.Rule `yaml:"architecture"`
+	// Routes pick the model per file; the first matching route wins.
+	Routes []routing.Route `yaml:"routes"`
+	// Models overrides the context window, output limit and prices of models by name.
+	Models map[string]modelinfo.Info `yaml:"models"`
+}
+
+// LanguageConfig overrides the review guidance for one language.
+type LanguageConfig struct {
+	// Prompt replaces the built-in prompt appended for files of the language.
+	Prompt string `yaml:"prompt"`
+}
+
+// ModuleConfig overrides settings for one Go module of a monorepo. Keys of
+// Config.Modules are module directories relative to the project root.
+type ModuleConfig struct {
+	Model string `yaml:"model"`
+	// Prompt is appended to the system prompt for files of the module.
+	Prompt string `yaml:"prompt"`
+	// Exclude holds glob patterns relative to the module directory.
+	Exclude []string `yaml:"exclude"`
+	// MaxFiles caps how many files of the module are reviewed per run (0 = unlimited).
+	MaxFiles int `yaml:"max_files"`
+}
+
+// UserFilePath returns the user's own config file, or "" when the user config directory
+// is unknown. Unlike a project config file it may set every key.
+func UserFilePath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "aireview", "config.yaml")
+}
+
+// projectRestricted lists the keys a project config file cannot set. They run commands
+// or decide where credentials are sent, and the reviewed checkout is not trusted with
+// either.
+var projectRestricted = map[string]bool{
+	"provider_command": true,
+	"api_key_command":  true,
+	"url":              true,
+	"urls":             true,
+	"triage_url":       true,
+	"jira_url":         true,
+}
+
+// CheckProject rejects the keys of a project config file that only flags, environment
+// variables and the user config file may set.
+func (f *File) CheckProject() error {
+	var keys []string
+	for _, s := range f.Settings() {
+		if s.Present && projectRestricted[s.Key] {
+			keys = append(keys, s.Key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s cannot be set in a project config file; use a flag, an AIREVIEW_ variable or the user config file (%s)",
+		strings.Join(keys, ", "), UserFilePath())
+}
+
+// DiscoverFile returns the first default config file present in projectPath, or "".
+func DiscoverFile(projectPath string) string {
+	for _, p := range DefaultFilePaths {
+		candidate := filepath.Join(projectPath, p)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// LoadFile parses a YAML config file. Unknown keys are rejected to catch typos.
+func LoadFile(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+
+	var fc File
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(&fc); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return &fc, nil
+}
+
+// Apply copies the values present in the file into c, skipping settings for which
+// isSet reports that a command-line flag was given: flags take precedence over files.
+func (c *Config) Apply(f *File, isSet func(flag string) bool) {
+	set := func(flag string, present bool, assign func()) {
+		if present && !isSet(flag) {
+			assign()
+		}
+	}
+
+	set("provider", f.Provider != nil, func() { c.Provider = *f.Provider })
+	set("provider-command", f.ProviderCommand != nil, func() { c.ProviderCommand = *f.ProviderCommand })
+	set("aws-region", f.AWSRegion != nil, func() { c.AWSRegion = *f.AWSRegion })
+	set("gcp-project", f.GCPProject != nil, func() { c.GCPProject = *f.GCPProject })
+	set("gcp-region", f.GCPRegion != nil, func() { c.GCPRegion = *f.GCPRegion })
+	set("api-key-command", f.APIKeyCommand != nil, func() { c.APIKeyCommand = *f.APIKeyCommand })
+	set("api-key-keychain", f.APIKeyKeychain != nil, func() { c.APIKeyKeychain = *f.APIKeyKeychain })
+	set("api-key-file", f.APIKeyFile != nil, func() { c.APIKeyFile = *f.APIKeyFile })
+	set("url", f.URL != nil, func() { c.APIURL = *f.URL })
+	set("urls", f.URLs != nil, func() { c.APIURLs = f.URLs })
+	set("gzip", f.Gzip != nil, func() { c.Gzip = f.Gzip })
+	set("model", f.Model != nil, func() { c.Model = *f.Model })
+	set("max-size", f.MaxFileSize != nil, func() { c.MaxFileSize = *f.MaxFileSize })
+	set("max-review-chars", f.MaxReviewChars != nil, func() { c.MaxReviewChars = *f.MaxReviewChars })
+	set("chunk-tokens", f.ChunkTokens != nil, func() { c.ChunkTokens = *f.ChunkTokens })
+	set("complexity-threshold", f.ComplexityThreshold != nil, func() { c.ComplexityThreshold = *f.ComplexityThreshold })
+	set("duplicates", f.Duplicates != nil, func() { c.Duplicates = *f.Duplicates })
+	set("duplicate-min-tokens", f.DuplicateMinTokens != nil, func() { c.DuplicateMinTokens = *f.DuplicateMinTokens })
+	set("todos", f.Todos != nil, func() { c.Todos = *f.Todos })
+	set("churn-since", f.ChurnSince != nil, func() { c.ChurnSince = *f.ChurnSince })
+	set("max-files", f.MaxFiles != nil, func() { c.MaxFiles = *f.MaxFiles })
+	set("max-total-bytes", f.MaxTotalBytes != nil, func() { c.MaxTotalBytes = *f.MaxTotalBytes })
+	set("max-memory", f.MaxMemory != nil, func() { c.MaxMemory = *f.MaxMemory })
+	set("concurrency", f.Concurrency != nil, func() { c.MaxConcurrency = *f.Concurrency })
+	set("timeout", f.Timeout != nil, func() { c.RequestTimeout = *f.Timeout })
+	set("run-timeout", f.RunTimeout != nil, func() { c.RunTimeout = *f.RunTimeout })
+	set("max-errors", f.MaxErrors != nil, func() { c.MaxErrors = *f.MaxErrors })
+	set("continue-on-error", f.ContinueOnError != nil, func() { c.ContinueOnError = *f.ContinueOnError })
+	set("retry-failed", f.RetryFailed != nil, func() { c.RetryFailed = *f.RetryFailed })
+	set("batch", f.Batch != nil, func() { c.Batch = *f.Batch })
+	set("prompt-cache", f.PromptCache != nil, func() { c.PromptCache = *f.PromptCache })
+	set("context-lines", f.ContextLines != nil, func() { c.ContextLines = *f.ContextLines })
+	set("full-function-context", f.FullFunctionContext != nil, func() { c.FullFunctionContext = *f.FullFunctionContext })
+	set("include-trivial-changes", f.IncludeTrivial != nil, func() { c.IncludeTrivialChanges = *f.IncludeTrivial })
+	set("batch-poll-interval", f.BatchPollInterval != nil, func() { c.BatchPollInterval = *f.BatchPollInterval })
+	set("audit-log", f.AuditLog != nil, func() { c.AuditLog = *f.AuditLog })
+	set("report-file", f.ReportFile != nil, func() { c.ReportFile = *f.ReportFile })
+	set("report-append", f.ReportAppend != nil, func() { c.ReportAppend = *f.ReportAppend })
+	set("keep-reports", f.KeepReports != nil, func() { c.KeepReports = *f.KeepReports })
+	set("format", f.Format != nil, func() { c.Format = *f.Format })
+	set("report-template", f.ReportTemplate != nil, func() { c.ReportTemplate = *f.ReportTemplate })
+	set("output", f.Outputs != nil, func() { c.Outputs = f.Outputs })
+	set("path-style", f.PathStyle != nil, func() { c.PathStyle = *f.PathStyle })
+	set("quiet", f.Quiet != nil, func() { c.Quiet = *f.Quiet })
+	set("summary-only", f.SummaryOnly != nil, func() { c.SummaryOnly = *f.SummaryOnly })
+	set("profile", f.Profile != nil, func() { c.Profile = *f.Profile })
+	set("govulncheck", f.Govulncheck != nil, func() { c.Govulncheck = *f.Govulncheck })
+	set("race-log", f.RaceLog != nil, func() { c.RaceLog = *f.RaceLog })
+	set("pprof", f.Pprof != nil, func() { c.Pprof = f.Pprof })
+	set("base", f.Base != nil, func() { c.Base = *f.Base })
+	set("context", f.Context != nil, func() { c.Context = f.Context })
+	set("context-budget", f.ContextBudget != nil, func() { c.ContextBudget = *f.ContextBudget })
+	set("embedding-model", f.EmbeddingModel != nil, func() { c.EmbeddingModel = *f.EmbeddingModel })
+	set("rules", f.Rules != nil, func() { c.RulesFile = *f.Rules })
+	set("policy", f.Policy != nil, func() { c.PolicyFile = *f.Policy })
+	set("passes", f.Passes != nil, func() { c.Passes = *f.Passes })
+	set("temperature", f.Temperature != nil, func() { c.Temperature = *f.Temperature })
+	set("seed", f.Seed != nil, func() { c.Seed = *f.Seed })
+	set("deterministic", f.Deterministic != nil, func() { c.Deterministic = *f.Deterministic })
+	set("consensus", f.Consensus != nil, func() { c.Consensus = *f.Consensus })
+	set("consensus-models", f.ConsensusModels != nil, func() { c.ConsensusModels = f.ConsensusModels })
+	set("triage-model", f.TriageModel != nil, func() { c.TriageModel = *f.TriageModel })
+	set("triage-url", f.TriageURL != nil, func() { c.TriageURL = *f.TriageURL })
+	set("notify", f.Notify != nil, func() { c.Notify = f.Notify })
+	set("slack-webhook", f.SlackWebhook != nil, func() { c.SlackWebhook = *f.SlackWebhook })
+	set("teams-webhook", f.TeamsWebhook != nil, func() { c.TeamsWebhook = *f.TeamsWebhook })
+	set("notify-webhook", f.NotifyWebhook != nil, func() { c.NotifyWebhook = *f.NotifyWebhook })
+	set("report-url", f.ReportURL != nil, func() { c.ReportURL = *f.ReportURL })
+	set("github-check", f.GitHubCheck != nil, func() { c.GitHubCheck = *f.GitHubCheck })
+	set("github-check-name", f.GitHubCheckName != nil, func() { c.GitHubCheckName = *f.GitHubCheckName })
+	set("pr-comments", f.PRComments != nil, func() { c.PRComments = *f.PRComments })
+	set("create-tickets", f.CreateTickets != nil, func() { c.CreateTickets = *f.CreateTickets })
+	set("ticket-severity", f.TicketSeverity != nil, func() { c.TicketSeverity = *f.TicketSeverity })
+	set("ticket-consolidate", f.TicketConsolidate != nil, func() { c.TicketConsolidate = *f.TicketConsolidate })
+	set("jira-url", f.JiraURL != nil, func() { c.JiraURL = *f.JiraURL })
+	set("jira-project", f.JiraProject != nil, func() { c.JiraProject = *f.JiraProject })
+	set("jira-issue-type", f.JiraIssueType != nil, func() { c.JiraIssueType = *f.JiraIssueType })
+	set("jira-user", f.JiraUser != nil, func() { c.JiraUser = *f.JiraUser })
+	set("exclude", f.Exclude != nil, func() { c.Exclude = f.Exclude })
+	set("group-by-owner", f.GroupByOwner != nil, func() { c.GroupByOwner = *f.GroupByOwner })
+	set("follow-symlinks", f.FollowSymlinks != nil, func() { c.FollowSymlinks = *f.FollowSymlinks })
+	set("include-submodules", f.IncludeSubmodules != nil, func() { c.IncludeSubmodules = *f.IncludeSubmodules })
+	set("scan-workers", f.ScanWorkers != nil, func() { c.ScanWorkers = *f.ScanWorkers })
+	set("update-check", f.UpdateCheck != nil, func() { c.UpdateCheck = *f.UpdateCheck })
+	if f.Modules != nil {
+		c.Modules = f.Modules
+	}
+	if f.Languages != nil {
+		c.Languages = f.Languages
+	}
+	if f.Architecture != nil {
+		c.Architecture = f.Architecture
+	}
+	if f.Routes != nil {
+		c.Routes = f.Routes
+	}
+	if f.Models != nil {
+		c.Models = f.Models
+	}
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxOrgConfigSize bounds the download of an organization config.
+const maxOrgConfigSize = 1 << 20
+
+// Org is a config distributed centrally by a platform team. Its settings are defaults a
+// project may override, except for locked keys; its prompt, excludes and policy rules
+// always apply in addition to the project's own.
+type Org struct {
+	// Settings has the layout of a config file.
+	Settings File `yaml:"settings"`
+	// Locked lists keys of Settings that project config files, environment variables
+	// and flags cannot change.
+	Locked []string `yaml:"locked"`
+	// Prompt is appended to the review prompt of every file.
+	Prompt string `yaml:"prompt"`
+	// Exclude holds glob patterns excluded on top of the project's excludes.
+	Exclude []string `yaml:"exclude"`
+	// Policy has the layout of a policy file; its rules are evaluated with the project's.
+	Policy yaml.Node `yaml:"policy"`
+}
+
+// FetchOrg downloads an organization config. When sum is set, the SHA-256 of the
+// document must match it, so a pinned config cannot change unnoticed. It returns the
+// config and the checksum of the document.
+func FetchOrg(ctx context.Context, url, sum string) (*Org, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request for %s: %w", url, err)
+	}
+	resp, err := (&http.Client{Timeout: 30 * time.Second}).Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch config %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("failed to fetch config %s: status %d", url, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxOrgConfigSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read config %s: %w", url, err)
+	}
+	if len(data) > maxOrgConfigSize {
+		return nil, "", fmt.Errorf("config %s is larger than %d bytes", url, maxOrgConfigSize)
+	}
+
+	digest := sha256.Sum256(data)
+	actual := hex.EncodeToString(digest[:])
+	if sum != "" && !strings.EqualFold(strings.TrimPrefix(sum, "sha256:"), actual) {
+		return nil, "", fmt.Errorf("config %s has checksum %s, expected %s", url, actual, sum)
+	}
+	org, err := ParseOrg(data)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse config %s: %w", url, err)
+	}
+	return org, actual, nil
+}
+
+// ParseOrg parses an organization config. Unknown keys are rejected to catch typos.
+func ParseOrg(data []byte) (*Org, error) {
+	var org Org
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&org); err != nil {
+		return nil, err
+	}
+	present := make(map[string]bool)
+	for _, s := range org.Settings.Settings() {
+		present[s.Key] = s.Present
+	}
+	for _, key := range org.Locked {
+		if !present[key] {
+			return nil, fmt.Errorf("locked key %q is not among the settings", key)
+		}
+	}
+	return &org, nil
+}
+
+// LockedSettings returns a File with only the locked keys of the settings.
+func (o *Org) LockedSettings() *File {
+	var locked File
+	src := reflect.ValueOf(&o.Settings).Elem()
+	dst := reflect.ValueOf(&locked).Elem()
+	for i := 0; i < src.NumField(); i++ {
+		key, _, _ := strings.Cut(src.Type().Field(i).Tag.Get("yaml"), ",")
+		for _, l := range o.Locked {
+			if l == key {
+				dst.Field(i).Set(src.Field(i))
+			}
+		}
+	}
+	return &locked
+}
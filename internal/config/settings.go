@@ -0,0 +1,154 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Setting is one config file key and its value.
+type Setting struct {
+	Key   string
+	Value any
+	// Present is false when the key is absent from the file.
+	Present bool
+}
+
+// Settings lists the keys of f in declaration order.
+func (f *File) Settings() []Setting {
+	v := reflect.ValueOf(f).Elem()
+	t := v.Type()
+	settings := make([]Setting, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		key, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		field := v.Field(i)
+		s := Setting{Key: key, Present: !field.IsNil()}
+		if field.Kind() == reflect.Pointer && !field.IsNil() {
+			s.Value = field.Elem().Interface()
+		} else {
+			s.Value = field.Interface()
+		}
+		settings = append(settings, s)
+	}
+	return settings
+}
+
+// FlagName returns the command-line flag that overrides a config file key, or "" for
+// keys without a flag.
+func FlagName(key string) string {
+	switch key {
+	case "outputs":
+		return "output"
+	case "modules", "languages", "architecture", "update_check":
+		return ""
+	}
+	return strings.ReplaceAll(key, "_", "-")
+}
+
+// AsFile returns the file layout of c with every key present; it is the inverse of Apply.
+func (c *Config) AsFile() *File {
+	return &File{
+		Provider:            &c.Provider,
+		ProviderCommand:     &c.ProviderCommand,
+		AWSRegion:           &c.AWSRegion,
+		GCPProject:          &c.GCPProject,
+		GCPRegion:           &c.GCPRegion,
+		APIKeyCommand:       &c.APIKeyCommand,
+		APIKeyKeychain:      &c.APIKeyKeychain,
+		APIKeyFile:          &c.APIKeyFile,
+		URL:                 &c.APIURL,
+		URLs:                nonNil(c.APIURLs),
+		Gzip:                nonNil(c.Gzip),
+		Model:               &c.Model,
+		MaxFileSize:         &c.MaxFileSize,
+		MaxReviewChars:      &c.MaxReviewChars,
+		ChunkTokens:         &c.ChunkTokens,
+		ComplexityThreshold: &c.ComplexityThreshold,
+		Duplicates:          &c.Duplicates,
+		DuplicateMinTokens:  &c.DuplicateMinTokens,
+		Todos:               &c.Todos,
+		ChurnSince:          &c.ChurnSince,
+		MaxFiles:            &c.MaxFiles,
+		MaxTotalBytes:       &c.MaxTotalBytes,
+		MaxMemory:           &c.MaxMemory,
+		Concurrency:         &c.MaxConcurrency,
+		Timeout:             &c.RequestTimeout,
+		RunTimeout:          &c.RunTimeout,
+		MaxErrors:           &c.MaxErrors,
+		ContinueOnError:     &c.ContinueOnError,
+		RetryFailed:         &c.RetryFailed,
+		Batch:               &c.Batch,
+		PromptCache:         &c.PromptCache,
+		ContextLines:        &c.ContextLines,
+		FullFunctionContext: &c.FullFunctionContext,
+		IncludeTrivial:      &c.IncludeTrivialChanges,
+		BatchPollInterval:   &c.BatchPollInterval,
+		ReportFile:          &c.ReportFile,
+		ReportAppend:        &c.ReportAppend,
+		KeepReports:         &c.KeepReports,
+		AuditLog:            &c.AuditLog,
+		Format:              &c.Format,
+		ReportTemplate:      &c.ReportTemplate,
+		Outputs:             nonNil(c.Outputs),
+		PathStyle:           &c.PathStyle,
+		Quiet:               &c.Quiet,
+		SummaryOnly:         &c.SummaryOnly,
+		Profile:             &c.Profile,
+		Govulncheck:         &c.Govulncheck,
+		RaceLog:             &c.RaceLog,
+		Pprof:               nonNil(c.Pprof),
+		Base:                &c.Base,
+		Context:             nonNil(c.Context),
+		ContextBudget:       &c.ContextBudget,
+		EmbeddingModel:      &c.EmbeddingModel,
+		Rules:               &c.RulesFile,
+		Policy:              &c.PolicyFile,
+		Passes:              &c.Passes,
+		Temperature:         &c.Temperature,
+		Seed:                &c.Seed,
+		Deterministic:       &c.Deterministic,
+		Consensus:           &c.Consensus,
+		ConsensusModels:     nonNil(c.ConsensusModels),
+		TriageModel:         &c.TriageModel,
+		TriageURL:           &c.TriageURL,
+		Notify:              nonNil(c.Notify),
+		SlackWebhook:        &c.SlackWebhook,
+		TeamsWebhook:        &c.TeamsWebhook,
+		NotifyWebhook:       &c.NotifyWebhook,
+		ReportURL:           &c.ReportURL,
+		GitHubCheck:         &c.GitHubCheck,
+		GitHubCheckName:     &c.GitHubCheckName,
+		PRComments:          &c.PRComments,
+		CreateTickets:       &c.CreateTickets,
+		TicketSeverity:      &c.TicketSeverity,
+		TicketConsolidate:   &c.TicketConsolidate,
+		JiraURL:             &c.JiraURL,
+		JiraProject:         &c.JiraProject,
+		JiraIssueType:       &c.JiraIssueType,
+		JiraUser:            &c.JiraUser,
+		Exclude:             nonNil(c.Exclude),
+		GroupByOwner:        &c.GroupByOwner,
+		FollowSymlinks:      &c.FollowSymlinks,
+		IncludeSubmodules:   &c.IncludeSubmodules,
+		ScanWorkers:         &c.ScanWorkers,
+		UpdateCheck:         &c.UpdateCheck,
+		Modules:             nonNilMap(c.Modules),
+		Languages:           nonNilMap(c.Languages),
+		Architecture:        nonNil(c.Architecture),
+		Routes:              nonNil(c.Routes),
+		Models:              nonNilMap(c.Models),
+	}
+}
+
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
+
+func nonNilMap[V any](m map[string]V) map[string]V {
+	if m == nil {
+		return map[string]V{}
+	}
+	return m
+}
@@ -0,0 +1,57 @@
+package config
+
+// Starter is the commented config file written by "aireview config init". Every key
+// mirrors a command-line flag; uncomment the ones to change.
+const Starter = `# aireview configuration. Keys mirror the command-line flags, which take precedence.
+# Run "aireview config show" to see the effective settings and where they come from.
+
+# Model backend: openai (any OpenAI-compatible endpoint), openrouter, litellm,
+# bedrock, vertex or exec.
+provider: openai
+
+# Endpoints (url, urls, triage_url, jira_url) and commands (api_key_command,
+# provider_command) decide where credentials go and what runs, so a project's config
+# file cannot set them: use flags, AIREVIEW_* variables or the user config file
+# (aireview/config.yaml in the user config directory).
+
+model: devstral-small-2507-mlx
+
+# API keys are best kept out of this file: set AIREVIEW_API_KEY or read the key from the
+# OS keychain.
+# api_key_keychain: aireview/me
+
+# Limits
+concurrency: 10
+timeout: 12m
+# run_timeout: 30m
+# max_size: 10485760
+# max_files: 5000
+# max_total_bytes: 104857600
+# max_errors: 0
+# continue_on_error: false
+# retry_failed: true
+
+# Files left out of the review (globs relative to the project)
+# exclude:
+#   - "**/mocks/**"
+#   - "*_gen.go"
+
+# Reports
+# format: text
+# path_style: relative
+# report_file: ./reports/review-{{date}}.md
+# keep_reports: 10
+# outputs:
+#   - sarif=review.sarif
+
+# Review quality
+# rules: .aireview/rules.md
+# passes: 1
+# consensus: false
+# consensus_models: [gpt-4o, claude-3-5-sonnet]
+
+# Notifications (webhook URLs can also come from AIREVIEW_SLACK_WEBHOOK and
+# AIREVIEW_TEAMS_WEBHOOK)
+# notify: [slack]
+# report_url: https://ci.example.com/reports/latest
+`
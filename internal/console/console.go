@@ -0,0 +1,18 @@
+// Package console prepares the terminal for output with ANSI escape sequences and
+// non-ASCII text, which Windows consoles only render once enabled.
+package console
+
+import "os"
+
+// Enable reports whether f is a terminal that renders ANSI escape sequences. On
+// Windows it switches the console to UTF-8 and turns on virtual terminal processing
+// first; redirected output is never a terminal.
+func Enable(f *os.File) bool {
+	return enable(f)
+}
+
+// IsTerminal reports whether f is an interactive terminal rather than a file or pipe.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package console
+
+import "os"
+
+func enable(f *os.File) bool {
+	return IsTerminal(f) && os.Getenv("TERM") != "dumb"
+}
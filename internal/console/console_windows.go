@@ -0,0 +1,34 @@
+package console
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	enableVirtualTerminalProcessing = 0x0004
+	utf8CodePage                    = 65001
+)
+
+var (
+	kernel32               = syscall.NewLazyDLL("kernel32.dll")
+	procGetConsoleMode     = kernel32.NewProc("GetConsoleMode")
+	procSetConsoleMode     = kernel32.NewProc("SetConsoleMode")
+	procSetConsoleOutputCP = kernel32.NewProc("SetConsoleOutputCP")
+)
+
+// enable turns on escape sequence processing, available since Windows 10. Terminals
+// such as mintty are pipes to Windows and are treated as not rendering escapes.
+func enable(f *os.File) bool {
+	var mode uint32
+	if r, _, _ := procGetConsoleMode.Call(f.Fd(), uintptr(unsafe.Pointer(&mode))); r == 0 {
+		return false
+	}
+	procSetConsoleOutputCP.Call(utf8CodePage)
+	if mode&enableVirtualTerminalProcessing != 0 {
+		return true
+	}
+	r, _, _ := procSetConsoleMode.Call(f.Fd(), uintptr(mode|enableVirtualTerminalProcessing))
+	return r != 0
+}
@@ -0,0 +1,144 @@
+// Package experiment holds the results of reviewing the same files with several
+// models and prompts, and renders their comparison.
+package experiment
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/disconnekt/goreview/internal/findings"
+	"github.com/disconnekt/goreview/internal/history"
+)
+
+// nearbyLines is how close two findings of a file must be to count as the same issue
+// when variants are compared; their wording differs between models and prompts.
+const nearbyLines = 2
+
+// Variant is the outcome of one model and prompt combination.
+type Variant struct {
+	Model string `json:"model"`
+	// Prompt is the profile name or prompt file given on the command line, PromptID
+	// the resulting reviewer.Service.PromptID.
+	Prompt   string `json:"prompt"`
+	PromptID string `json:"prompt_id"`
+	// Files counts the reviewed files, Failed those whose review failed.
+	Files            int                `json:"files"`
+	Failed           int                `json:"failed"`
+	Findings         []findings.Finding `json:"findings"`
+	Requests         int                `json:"requests"`
+	PromptTokens     int                `json:"prompt_tokens"`
+	CompletionTokens int                `json:"completion_tokens"`
+	// Cost estimates the price in USD of the tokens from the model's prices; Priced is
+	// false when the model has none.
+	Cost   float64 `json:"cost_usd,omitempty"`
+	Priced bool    `json:"priced"`
+	// Latency sums the latency of the variant's requests.
+	Latency time.Duration `json:"latency_ns"`
+	// Precision is the recorded feedback on the model with this prompt, if any.
+	Precision *history.Precision `json:"precision,omitempty"`
+}
+
+// Name labels the variant in reports.
+func (v Variant) Name() string {
+	return v.Model + " / " + v.Prompt
+}
+
+// Report is a finished experiment.
+type Report struct {
+	ID        string    `json:"id"`
+	StartedAt time.Time `json:"started_at"`
+	// Files lists the sampled files, relative to the project.
+	Files    []string  `json:"files"`
+	Variants []Variant `json:"variants"`
+}
+
+// Shared counts the findings of variant i that another variant also reported at the
+// same place of the file, a rough signal that the finding is real.
+func (r Report) Shared(i int) int {
+	n := 0
+	for _, f := range r.Variants[i].Findings {
+		if r.reportedElsewhere(i, f) {
+			n++
+		}
+	}
+	return n
+}
+
+func (r Report) reportedElsewhere(i int, f findings.Finding) bool {
+	for j, v := range r.Variants {
+		if j == i {
+			continue
+		}
+		for _, g := range v.Findings {
+			if g.File == f.File && abs(g.Line-f.Line) <= nearbyLines {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// WriteMarkdown renders the comparison as a Markdown table, one row per variant.
+func WriteMarkdown(w io.Writer, r Report) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Experiment %s\n\n", r.ID)
+	fmt.Fprintf(&b, "%d variants reviewed the same %d files.\n\n", len(r.Variants), len(r.Files))
+	b.WriteString("| Variant | Prompt ID | Files (failed) | Findings | By severity | Shared | Prompt tokens | Completion tokens | Est. cost | Avg latency | Precision |\n")
+	b.WriteString("|---|---|---|---|---|---|---|---|---|---|---|\n")
+	for i, v := range r.Variants {
+		avg := time.Duration(0)
+		if v.Requests > 0 {
+			avg = v.Latency / time.Duration(v.Requests)
+		}
+		precision := "-"
+		if p := v.Precision; p != nil {
+			precision = fmt.Sprintf("%.0f%% of %d", p.Rate()*100, p.Useful+p.Wrong)
+		}
+		cost := "-"
+		if v.Priced {
+			cost = fmt.Sprintf("$%.4f", v.Cost)
+		}
+		fmt.Fprintf(&b, "| %s | `%s` | %d (%d) | %d | %s | %d | %d | %d | %s | %s | %s |\n",
+			v.Name(), v.PromptID, v.Files, v.Failed, len(v.Findings), bySeverity(v.Findings), r.Shared(i),
+			v.PromptTokens, v.CompletionTokens, cost, avg.Round(time.Millisecond), precision)
+	}
+	b.WriteString("\nShared counts findings another variant also reported within two lines. The cost is " +
+		"estimated from the model's prices, \"-\" when it has none. Precision comes from verdicts recorded " +
+		"with `aireview feedback`.\n\n## Files\n\n")
+	for _, f := range r.Files {
+		fmt.Fprintf(&b, "- `%s`\n", f)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON renders the report, including every finding, as indented JSON.
+func WriteJSON(w io.Writer, r Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+func bySeverity(list []findings.Finding) string {
+	counts := findings.CountBySeverity(list)
+	var parts []string
+	for _, sev := range findings.Severities {
+		if counts[sev] > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", sev, counts[sev]))
+		}
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, ", ")
+}
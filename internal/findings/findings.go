@@ -0,0 +1,152 @@
+package findings
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Severity ranks how important a finding is.
+type Severity string
+
+const (
+	SeverityCritical Severity = "critical"
+	SeverityHigh     Severity = "high"
+	SeverityMedium   Severity = "medium"
+	SeverityLow      Severity = "low"
+	SeverityInfo     Severity = "info"
+)
+
+// Severities lists all severities from most to least important.
+var Severities = []Severity{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow, SeverityInfo}
+
+// Rank returns a comparable weight for the severity; higher is more important.
+func (s Severity) Rank() int {
+	switch s {
+	case SeverityCritical:
+		return 5
+	case SeverityHigh:
+		return 4
+	case SeverityMedium:
+		return 3
+	case SeverityLow:
+		return 2
+	case SeverityInfo:
+		return 1
+	}
+	return 0
+}
+
+// ParseSeverity converts a case-insensitive severity name.
+func ParseSeverity(s string) (Severity, error) {
+	sev := Severity(strings.ToLower(strings.TrimSpace(s)))
+	if sev.Rank() == 0 {
+		return "", fmt.Errorf("unknown severity %q (expected critical, high, medium, low or info)", s)
+	}
+	return sev, nil
+}
+
+// Finding is a single issue reported by a review.
+type Finding struct {
+	File string `json:"file,omitempty"`
+	// Module is the path of the Go module the file belongs to.
+	Module   string   `json:"module,omitempty"`
+	Line     int      `json:"line,omitempty"`
+	EndLine  int      `json:"end_line,omitempty"`
+	Severity Severity `json:"severity"`
+	RuleID   string   `json:"rule_id,omitempty"`
+	Message  string   `json:"message"`
+	// Models lists the models that reported the finding (consensus mode only).
+	Models []string `json:"models,omitempty"`
+	// Owners are the CODEOWNERS owners of the file.
+	Owners []string `json:"owners,omitempty"`
+}
+
+// FormatInstructions tells the model how to lay out findings so Parse can read them.
+const FormatInstructions = `Report each finding as a separate top-level bullet in exactly this format:
+	- [SEVERITY] Line N: description and suggested fix
+	where SEVERITY is one of CRITICAL, HIGH, MEDIUM, LOW or INFO and N is the line number in the provided code
+	(use "Lines N-M" for a range, omit the line part when the finding is not tied to specific lines).`
+
+var (
+	bulletPattern = regexp.MustCompile(`(?i)^\s*(?:[-*+]|\d+[.)])\s+\**\[?(critical|high|medium|low|info)\]?\**:?\s*(.*)$`)
+	linePattern   = regexp.MustCompile(`(?i)^\(?(?:lines?|l)\s*(\d+)(?:\s*[-–]\s*(\d+))?\)?\s*[:\-–]?\s*`)
+	rulePattern   = regexp.MustCompile(`\[([A-Z][A-Z0-9_-]*)\]`)
+)
+
+// Parse extracts findings from review text produced with FormatInstructions.
+// Indented lines following a finding bullet are appended to its message.
+func Parse(review string) []Finding {
+	var out []Finding
+	for _, line := range strings.Split(review, "\n") {
+		m := bulletPattern.FindStringSubmatch(line)
+		if m == nil {
+			if len(out) > 0 && strings.TrimSpace(line) != "" && line != strings.TrimLeft(line, " \t") {
+				out[len(out)-1].Message += " " + strings.TrimSpace(line)
+			}
+			continue
+		}
+
+		f := Finding{Severity: Severity(strings.ToLower(m[1]))}
+		rest := strings.TrimSpace(m[2])
+		if lm := linePattern.FindStringSubmatch(rest); lm != nil {
+			f.Line, _ = strconv.Atoi(lm[1])
+			if lm[2] != "" {
+				f.EndLine, _ = strconv.Atoi(lm[2])
+			}
+			rest = rest[len(lm[0]):]
+		}
+		if rm := rulePattern.FindStringSubmatch(rest); rm != nil {
+			f.RuleID = rm[1]
+		}
+		f.Message = strings.TrimSpace(rest)
+		out = append(out, f)
+	}
+	return out
+}
+
+// Fingerprint identifies a finding independently of its exact line so it stays stable
+// across runs while the surrounding code moves.
+func (f Finding) Fingerprint() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s", f.File, f.RuleID, normalizeMessage(f.Message))
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// Location renders "file:line" (or just the file) for display.
+func (f Finding) Location() string {
+	if f.Line > 0 {
+		return fmt.Sprintf("%s:%d", f.File, f.Line)
+	}
+	return f.File
+}
+
+func normalizeMessage(msg string) string {
+	return strings.Join(strings.Fields(strings.ToLower(msg)), " ")
+}
+
+// CountBySeverity tallies findings per severity.
+func CountBySeverity(list []Finding) map[Severity]int {
+	counts := make(map[Severity]int, len(Severities))
+	for _, f := range list {
+		counts[f.Severity]++
+	}
+	return counts
+}
+
+// Sort orders findings by file, then line, then descending severity.
+func Sort(list []Finding) {
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].File != list[j].File {
+			return list[i].File < list[j].File
+		}
+		if list[i].Line != list[j].Line {
+			return list[i].Line < list[j].Line
+		}
+		return list[i].Severity.Rank() > list[j].Severity.Rank()
+	})
+}
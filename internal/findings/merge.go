@@ -0,0 +1,149 @@
+package findings
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// lineTolerance is how far apart two findings' lines may be and still describe the same issue.
+const lineTolerance = 3
+
+// similarityThreshold is the minimum word overlap for two messages to be considered the same finding.
+const similarityThreshold = 0.3
+
+// Merge combines the findings reported by several models. Findings that describe the same
+// issue are collapsed into one entry whose Models lists every model that reported it; the
+// highest severity among duplicates wins.
+func Merge(byModel map[string][]Finding) []Finding {
+	models := make([]string, 0, len(byModel))
+	for m := range byModel {
+		models = append(models, m)
+	}
+	sort.Strings(models)
+
+	var merged []Finding
+	for _, model := range models {
+		for _, f := range byModel[model] {
+			if i := matchIndex(merged, f); i >= 0 {
+				if !contains(merged[i].Models, model) {
+					merged[i].Models = append(merged[i].Models, model)
+				}
+				if f.Severity.Rank() > merged[i].Severity.Rank() {
+					merged[i].Severity = f.Severity
+				}
+				continue
+			}
+			f.Models = []string{model}
+			merged = append(merged, f)
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		if len(merged[i].Models) != len(merged[j].Models) {
+			return len(merged[i].Models) > len(merged[j].Models)
+		}
+		return merged[i].Severity.Rank() > merged[j].Severity.Rank()
+	})
+	return merged
+}
+
+func matchIndex(list []Finding, f Finding) int {
+	for i, existing := range list {
+		if existing.File != f.File {
+			continue
+		}
+		if existing.Line > 0 && f.Line > 0 && abs(existing.Line-f.Line) > lineTolerance {
+			continue
+		}
+		if existing.RuleID != "" && existing.RuleID == f.RuleID {
+			return i
+		}
+		if similarity(existing.Message, f.Message) >= similarityThreshold {
+			return i
+		}
+	}
+	return -1
+}
+
+// similarity is the Jaccard index of the significant words in two messages.
+func similarity(a, b string) float64 {
+	wa, wb := words(a), words(b)
+	if len(wa) == 0 || len(wb) == 0 {
+		return 0
+	}
+	inter := 0
+	for w := range wa {
+		if wb[w] {
+			inter++
+		}
+	}
+	return float64(inter) / float64(len(wa)+len(wb)-inter)
+}
+
+func words(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_')
+	}) {
+		if len(w) > 3 {
+			set[w] = true
+		}
+	}
+	return set
+}
+
+// ConsensusMarkdown renders merged findings grouped by agreement level.
+func ConsensusMarkdown(merged []Finding, totalModels int) string {
+	if len(merged) == 0 {
+		return "No significant issues found by any model."
+	}
+	var b strings.Builder
+	section := ""
+	for _, f := range merged {
+		want := "Reported by a single model (lower confidence)"
+		if len(f.Models) > 1 {
+			want = "Reported by multiple models (higher confidence)"
+		}
+		if want != section {
+			if section != "" {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "### %s\n\n", want)
+			section = want
+		}
+		b.WriteString(FormatBullet(f))
+		fmt.Fprintf(&b, " _(%d/%d: %s)_\n", len(f.Models), totalModels, strings.Join(f.Models, ", "))
+	}
+	return b.String()
+}
+
+// FormatBullet renders a finding in the same layout the model is asked to produce.
+func FormatBullet(f Finding) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "- [%s] ", strings.ToUpper(string(f.Severity)))
+	switch {
+	case f.Line > 0 && f.EndLine > f.Line:
+		fmt.Fprintf(&b, "Lines %d-%d: ", f.Line, f.EndLine)
+	case f.Line > 0:
+		fmt.Fprintf(&b, "Line %d: ", f.Line)
+	}
+	b.WriteString(f.Message)
+	return b.String()
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,114 @@
+// Package frameworks detects the libraries a Go file builds on from its imports and
+// holds review guidance for each, so a review checks the pitfalls of those libraries.
+package frameworks
+
+import (
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// Framework is the review guidance for one library.
+type Framework struct {
+	Name string
+	// Imports are the import paths of the library; their subpackages match too.
+	Imports []string
+	// Prompt is added to the review prompt of files importing the library.
+	Prompt string
+}
+
+// builtins are checked in order, which is also the order of the prompt sections.
+var builtins = []Framework{
+	{
+		Name:    "gin",
+		Imports: []string{"github.com/gin-gonic/gin"},
+		Prompt: `gin: *gin.Context must not be used after the handler returns or shared with goroutines
+without c.Copy(); use c.Request.Context() for cancellation. Check that handlers return after
+c.AbortWithStatus or an error response, that ShouldBind errors are handled, and that binding
+structs validate their input.`,
+	},
+	{
+		Name:    "echo",
+		Imports: []string{"github.com/labstack/echo"},
+		Prompt: `echo: echo.Context is pooled and must not be kept after the handler returns or used from
+goroutines; use c.Request().Context() for cancellation. Check that handler errors are returned
+rather than swallowed, that c.Bind errors are handled and validated, and that middleware calls next.`,
+	},
+	{
+		Name:    "grpc",
+		Imports: []string{"google.golang.org/grpc"},
+		Prompt: `gRPC: errors returned to clients should carry status codes (status.Error) instead of plain
+errors, and must not leak internals. Check that the request context is honored in long work,
+that client connections are reused and closed, that calls have deadlines, and that streams
+handle io.EOF and are closed.`,
+	},
+	{
+		Name:    "gorm",
+		Imports: []string{"gorm.io/gorm", "github.com/jinzhu/gorm"},
+		Prompt: `GORM: look for N+1 queries from loading associations in loops instead of Preload or
+Joins, ignored result.Error, queries built with string concatenation instead of placeholders,
+Save overwriting all columns, updates or deletes without conditions, and missing
+WithContext on queries in request paths.`,
+	},
+	{
+		Name:    "cobra",
+		Imports: []string{"github.com/spf13/cobra"},
+		Prompt: `cobra: commands should use RunE and return errors instead of calling os.Exit or
+log.Fatal, validate arguments with Args, read flags through the command rather than
+package-level state shared across commands, and print through cmd.OutOrStdout and
+cmd.ErrOrStderr.`,
+	},
+}
+
+// Detect returns the frameworks imported by Go source. Source that does not parse has
+// no frameworks.
+func Detect(src string) []Framework {
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ImportsOnly)
+	if err != nil {
+		return nil
+	}
+	var found []Framework
+	for _, fw := range builtins {
+		for _, spec := range file.Imports {
+			path, err := strconv.Unquote(spec.Path.Value)
+			if err == nil && fw.matches(path) {
+				found = append(found, fw)
+				break
+			}
+		}
+	}
+	return found
+}
+
+func (fw Framework) matches(path string) bool {
+	for _, p := range fw.Imports {
+		if path == p || strings.HasPrefix(path, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// PromptSection returns the guidance for the frameworks a file imports, or "" when
+// there are none.
+func PromptSection(list []Framework) string {
+	if len(list) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("The file uses these frameworks; also check their specific pitfalls:")
+	for _, fw := range list {
+		b.WriteString("\n\n" + fw.Prompt)
+	}
+	return b.String()
+}
+
+// Names lists the built-in framework names.
+func Names() []string {
+	names := make([]string, len(builtins))
+	for i, fw := range builtins {
+		names[i] = fw.Name
+	}
+	return names
+}
@@ -0,0 +1,94 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// maxAnnotationsPerRequest is GitHub's limit on annotations per check run update.
+const maxAnnotationsPerRequest = 50
+
+// Annotation is a line-level comment attached to a check run.
+type Annotation struct {
+	Path            string `json:"path"`
+	StartLine       int    `json:"start_line"`
+	EndLine         int    `json:"end_line"`
+	AnnotationLevel string `json:"annotation_level"`
+	Message         string `json:"message"`
+	Title           string `json:"title,omitempty"`
+}
+
+// CheckOutput is the title, summary and annotations shown on the check run page.
+type CheckOutput struct {
+	Title       string       `json:"title"`
+	Summary     string       `json:"summary"`
+	Text        string       `json:"text,omitempty"`
+	Annotations []Annotation `json:"annotations,omitempty"`
+}
+
+type checkRunRequest struct {
+	Name        string       `json:"name,omitempty"`
+	HeadSHA     string       `json:"head_sha,omitempty"`
+	Status      string       `json:"status,omitempty"`
+	Conclusion  string       `json:"conclusion,omitempty"`
+	StartedAt   *time.Time   `json:"started_at,omitempty"`
+	CompletedAt *time.Time   `json:"completed_at,omitempty"`
+	Output      *CheckOutput `json:"output,omitempty"`
+}
+
+type checkRunResponse struct {
+	ID      int64  `json:"id"`
+	HTMLURL string `json:"html_url"`
+}
+
+// CheckRun is an in-progress check run created by CreateCheckRun.
+type CheckRun struct {
+	ID      int64
+	HTMLURL string
+	client  *Client
+}
+
+// CreateCheckRun starts a check run in the in_progress state.
+func (c *Client) CreateCheckRun(ctx context.Context, name, headSHA string) (*CheckRun, error) {
+	now := time.Now().UTC()
+	var resp checkRunResponse
+	err := c.do(ctx, http.MethodPost, c.repoPath("/check-runs"), checkRunRequest{
+		Name:      name,
+		HeadSHA:   headSHA,
+		Status:    "in_progress",
+		StartedAt: &now,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &CheckRun{ID: resp.ID, HTMLURL: resp.HTMLURL, client: c}, nil
+}
+
+// Complete marks the check run completed. Annotations beyond the per-request limit are
+// sent in additional updates, as the API appends annotations across updates.
+func (r *CheckRun) Complete(ctx context.Context, conclusion string, output CheckOutput) error {
+	annotations := output.Annotations
+	for len(annotations) > maxAnnotationsPerRequest {
+		batch := output
+		batch.Annotations = annotations[:maxAnnotationsPerRequest]
+		if err := r.update(ctx, checkRunRequest{Output: &batch}); err != nil {
+			return err
+		}
+		annotations = annotations[maxAnnotationsPerRequest:]
+	}
+
+	now := time.Now().UTC()
+	final := output
+	final.Annotations = annotations
+	return r.update(ctx, checkRunRequest{
+		Status:      "completed",
+		Conclusion:  conclusion,
+		CompletedAt: &now,
+		Output:      &final,
+	})
+}
+
+func (r *CheckRun) update(ctx context.Context, req checkRunRequest) error {
+	return r.client.do(ctx, http.MethodPatch, r.client.repoPath("/check-runs/%d", r.ID), req, nil)
+}
@@ -0,0 +1,141 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is used when GITHUB_API_URL is not set (GitHub Enterprise sets it).
+const DefaultAPIURL = "https://api.github.com"
+
+// Client is a minimal GitHub REST API client scoped to one repository.
+type Client struct {
+	APIURL string
+	Token  string
+	Owner  string
+	Repo   string
+	client *http.Client
+}
+
+// NewClient creates a client for "owner/name".
+func NewClient(apiURL, token, repository string) (*Client, error) {
+	owner, repo, ok := strings.Cut(repository, "/")
+	if !ok || owner == "" || repo == "" {
+		return nil, fmt.Errorf("invalid repository %q: expected owner/name", repository)
+	}
+	if token == "" {
+		return nil, errors.New("a GitHub token is required (set GITHUB_TOKEN)")
+	}
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	return &Client{
+		APIURL: strings.TrimSuffix(apiURL, "/"),
+		Token:  token,
+		Owner:  owner,
+		Repo:   repo,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// NewClientFromEnv builds a client from the variables GitHub Actions provides.
+func NewClientFromEnv(token string) (*Client, error) {
+	if token == "" {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+	repository := os.Getenv("GITHUB_REPOSITORY")
+	if repository == "" {
+		return nil, errors.New("GITHUB_REPOSITORY is not set")
+	}
+	return NewClient(os.Getenv("GITHUB_API_URL"), token, repository)
+}
+
+// repoPath returns the API path for a repository-scoped resource.
+func (c *Client) repoPath(format string, args ...interface{}) string {
+	return fmt.Sprintf("%s/repos/%s/%s", c.APIURL, c.Owner, c.Repo) + fmt.Sprintf(format, args...)
+}
+
+// do sends a JSON request and decodes a JSON response into out (if non-nil).
+func (c *Client) do(ctx context.Context, method, url string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("User-Agent", "aireview/1.0")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call GitHub API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("GitHub API %s %s returned %d: %s", method, url, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode GitHub response: %w", err)
+	}
+	return nil
+}
+
+// HeadSHA returns the commit to attach statuses to. For pull_request events this is
+// the PR head rather than the synthetic merge commit in GITHUB_SHA.
+func HeadSHA() string {
+	if ev, err := LoadEvent(); err == nil && ev.PullRequest != nil && ev.PullRequest.Head.SHA != "" {
+		return ev.PullRequest.Head.SHA
+	}
+	return os.Getenv("GITHUB_SHA")
+}
+
+// Event is the subset of the workflow event payload goreview uses.
+type Event struct {
+	PullRequest *struct {
+		Number int `json:"number"`
+		Head   struct {
+			SHA string `json:"sha"`
+		} `json:"head"`
+	} `json:"pull_request"`
+}
+
+// LoadEvent reads the payload referenced by GITHUB_EVENT_PATH.
+func LoadEvent() (*Event, error) {
+	path := os.Getenv("GITHUB_EVENT_PATH")
+	if path == "" {
+		return nil, errors.New("GITHUB_EVENT_PATH is not set")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read event payload: %w", err)
+	}
+	var ev Event
+	if err := json.Unmarshal(data, &ev); err != nil {
+		return nil, fmt.Errorf("failed to parse event payload: %w", err)
+	}
+	return &ev, nil
+}
@@ -0,0 +1,104 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/findings"
+	"github.com/disconnekt/goreview/internal/tickets"
+)
+
+// IssueLabel is put on every issue goreview opens; existing tickets are looked up by it.
+const IssueLabel = "aireview"
+
+// Issues implements tickets.Tracker and tickets.Consolidator with repository issues.
+// Each issue body carries hidden markers with the fingerprints of its findings.
+type Issues struct {
+	client *Client
+}
+
+func (c *Client) Issues() *Issues {
+	return &Issues{client: c}
+}
+
+type issue struct {
+	Number  int    `json:"number"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+}
+
+// Existing returns the fingerprints marked in open or closed goreview issues, so a
+// closed ticket is not filed again.
+func (i *Issues) Existing(ctx context.Context, fingerprints []string) (map[string]bool, error) {
+	found := make(map[string]bool)
+	for page := 1; ; page++ {
+		var batch []issue
+		url := i.client.repoPath("/issues?labels=%s&state=all&per_page=100&page=%d", IssueLabel, page)
+		if err := i.client.do(ctx, http.MethodGet, url, nil, &batch); err != nil {
+			return nil, err
+		}
+		for _, is := range batch {
+			for _, fp := range tickets.ParseMarkers(is.Body) {
+				found[fp] = true
+			}
+		}
+		if len(batch) < 100 {
+			return found, nil
+		}
+	}
+}
+
+// Create opens an issue for one finding and returns its URL.
+func (i *Issues) Create(ctx context.Context, t tickets.Ticket) (string, error) {
+	return i.create(ctx, t.Title(), findingSection(t, "###")+"\n"+footer, t.Finding.Severity)
+}
+
+// CreateConsolidated opens one issue listing several findings and returns its URL.
+func (i *Issues) CreateConsolidated(ctx context.Context, ts []tickets.Ticket) (string, error) {
+	var b strings.Builder
+	top := ts[0].Finding.Severity
+	files := make(map[string]bool)
+	for _, t := range ts {
+		if t.Finding.Severity.Rank() > top.Rank() {
+			top = t.Finding.Severity
+		}
+		files[t.Finding.File] = true
+		fmt.Fprintf(&b, "### [%s] %s\n\n%s\n", strings.ToUpper(string(t.Finding.Severity)), t.Finding.Location(),
+			findingSection(t, "####"))
+	}
+	title := fmt.Sprintf("aireview: %d findings in %d files", len(ts), len(files))
+	return i.create(ctx, title, b.String()+footer, top)
+}
+
+const footer = "_Reported by aireview. Keep the hidden markers in this description so later runs do not file these findings again._\n"
+
+// findingSection renders a finding, its code and its marker in Markdown.
+func findingSection(t tickets.Ticket, heading string) string {
+	f := t.Finding
+	var b strings.Builder
+	fmt.Fprintf(&b, "**Severity:** %s  \n**Location:** `%s`  \n", f.Severity, f.Location())
+	if f.RuleID != "" {
+		fmt.Fprintf(&b, "**Rule:** %s  \n", f.RuleID)
+	}
+	fmt.Fprintf(&b, "\n%s Finding and suggested fix\n\n%s\n", heading, f.Message)
+	if t.Excerpt != "" {
+		fmt.Fprintf(&b, "\n%s Code\n\n```go\n%s```\n", heading, t.Excerpt)
+	}
+	fmt.Fprintf(&b, "\n%s\n", tickets.Marker(f.Fingerprint()))
+	return b.String()
+}
+
+func (i *Issues) create(ctx context.Context, title, body string, severity findings.Severity) (string, error) {
+	var created issue
+	err := i.client.do(ctx, http.MethodPost, i.client.repoPath("/issues"), map[string]interface{}{
+		"title":  title,
+		"body":   body,
+		"labels": []string{IssueLabel, "severity: " + string(severity)},
+	}, &created)
+	if err != nil {
+		return "", err
+	}
+	return created.HTMLURL, nil
+}
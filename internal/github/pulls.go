@@ -0,0 +1,152 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/prcomment"
+)
+
+// PullRequestNumber returns the PR number of the current workflow run, from the event
+// payload or from a refs/pull/N/merge GITHUB_REF.
+func PullRequestNumber() (int, error) {
+	if ev, err := LoadEvent(); err == nil && ev.PullRequest != nil && ev.PullRequest.Number > 0 {
+		return ev.PullRequest.Number, nil
+	}
+	ref := os.Getenv("GITHUB_REF")
+	if rest, ok := strings.CutPrefix(ref, "refs/pull/"); ok {
+		if n, err := strconv.Atoi(strings.SplitN(rest, "/", 2)[0]); err == nil {
+			return n, nil
+		}
+	}
+	return 0, errors.New("not running for a pull request (no pull_request event or refs/pull ref)")
+}
+
+// PullRequestComments implements prcomment.Platform with pull request review comments
+// and a summary issue comment.
+type PullRequestComments struct {
+	client   *Client
+	number   int
+	commitID string
+}
+
+func (c *Client) PullRequestComments(number int, commitID string) *PullRequestComments {
+	return &PullRequestComments{client: c, number: number, commitID: commitID}
+}
+
+type reviewComment struct {
+	ID     int64  `json:"id"`
+	NodeID string `json:"node_id"`
+	Body   string `json:"body"`
+	Path   string `json:"path"`
+	Line   int    `json:"line"`
+}
+
+func (p *PullRequestComments) ListComments(ctx context.Context) ([]prcomment.Existing, error) {
+	var out []prcomment.Existing
+	for page := 1; ; page++ {
+		var batch []reviewComment
+		url := p.client.repoPath("/pulls/%d/comments?per_page=100&page=%d", p.number, page)
+		if err := p.client.do(ctx, http.MethodGet, url, nil, &batch); err != nil {
+			return nil, err
+		}
+		for _, rc := range batch {
+			fp, path, line, ok := prcomment.ParseMarker(rc.Body)
+			if !ok {
+				continue
+			}
+			out = append(out, prcomment.Existing{
+				ID:          strconv.FormatInt(rc.ID, 10) + "/" + rc.NodeID,
+				Path:        path,
+				Line:        line,
+				Fingerprint: fp,
+				Body:        rc.Body,
+				Outdated:    prcomment.IsOutdated(rc.Body),
+			})
+		}
+		if len(batch) < 100 {
+			return out, nil
+		}
+	}
+}
+
+func (p *PullRequestComments) CreateComment(ctx context.Context, c prcomment.Comment) error {
+	return p.client.do(ctx, http.MethodPost, p.client.repoPath("/pulls/%d/comments", p.number), map[string]interface{}{
+		"body":      c.Body,
+		"commit_id": p.commitID,
+		"path":      c.Path,
+		"line":      c.Line,
+		"side":      "RIGHT",
+	}, nil)
+}
+
+func (p *PullRequestComments) UpdateComment(ctx context.Context, e prcomment.Existing, body string) error {
+	id, _, _ := strings.Cut(e.ID, "/")
+	return p.client.do(ctx, http.MethodPatch, p.client.repoPath("/pulls/comments/%s", id), map[string]string{"body": body}, nil)
+}
+
+// ResolveComment marks the comment outdated and minimizes it so it collapses in the UI.
+func (p *PullRequestComments) ResolveComment(ctx context.Context, e prcomment.Existing) error {
+	body := prcomment.OutdatedMarker + "\n~~This finding is no longer reported.~~\n\n" + e.Body
+	if err := p.UpdateComment(ctx, e, body); err != nil {
+		return err
+	}
+	_, nodeID, _ := strings.Cut(e.ID, "/")
+	if nodeID == "" {
+		return nil
+	}
+	query := `mutation($id: ID!) { minimizeComment(input: {subjectId: $id, classifier: OUTDATED}) { clientMutationId } }`
+	return p.client.graphQL(ctx, query, map[string]interface{}{"id": nodeID})
+}
+
+type issueComment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+func (p *PullRequestComments) UpsertSummary(ctx context.Context, body string) error {
+	for page := 1; ; page++ {
+		var batch []issueComment
+		url := p.client.repoPath("/issues/%d/comments?per_page=100&page=%d", p.number, page)
+		if err := p.client.do(ctx, http.MethodGet, url, nil, &batch); err != nil {
+			return err
+		}
+		for _, ic := range batch {
+			if strings.Contains(ic.Body, prcomment.SummaryMarker) {
+				if ic.Body == body {
+					return nil
+				}
+				return p.client.do(ctx, http.MethodPatch, p.client.repoPath("/issues/comments/%d", ic.ID), map[string]string{"body": body}, nil)
+			}
+		}
+		if len(batch) < 100 {
+			break
+		}
+	}
+	return p.client.do(ctx, http.MethodPost, p.client.repoPath("/issues/%d/comments", p.number), map[string]string{"body": body}, nil)
+}
+
+// graphQL runs a GraphQL mutation or query, discarding the data.
+func (c *Client) graphQL(ctx context.Context, query string, variables map[string]interface{}) error {
+	endpoint := c.APIURL + "/graphql"
+	if base, ok := strings.CutSuffix(c.APIURL, "/api/v3"); ok {
+		endpoint = base + "/api/graphql"
+	}
+	var resp struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := c.do(ctx, http.MethodPost, endpoint, map[string]interface{}{"query": query, "variables": variables}, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf("GraphQL error: %s", resp.Errors[0].Message)
+	}
+	return nil
+}
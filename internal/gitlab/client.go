@@ -0,0 +1,91 @@
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Client is a minimal GitLab REST (v4) API client scoped to one project.
+type Client struct {
+	APIURL  string
+	Token   string
+	Project string
+	client  *http.Client
+}
+
+// NewClientFromEnv builds a client from the variables GitLab CI provides. The token must
+// be a project or personal access token with api scope (CI_JOB_TOKEN cannot post notes).
+func NewClientFromEnv(token string) (*Client, error) {
+	if token == "" {
+		token = os.Getenv("GITLAB_TOKEN")
+	}
+	if token == "" {
+		return nil, errors.New("a GitLab token is required (set GITLAB_TOKEN)")
+	}
+	apiURL := os.Getenv("CI_API_V4_URL")
+	if apiURL == "" {
+		apiURL = "https://gitlab.com/api/v4"
+	}
+	project := os.Getenv("CI_PROJECT_ID")
+	if project == "" {
+		return nil, errors.New("CI_PROJECT_ID is not set")
+	}
+	return &Client{
+		APIURL:  strings.TrimSuffix(apiURL, "/"),
+		Token:   token,
+		Project: project,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// projectPath returns the API URL for a project-scoped resource.
+func (c *Client) projectPath(format string, args ...interface{}) string {
+	return fmt.Sprintf("%s/projects/%s", c.APIURL, url.PathEscape(c.Project)) + fmt.Sprintf(format, args...)
+}
+
+func (c *Client) do(ctx context.Context, method, url string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("PRIVATE-TOKEN", c.Token)
+	req.Header.Set("User-Agent", "aireview/1.0")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call GitLab API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("GitLab API %s %s returned %d: %s", method, url, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode GitLab response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/prcomment"
+)
+
+// MergeRequestIID returns the merge request of the current pipeline.
+func MergeRequestIID() (int, error) {
+	iid, err := strconv.Atoi(os.Getenv("CI_MERGE_REQUEST_IID"))
+	if err != nil || iid <= 0 {
+		return 0, errors.New("not running in a merge request pipeline (CI_MERGE_REQUEST_IID is not set)")
+	}
+	return iid, nil
+}
+
+// MergeRequestComments implements prcomment.Platform with merge request discussions.
+type MergeRequestComments struct {
+	client  *Client
+	iid     int
+	version *mrVersion
+}
+
+type mrVersion struct {
+	BaseCommitSHA  string `json:"base_commit_sha"`
+	StartCommitSHA string `json:"start_commit_sha"`
+	HeadCommitSHA  string `json:"head_commit_sha"`
+}
+
+func (c *Client) MergeRequestComments(iid int) *MergeRequestComments {
+	return &MergeRequestComments{client: c, iid: iid}
+}
+
+type note struct {
+	ID         int64  `json:"id"`
+	Body       string `json:"body"`
+	Resolved   bool   `json:"resolved"`
+	Resolvable bool   `json:"resolvable"`
+}
+
+type discussion struct {
+	ID    string `json:"id"`
+	Notes []note `json:"notes"`
+}
+
+func (m *MergeRequestComments) ListComments(ctx context.Context) ([]prcomment.Existing, error) {
+	var out []prcomment.Existing
+	for page := 1; ; page++ {
+		var batch []discussion
+		url := m.client.projectPath("/merge_requests/%d/discussions?per_page=100&page=%d", m.iid, page)
+		if err := m.client.do(ctx, http.MethodGet, url, nil, &batch); err != nil {
+			return nil, err
+		}
+		for _, d := range batch {
+			if len(d.Notes) == 0 {
+				continue
+			}
+			first := d.Notes[0]
+			fp, path, line, ok := prcomment.ParseMarker(first.Body)
+			if !ok {
+				continue
+			}
+			out = append(out, prcomment.Existing{
+				ID:          fmt.Sprintf("%s/%d", d.ID, first.ID),
+				Path:        path,
+				Line:        line,
+				Fingerprint: fp,
+				Body:        first.Body,
+				Outdated:    first.Resolved || prcomment.IsOutdated(first.Body),
+			})
+		}
+		if len(batch) < 100 {
+			return out, nil
+		}
+	}
+}
+
+func (m *MergeRequestComments) CreateComment(ctx context.Context, c prcomment.Comment) error {
+	if m.version == nil {
+		var versions []mrVersion
+		if err := m.client.do(ctx, http.MethodGet, m.client.projectPath("/merge_requests/%d/versions", m.iid), nil, &versions); err != nil {
+			return err
+		}
+		if len(versions) == 0 {
+			return errors.New("merge request has no diff versions")
+		}
+		m.version = &versions[0]
+	}
+	return m.client.do(ctx, http.MethodPost, m.client.projectPath("/merge_requests/%d/discussions", m.iid), map[string]interface{}{
+		"body": c.Body,
+		"position": map[string]interface{}{
+			"position_type": "text",
+			"base_sha":      m.version.BaseCommitSHA,
+			"start_sha":     m.version.StartCommitSHA,
+			"head_sha":      m.version.HeadCommitSHA,
+			"new_path":      c.Path,
+			"new_line":      c.Line,
+		},
+	}, nil)
+}
+
+func (m *MergeRequestComments) UpdateComment(ctx context.Context, e prcomment.Existing, body string) error {
+	discussionID, noteID, _ := strings.Cut(e.ID, "/")
+	url := m.client.projectPath("/merge_requests/%d/discussions/%s/notes/%s", m.iid, discussionID, noteID)
+	return m.client.do(ctx, http.MethodPut, url, map[string]string{"body": body}, nil)
+}
+
+// ResolveComment marks the note outdated and resolves its discussion, which collapses it.
+func (m *MergeRequestComments) ResolveComment(ctx context.Context, e prcomment.Existing) error {
+	body := prcomment.OutdatedMarker + "\n~~This finding is no longer reported.~~\n\n" + e.Body
+	if err := m.UpdateComment(ctx, e, body); err != nil {
+		return err
+	}
+	discussionID, _, _ := strings.Cut(e.ID, "/")
+	url := m.client.projectPath("/merge_requests/%d/discussions/%s?resolved=true", m.iid, discussionID)
+	return m.client.do(ctx, http.MethodPut, url, nil, nil)
+}
+
+func (m *MergeRequestComments) UpsertSummary(ctx context.Context, body string) error {
+	for page := 1; ; page++ {
+		var batch []note
+		url := m.client.projectPath("/merge_requests/%d/notes?per_page=100&page=%d", m.iid, page)
+		if err := m.client.do(ctx, http.MethodGet, url, nil, &batch); err != nil {
+			return err
+		}
+		for _, n := range batch {
+			if strings.Contains(n.Body, prcomment.SummaryMarker) {
+				if n.Body == body {
+					return nil
+				}
+				return m.client.do(ctx, http.MethodPut, m.client.projectPath("/merge_requests/%d/notes/%d", m.iid, n.ID), map[string]string{"body": body}, nil)
+			}
+		}
+		if len(batch) < 100 {
+			break
+		}
+	}
+	return m.client.do(ctx, http.MethodPost, m.client.projectPath("/merge_requests/%d/notes", m.iid), map[string]string{"body": body}, nil)
+}
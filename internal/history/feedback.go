@@ -0,0 +1,139 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/disconnekt/goreview/internal/findings"
+)
+
+// FeedbackFileName is the name of the file of finding verdicts, kept next to the
+// history file.
+const FeedbackFileName = "feedback.jsonl"
+
+// Verdicts on findings.
+const (
+	Useful = "useful"
+	Wrong  = "wrong"
+)
+
+// Verdict is a human judgment of a finding reported by a model.
+type Verdict struct {
+	Fingerprint string           `json:"fingerprint"`
+	RunID       string           `json:"run_id"`
+	Model       string           `json:"model"`
+	Prompt      string           `json:"prompt,omitempty"`
+	Verdict     string           `json:"verdict"`
+	RecordedAt  time.Time        `json:"recorded_at"`
+	Finding     findings.Finding `json:"finding"`
+}
+
+func (s *Store) feedbackPath() string {
+	return filepath.Join(filepath.Dir(s.path), FeedbackFileName)
+}
+
+// RecordVerdict appends a verdict; a later verdict on the same finding by the same
+// model and prompt replaces it.
+func (s *Store) RecordVerdict(v Verdict) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode verdict: %w", err)
+	}
+	f, err := os.OpenFile(s.feedbackPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open feedback: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write feedback: %w", err)
+	}
+	return nil
+}
+
+// Verdicts returns the current verdicts, the latest per finding, model and prompt, in
+// the order they were first recorded. Missing feedback yields no verdicts.
+func (s *Store) Verdicts() ([]Verdict, error) {
+	f, err := os.Open(s.feedbackPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open feedback: %w", err)
+	}
+	defer f.Close()
+
+	var verdicts []Verdict
+	index := make(map[[3]string]int)
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var v Verdict
+		if err := json.Unmarshal(sc.Bytes(), &v); err != nil {
+			return nil, fmt.Errorf("failed to parse feedback record: %w", err)
+		}
+		key := [3]string{v.Fingerprint, v.Model, v.Prompt}
+		if i, ok := index[key]; ok {
+			verdicts[i] = v
+			continue
+		}
+		index[key] = len(verdicts)
+		verdicts = append(verdicts, v)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read feedback: %w", err)
+	}
+	return verdicts, nil
+}
+
+// Precision tallies the verdicts on the findings of one model with one prompt.
+type Precision struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt,omitempty"`
+	Useful int    `json:"useful"`
+	Wrong  int    `json:"wrong"`
+}
+
+// Rate is the share of judged findings that were useful.
+func (p Precision) Rate() float64 {
+	if p.Useful+p.Wrong == 0 {
+		return 0
+	}
+	return float64(p.Useful) / float64(p.Useful+p.Wrong)
+}
+
+// PrecisionByModel aggregates verdicts per model and prompt, most judged first.
+func PrecisionByModel(verdicts []Verdict) []Precision {
+	index := make(map[[2]string]int)
+	var list []Precision
+	for _, v := range verdicts {
+		key := [2]string{v.Model, v.Prompt}
+		i, ok := index[key]
+		if !ok {
+			i = len(list)
+			index[key] = i
+			list = append(list, Precision{Model: v.Model, Prompt: v.Prompt})
+		}
+		switch v.Verdict {
+		case Useful:
+			list[i].Useful++
+		case Wrong:
+			list[i].Wrong++
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Useful+list[i].Wrong > list[j].Useful+list[j].Wrong
+	})
+	return list
+}
@@ -0,0 +1,95 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/disconnekt/goreview/internal/findings"
+)
+
+// FileName is the name of the history file inside the state directory.
+const FileName = "history.jsonl"
+
+// Run is one recorded review run.
+type Run struct {
+	ID        string        `json:"id"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration_ns"`
+	Status    string        `json:"status"`
+	Model     string        `json:"model"`
+	// Prompt identifies the review prompt, see reviewer.Service.PromptID.
+	Prompt        string             `json:"prompt,omitempty"`
+	FilesReviewed int                `json:"files_reviewed"`
+	FilesFailed   int                `json:"files_failed"`
+	Error         string             `json:"error,omitempty"`
+	Findings      []findings.Finding `json:"findings,omitempty"`
+}
+
+// Store is an append-only history of runs kept as JSON lines.
+type Store struct {
+	path string
+}
+
+func Open(path string) *Store {
+	return &Store{path: path}
+}
+
+// NewRunID returns an identifier derived from the start time, sortable by time.
+func NewRunID(start time.Time) string {
+	return start.UTC().Format("20060102T150405.000Z")
+}
+
+// Append adds a run to the end of the history.
+func (s *Store) Append(run Run) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	data, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("failed to encode history record: %w", err)
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// Runs returns all recorded runs, oldest first. A missing history yields no runs.
+func (s *Store) Runs() ([]Run, error) {
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+
+	var runs []Run
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for sc.Scan() {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var r Run
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("failed to parse history record: %w", err)
+		}
+		runs = append(runs, r)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return runs, nil
+}
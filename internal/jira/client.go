@@ -0,0 +1,186 @@
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/disconnekt/goreview/internal/tickets"
+)
+
+// searchBatch is the number of labels looked up per JQL search.
+const searchBatch = 50
+
+// Client files goreview tickets in one Jira project through the REST API v2.
+type Client struct {
+	BaseURL   string
+	User      string
+	Token     string
+	Project   string
+	IssueType string
+	client    *http.Client
+}
+
+// NewClient creates a client for a Jira site. With a user the token is sent as a Jira
+// Cloud API token (basic auth); without one as a Data Center personal access token.
+func NewClient(baseURL, user, token, project, issueType string) (*Client, error) {
+	if baseURL == "" {
+		return nil, errors.New("a Jira URL is required (--jira-url)")
+	}
+	if project == "" {
+		return nil, errors.New("a Jira project key is required (--jira-project)")
+	}
+	if token == "" {
+		token = os.Getenv("JIRA_API_TOKEN")
+	}
+	if token == "" {
+		return nil, errors.New("a Jira API token is required (set JIRA_API_TOKEN)")
+	}
+	return &Client{
+		BaseURL:   strings.TrimSuffix(baseURL, "/"),
+		User:      user,
+		Token:     token,
+		Project:   project,
+		IssueType: issueType,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Existing returns the fingerprints whose label an issue of the project already carries.
+func (c *Client) Existing(ctx context.Context, fingerprints []string) (map[string]bool, error) {
+	found := make(map[string]bool)
+	for start := 0; start < len(fingerprints); start += searchBatch {
+		batch := fingerprints[start:min(start+searchBatch, len(fingerprints))]
+		quoted := make([]string, len(batch))
+		for i, fp := range batch {
+			quoted[i] = jqlString(tickets.LabelPrefix + fp)
+		}
+		jql := fmt.Sprintf("project = %s AND labels in (%s)", jqlString(c.Project), strings.Join(quoted, ", "))
+		// Several issues can carry a label, so a batch may need more than one page
+		for startAt := 0; ; {
+			query := url.Values{
+				"jql":        {jql},
+				"fields":     {"labels"},
+				"startAt":    {fmt.Sprint(startAt)},
+				"maxResults": {fmt.Sprint(2 * searchBatch)},
+			}
+			var result struct {
+				Total  int `json:"total"`
+				Issues []struct {
+					Fields struct {
+						Labels []string `json:"labels"`
+					} `json:"fields"`
+				} `json:"issues"`
+			}
+			if err := c.do(ctx, http.MethodGet, c.BaseURL+"/rest/api/2/search?"+query.Encode(), nil, &result); err != nil {
+				return nil, err
+			}
+			for _, issue := range result.Issues {
+				for _, l := range issue.Fields.Labels {
+					if fp, ok := strings.CutPrefix(l, tickets.LabelPrefix); ok {
+						found[fp] = true
+					}
+				}
+			}
+			startAt += len(result.Issues)
+			if len(result.Issues) == 0 || startAt >= result.Total {
+				break
+			}
+		}
+	}
+	return found, nil
+}
+
+// Create opens an issue for the ticket and returns its key.
+func (c *Client) Create(ctx context.Context, t tickets.Ticket) (string, error) {
+	issue := map[string]any{
+		"fields": map[string]any{
+			"project":     map[string]string{"key": c.Project},
+			"issuetype":   map[string]string{"name": c.IssueType},
+			"summary":     t.Title(),
+			"description": description(t),
+			"labels":      []string{"aireview", t.Label()},
+		},
+	}
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := c.do(ctx, http.MethodPost, c.BaseURL+"/rest/api/2/issue", issue, &created); err != nil {
+		return "", err
+	}
+	return created.Key, nil
+}
+
+// description renders the ticket body in Jira wiki markup.
+func description(t tickets.Ticket) string {
+	f := t.Finding
+	var b strings.Builder
+	fmt.Fprintf(&b, "*Severity:* %s\n*Location:* {{%s}}\n", f.Severity, f.Location())
+	if f.RuleID != "" {
+		fmt.Fprintf(&b, "*Rule:* %s\n", f.RuleID)
+	}
+	fmt.Fprintf(&b, "\nh3. Finding and suggested fix\n%s\n", f.Message)
+	if t.Excerpt != "" {
+		fmt.Fprintf(&b, "\nh3. Code\n{code:go}\n%s{code}\n", t.Excerpt)
+	}
+	fmt.Fprintf(&b, "\n_Reported by aireview. Fingerprint %s; keep the %s label so reruns do not file it again._\n",
+		f.Fingerprint(), t.Label())
+	return b.String()
+}
+
+// jqlString quotes a value for a JQL query.
+func jqlString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// do sends a JSON request and decodes a JSON response into out (if non-nil).
+func (c *Client) do(ctx context.Context, method, endpoint string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.User != "" {
+		req.SetBasicAuth(c.User, c.Token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	req.Header.Set("User-Agent", "aireview/1.0")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call Jira API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("Jira API %s %s returned %d: %s", method, endpoint, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode Jira response: %w", err)
+	}
+	return nil
+}
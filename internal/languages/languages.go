@@ -0,0 +1,97 @@
+// Package languages detects the language of a source file and holds the review
+// guidance for each, so a review checks the idioms of the file's language.
+package languages
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Language is the review guidance for one programming language.
+type Language struct {
+	Name       string
+	Extensions []string
+	// Fence is the info string of Markdown code blocks holding the language.
+	Fence string
+	// Prompt is appended to the system prompt for files of the language.
+	Prompt string
+}
+
+var builtins = map[string]Language{
+	"go": {
+		Name:       "go",
+		Extensions: []string{".go"},
+		Fence:      "go",
+		Prompt: `The code is Go. Check Go idioms: every error is handled or deliberately ignored and wrapped with
+	%w when returned, contexts are passed down and honored, goroutines have a clear owner and exit,
+	resources are closed with defer (not inside loops), and shared state is guarded. Prefer small
+	interfaces defined by their consumers and doc comments on exported identifiers.`,
+	},
+	"python": {
+		Name:       "python",
+		Extensions: []string{".py"},
+		Fence:      "python",
+		Prompt: `The code is Python. Check Python idioms: mutable default arguments, bare or overly broad except
+	clauses, resources not managed with "with", string-built SQL or shell commands, missing type hints
+	on public functions, and blocking calls inside async functions.`,
+	},
+	"sql": {
+		Name:       "sql",
+		Extensions: []string{".sql"},
+		Fence:      "sql",
+		Prompt: `The code is SQL. Check for queries that cannot use an index, SELECT *, implicit type conversions,
+	missing WHERE clauses on UPDATE and DELETE, non-deterministic ORDER BY with LIMIT, locking and
+	transaction scope problems, and migrations that are not reversible or rewrite large tables.`,
+	},
+	"javascript": {
+		Name:       "javascript",
+		Extensions: []string{".js", ".mjs", ".cjs", ".jsx"},
+		Fence:      "javascript",
+		Prompt: `The code is JavaScript. Check for unhandled promise rejections, missing await, == instead of ===,
+	prototype pollution, unescaped values reaching the DOM, and event listeners or timers never removed.`,
+	},
+	"typescript": {
+		Name:       "typescript",
+		Extensions: []string{".ts", ".tsx"},
+		Fence:      "typescript",
+		Prompt: `The code is TypeScript. Check for any and non-null assertions hiding real type errors, unhandled
+	promise rejections, missing await, unsafe casts of external data, and unescaped values reaching the DOM.`,
+	},
+	"shell": {
+		Name:       "shell",
+		Extensions: []string{".sh", ".bash"},
+		Fence:      "sh",
+		Prompt: `The code is a shell script. Check for unquoted variables, missing "set -euo pipefail" or
+	equivalent error handling, unsafe temporary files, parsing ls output, and commands built from input.`,
+	},
+}
+
+// Detect returns the language of a file by its extension, or "" when it is not known.
+func Detect(path string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	for name, l := range builtins {
+		for _, e := range l.Extensions {
+			if e == ext {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// Get returns the built-in language with the given name.
+func Get(name string) (Language, bool) {
+	l, ok := builtins[name]
+	return l, ok
+}
+
+// Names lists the built-in language names, sorted.
+func Names() []string {
+	names := make([]string, 0, len(builtins))
+	for n := range builtins {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
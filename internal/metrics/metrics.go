@@ -0,0 +1,156 @@
+// Package metrics computes per-function complexity metrics of Go source, so reviews
+// can prioritize the functions that are hardest to get right.
+package metrics
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// Limits above which a function is pointed out regardless of its complexity.
+const (
+	LongFunction = 80
+	DeepNesting  = 4
+)
+
+// Function holds the metrics of one function or method.
+type Function struct {
+	// Name is the function name, qualified by the receiver type for methods, e.g. "Scanner.Scan".
+	Name string `json:"name"`
+	Line int    `json:"line"`
+	// Lines counts the lines from the signature to the closing brace.
+	Lines int `json:"lines"`
+	// Complexity is the cyclomatic complexity: one plus the number of branches.
+	Complexity int `json:"complexity"`
+	// Nesting is the deepest nesting of blocks inside the function.
+	Nesting int `json:"nesting"`
+}
+
+// Analyze returns the metrics of the functions in Go source, in source order. Source
+// that does not parse has no functions.
+func Analyze(src string) []Function {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+	var list []Function
+	for _, decl := range file.Decls {
+		fd, ok := decl.(*ast.FuncDecl)
+		if !ok || fd.Body == nil {
+			continue
+		}
+		start, end := fset.Position(fd.Pos()).Line, fset.Position(fd.End()).Line
+		list = append(list, Function{
+			Name:       funcName(fd),
+			Line:       start,
+			Lines:      end - start + 1,
+			Complexity: complexity(fd.Body),
+			Nesting:    nesting(fd.Body, 0),
+		})
+	}
+	return list
+}
+
+func funcName(fd *ast.FuncDecl) string {
+	if fd.Recv == nil || len(fd.Recv.List) == 0 {
+		return fd.Name.Name
+	}
+	t := fd.Recv.List[0].Type
+	if star, ok := t.(*ast.StarExpr); ok {
+		t = star.X
+	}
+	switch e := t.(type) {
+	case *ast.IndexExpr:
+		t = e.X
+	case *ast.IndexListExpr:
+		t = e.X
+	}
+	if id, ok := t.(*ast.Ident); ok {
+		return id.Name + "." + fd.Name.Name
+	}
+	return fd.Name.Name
+}
+
+// complexity counts the branches of a body like gocyclo: function literals count
+// toward the enclosing function.
+func complexity(body *ast.BlockStmt) int {
+	c := 1
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			c++
+		case *ast.CaseClause:
+			if n.List != nil {
+				c++
+			}
+		case *ast.CommClause:
+			if n.Comm != nil {
+				c++
+			}
+		case *ast.BinaryExpr:
+			if n.Op == token.LAND || n.Op == token.LOR {
+				c++
+			}
+		}
+		return true
+	})
+	return c
+}
+
+// nesting returns the deepest nesting of control structures below n. An "else if"
+// continues its chain rather than nesting deeper.
+func nesting(n ast.Node, depth int) int {
+	deepest := depth
+	ast.Inspect(n, func(child ast.Node) bool {
+		if child == n {
+			return true
+		}
+		switch c := child.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt,
+			*ast.SelectStmt, *ast.FuncLit:
+			next := depth + 1
+			if parent, ok := n.(*ast.IfStmt); ok && parent.Else == child {
+				next = depth
+			}
+			if d := nesting(c, next); d > deepest {
+				deepest = d
+			}
+			return false
+		}
+		return true
+	})
+	return deepest
+}
+
+// Notable returns the functions at or above the complexity threshold, longer than
+// LongFunction lines or nested DeepNesting levels deep, most complex first.
+func Notable(list []Function, threshold int) []Function {
+	var out []Function
+	for _, f := range list {
+		if f.Complexity >= threshold || f.Lines > LongFunction || f.Nesting >= DeepNesting {
+			out = append(out, f)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Complexity > out[j].Complexity })
+	return out
+}
+
+// PromptSection lists notable functions for the prompt, or returns "" when there are none.
+func PromptSection(list []Function) string {
+	if len(list) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("The most complex functions of this file, measured locally. Prioritize findings about " +
+		"them, and suggest how to simplify them where it would reduce the risk of bugs:\n")
+	for _, f := range list {
+		fmt.Fprintf(&b, "- %s (line %d): cyclomatic complexity %d, %d lines, nesting depth %d\n",
+			f.Name, f.Line, f.Complexity, f.Lines, f.Nesting)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,127 @@
+// Package modelinfo holds the context window, output limit and pricing of models, so
+// request limits and cost estimates follow the model instead of fixed constants.
+package modelinfo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Info describes a model. Zero fields of an override keep the built-in values.
+type Info struct {
+	// ContextWindow is the number of tokens of prompt and reply together.
+	ContextWindow int `yaml:"context_window,omitempty" json:"context_window"`
+	// MaxOutput is the most tokens the model generates in one reply.
+	MaxOutput int `yaml:"max_output,omitempty" json:"max_output"`
+	// InputPrice and OutputPrice are in USD per million prompt and completion tokens.
+	InputPrice  float64 `yaml:"input_price,omitempty" json:"input_price,omitempty"`
+	OutputPrice float64 `yaml:"output_price,omitempty" json:"output_price,omitempty"`
+	// CachedInputPrice is the price of prompt tokens read from the prompt cache; 0
+	// charges them at InputPrice.
+	CachedInputPrice float64 `yaml:"cached_input_price,omitempty" json:"cached_input_price,omitempty"`
+}
+
+// Default applies to models missing from the table: the limits used before models had
+// metadata, without pricing.
+var Default = Info{ContextWindow: 32768, MaxOutput: 4000}
+
+// builtin lists known models by name prefix, with list prices at the time of writing.
+var builtin = map[string]Info{
+	"gpt-4o":            {ContextWindow: 128000, MaxOutput: 16384, InputPrice: 2.5, OutputPrice: 10, CachedInputPrice: 1.25},
+	"gpt-4o-mini":       {ContextWindow: 128000, MaxOutput: 16384, InputPrice: 0.15, OutputPrice: 0.6, CachedInputPrice: 0.075},
+	"gpt-4.1":           {ContextWindow: 1047576, MaxOutput: 32768, InputPrice: 2, OutputPrice: 8, CachedInputPrice: 0.5},
+	"gpt-4.1-mini":      {ContextWindow: 1047576, MaxOutput: 32768, InputPrice: 0.4, OutputPrice: 1.6, CachedInputPrice: 0.1},
+	"gpt-4.1-nano":      {ContextWindow: 1047576, MaxOutput: 32768, InputPrice: 0.1, OutputPrice: 0.4, CachedInputPrice: 0.025},
+	"o3":                {ContextWindow: 200000, MaxOutput: 100000, InputPrice: 2, OutputPrice: 8, CachedInputPrice: 0.5},
+	"o4-mini":           {ContextWindow: 200000, MaxOutput: 100000, InputPrice: 1.1, OutputPrice: 4.4, CachedInputPrice: 0.275},
+	"claude-opus-4":     {ContextWindow: 200000, MaxOutput: 32000, InputPrice: 15, OutputPrice: 75, CachedInputPrice: 1.5},
+	"claude-sonnet-4":   {ContextWindow: 200000, MaxOutput: 64000, InputPrice: 3, OutputPrice: 15, CachedInputPrice: 0.3},
+	"claude-3-5-haiku":  {ContextWindow: 200000, MaxOutput: 8192, InputPrice: 0.8, OutputPrice: 4, CachedInputPrice: 0.08},
+	"gemini-2.5-pro":    {ContextWindow: 1048576, MaxOutput: 65536, InputPrice: 1.25, OutputPrice: 10, CachedInputPrice: 0.31},
+	"gemini-2.5-flash":  {ContextWindow: 1048576, MaxOutput: 65536, InputPrice: 0.3, OutputPrice: 2.5, CachedInputPrice: 0.075},
+	"devstral-small":    {ContextWindow: 131072, MaxOutput: 8192},
+	"qwen2.5-coder":     {ContextWindow: 32768, MaxOutput: 8192},
+	"llama-3.1":         {ContextWindow: 131072, MaxOutput: 8192},
+	"codestral":         {ContextWindow: 256000, MaxOutput: 8192},
+	"deepseek-coder-v2": {ContextWindow: 131072, MaxOutput: 8192},
+}
+
+// Lookup returns the metadata of a model: the built-in entry whose name is the longest
+// prefix of the model name, ignoring a provider prefix such as "openai/" or
+// "anthropic.", with the fields of overrides[model] applied on top. It reports whether
+// the model is known; unknown models get Default.
+func Lookup(model string, overrides map[string]Info) (Info, bool) {
+	info, known := builtinInfo(model)
+	if o, ok := overrides[model]; ok {
+		known = true
+		if o.ContextWindow > 0 {
+			info.ContextWindow = o.ContextWindow
+		}
+		if o.MaxOutput > 0 {
+			info.MaxOutput = o.MaxOutput
+		}
+		if o.InputPrice > 0 {
+			info.InputPrice = o.InputPrice
+		}
+		if o.OutputPrice > 0 {
+			info.OutputPrice = o.OutputPrice
+		}
+		if o.CachedInputPrice > 0 {
+			info.CachedInputPrice = o.CachedInputPrice
+		}
+	}
+	return info, known
+}
+
+func builtinInfo(model string) (Info, bool) {
+	name := strings.ToLower(model)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	// Bedrock names models like "us.anthropic.claude-sonnet-4-20250514-v1:0"
+	candidates := []string{name}
+	for i := range name {
+		if name[i] == '.' {
+			candidates = append(candidates, name[i+1:])
+		}
+	}
+	best := ""
+	for _, candidate := range candidates {
+		for prefix := range builtin {
+			if strings.HasPrefix(candidate, prefix) && len(prefix) > len(best) {
+				best = prefix
+			}
+		}
+	}
+	if best == "" {
+		return Default, false
+	}
+	return builtin[best], true
+}
+
+// Validate checks an override of the table.
+func (i Info) Validate(model string) error {
+	if i.ContextWindow < 0 || i.MaxOutput < 0 || i.InputPrice < 0 || i.OutputPrice < 0 || i.CachedInputPrice < 0 {
+		return fmt.Errorf("model %s: limits and prices must not be negative", model)
+	}
+	if i.ContextWindow > 0 && i.MaxOutput >= i.ContextWindow {
+		return fmt.Errorf("model %s: max_output must be smaller than context_window", model)
+	}
+	return nil
+}
+
+// Priced reports whether the model has prices to estimate costs with.
+func (i Info) Priced() bool {
+	return i.InputPrice > 0 || i.OutputPrice > 0
+}
+
+// Cost is the price in USD of the given prompt and completion tokens, of which
+// cachedTokens prompt tokens were read from the prompt cache.
+func (i Info) Cost(promptTokens, cachedTokens, completionTokens int) float64 {
+	cachedPrice := i.CachedInputPrice
+	if cachedPrice == 0 {
+		cachedPrice = i.InputPrice
+	}
+	uncached := max(promptTokens-cachedTokens, 0)
+	return (float64(uncached)*i.InputPrice + float64(cachedTokens)*cachedPrice + float64(completionTokens)*i.OutputPrice) / 1e6
+}
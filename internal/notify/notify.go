@@ -0,0 +1,82 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/disconnekt/goreview/internal/findings"
+)
+
+// Summary describes a finished (or failed) review run.
+type Summary struct {
+	Project       string                    `json:"project"`
+	Status        string                    `json:"status"`
+	FilesReviewed int                       `json:"files_reviewed"`
+	FilesFailed   int                       `json:"files_failed"`
+	Findings      map[findings.Severity]int `json:"findings"`
+	ReportURL     string                    `json:"report_url,omitempty"`
+	Error         string                    `json:"error,omitempty"`
+	Duration      time.Duration             `json:"duration_ns"`
+}
+
+const (
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// Failed reports whether the run did not complete successfully.
+func (s Summary) Failed() bool {
+	return s.Status == StatusFailed
+}
+
+// Notifier delivers run summaries to an external system.
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, s Summary) error
+}
+
+// Headline is a one-line description of the run used as a message title.
+func (s Summary) Headline() string {
+	if s.Failed() {
+		return fmt.Sprintf("goreview run failed for %s", s.Project)
+	}
+	return fmt.Sprintf("goreview run completed for %s", s.Project)
+}
+
+// SeverityLine renders finding counts, e.g. "critical: 1, high: 3, medium: 0, low: 2, info: 0".
+func (s Summary) SeverityLine() string {
+	parts := make([]string, 0, len(findings.Severities))
+	for _, sev := range findings.Severities {
+		parts = append(parts, fmt.Sprintf("%s: %d", sev, s.Findings[sev]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// postJSON sends payload to url and treats any non-2xx status as an error.
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "aireview/1.0")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
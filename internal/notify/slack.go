@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Slack posts run summaries to a Slack incoming webhook.
+type Slack struct {
+	WebhookURL string
+	client     *http.Client
+}
+
+func NewSlack(webhookURL string) *Slack {
+	return &Slack{
+		WebhookURL: webhookURL,
+		client:     &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (s *Slack) Name() string { return "slack" }
+
+func (s *Slack) Notify(ctx context.Context, sum Summary) error {
+	emoji := ":white_check_mark:"
+	if sum.Failed() {
+		emoji = ":x:"
+	}
+
+	var lines []string
+	lines = append(lines, fmt.Sprintf("%s *%s*", emoji, sum.Headline()))
+	lines = append(lines, fmt.Sprintf("Files reviewed: %d (failed: %d) in %s",
+		sum.FilesReviewed, sum.FilesFailed, sum.Duration.Round(time.Second)))
+	lines = append(lines, "Findings: "+sum.SeverityLine())
+	if sum.Error != "" {
+		lines = append(lines, "Error: `"+sum.Error+"`")
+	}
+	if sum.ReportURL != "" {
+		lines = append(lines, fmt.Sprintf("<%s|View report>", sum.ReportURL))
+	}
+	text := strings.Join(lines, "\n")
+
+	payload := map[string]interface{}{
+		"text": text,
+		"blocks": []map[string]interface{}{
+			{
+				"type": "section",
+				"text": map[string]string{"type": "mrkdwn", "text": text},
+			},
+		},
+	}
+	return postJSON(ctx, s.client, s.WebhookURL, payload)
+}
@@ -0,0 +1,58 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Teams posts run summaries to a Microsoft Teams incoming webhook as a MessageCard.
+type Teams struct {
+	WebhookURL string
+	client     *http.Client
+}
+
+func NewTeams(webhookURL string) *Teams {
+	return &Teams{
+		WebhookURL: webhookURL,
+		client:     &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (t *Teams) Name() string { return "teams" }
+
+func (t *Teams) Notify(ctx context.Context, sum Summary) error {
+	color := "2EB886"
+	if sum.Failed() {
+		color = "D00000"
+	}
+
+	facts := []map[string]string{
+		{"name": "Files reviewed", "value": fmt.Sprintf("%d (failed: %d)", sum.FilesReviewed, sum.FilesFailed)},
+		{"name": "Findings", "value": sum.SeverityLine()},
+		{"name": "Duration", "value": sum.Duration.Round(time.Second).String()},
+	}
+	if sum.Error != "" {
+		facts = append(facts, map[string]string{"name": "Error", "value": sum.Error})
+	}
+
+	card := map[string]interface{}{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    sum.Headline(),
+		"themeColor": color,
+		"title":      sum.Headline(),
+		"sections":   []map[string]interface{}{{"facts": facts}},
+	}
+	if sum.ReportURL != "" {
+		card["potentialAction"] = []map[string]interface{}{
+			{
+				"@type":   "OpenUri",
+				"name":    "View report",
+				"targets": []map[string]string{{"os": "default", "uri": sum.ReportURL}},
+			},
+		}
+	}
+	return postJSON(ctx, t.client, t.WebhookURL, card)
+}
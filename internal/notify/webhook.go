@@ -0,0 +1,26 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Webhook POSTs the JSON-encoded Summary to an arbitrary URL for chatops or automation.
+type Webhook struct {
+	URL    string
+	client *http.Client
+}
+
+func NewWebhook(url string) *Webhook {
+	return &Webhook{
+		URL:    url,
+		client: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (w *Webhook) Name() string { return "webhook" }
+
+func (w *Webhook) Notify(ctx context.Context, sum Summary) error {
+	return postJSON(ctx, w.client, w.URL, sum)
+}
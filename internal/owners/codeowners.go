@@ -0,0 +1,149 @@
+package owners
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Locations are the paths, relative to the repository root, that GitHub and GitLab read
+// CODEOWNERS from, in order of precedence.
+var Locations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS", ".gitlab/CODEOWNERS"}
+
+type rule struct {
+	pattern *regexp.Regexp
+	owners  []string
+}
+
+// Codeowners maps repository paths to their owners. As on GitHub, the last matching
+// pattern wins, and a pattern without owners leaves its files unowned.
+type Codeowners struct {
+	// Path is the CODEOWNERS file the rules were read from.
+	Path string
+	// Root is the repository root the patterns are relative to.
+	Root  string
+	rules []rule
+}
+
+// Find looks for a CODEOWNERS file in the repository containing dir: the closest
+// parent with a .git entry, or dir itself outside a repository. It returns nil when
+// there is none.
+func Find(dir string) (*Codeowners, error) {
+	root := repositoryRoot(dir)
+	for _, loc := range Locations {
+		path := filepath.Join(root, filepath.FromSlash(loc))
+		file, err := os.Open(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		defer file.Close()
+		rules, err := parse(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		return &Codeowners{Path: path, Root: root, rules: rules}, nil
+	}
+	return nil, nil
+}
+
+func repositoryRoot(dir string) string {
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return d
+		}
+		if filepath.Dir(d) == d {
+			return dir
+		}
+	}
+}
+
+func parse(r io.Reader) ([]rule, error) {
+	var rules []rule
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		// GitLab section headers ("[Section] @owner") are skipped; their rules still apply
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "[") || strings.HasPrefix(line, "^[") {
+			continue
+		}
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		pattern, err := compile(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		rules = append(rules, rule{pattern: pattern, owners: fields[1:]})
+	}
+	return rules, sc.Err()
+}
+
+// compile turns a gitignore-style CODEOWNERS pattern into a regexp over slash-separated
+// paths relative to the repository root. A pattern also matches everything under the
+// directories it matches.
+func compile(pattern string) (*regexp.Regexp, error) {
+	anchored := strings.HasPrefix(pattern, "/") || strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.Trim(pattern, "/")
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("(/.*)?$")
+	return regexp.Compile(b.String())
+}
+
+// Owners returns the owners of a file, given by absolute path or relative to the root.
+func (c *Codeowners) Owners(path string) []string {
+	if filepath.IsAbs(path) {
+		rel, err := filepath.Rel(c.Root, path)
+		if err != nil {
+			return nil
+		}
+		path = rel
+	}
+	path = filepath.ToSlash(path)
+	for i := len(c.rules) - 1; i >= 0; i-- {
+		if c.rules[i].pattern.MatchString(path) {
+			return c.rules[i].owners
+		}
+	}
+	return nil
+}
+
+// Match reports whether owner is among owners, ignoring case and a leading "@".
+func Match(owners []string, owner string) bool {
+	owner = strings.TrimPrefix(owner, "@")
+	for _, o := range owners {
+		if strings.EqualFold(strings.TrimPrefix(o, "@"), owner) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+// Package patch turns line edits of source files into unified diffs and applies them.
+package patch
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines shown around each change.
+const contextLines = 3
+
+// Edit replaces the lines [Start, End) of a file, counted from 1, with Lines. An edit
+// with Start == End inserts Lines before line Start.
+type Edit struct {
+	Start, End int
+	Lines      []string
+}
+
+// File is a set of non-overlapping edits to one file.
+type File struct {
+	// Path is the file on disk; Name is the path shown in the diff.
+	Path, Name string
+	// Old is the content on disk the edits were computed against. Files with CRLF line
+	// endings keep them, and a byte order mark stays part of the first line.
+	Old   string
+	Edits []Edit
+}
+
+// lines splits content into lines without their line endings.
+func lines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	split := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	for i, l := range split {
+		split[i] = strings.TrimSuffix(l, "\r")
+	}
+	return split
+}
+
+// eol returns the line ending of content: CRLF when it uses them, otherwise LF.
+func eol(content string) string {
+	if strings.Contains(content, "\r\n") {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+func (f *File) sorted() []Edit {
+	edits := append([]Edit(nil), f.Edits...)
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].Start < edits[j].Start })
+	return edits
+}
+
+// New returns the content with all edits applied.
+func (f *File) New() string {
+	old := lines(f.Old)
+	var out []string
+	next := 1
+	for _, e := range f.sorted() {
+		out = append(out, old[next-1:e.Start-1]...)
+		out = append(out, e.Lines...)
+		next = e.End
+	}
+	out = append(out, old[next-1:]...)
+	nl := eol(f.Old)
+	return strings.Join(out, nl) + nl
+}
+
+// hunk is a range of old lines shown in the diff and the edits inside it.
+type hunk struct {
+	start, end int
+	edits      []Edit
+}
+
+// Unified renders the edits as a unified diff.
+func (f *File) Unified() string {
+	if len(f.Edits) == 0 {
+		return ""
+	}
+	old := lines(f.Old)
+	var hunks []hunk
+	for _, e := range f.sorted() {
+		start := max(1, e.Start-contextLines)
+		end := min(len(old)+1, e.End+contextLines)
+		if n := len(hunks); n > 0 && start <= hunks[n-1].end {
+			hunks[n-1].end = max(hunks[n-1].end, end)
+			hunks[n-1].edits = append(hunks[n-1].edits, e)
+			continue
+		}
+		hunks = append(hunks, hunk{start: start, end: end, edits: []Edit{e}})
+	}
+
+	nl := eol(f.Old)
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", f.Name, f.Name)
+	delta := 0
+	for _, h := range hunks {
+		var body strings.Builder
+		oldCount, newCount := 0, 0
+		line := h.start
+		for _, e := range h.edits {
+			for ; line < e.Start; line++ {
+				body.WriteString(" " + old[line-1] + nl)
+				oldCount++
+				newCount++
+			}
+			for ; line < e.End; line++ {
+				body.WriteString("-" + old[line-1] + nl)
+				oldCount++
+			}
+			for _, l := range e.Lines {
+				body.WriteString("+" + l + nl)
+				newCount++
+			}
+		}
+		for ; line < h.end; line++ {
+			body.WriteString(" " + old[line-1] + nl)
+			oldCount++
+			newCount++
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(h.start, oldCount), hunkRange(h.start+delta, newCount))
+		b.WriteString(body.String())
+		delta += newCount - oldCount
+	}
+	return b.String()
+}
+
+// hunkRange formats a hunk header range; an empty range names the line before it.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// Apply writes the edited content of every file, refusing files that changed on disk
+// since the edits were computed.
+func Apply(files []*File) error {
+	for _, f := range files {
+		current, err := os.ReadFile(f.Path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", f.Path, err)
+		}
+		if string(current) != f.Old {
+			return errors.New(f.Path + " changed since the patch was generated; run again")
+		}
+	}
+	for _, f := range files {
+		info, err := os.Stat(f.Path)
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", f.Path, err)
+		}
+		if err := os.WriteFile(f.Path, []byte(f.New()), info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.Path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package perf
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Benchmark is one result line of "go test -bench".
+type Benchmark struct {
+	// Name is the benchmark function with sub-benchmarks, without the GOMAXPROCS suffix.
+	Name string
+	// Package is the import path from the preceding "pkg:" line, or "".
+	Package    string
+	Iterations int64
+	// Metrics are the measurements in output order, e.g. "1234 ns/op".
+	Metrics []string
+}
+
+// Function returns the benchmark function, e.g. "BenchmarkParse" for "BenchmarkParse/small".
+func (b Benchmark) Function() string {
+	name, _, _ := strings.Cut(b.Name, "/")
+	return name
+}
+
+var benchmarkLine = regexp.MustCompile(`^(Benchmark\S*?)(?:-\d+)?\s+(\d+)\s+(.+)$`)
+
+// ParseBenchmarks reads the results of "go test -bench" output. Other output is ignored.
+func ParseBenchmarks(r io.Reader) ([]Benchmark, error) {
+	var list []Benchmark
+	var pkg string
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if p, ok := strings.CutPrefix(line, "pkg: "); ok {
+			pkg = strings.TrimSpace(p)
+			continue
+		}
+		m := benchmarkLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		n, _ := strconv.ParseInt(m[2], 10, 64)
+		b := Benchmark{Name: m[1], Package: pkg, Iterations: n}
+		fields := strings.Fields(m[3])
+		for i := 0; i+1 < len(fields); i += 2 {
+			b.Metrics = append(b.Metrics, fields[i]+" "+fields[i+1])
+		}
+		list = append(list, b)
+	}
+	return list, sc.Err()
+}
+
+// Load reads a pprof profile or saved benchmark output, telling them apart by content.
+// Exactly one of the results is set.
+func Load(path string) (*Profile, []Benchmark, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if isText(data) {
+		list, err := ParseBenchmarks(bytes.NewReader(data))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read benchmarks %s: %w", path, err)
+		}
+		if len(list) == 0 {
+			return nil, nil, fmt.Errorf("%s has no benchmark results", path)
+		}
+		return nil, list, nil
+	}
+	p, err := ParseProfile(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse profile %s: %w", path, err)
+	}
+	return p, nil, nil
+}
+
+// isText reports whether data looks like command output rather than a binary profile.
+func isText(data []byte) bool {
+	head := data
+	if len(head) > 512 {
+		head = head[:512]
+	}
+	for _, c := range head {
+		if c < 0x20 && c != '\n' && c != '\r' && c != '\t' {
+			return false
+		}
+	}
+	return true
+}
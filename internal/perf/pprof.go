@@ -0,0 +1,301 @@
+// Package perf reads CPU and memory profiles and benchmark results, so performance
+// reviews can focus on the code that measurably matters.
+package perf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// MinShare is the share of the profile total below which functions are not hot.
+const MinShare = 0.01
+
+// Profile is the summary of a pprof profile.
+type Profile struct {
+	// SampleType is the measured value, e.g. "cpu (nanoseconds)".
+	SampleType string
+	// Hotspots are the functions with at least MinShare of the total, flat or
+	// cumulative, hottest first.
+	Hotspots []Hotspot
+}
+
+// Hotspot is a function's share of a profile.
+type Hotspot struct {
+	Function string
+	File     string
+	// Line is where the function starts, or 0 when the profile does not say.
+	Line int
+	// Flat is the share spent in the function itself, Cum the share including callees.
+	Flat, Cum float64
+}
+
+type function struct {
+	name, file string
+	line       int
+}
+
+// location is the function of a program counter with the functions inlined into it,
+// innermost first.
+type location struct {
+	functions []uint64
+}
+
+// ParseProfile reads a pprof profile, gzip-compressed as written by the Go runtime or
+// uncompressed.
+func ParseProfile(data []byte) (*Profile, error) {
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress profile: %w", err)
+		}
+		if data, err = io.ReadAll(zr); err != nil {
+			return nil, fmt.Errorf("failed to decompress profile: %w", err)
+		}
+	}
+
+	var (
+		strs        []string
+		sampleTypes [][2]int64
+		samples     [][]byte
+		locations   = make(map[uint64]location)
+		functions   = make(map[uint64]function)
+		defaultType int64
+	)
+	type rawFunction struct {
+		id, name, file, line uint64
+	}
+	var rawFunctions []rawFunction
+
+	d := decoder{data: data}
+	for {
+		num, typ, ok, err := d.next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		switch {
+		case num == 1 && typ == wireBytes: // sample_type
+			b, err := d.bytes()
+			if err != nil {
+				return nil, err
+			}
+			vals, err := message(b, map[int]bool{1: true, 2: true})
+			if err != nil {
+				return nil, err
+			}
+			sampleTypes = append(sampleTypes, [2]int64{int64(first(vals[1])), int64(first(vals[2]))})
+		case num == 2 && typ == wireBytes: // sample, decoded once the types are known
+			b, err := d.bytes()
+			if err != nil {
+				return nil, err
+			}
+			samples = append(samples, b)
+		case num == 4 && typ == wireBytes: // location
+			b, err := d.bytes()
+			if err != nil {
+				return nil, err
+			}
+			id, loc, err := parseLocation(b)
+			if err != nil {
+				return nil, err
+			}
+			locations[id] = loc
+		case num == 5 && typ == wireBytes: // function
+			b, err := d.bytes()
+			if err != nil {
+				return nil, err
+			}
+			vals, err := message(b, map[int]bool{1: true, 2: true, 4: true, 5: true})
+			if err != nil {
+				return nil, err
+			}
+			rawFunctions = append(rawFunctions, rawFunction{first(vals[1]), first(vals[2]), first(vals[4]), first(vals[5])})
+		case num == 6 && typ == wireBytes: // string_table
+			b, err := d.bytes()
+			if err != nil {
+				return nil, err
+			}
+			strs = append(strs, string(b))
+		case num == 14 && typ == wireVarint: // default_sample_type
+			v, err := d.varint()
+			if err != nil {
+				return nil, err
+			}
+			defaultType = int64(v)
+		default:
+			if err := d.skip(typ); err != nil {
+				return nil, err
+			}
+		}
+	}
+	str := func(i int64) string {
+		if i < 0 || int(i) >= len(strs) {
+			return ""
+		}
+		return strs[i]
+	}
+	for _, f := range rawFunctions {
+		functions[f.id] = function{name: str(int64(f.name)), file: str(int64(f.file)), line: int(f.line)}
+	}
+	if len(sampleTypes) == 0 {
+		return nil, fmt.Errorf("profile has no sample types")
+	}
+
+	// Like pprof, report the default sample type, or the last one
+	index := len(sampleTypes) - 1
+	for i, st := range sampleTypes {
+		if defaultType != 0 && st[0] == defaultType {
+			index = i
+		}
+	}
+	p := &Profile{SampleType: fmt.Sprintf("%s (%s)", str(sampleTypes[index][0]), str(sampleTypes[index][1]))}
+
+	flat := make(map[uint64]int64)
+	cum := make(map[uint64]int64)
+	var total int64
+	for _, b := range samples {
+		locIDs, values, err := parseSample(b)
+		if err != nil {
+			return nil, err
+		}
+		if index >= len(values) {
+			continue
+		}
+		v := values[index]
+		total += v
+		seen := make(map[uint64]bool)
+		for i, id := range locIDs {
+			for j, fn := range locations[id].functions {
+				if i == 0 && j == 0 {
+					flat[fn] += v
+				}
+				if !seen[fn] {
+					seen[fn] = true
+					cum[fn] += v
+				}
+			}
+		}
+	}
+	if total == 0 {
+		return p, nil
+	}
+	for id, c := range cum {
+		h := Hotspot{Flat: float64(flat[id]) / float64(total), Cum: float64(c) / float64(total)}
+		if h.Flat < MinShare && h.Cum < MinShare {
+			continue
+		}
+		fn := functions[id]
+		h.Function, h.File, h.Line = fn.name, fn.file, fn.line
+		p.Hotspots = append(p.Hotspots, h)
+	}
+	sort.Slice(p.Hotspots, func(i, j int) bool {
+		a, b := p.Hotspots[i], p.Hotspots[j]
+		if a.Flat != b.Flat {
+			return a.Flat > b.Flat
+		}
+		if a.Cum != b.Cum {
+			return a.Cum > b.Cum
+		}
+		return a.Function < b.Function
+	})
+	return p, nil
+}
+
+func parseLocation(b []byte) (uint64, location, error) {
+	var id uint64
+	var loc location
+	d := decoder{data: b}
+	for {
+		num, typ, ok, err := d.next()
+		if err != nil || !ok {
+			return id, loc, err
+		}
+		switch {
+		case num == 1 && typ == wireVarint:
+			if id, err = d.varint(); err != nil {
+				return 0, loc, err
+			}
+		case num == 4 && typ == wireBytes: // line
+			lb, err := d.bytes()
+			if err != nil {
+				return 0, loc, err
+			}
+			vals, err := message(lb, map[int]bool{1: true})
+			if err != nil {
+				return 0, loc, err
+			}
+			loc.functions = append(loc.functions, first(vals[1]))
+		default:
+			if err := d.skip(typ); err != nil {
+				return 0, loc, err
+			}
+		}
+	}
+}
+
+func parseSample(b []byte) (locIDs []uint64, values []int64, err error) {
+	var raw []uint64
+	d := decoder{data: b}
+	for {
+		num, typ, ok, err := d.next()
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			break
+		}
+		switch num {
+		case 1:
+			locIDs, err = d.uint64s(typ, locIDs)
+		case 2:
+			raw, err = d.uint64s(typ, raw)
+		default:
+			err = d.skip(typ)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, v := range raw {
+		values = append(values, int64(v))
+	}
+	return locIDs, values, nil
+}
+
+// message reads the varint fields of a small message, keeping the wanted ones.
+func message(b []byte, wanted map[int]bool) (map[int][]uint64, error) {
+	vals := make(map[int][]uint64)
+	d := decoder{data: b}
+	for {
+		num, typ, ok, err := d.next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return vals, nil
+		}
+		if !wanted[num] || typ != wireVarint {
+			if err := d.skip(typ); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		v, err := d.varint()
+		if err != nil {
+			return nil, err
+		}
+		vals[num] = append(vals[num], v)
+	}
+}
+
+func first(vals []uint64) uint64 {
+	if len(vals) == 0 {
+		return 0
+	}
+	return vals[0]
+}
@@ -0,0 +1,103 @@
+package perf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Wire types of the protocol buffer encoding.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errTruncated = errors.New("truncated protocol buffer")
+
+// decoder reads the fields of one protocol buffer message. The pprof format needs
+// only a handful of messages, which is not worth a protobuf dependency.
+type decoder struct {
+	data []byte
+}
+
+// next reads the key of the next field. It returns false at the end of the message.
+func (d *decoder) next() (num, typ int, ok bool, err error) {
+	if len(d.data) == 0 {
+		return 0, 0, false, nil
+	}
+	key, err := d.varint()
+	if err != nil {
+		return 0, 0, false, err
+	}
+	return int(key >> 3), int(key & 7), true, nil
+}
+
+func (d *decoder) varint() (uint64, error) {
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		return 0, errTruncated
+	}
+	d.data = d.data[n:]
+	return v, nil
+}
+
+func (d *decoder) bytes() ([]byte, error) {
+	n, err := d.varint()
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(d.data)) {
+		return nil, errTruncated
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b, nil
+}
+
+// skip steps over the value of a field that is not needed.
+func (d *decoder) skip(typ int) error {
+	var n int
+	switch typ {
+	case wireVarint:
+		_, err := d.varint()
+		return err
+	case wireBytes:
+		_, err := d.bytes()
+		return err
+	case wireFixed64:
+		n = 8
+	case wireFixed32:
+		n = 4
+	default:
+		return fmt.Errorf("unsupported protocol buffer wire type %d", typ)
+	}
+	if len(d.data) < n {
+		return errTruncated
+	}
+	d.data = d.data[n:]
+	return nil
+}
+
+// uint64s reads a repeated integer field, which is either packed into one
+// length-delimited value or sent as single varints.
+func (d *decoder) uint64s(typ int, dst []uint64) ([]uint64, error) {
+	if typ == wireVarint {
+		v, err := d.varint()
+		return append(dst, v), err
+	}
+	packed, err := d.bytes()
+	if err != nil {
+		return nil, err
+	}
+	inner := decoder{data: packed}
+	for len(inner.data) > 0 {
+		v, err := inner.varint()
+		if err != nil {
+			return nil, err
+		}
+		dst = append(dst, v)
+	}
+	return dst, nil
+}
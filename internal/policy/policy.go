@@ -0,0 +1,200 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/disconnekt/goreview/internal/findings"
+	"github.com/disconnekt/goreview/internal/scanner"
+)
+
+// DefaultPath is where the policy is looked up in the project when --policy is not set.
+const DefaultPath = ".aireview/policy.yaml"
+
+// Policy decides whether a run passes, e.g. as a required pull request check.
+type Policy struct {
+	// Base is the git revision that changed lines are computed against, for rules
+	// with changed_lines.
+	Base  string `yaml:"base"`
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule fails the run when more than Max findings match all of its conditions. Empty
+// conditions match every finding.
+type Rule struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	// Severity is the lowest severity that matches.
+	Severity string `yaml:"severity"`
+	// Profile limits the rule to runs with this review profile, e.g. security.
+	Profile string `yaml:"profile"`
+	// RuleID is a glob matched against the finding's rule ID.
+	RuleID string `yaml:"rule_id"`
+	// Message is a regular expression matched against the finding's message.
+	Message string `yaml:"message"`
+	// Path is a glob matched against the file path relative to the project root.
+	Path string `yaml:"path"`
+	// ChangedLines only matches findings on lines changed since the policy's base.
+	ChangedLines bool `yaml:"changed_lines"`
+	// Max is the number of matching findings tolerated.
+	Max int `yaml:"max"`
+
+	severity findings.Severity
+	message  *regexp.Regexp
+}
+
+// Load reads and validates a policy file.
+func Load(file string) (*Policy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy: %w", err)
+	}
+	return Parse(data, file)
+}
+
+// Parse validates a policy document; file identifies it in errors.
+func Parse(data []byte, file string) (*Policy, error) {
+	var p Policy
+	var err error
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse policy %s: %w", file, err)
+	}
+	if len(p.Rules) == 0 {
+		return nil, fmt.Errorf("policy %s has no rules", file)
+	}
+	for i := range p.Rules {
+		r := &p.Rules[i]
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		if r.Severity != "" {
+			if r.severity, err = findings.ParseSeverity(r.Severity); err != nil {
+				return nil, fmt.Errorf("policy %s, %s: %w", file, r.Name, err)
+			}
+		}
+		if r.Message != "" {
+			if r.message, err = regexp.Compile(r.Message); err != nil {
+				return nil, fmt.Errorf("policy %s, %s: invalid message pattern: %w", file, r.Name, err)
+			}
+		}
+		if r.RuleID != "" {
+			if _, err := path.Match(r.RuleID, ""); err != nil {
+				return nil, fmt.Errorf("policy %s, %s: invalid rule_id pattern: %w", file, r.Name, err)
+			}
+		}
+		if r.Max < 0 {
+			return nil, fmt.Errorf("policy %s, %s: max must not be negative", file, r.Name)
+		}
+		if r.ChangedLines && p.Base == "" {
+			return nil, fmt.Errorf("policy %s, %s: changed_lines needs a base revision", file, r.Name)
+		}
+	}
+	return &p, nil
+}
+
+// Discover returns the policy file in the project, or "" when there is none.
+func Discover(projectPath string) string {
+	candidate := filepath.Join(projectPath, filepath.FromSlash(DefaultPath))
+	if _, err := os.Stat(candidate); errors.Is(err, os.ErrNotExist) {
+		return ""
+	}
+	return candidate
+}
+
+// Extend adds the rules of another policy, which keeps its own base revision when
+// this policy has none.
+func (p *Policy) Extend(other *Policy) {
+	p.Rules = append(p.Rules, other.Rules...)
+	if p.Base == "" {
+		p.Base = other.Base
+	}
+}
+
+// NeedsChangedLines reports whether any rule depends on changed lines.
+func (p *Policy) NeedsChangedLines() bool {
+	for _, r := range p.Rules {
+		if r.ChangedLines {
+			return true
+		}
+	}
+	return false
+}
+
+// Run describes what a policy is evaluated against.
+type Run struct {
+	Profile  string
+	Findings []findings.Finding
+	// Changed reports whether a line of a file (relative to the project root) changed
+	// since the policy's base. It is only called for rules with changed_lines.
+	Changed func(file string, line int) bool
+}
+
+// Result is the outcome of one rule.
+type Result struct {
+	Rule    Rule
+	Matched []findings.Finding
+}
+
+// Failed reports whether the rule failed the run.
+func (r Result) Failed() bool {
+	return len(r.Matched) > r.Rule.Max
+}
+
+// Decision is the outcome of evaluating a policy.
+type Decision struct {
+	Results []Result
+}
+
+// Passed reports whether no rule failed.
+func (d Decision) Passed() bool {
+	for _, r := range d.Results {
+		if r.Failed() {
+			return false
+		}
+	}
+	return true
+}
+
+// Evaluate applies every rule to the findings of a run.
+func (p *Policy) Evaluate(run Run) Decision {
+	var d Decision
+	for _, r := range p.Rules {
+		res := Result{Rule: r}
+		if r.Profile == "" || r.Profile == run.Profile {
+			for _, f := range run.Findings {
+				if r.matches(f, run.Changed) {
+					res.Matched = append(res.Matched, f)
+				}
+			}
+		}
+		d.Results = append(d.Results, res)
+	}
+	return d
+}
+
+func (r Rule) matches(f findings.Finding, changed func(string, int) bool) bool {
+	if r.severity != "" && f.Severity.Rank() < r.severity.Rank() {
+		return false
+	}
+	if r.RuleID != "" {
+		if ok, _ := path.Match(r.RuleID, f.RuleID); !ok {
+			return false
+		}
+	}
+	if r.message != nil && !r.message.MatchString(f.Message) {
+		return false
+	}
+	if r.Path != "" && !scanner.MatchGlob(r.Path, f.File) {
+		return false
+	}
+	if r.ChangedLines && (changed == nil || !changed(f.File, f.Line)) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,176 @@
+package prcomment
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/findings"
+)
+
+// Comment is a line comment goreview wants to exist on the pull/merge request.
+type Comment struct {
+	Path        string
+	Line        int
+	Fingerprint string
+	Body        string
+}
+
+// Existing is a goreview comment already present on the pull/merge request.
+type Existing struct {
+	ID          string
+	Path        string
+	Line        int
+	Fingerprint string
+	Body        string
+	Outdated    bool
+}
+
+// Platform abstracts the code host's comment API.
+type Platform interface {
+	// ListComments returns line comments; only ones carrying a goreview marker are used.
+	ListComments(ctx context.Context) ([]Existing, error)
+	CreateComment(ctx context.Context, c Comment) error
+	UpdateComment(ctx context.Context, e Existing, body string) error
+	// ResolveComment collapses or resolves a comment whose finding disappeared.
+	ResolveComment(ctx context.Context, e Existing) error
+	// UpsertSummary creates or replaces the single summary comment.
+	UpsertSummary(ctx context.Context, body string) error
+}
+
+const (
+	// SummaryMarker identifies the run summary comment.
+	SummaryMarker = "<!-- goreview:summary -->"
+	// OutdatedMarker is added to comments whose finding is no longer reported.
+	OutdatedMarker = "<!-- goreview:outdated -->"
+)
+
+var markerPattern = regexp.MustCompile(`<!-- goreview:finding fp=([0-9a-f]+) loc=(.*?):(\d+) -->`)
+
+// Marker returns the hidden marker embedded in a finding comment.
+func Marker(fingerprint, path string, line int) string {
+	return fmt.Sprintf("<!-- goreview:finding fp=%s loc=%s:%d -->", fingerprint, path, line)
+}
+
+// ParseMarker extracts marker data from a comment body.
+func ParseMarker(body string) (fingerprint, path string, line int, ok bool) {
+	m := markerPattern.FindStringSubmatch(body)
+	if m == nil {
+		return "", "", 0, false
+	}
+	line, _ = strconv.Atoi(m[3])
+	return m[1], m[2], line, true
+}
+
+// IsOutdated reports whether a comment body was already marked outdated.
+func IsOutdated(body string) bool {
+	return strings.Contains(body, OutdatedMarker)
+}
+
+// FromFinding builds the comment for a finding at path.
+func FromFinding(path string, f findings.Finding) Comment {
+	title := strings.ToUpper(string(f.Severity))
+	if f.RuleID != "" {
+		title += " [" + f.RuleID + "]"
+	}
+	fp := f.Fingerprint()
+	body := fmt.Sprintf("**goreview** · %s\n\n%s\n\n%s", title, f.Message, Marker(fp, path, f.Line))
+	return Comment{Path: path, Line: f.Line, Fingerprint: fp, Body: body}
+}
+
+// Stats describes what Sync changed.
+type Stats struct {
+	Created, Updated, Unchanged, Resolved int
+	// Unplaced holds comments the platform rejected, typically lines outside the diff.
+	Unplaced []Comment
+}
+
+// Sync reconciles the desired comments with the ones already posted: existing comments
+// are matched by fingerprint, then by location, and updated in place; comments for
+// findings that disappeared are resolved; only genuinely new findings are posted.
+func Sync(ctx context.Context, p Platform, want []Comment) (Stats, error) {
+	var stats Stats
+	existing, err := p.ListComments(ctx)
+	if err != nil {
+		return stats, fmt.Errorf("failed to list existing comments: %w", err)
+	}
+
+	byFingerprint := make(map[string]int)
+	byLocation := make(map[string]int)
+	for i, e := range existing {
+		if e.Outdated {
+			continue
+		}
+		byFingerprint[e.Fingerprint] = i
+		byLocation[location(e.Path, e.Line)] = i
+	}
+
+	matched := make(map[int]bool)
+	for _, c := range want {
+		i, ok := byFingerprint[c.Fingerprint]
+		if !ok || matched[i] {
+			i, ok = byLocation[location(c.Path, c.Line)]
+		}
+		if ok && !matched[i] {
+			matched[i] = true
+			if existing[i].Body == c.Body {
+				stats.Unchanged++
+				continue
+			}
+			if err := p.UpdateComment(ctx, existing[i], c.Body); err != nil {
+				return stats, fmt.Errorf("failed to update comment on %s:%d: %w", c.Path, c.Line, err)
+			}
+			stats.Updated++
+			continue
+		}
+
+		if c.Line <= 0 {
+			stats.Unplaced = append(stats.Unplaced, c)
+			continue
+		}
+		if err := p.CreateComment(ctx, c); err != nil {
+			stats.Unplaced = append(stats.Unplaced, c)
+			continue
+		}
+		stats.Created++
+	}
+
+	for i, e := range existing {
+		if e.Outdated || matched[i] {
+			continue
+		}
+		if err := p.ResolveComment(ctx, e); err != nil {
+			return stats, fmt.Errorf("failed to resolve outdated comment on %s:%d: %w", e.Path, e.Line, err)
+		}
+		stats.Resolved++
+	}
+	return stats, nil
+}
+
+func location(path string, line int) string {
+	return fmt.Sprintf("%s:%d", path, line)
+}
+
+// SummaryBody renders the summary comment, listing findings that could not be placed
+// on a diff line.
+func SummaryBody(headline string, unplaced []Comment) string {
+	var b strings.Builder
+	b.WriteString(SummaryMarker + "\n")
+	b.WriteString("### goreview\n\n")
+	b.WriteString(headline + "\n")
+	if len(unplaced) > 0 {
+		b.WriteString("\n<details><summary>Findings outside the diff</summary>\n\n")
+		for _, c := range unplaced {
+			msg := c.Body
+			if i := strings.Index(msg, "<!--"); i >= 0 {
+				msg = strings.TrimSpace(msg[:i])
+			}
+			msg = strings.ReplaceAll(msg, "\n\n", " — ")
+			fmt.Fprintf(&b, "- `%s:%d` %s\n", c.Path, c.Line, msg)
+		}
+		b.WriteString("\n</details>\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,119 @@
+// Package profiles defines the built-in review profiles that focus a review on one
+// concern.
+package profiles
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Default is the profile used when none is selected.
+const Default = "general"
+
+// Profile focuses the review by extending the system prompt.
+type Profile struct {
+	Name        string
+	Description string
+	// Prompt is appended to the system prompt; empty for the general review.
+	Prompt string
+}
+
+var builtins = map[string]Profile{
+	Default: {
+		Name:        Default,
+		Description: "Balanced review of correctness, security, performance and readability",
+	},
+	"security": {
+		Name:        "security",
+		Description: "Injection, authentication, secrets handling, crypto misuse and unsafe input handling",
+		Prompt: `Focus this review on security. Report only issues an attacker could exploit or that weaken the
+	security posture: injection (SQL, command, path traversal, template), missing authentication or
+	authorization checks, secrets in code or logs, weak or misused cryptography, unsafe deserialization,
+	SSRF, and unchecked input reaching sensitive sinks. Explain the attack scenario for each finding.`,
+	},
+	"license": {
+		Name:        "license",
+		Description: "Required license headers and copied code under incompatible licenses",
+		Prompt: `Focus this review on license compliance. Report code that appears to be copied from another
+	project: foreign copyright or license notices, attribution comments, links to Stack Overflow
+	answers or other repositories, and distinctive code reproduced verbatim from well-known libraries.
+	Name the likely source and its license, and explain whether it conflicts with the license policy
+	below; include [LICENSE-COPY] in each such finding. Do not report other issues.`,
+	},
+	"performance": {
+		Name:        "performance",
+		Description: "Allocations, algorithmic complexity, blocking I/O and contention on hot paths",
+		Prompt: `Focus this review on performance. Report avoidable allocations, quadratic or worse algorithms,
+	repeated work inside loops, unbuffered or blocking I/O, lock contention, and missing preallocation.
+	Estimate the impact of each finding and skip micro-optimizations that would not be measurable.`,
+	},
+	"testgap": {
+		Name:        "testgap",
+		Description: "Untested branches and risky paths, as a prioritized test backlog",
+		Prompt: `Focus this review on test coverage. Compare the code with its existing tests, shown below, and
+	report each branch, error path, edge case and risky behavior that no test exercises. Each finding
+	is a test to write: name the scenario and the expected outcome, and tie it to the line of the
+	untested code. Use the severity as the priority of the test: CRITICAL or HIGH for paths whose
+	failure would corrupt data, leak resources or break callers, LOW or INFO for trivial code. Do not
+	report issues in the code itself.`,
+	},
+	"vuln": {
+		Name:        "vuln",
+		Description: "Exploitability and remediation of known vulnerabilities govulncheck finds reachable",
+		Prompt: `Focus this review on the known vulnerabilities listed below, which govulncheck found reachable
+	from this file. For each one, explain whether the call sites in this file make it exploitable,
+	given how inputs reach them, and how to remediate it: upgrading to the fixed version, or a code
+	change when no fix exists or the upgrade is not possible. Tie each finding to the line of the call
+	site and include the vulnerability ID in brackets, e.g. [GO-2024-1234]. Report other issues only
+	when they make a listed vulnerability easier to exploit.`,
+	},
+	"api": {
+		Name:        "api",
+		Description: "Backward compatibility of exported API changes since a base revision",
+		Prompt: `Focus this review on the backward compatibility of the exported API changes listed below. For
+	each breaking change, explain which callers or implementers break and how (compile errors, changed
+	behavior, changed defaults), and suggest a compatible alternative such as keeping a deprecated
+	wrapper, adding a new function or using an options struct. Also report behavior changes behind
+	unchanged signatures that callers would not expect. Do not report other issues.`,
+	},
+	"concurrency": {
+		Name:        "concurrency",
+		Description: "Data races, deadlocks and goroutine leaks in goroutine, channel and lock usage",
+		Prompt: `Focus this review on concurrency. For the goroutines, channels, locks and atomics listed below,
+	report data races on shared state, deadlocks from lock ordering or blocked channel operations,
+	goroutines that can leak because nothing stops them or their channel is never read, sends on
+	closed channels, copied mutexes, WaitGroup.Add called inside the goroutine, and contexts not used
+	for cancellation. Describe the interleaving that triggers each issue. When race detector reports
+	are included, explain their cause and fix and include [DATA-RACE] in those findings. Do not report
+	other issues.`,
+	},
+	"readability": {
+		Name:        "readability",
+		Description: "Naming, structure, comments and idiomatic Go for maintainers",
+		Prompt: `Focus this review on readability and maintainability. Report unclear names, functions doing too
+	much, deep nesting, missing or misleading doc comments on exported identifiers, and code that is
+	not idiomatic Go. Do not report security or performance issues unless they are severe.`,
+	},
+}
+
+// Get returns the built-in profile with the given name.
+func Get(name string) (Profile, error) {
+	if name == "" {
+		name = Default
+	}
+	p, ok := builtins[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("unknown profile %q (available: %s)", name, Names())
+	}
+	return p, nil
+}
+
+// Names lists the built-in profile names, sorted.
+func Names() []string {
+	names := make([]string, 0, len(builtins))
+	for n := range builtins {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
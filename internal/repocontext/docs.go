@@ -0,0 +1,75 @@
+// Package repocontext gathers repository-level context, such as the project's
+// documentation, that is added to review prompts.
+package repocontext
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CharsPerToken approximates how many characters one token covers, to keep context
+// within a token budget without a tokenizer.
+const CharsPerToken = 4
+
+// DocFiles are the documentation files used by Docs, in order of priority.
+var DocFiles = []string{"README.md", "ARCHITECTURE.md", "CONTRIBUTING.md"}
+
+// Docs returns a prompt section with the project's documentation, truncated so the
+// whole section stays within budget tokens. Missing files are skipped; "" means none
+// were found.
+func Docs(projectPath string, budget int) (string, error) {
+	remaining := budget * CharsPerToken
+	var b strings.Builder
+	for _, name := range DocFiles {
+		if remaining <= 0 {
+			break
+		}
+		path := findFile(projectPath, name)
+		if path == "" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		text := strings.TrimSpace(string(data))
+		if text == "" {
+			continue
+		}
+		if len(text) > remaining {
+			text = truncate(text, remaining) + "\n[truncated]"
+		}
+		remaining -= len(text)
+		fmt.Fprintf(&b, "### %s\n%s\n\n", filepath.Base(path), text)
+	}
+	if b.Len() == 0 {
+		return "", nil
+	}
+	return "Repository documentation. Use it to judge whether the code follows the project's intended " +
+		"design and conventions, rather than giving generic advice:\n\n" + strings.TrimSpace(b.String()), nil
+}
+
+// findFile looks up name in dir case-insensitively, returning "" when it is absent.
+func findFile(dir, name string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	for _, e := range entries {
+		if !e.IsDir() && strings.EqualFold(e.Name(), name) {
+			return filepath.Join(dir, e.Name())
+		}
+	}
+	return ""
+}
+
+// truncate cuts text to at most n bytes at a line boundary when one is close.
+func truncate(text string, n int) string {
+	text = text[:n]
+	if i := strings.LastIndexByte(text, '\n'); i > n/2 {
+		return text[:i]
+	}
+	return strings.ToValidUTF8(text, "")
+}
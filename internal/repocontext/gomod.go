@@ -0,0 +1,81 @@
+package repocontext
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// GoMod summarizes a go.mod file (module path, Go version and direct dependencies) as
+// a prompt section, so suggestions respect the Go version and available libraries.
+func GoMod(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read go.mod: %w", err)
+	}
+
+	var module, goVersion, toolchain string
+	var deps []string
+	inRequire := false
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		indirect := strings.HasSuffix(line, "// indirect")
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" {
+			continue
+		}
+		if inRequire {
+			if line == ")" {
+				inRequire = false
+			} else if !indirect {
+				deps = append(deps, line)
+			}
+			continue
+		}
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "module":
+			if len(fields) > 1 {
+				module = strings.Trim(fields[1], `"`)
+			}
+		case "go":
+			if len(fields) > 1 {
+				goVersion = fields[1]
+			}
+		case "toolchain":
+			if len(fields) > 1 {
+				toolchain = fields[1]
+			}
+		case "require":
+			if len(fields) > 1 && fields[1] == "(" {
+				inRequire = true
+			} else if len(fields) > 2 && !indirect {
+				deps = append(deps, strings.Join(fields[1:], " "))
+			}
+		}
+	}
+	if module == "" {
+		return "", fmt.Errorf("%s has no module directive", path)
+	}
+
+	var b strings.Builder
+	b.WriteString("Go module context. Respect the Go version (do not suggest language features or standard library ")
+	b.WriteString("APIs newer than it) and prefer the existing dependencies over adding new ones:\n")
+	fmt.Fprintf(&b, "- Module: %s\n", module)
+	if goVersion != "" {
+		fmt.Fprintf(&b, "- Go version: %s", goVersion)
+		if toolchain != "" {
+			fmt.Fprintf(&b, " (toolchain %s)", toolchain)
+		}
+		b.WriteString("\n")
+	}
+	if len(deps) > 0 {
+		b.WriteString("- Direct dependencies:\n")
+		for _, d := range deps {
+			fmt.Fprintf(&b, "  - %s\n", d)
+		}
+	}
+	return b.String(), nil
+}
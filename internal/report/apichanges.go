@@ -0,0 +1,58 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/disconnekt/goreview/internal/apidiff"
+)
+
+// APIChanges collects the exported API changes attached to the reviewed files,
+// breaking changes first.
+func APIChanges(all []FileReview) []apidiff.Change {
+	var changes []apidiff.Change
+	for _, fr := range all {
+		if fr.DuplicateOf == "" {
+			changes = append(changes, fr.APIChanges...)
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Breaking != changes[j].Breaking {
+			return changes[i].Breaking
+		}
+		if changes[i].Package != changes[j].Package {
+			return changes[i].Package < changes[j].Package
+		}
+		return changes[i].Symbol < changes[j].Symbol
+	})
+	return changes
+}
+
+// writeBreakingChanges lists the breaking API changes and counts the compatible ones;
+// heading receives the number of breaking changes.
+func writeBreakingChanges(w io.Writer, heading string, all []FileReview) error {
+	changes := APIChanges(all)
+	if len(changes) == 0 {
+		return nil
+	}
+	breaking := 0
+	for _, c := range changes {
+		if c.Breaking {
+			breaking++
+		}
+	}
+	fmt.Fprintf(w, heading, breaking)
+	for _, c := range changes[:breaking] {
+		loc := c.File
+		if c.Line > 0 {
+			loc = fmt.Sprintf("%s:%d", loc, c.Line)
+		}
+		fmt.Fprintf(w, "- %s: %s (%s)\n", c.Package, c, loc)
+	}
+	if compatible := len(changes) - breaking; compatible > 0 {
+		fmt.Fprintf(w, "\nCompatible additions: %d\n", compatible)
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
@@ -0,0 +1,54 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/findings"
+)
+
+// TestBacklog collects the findings of a test-gap review across all files, highest
+// priority first; the severity of a finding is the priority of writing its test. File
+// paths are made relative to root.
+func TestBacklog(root string, all []FileReview) []findings.Finding {
+	var backlog []findings.Finding
+	for _, fr := range all {
+		if fr.DuplicateOf != "" {
+			continue
+		}
+		for _, f := range fr.Findings {
+			f.File = RelativeTo(root, fr.Path)
+			backlog = append(backlog, f)
+		}
+	}
+	sort.SliceStable(backlog, func(i, j int) bool {
+		if ri, rj := backlog[i].Severity.Rank(), backlog[j].Severity.Rank(); ri != rj {
+			return ri > rj
+		}
+		if backlog[i].File != backlog[j].File {
+			return backlog[i].File < backlog[j].File
+		}
+		return backlog[i].Line < backlog[j].Line
+	})
+	return backlog
+}
+
+// writeTestBacklog renders the backlog as a numbered list; heading receives the count.
+func writeTestBacklog(w io.Writer, heading, root string, all []FileReview) error {
+	backlog := TestBacklog(root, all)
+	if len(backlog) == 0 {
+		return nil
+	}
+	fmt.Fprintf(w, heading, len(backlog))
+	for i, f := range backlog {
+		loc := f.File
+		if f.Line > 0 {
+			loc = fmt.Sprintf("%s:%d", loc, f.Line)
+		}
+		fmt.Fprintf(w, "%d. [%s] %s: %s\n", i+1, strings.ToUpper(string(f.Severity)), loc, f.Message)
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
@@ -0,0 +1,52 @@
+package report
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/findings"
+)
+
+const (
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiDim   = "\x1b[2m"
+)
+
+// severityColors are the ANSI colors of the severity tags, from bold red for critical
+// findings to dim for informational ones.
+var severityColors = map[findings.Severity]string{
+	findings.SeverityCritical: "\x1b[1;31m",
+	findings.SeverityHigh:     "\x1b[31m",
+	findings.SeverityMedium:   "\x1b[33m",
+	findings.SeverityLow:      "\x1b[36m",
+	findings.SeverityInfo:     ansiDim,
+}
+
+var severityTag = regexp.MustCompile(`\[(CRITICAL|HIGH|MEDIUM|LOW|INFO)\]`)
+
+// palette styles terminal output; the zero value leaves text unchanged.
+type palette struct {
+	enabled bool
+}
+
+func (p palette) paint(style, s string) string {
+	if !p.enabled || s == "" {
+		return s
+	}
+	return style + s + ansiReset
+}
+
+func (p palette) bold(s string) string { return p.paint(ansiBold, s) }
+func (p palette) dim(s string) string  { return p.paint(ansiDim, s) }
+
+// severities colors every severity tag such as "[HIGH]" in the text.
+func (p palette) severities(text string) string {
+	if !p.enabled {
+		return text
+	}
+	return severityTag.ReplaceAllStringFunc(text, func(tag string) string {
+		sev := findings.Severity(strings.ToLower(strings.Trim(tag, "[]")))
+		return p.paint(severityColors[sev], tag)
+	})
+}
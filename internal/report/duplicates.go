@@ -0,0 +1,66 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/findings"
+)
+
+// DuplicateRulePrefix starts the rule IDs of duplicate code findings, which end with the
+// number of the duplicated pair, e.g. DUP-3. Both copies share the ID.
+const DuplicateRulePrefix = "DUP-"
+
+// Duplicates collects the duplicate code findings across all files, largest pairs
+// first. File paths are made relative to root.
+func Duplicates(root string, all []FileReview) []findings.Finding {
+	var dups []findings.Finding
+	for _, fr := range all {
+		if fr.DuplicateOf != "" {
+			continue
+		}
+		for _, f := range fr.Findings {
+			if strings.HasPrefix(f.RuleID, DuplicateRulePrefix) {
+				f.File = RelativeTo(root, fr.Path)
+				dups = append(dups, f)
+			}
+		}
+	}
+	sort.SliceStable(dups, func(i, j int) bool {
+		ni, nj := duplicateNumber(dups[i].RuleID), duplicateNumber(dups[j].RuleID)
+		if ni != nj {
+			return ni < nj
+		}
+		if dups[i].File != dups[j].File {
+			return dups[i].File < dups[j].File
+		}
+		return dups[i].Line < dups[j].Line
+	})
+	return dups
+}
+
+func duplicateNumber(rule string) int {
+	var n int
+	fmt.Sscanf(strings.TrimPrefix(rule, DuplicateRulePrefix), "%d", &n)
+	return n
+}
+
+// writeDuplicates lists the duplicate code findings; heading receives the count.
+func writeDuplicates(w io.Writer, heading, root string, all []FileReview) error {
+	dups := Duplicates(root, all)
+	if len(dups) == 0 {
+		return nil
+	}
+	fmt.Fprintf(w, heading, len(dups))
+	for _, f := range dups {
+		loc := f.File
+		if f.Line > 0 {
+			loc = fmt.Sprintf("%s:%d", loc, f.Line)
+		}
+		fmt.Fprintf(w, "- [%s] %s: %s\n", f.RuleID, loc, f.Message)
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
@@ -0,0 +1,162 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/history"
+)
+
+// Formatter renders file reviews in a specific output format. Streaming formats write
+// each entry as soon as the file is reviewed; document formats collect everything and
+// write it in Finish.
+type Formatter interface {
+	WriteEntry(w io.Writer, fr FileReview) error
+	// WriteSkipped reports files that were left out of the review and why.
+	WriteSkipped(w io.Writer, skipped []SkippedFile) error
+	Finish(w io.Writer, all []FileReview) error
+}
+
+// SkippedFile is a file left out of the review, with the reason.
+type SkippedFile struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// Options tunes how formatters render paths and metadata.
+type Options struct {
+	// Root is the directory file paths are made relative to by the formats CI systems
+	// resolve against the checkout: sarif, warnings-ng and github-actions.
+	Root string
+	// PathRoot is the directory file paths are made relative to by the other formats;
+	// when empty they keep absolute paths.
+	PathRoot string
+	// Template is the path of the text/template used by the "template" format.
+	Template string
+	// TestBacklog adds a prioritized test backlog built from all findings to the
+	// text, markdown and json formats (test-gap reviews).
+	TestBacklog bool
+	// ByOwner adds the findings grouped by CODEOWNERS owner to the text, markdown and
+	// json formats.
+	ByOwner bool
+	// Color styles the text format with ANSI escape sequences for a terminal.
+	Color bool
+	// Precision is the recorded feedback on findings, shown by the summary format.
+	Precision []history.Precision
+	// Run describes the model and decoding parameters, written at the top of the text
+	// and markdown formats and in the json and template data.
+	Run RunInfo
+}
+
+var formatters = map[string]func(Options) (Formatter, error){
+	"text": func(o Options) (Formatter, error) {
+		return textFormatter{root: o.PathRoot, backlog: o.TestBacklog, byOwner: o.ByOwner, color: palette{o.Color}, run: o.Run}, nil
+	},
+	"github-actions": func(o Options) (Formatter, error) { return githubActionsFormatter{root: o.Root}, nil },
+	"template":       newTemplateFormatter,
+	"markdown": func(o Options) (Formatter, error) {
+		return markdownFormatter{root: o.PathRoot, backlog: o.TestBacklog, byOwner: o.ByOwner, run: o.Run}, nil
+	},
+	"json": func(o Options) (Formatter, error) {
+		return &jsonFormatter{root: o.PathRoot, backlog: o.TestBacklog, byOwner: o.ByOwner, run: o.Run}, nil
+	},
+	"sarif": func(o Options) (Formatter, error) { return sarifFormatter{root: o.Root}, nil },
+	"summary": func(o Options) (Formatter, error) {
+		return &summaryFormatter{root: o.PathRoot, precision: o.Precision}, nil
+	},
+	"risk":        func(o Options) (Formatter, error) { return riskFormatter{root: o.PathRoot}, nil },
+	"heatmap":     func(o Options) (Formatter, error) { return heatmapFormatter{root: o.PathRoot}, nil },
+	"warnings-ng": func(o Options) (Formatter, error) { return warningsFormatter{root: o.Root}, nil },
+}
+
+// formatAliases maps short names accepted in place of a format name.
+var formatAliases = map[string]string{"md": "markdown"}
+
+// NewFormatter returns the formatter registered under name.
+func NewFormatter(name string, opts Options) (Formatter, error) {
+	if alias, ok := formatAliases[name]; ok {
+		name = alias
+	}
+	f, ok := formatters[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown report format %q (available: %s)", name, FormatNames())
+	}
+	return f(opts)
+}
+
+// FormatNames lists the available format names, sorted.
+func FormatNames() []string {
+	names := make([]string, 0, len(formatters))
+	for n := range formatters {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// textFormatter is the plain "=== Review for ... ===" layout.
+type textFormatter struct {
+	root    string
+	backlog bool
+	byOwner bool
+	color   palette
+	run     RunInfo
+}
+
+func (t textFormatter) WriteHeader(w io.Writer) error {
+	fmt.Fprintln(w, t.color.dim("Model: "+t.run.modelLine()))
+	_, err := fmt.Fprintln(w, t.color.dim("Decoding: "+t.run.Decoding()))
+	return err
+}
+
+func (t textFormatter) WriteEntry(w io.Writer, fr FileReview) error {
+	if fr.Review == "" && len(fr.Findings) == 0 {
+		return nil
+	}
+	fr.Path = RelativeTo(t.root, fr.Path)
+	if fr.DuplicateOf != "" {
+		fr.DuplicateOf = RelativeTo(t.root, fr.DuplicateOf)
+	}
+	writeEntry(w, fr, t.color)
+	return nil
+}
+
+func (t textFormatter) WriteSkipped(w io.Writer, skipped []SkippedFile) error {
+	if len(skipped) == 0 {
+		return nil
+	}
+	fmt.Fprintf(w, "\n%s\n", t.color.bold(fmt.Sprintf("=== Skipped files (%d) ===", len(skipped))))
+	for _, sf := range skipped {
+		fmt.Fprintf(w, "- %s: %s\n", RelativeTo(t.root, sf.Path), sf.Reason)
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+func (t textFormatter) Finish(w io.Writer, all []FileReview) error {
+	// The sections are rendered first so their severity tags can be colored
+	var b strings.Builder
+	if t.byOwner {
+		if err := writeOwnerGroups(&b, "\n=== %s (%d) ===\n", t.root, all); err != nil {
+			return err
+		}
+	}
+	if t.backlog {
+		if err := writeTestBacklog(&b, "\n=== Test backlog (%d) ===\n", t.root, all); err != nil {
+			return err
+		}
+	}
+	if err := writeBreakingChanges(&b, "\n=== Breaking changes (%d) ===\n", all); err != nil {
+		return err
+	}
+	if err := writeDuplicates(&b, "\n=== Duplicate code (%d) ===\n", t.root, all); err != nil {
+		return err
+	}
+	if err := writeTodos(&b, "\n=== Tech debt (%d) ===\n", t.root, all); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, t.color.severities(b.String()))
+	return err
+}
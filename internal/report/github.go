@@ -0,0 +1,97 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/findings"
+)
+
+// githubActionsFormatter prints workflow commands so findings surface as annotations
+// in the pull request's Files Changed tab.
+type githubActionsFormatter struct {
+	root string
+}
+
+func (g githubActionsFormatter) WriteEntry(w io.Writer, fr FileReview) error {
+	file := RelativeTo(g.root, fr.Path)
+	if len(fr.Findings) == 0 {
+		if strings.TrimSpace(fr.Review) != "" && fr.Suppressed == 0 {
+			_, err := fmt.Fprintf(w, "::notice file=%s,title=goreview::%s\n", escapeProperty(file), escapeData(fr.Review))
+			return err
+		}
+		return nil
+	}
+
+	for _, f := range fr.Findings {
+		props := []string{"file=" + escapeProperty(file)}
+		if f.Line > 0 {
+			props = append(props, fmt.Sprintf("line=%d", f.Line))
+			if f.EndLine > f.Line {
+				props = append(props, fmt.Sprintf("endLine=%d", f.EndLine))
+			}
+		}
+		title := "goreview " + strings.ToUpper(string(f.Severity))
+		if f.RuleID != "" {
+			title += " [" + f.RuleID + "]"
+		}
+		props = append(props, "title="+escapeProperty(title))
+
+		if _, err := fmt.Fprintf(w, "::%s %s::%s\n", annotationLevel(f.Severity), strings.Join(props, ","), escapeData(f.Message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g githubActionsFormatter) WriteSkipped(w io.Writer, skipped []SkippedFile) error {
+	for _, sf := range skipped {
+		msg := "Not reviewed: " + sf.Reason
+		if _, err := fmt.Fprintf(w, "::notice file=%s,title=goreview skipped::%s\n",
+			escapeProperty(RelativeTo(g.root, sf.Path)), escapeData(msg)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (githubActionsFormatter) Finish(io.Writer, []FileReview) error { return nil }
+
+// RelativeTo makes p relative to root with forward slashes, which is how CI systems
+// resolve annotated files. Paths outside root are returned unchanged.
+func RelativeTo(root, p string) string {
+	if root == "" || !filepath.IsAbs(p) {
+		return filepath.ToSlash(p)
+	}
+	if rel, err := filepath.Rel(root, p); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(p)
+}
+
+func annotationLevel(s findings.Severity) string {
+	switch s {
+	case findings.SeverityCritical, findings.SeverityHigh:
+		return "error"
+	case findings.SeverityMedium:
+		return "warning"
+	default:
+		return "notice"
+	}
+}
+
+// escapeData escapes a workflow command message.
+func escapeData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+// escapeProperty escapes a workflow command property value.
+func escapeProperty(s string) string {
+	s = escapeData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	return strings.ReplaceAll(s, ",", "%2C")
+}
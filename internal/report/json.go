@@ -0,0 +1,102 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/disconnekt/goreview/internal/apidiff"
+	"github.com/disconnekt/goreview/internal/findings"
+	"github.com/disconnekt/goreview/internal/todo"
+)
+
+// jsonReport is the document written by the "json" format.
+type jsonReport struct {
+	Generated time.Time                 `json:"generated"`
+	Run       RunInfo                   `json:"run"`
+	Files     []FileReview              `json:"files"`
+	Findings  []findings.Finding        `json:"findings"`
+	Counts    map[findings.Severity]int `json:"counts"`
+	Skipped   []SkippedFile             `json:"skipped,omitempty"`
+	// TestBacklog is set for test-gap reviews.
+	TestBacklog []findings.Finding `json:"test_backlog,omitempty"`
+	// ByOwner groups the findings by CODEOWNERS owner when requested.
+	ByOwner map[string][]findings.Finding `json:"by_owner,omitempty"`
+	// APIChanges lists the exported API changes of an API review, breaking first.
+	APIChanges []apidiff.Change `json:"api_changes,omitempty"`
+	// Duplicates lists the duplicate code findings, both copies of each pair.
+	Duplicates []findings.Finding `json:"duplicates,omitempty"`
+	// Todos is the inventory of TODO comments collected with --todos.
+	Todos []todo.Todo `json:"todos,omitempty"`
+	// Usage sums the provider usage of the run.
+	Usage *Usage `json:"usage,omitempty"`
+}
+
+// jsonFormatter writes a single JSON document once all files are reviewed.
+type jsonFormatter struct {
+	root    string
+	backlog bool
+	byOwner bool
+	run     RunInfo
+	skipped []SkippedFile
+}
+
+func (*jsonFormatter) WriteEntry(io.Writer, FileReview) error { return nil }
+
+func (j *jsonFormatter) WriteSkipped(_ io.Writer, skipped []SkippedFile) error {
+	j.skipped = skipped
+	return nil
+}
+
+// withFile returns a copy of the findings with their file set to path, which follows
+// the report's path style.
+func withFile(list []findings.Finding, path string) []findings.Finding {
+	if list == nil {
+		return nil
+	}
+	out := make([]findings.Finding, len(list))
+	for i, f := range list {
+		f.File = path
+		out[i] = f
+	}
+	return out
+}
+
+func (j *jsonFormatter) Finish(w io.Writer, all []FileReview) error {
+	doc := jsonReport{
+		Generated: time.Now().UTC(),
+		Run:       j.run,
+		Files:     []FileReview{},
+		Findings:  []findings.Finding{},
+	}
+	for _, fr := range all {
+		fr.Path = RelativeTo(j.root, fr.Path)
+		fr.Findings = withFile(fr.Findings, fr.Path)
+		doc.Files = append(doc.Files, fr)
+		doc.Findings = append(doc.Findings, fr.Findings...)
+	}
+	for _, sf := range j.skipped {
+		sf.Path = RelativeTo(j.root, sf.Path)
+		doc.Skipped = append(doc.Skipped, sf)
+	}
+	doc.Usage = TotalUsage(all)
+	findings.Sort(doc.Findings)
+	doc.Counts = findings.CountBySeverity(doc.Findings)
+	if j.backlog {
+		doc.TestBacklog = TestBacklog(j.root, all)
+	}
+	if j.byOwner {
+		doc.ByOwner = ByOwner(j.root, all)
+	}
+	doc.APIChanges = APIChanges(all)
+	doc.Duplicates = Duplicates(j.root, all)
+	doc.Todos = Todos(j.root, all)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode JSON report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// markdownFormatter writes one "## path" section per reviewed file, suitable for
+// publishing as a job summary or wiki page.
+type markdownFormatter struct {
+	root    string
+	backlog bool
+	byOwner bool
+	run     RunInfo
+}
+
+func (m markdownFormatter) WriteHeader(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "_Model: %s. Decoding: %s._\n\n", m.run.modelLine(), m.run.Decoding())
+	return err
+}
+
+func (m markdownFormatter) WriteEntry(w io.Writer, fr FileReview) error {
+	if fr.Review == "" && len(fr.Findings) == 0 {
+		return nil
+	}
+	fmt.Fprintf(w, "## %s\n\n", RelativeTo(m.root, fr.Path))
+	if fr.Module != "" {
+		fmt.Fprintf(w, "Module: `%s`\n\n", fr.Module)
+	}
+	if len(fr.Owners) > 0 {
+		fmt.Fprintf(w, "Owners: %s\n\n", strings.Join(fr.Owners, ", "))
+	}
+	if fr.DuplicateOf != "" {
+		fmt.Fprintf(w, "_Identical to %s (review reused)._\n\n", RelativeTo(m.root, fr.DuplicateOf))
+	}
+	if fr.Suppressed > 0 {
+		fmt.Fprintf(w, "_Suppressed by the baseline: %d._\n\n", fr.Suppressed)
+	}
+	_, err := fmt.Fprintf(w, "%s\n\n", strings.TrimSpace(fr.Body()))
+	return err
+}
+
+func (m markdownFormatter) WriteSkipped(w io.Writer, skipped []SkippedFile) error {
+	if len(skipped) == 0 {
+		return nil
+	}
+	fmt.Fprintf(w, "## Skipped files (%d)\n\n", len(skipped))
+	for _, sf := range skipped {
+		fmt.Fprintf(w, "- `%s`: %s\n", RelativeTo(m.root, sf.Path), sf.Reason)
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+func (m markdownFormatter) Finish(w io.Writer, all []FileReview) error {
+	if m.byOwner {
+		if err := writeOwnerGroups(w, "## Owner %s (%d)\n\n", m.root, all); err != nil {
+			return err
+		}
+	}
+	if m.backlog {
+		if err := writeTestBacklog(w, "## Test backlog (%d)\n\n", m.root, all); err != nil {
+			return err
+		}
+	}
+	if err := writeBreakingChanges(w, "## Breaking changes (%d)\n\n", all); err != nil {
+		return err
+	}
+	if err := writeDuplicates(w, "## Duplicate code (%d)\n\n", m.root, all); err != nil {
+		return err
+	}
+	return writeTodos(w, "## Tech debt (%d)\n\n", m.root, all)
+}
@@ -0,0 +1,65 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/findings"
+)
+
+// Unowned is the group of findings in files without a CODEOWNERS owner.
+const Unowned = "(no owner)"
+
+// ByOwner groups the findings of all files by owner, most severe first; a finding in
+// a file with several owners is listed under each of them. File paths are made
+// relative to root.
+func ByOwner(root string, all []FileReview) map[string][]findings.Finding {
+	groups := make(map[string][]findings.Finding)
+	for _, fr := range all {
+		owners := fr.Owners
+		if len(owners) == 0 {
+			owners = []string{Unowned}
+		}
+		for _, f := range fr.Findings {
+			f.File = RelativeTo(root, fr.Path)
+			for _, o := range owners {
+				groups[o] = append(groups[o], f)
+			}
+		}
+	}
+	for _, list := range groups {
+		findings.Sort(list)
+		sort.SliceStable(list, func(i, j int) bool {
+			return list[i].Severity.Rank() > list[j].Severity.Rank()
+		})
+	}
+	return groups
+}
+
+// writeOwnerGroups renders one list of findings per owner, owners with the most
+// findings first; heading receives the owner and the count.
+func writeOwnerGroups(w io.Writer, heading, root string, all []FileReview) error {
+	groups := ByOwner(root, all)
+	names := make([]string, 0, len(groups))
+	for o := range groups {
+		names = append(names, o)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(groups[names[i]]) != len(groups[names[j]]) {
+			return len(groups[names[i]]) > len(groups[names[j]])
+		}
+		return names[i] < names[j]
+	})
+	for _, o := range names {
+		fmt.Fprintf(w, heading, o, len(groups[o]))
+		for _, f := range groups[o] {
+			fmt.Fprintf(w, "- [%s] %s: %s\n", strings.ToUpper(string(f.Severity)), f.Location(), f.Message)
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
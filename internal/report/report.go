@@ -0,0 +1,174 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/disconnekt/goreview/internal/apidiff"
+	"github.com/disconnekt/goreview/internal/findings"
+	"github.com/disconnekt/goreview/internal/metrics"
+	"github.com/disconnekt/goreview/internal/reviewer"
+	"github.com/disconnekt/goreview/internal/todo"
+)
+
+// FileReview is the review outcome for a single file.
+type FileReview struct {
+	Path     string             `json:"path"`
+	Module   string             `json:"module,omitempty"`
+	Owners   []string           `json:"owners,omitempty"`
+	Size     int64              `json:"size"`
+	Review   string             `json:"review,omitempty"`
+	Findings []findings.Finding `json:"findings,omitempty"`
+	// Suppressed counts findings hidden because they were dismissed in the baseline.
+	Suppressed int `json:"suppressed,omitempty"`
+	// DuplicateOf is the path of an identical file whose review was reused.
+	DuplicateOf string `json:"duplicate_of,omitempty"`
+	// Truncated reports that the review was cut at --max-review-chars.
+	Truncated bool `json:"truncated,omitempty"`
+	// APIChanges are the exported API changes of the file since the base revision.
+	APIChanges []apidiff.Change `json:"api_changes,omitempty"`
+	// Functions holds the complexity metrics of the file's functions.
+	Functions []metrics.Function `json:"functions,omitempty"`
+	// Todos are the TODO comments of the file.
+	Todos []todo.Todo `json:"todos,omitempty"`
+	// Churn counts the lines of the file added and deleted since --churn-since, for the
+	// risk score.
+	Churn int `json:"churn,omitempty"`
+	// RequestID is the ID sent with the review's requests; ProviderRequestIDs are the IDs
+	// the provider returned, to quote in support tickets.
+	RequestID          string   `json:"request_id,omitempty"`
+	ProviderRequestIDs []string `json:"provider_request_ids,omitempty"`
+	// Usage is the provider usage and response metadata of the review; nil for reused
+	// reviews.
+	Usage *Usage `json:"usage,omitempty"`
+	// Model is the model that reviewed the file when a module override or route
+	// replaced --model.
+	Model string `json:"model,omitempty"`
+	// Prefiltered reports that --triage-model found nothing worth a review, so the
+	// review model did not see the file.
+	Prefiltered bool `json:"prefiltered,omitempty"`
+	// Error is why the file has no review, for a file that a worker failed to review.
+	Error string `json:"error,omitempty"`
+}
+
+// Body returns the text shown for the file: the structured findings when the review
+// could be parsed, otherwise the raw review text.
+func (fr FileReview) Body() string {
+	if len(fr.Findings) == 0 {
+		if fr.Error != "" {
+			return "Not reviewed: " + fr.Error
+		}
+		if fr.Prefiltered {
+			return "Not reviewed: the triage model found no likely issues."
+		}
+		if fr.Suppressed > 0 {
+			return "No new issues (all findings are suppressed by the baseline)."
+		}
+		return fr.Review
+	}
+	if hasModels(fr.Findings) {
+		return findings.ConsensusMarkdown(fr.Findings, modelCount(fr.Findings))
+	}
+	var body string
+	for _, f := range fr.Findings {
+		body += findings.FormatBullet(f) + "\n"
+	}
+	if fr.Truncated {
+		// The raw review carries the marker; structured findings need it added
+		body += "\n" + reviewer.TruncationMarker + "\n"
+	}
+	return body
+}
+
+// WriteEntry writes a single file's review in the plain report layout.
+func WriteEntry(w io.Writer, fr FileReview) {
+	writeEntry(w, fr, palette{})
+}
+
+func writeEntry(w io.Writer, fr FileReview, p palette) {
+	fmt.Fprintf(w, "\n%s\n", p.bold("=== Review for "+fr.Path+" ==="))
+	if fr.Module != "" {
+		fmt.Fprintln(w, p.dim("Module: "+fr.Module))
+	}
+	fmt.Fprintln(w, p.dim(fmt.Sprintf("File size: %d bytes", fr.Size)))
+	if fr.RequestID != "" {
+		fmt.Fprintln(w, p.dim("Request ID: "+requestIDs(fr)))
+	}
+	if fr.DuplicateOf != "" {
+		fmt.Fprintln(w, p.dim("Identical to: "+fr.DuplicateOf+" (review reused)"))
+	}
+	if fr.Suppressed > 0 {
+		fmt.Fprintln(w, p.dim(fmt.Sprintf("Suppressed findings: %d", fr.Suppressed)))
+	}
+	fmt.Fprintf(w, "Review:\n%s\n\n", p.severities(fr.Body()))
+}
+
+// requestIDs renders the request ID of a review with the provider's IDs for it.
+func requestIDs(fr FileReview) string {
+	if len(fr.ProviderRequestIDs) == 0 {
+		return fr.RequestID
+	}
+	return fr.RequestID + " (provider: " + strings.Join(fr.ProviderRequestIDs, ", ") + ")"
+}
+
+func hasModels(list []findings.Finding) bool {
+	for _, f := range list {
+		if len(f.Models) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func modelCount(list []findings.Finding) int {
+	seen := make(map[string]bool)
+	for _, f := range list {
+		for _, m := range f.Models {
+			seen[m] = true
+		}
+	}
+	return len(seen)
+}
+
+// LastRunFile is the name of the file, inside the state directory, that stores the
+// findings of the most recent run for follow-up commands such as triage.
+const LastRunFile = "last-run.json"
+
+// LastRun is the persisted outcome of the most recent run.
+type LastRun struct {
+	GeneratedAt time.Time          `json:"generated_at"`
+	Findings    []findings.Finding `json:"findings"`
+}
+
+// SaveLastRun writes the findings of a run to path, creating parent directories as needed.
+func SaveLastRun(path string, list []findings.Finding) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	data, err := json.MarshalIndent(LastRun{GeneratedAt: time.Now().UTC(), Findings: list}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode findings: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write findings: %w", err)
+	}
+	return nil
+}
+
+// LoadLastRun reads findings written by SaveLastRun.
+func LoadLastRun(path string) (*LastRun, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read findings from last run: %w", err)
+	}
+	var run LastRun
+	if err := json.Unmarshal(data, &run); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &run, nil
+}
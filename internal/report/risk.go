@@ -0,0 +1,122 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"path"
+	"sort"
+
+	"github.com/disconnekt/goreview/internal/risk"
+)
+
+// RiskFiles scores the reviewed files, riskiest first. File paths are made relative
+// to root.
+func RiskFiles(root string, all []FileReview) []risk.File {
+	var files []risk.File
+	for _, fr := range all {
+		// A copy is scored once, through the file it duplicates
+		if fr.DuplicateOf != "" {
+			continue
+		}
+		complexity := 0
+		for _, fn := range fr.Functions {
+			complexity += fn.Complexity - 1
+		}
+		files = append(files, risk.File{
+			Path:       RelativeTo(root, fr.Path),
+			Findings:   risk.FindingsWeight(fr.Findings),
+			Complexity: complexity,
+			Churn:      fr.Churn,
+			Size:       fr.Size,
+		})
+	}
+	risk.Rank(files)
+	return files
+}
+
+// riskFormatter writes the files as a table sorted by risk score.
+type riskFormatter struct {
+	root string
+}
+
+func (riskFormatter) WriteEntry(io.Writer, FileReview) error      { return nil }
+func (riskFormatter) WriteSkipped(io.Writer, []SkippedFile) error { return nil }
+
+func (r riskFormatter) Finish(w io.Writer, all []FileReview) error {
+	files := RiskFiles(r.root, all)
+	fmt.Fprintf(w, "\n=== Risk (%d files) ===\n", len(files))
+	fmt.Fprintf(w, "%6s %9s %11s %7s %8s  %s\n", "SCORE", "FINDINGS", "COMPLEXITY", "CHURN", "SIZE", "FILE")
+	for _, f := range files {
+		fmt.Fprintf(w, "%6.1f %9d %11d %7d %8d  %s\n", f.Score, f.Findings, f.Complexity, f.Churn, f.Size, f.Path)
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// heatmapFormatter writes a self-contained HTML page of the files as tiles grouped by
+// directory and colored by risk score.
+type heatmapFormatter struct {
+	root string
+}
+
+type heatmapDir struct {
+	Name  string
+	Files []risk.File
+}
+
+var heatmapTemplate = template.Must(template.New("heatmap").Funcs(template.FuncMap{
+	"base": path.Base,
+	// Green at 0 to red at 100
+	"hue": func(score float64) int { return int(120 - score*1.2) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Risk heatmap</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+h2 { font-size: 1em; margin: 1.5em 0 .5em; font-family: monospace; }
+.tiles { display: flex; flex-wrap: wrap; gap: 4px; }
+.tile { padding: .5em; min-width: 9em; border-radius: 3px; font-size: .85em; color: #111; }
+.tile b { display: block; font-family: monospace; overflow-wrap: anywhere; }
+</style>
+</head>
+<body>
+<h1>Risk heatmap</h1>
+<p>{{len .Files}} files. The score combines severity-weighted findings, complexity, recent churn and size, relative to the other files.</p>
+{{range .Dirs}}<h2>{{.Name}}/</h2>
+<div class="tiles">
+{{range .Files}}<div class="tile" style="background: hsl({{hue .Score}}, 70%, 60%)" title="{{.Path}}: findings {{.Findings}}, complexity {{.Complexity}}, churn {{.Churn}}, {{.Size}} bytes"><b>{{base .Path}}</b>{{printf "%.1f" .Score}}</div>
+{{end}}</div>
+{{end}}</body>
+</html>
+`))
+
+func (heatmapFormatter) WriteEntry(io.Writer, FileReview) error      { return nil }
+func (heatmapFormatter) WriteSkipped(io.Writer, []SkippedFile) error { return nil }
+
+func (h heatmapFormatter) Finish(w io.Writer, all []FileReview) error {
+	files := RiskFiles(h.root, all)
+	byDir := make(map[string]*heatmapDir)
+	var dirs []*heatmapDir
+	for _, f := range files {
+		name := path.Dir(f.Path)
+		d := byDir[name]
+		if d == nil {
+			d = &heatmapDir{Name: name}
+			byDir[name] = d
+			dirs = append(dirs, d)
+		}
+		d.Files = append(d.Files, f)
+	}
+	// Directories follow their riskiest file, which the ranking put first
+	sort.SliceStable(dirs, func(i, j int) bool { return dirs[i].Files[0].Score > dirs[j].Files[0].Score })
+	if err := heatmapTemplate.Execute(w, struct {
+		Files []risk.File
+		Dirs  []*heatmapDir
+	}{files, dirs}); err != nil {
+		return fmt.Errorf("failed to render the heatmap: %w", err)
+	}
+	return nil
+}
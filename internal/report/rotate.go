@@ -0,0 +1,119 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// pathPlaceholders are expanded in report paths so recurring runs write new files.
+var pathPlaceholders = map[string]string{
+	"{{date}}":      "2006-01-02",
+	"{{time}}":      "150405",
+	"{{timestamp}}": "20060102-150405",
+}
+
+// placeholderPatterns match what each placeholder expands to.
+var placeholderPatterns = map[string]string{
+	"{{date}}":      `\d{4}-\d{2}-\d{2}`,
+	"{{time}}":      `\d{6}`,
+	"{{timestamp}}": `\d{8}-\d{6}`,
+}
+
+// pathPattern returns a regexp matching exactly the paths ExpandPath can produce from path.
+func pathPattern(path string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for rest := path; rest != ""; {
+		next, placeholder := len(rest), ""
+		for p := range placeholderPatterns {
+			if i := strings.Index(rest, p); i >= 0 && i < next {
+				next, placeholder = i, p
+			}
+		}
+		b.WriteString(regexp.QuoteMeta(rest[:next]))
+		if placeholder == "" {
+			break
+		}
+		b.WriteString(placeholderPatterns[placeholder])
+		rest = rest[next+len(placeholder):]
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// ExpandPath replaces the date placeholders of a report path with t.
+func ExpandPath(path string, t time.Time) string {
+	for placeholder, layout := range pathPlaceholders {
+		path = strings.ReplaceAll(path, placeholder, t.Format(layout))
+	}
+	return path
+}
+
+// IsTemplatedPath reports whether path contains a date placeholder.
+func IsTemplatedPath(path string) bool {
+	for placeholder := range pathPlaceholders {
+		if strings.Contains(path, placeholder) {
+			return true
+		}
+	}
+	return false
+}
+
+// PruneReports deletes all but the keep most recent reports written to the templated
+// path and returns the removed files. Only files whose names the placeholders could have
+// produced count as reports. Paths without placeholders are left alone.
+func PruneReports(path string, keep int) ([]string, error) {
+	if keep <= 0 || !IsTemplatedPath(path) {
+		return nil, nil
+	}
+	pattern := path
+	for placeholder := range pathPlaceholders {
+		pattern = strings.ReplaceAll(pattern, placeholder, "*")
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reports: %w", err)
+	}
+	// The glob also matches other files next to the reports, e.g. README.md for
+	// "{{date}}.md"
+	expanded := pathPattern(path)
+
+	type report struct {
+		path    string
+		modTime time.Time
+	}
+	var reports []report
+	for _, m := range matches {
+		if !expanded.MatchString(m) {
+			continue
+		}
+		info, err := os.Stat(m)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		reports = append(reports, report{m, info.ModTime()})
+	}
+	if len(reports) <= keep {
+		return nil, nil
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if !reports[i].modTime.Equal(reports[j].modTime) {
+			return reports[i].modTime.After(reports[j].modTime)
+		}
+		return reports[i].path > reports[j].path
+	})
+
+	var removed []string
+	for _, r := range reports[keep:] {
+		if err := os.Remove(r.path); err != nil {
+			return removed, fmt.Errorf("failed to remove old report: %w", err)
+		}
+		removed = append(removed, r.path)
+	}
+	return removed, nil
+}
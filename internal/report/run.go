@@ -0,0 +1,57 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// RunInfo records how the reviews of a run were produced, so an audit can reproduce
+// the run.
+type RunInfo struct {
+	Model    string `json:"model"`
+	Provider string `json:"provider,omitempty"`
+	Profile  string `json:"profile,omitempty"`
+	// Prompt identifies the system prompt, see reviewer.Service.PromptID.
+	Prompt      string  `json:"prompt,omitempty"`
+	Temperature float64 `json:"temperature"`
+	// Seed is the sampling seed; 0 when none was sent.
+	Seed          int64 `json:"seed,omitempty"`
+	MaxTokens     int   `json:"max_tokens"`
+	Passes        int   `json:"passes"`
+	Deterministic bool  `json:"deterministic,omitempty"`
+}
+
+// HeaderWriter is implemented by formatters that open the report with the run's
+// parameters.
+type HeaderWriter interface {
+	WriteHeader(w io.Writer) error
+}
+
+// Decoding renders the sampling parameters, e.g. "temperature 0, seed 42, max tokens 4000".
+func (r RunInfo) Decoding() string {
+	parts := []string{"temperature " + strconv.FormatFloat(r.Temperature, 'g', -1, 64)}
+	if r.Seed != 0 {
+		parts = append(parts, fmt.Sprintf("seed %d", r.Seed))
+	}
+	parts = append(parts, fmt.Sprintf("max tokens %d", r.MaxTokens), fmt.Sprintf("passes %d", r.Passes))
+	if r.Deterministic {
+		parts = append(parts, "deterministic")
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (r RunInfo) modelLine() string {
+	s := r.Model
+	if r.Provider != "" {
+		s += " (" + r.Provider + ")"
+	}
+	if r.Profile != "" {
+		s += ", profile " + r.Profile
+	}
+	if r.Prompt != "" {
+		s += ", prompt " + r.Prompt
+	}
+	return s
+}
@@ -0,0 +1,145 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/disconnekt/goreview/internal/findings"
+)
+
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// defaultRuleID is used for findings that do not cite a project rule.
+const defaultRuleID = "goreview"
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           *sarifRegion  `json:"region,omitempty"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine,omitempty"`
+}
+
+// sarifFormatter writes a SARIF 2.1.0 log for code scanning dashboards once all files
+// are reviewed. Only structured findings are included.
+type sarifFormatter struct {
+	root string
+}
+
+func (sarifFormatter) WriteEntry(io.Writer, FileReview) error      { return nil }
+func (sarifFormatter) WriteSkipped(io.Writer, []SkippedFile) error { return nil }
+
+func (s sarifFormatter) Finish(w io.Writer, all []FileReview) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "goreview",
+			InformationURI: "https://github.com/disconnekt/goreview",
+		}},
+		Results: []sarifResult{},
+	}
+	rules := map[string]bool{defaultRuleID: true}
+	for _, fr := range all {
+		uri := RelativeTo(s.root, fr.Path)
+		for _, f := range fr.Findings {
+			ruleID := f.RuleID
+			if ruleID == "" {
+				ruleID = defaultRuleID
+			}
+			rules[ruleID] = true
+			loc := sarifPhysicalLocation{ArtifactLocation: sarifArtifact{URI: uri}}
+			if f.Line > 0 {
+				loc.Region = &sarifRegion{StartLine: f.Line}
+				if f.EndLine > f.Line {
+					loc.Region.EndLine = f.EndLine
+				}
+			}
+			run.Results = append(run.Results, sarifResult{
+				RuleID:              ruleID,
+				Level:               sarifLevel(f.Severity),
+				Message:             sarifMessage{Text: f.Message},
+				Locations:           []sarifLocation{{PhysicalLocation: loc}},
+				PartialFingerprints: map[string]string{"goreview/v1": f.Fingerprint()},
+			})
+		}
+	}
+	ids := make([]string, 0, len(rules))
+	for id := range rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		desc := "Project rule " + id
+		if id == defaultRuleID {
+			desc = "AI code review finding"
+		}
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: id, ShortDescription: sarifMessage{Text: desc}})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(sarifLog{Schema: sarifSchema, Version: "2.1.0", Runs: []sarifRun{run}}); err != nil {
+		return fmt.Errorf("failed to encode SARIF report: %w", err)
+	}
+	return nil
+}
+
+func sarifLevel(s findings.Severity) string {
+	switch s {
+	case findings.SeverityCritical, findings.SeverityHigh:
+		return "error"
+	case findings.SeverityMedium:
+		return "warning"
+	default:
+		return "note"
+	}
+}
@@ -0,0 +1,107 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/findings"
+	"github.com/disconnekt/goreview/internal/history"
+)
+
+// summaryTopFiles is how many files the summary lists by number of findings.
+const summaryTopFiles = 10
+
+// summaryFormatter writes only a roll-up of the run: totals by severity and the files
+// with the most findings, which keeps CI logs short.
+type summaryFormatter struct {
+	root      string
+	skipped   int
+	precision []history.Precision
+}
+
+func (*summaryFormatter) WriteEntry(io.Writer, FileReview) error { return nil }
+
+func (s *summaryFormatter) WriteSkipped(_ io.Writer, skipped []SkippedFile) error {
+	s.skipped = len(skipped)
+	return nil
+}
+
+func (s *summaryFormatter) Finish(w io.Writer, all []FileReview) error {
+	var list []findings.Finding
+	var withFindings []FileReview
+	suppressed := 0
+	for _, fr := range all {
+		list = append(list, fr.Findings...)
+		suppressed += fr.Suppressed
+		if len(fr.Findings) > 0 {
+			withFindings = append(withFindings, fr)
+		}
+	}
+
+	fmt.Fprintf(w, "\n=== Summary ===\n")
+	fmt.Fprintf(w, "Files reviewed: %d (%d with findings)\n", len(all), len(withFindings))
+	fmt.Fprintf(w, "Findings: %d (%s)\n", len(list), severityCounts(list))
+	if suppressed > 0 {
+		fmt.Fprintf(w, "Suppressed by the baseline: %d\n", suppressed)
+	}
+	if s.skipped > 0 {
+		fmt.Fprintf(w, "Skipped files: %d\n", s.skipped)
+	}
+	if u := TotalUsage(all); u != nil {
+		writeUsage(w, u)
+	}
+	if len(s.precision) > 0 {
+		fmt.Fprintf(w, "\nPrecision from recorded feedback:\n")
+		WritePrecision(w, s.precision)
+	}
+	if len(withFindings) == 0 {
+		_, err := fmt.Fprintln(w)
+		return err
+	}
+
+	sort.SliceStable(withFindings, func(i, j int) bool {
+		a, b := withFindings[i], withFindings[j]
+		if len(a.Findings) != len(b.Findings) {
+			return len(a.Findings) > len(b.Findings)
+		}
+		return a.Path < b.Path
+	})
+	if len(withFindings) > summaryTopFiles {
+		withFindings = withFindings[:summaryTopFiles]
+	}
+	fmt.Fprintf(w, "\nFiles with the most findings:\n")
+	for _, fr := range withFindings {
+		fmt.Fprintf(w, "%5d  %s (%s)\n", len(fr.Findings), RelativeTo(s.root, fr.Path), severityCounts(fr.Findings))
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// WritePrecision renders the precision of models and prompts as a table.
+func WritePrecision(w io.Writer, list []history.Precision) {
+	fmt.Fprintf(w, "%9s %7s %6s  %s\n", "PRECISION", "USEFUL", "WRONG", "MODEL (PROMPT)")
+	for _, p := range list {
+		name := p.Model
+		if p.Prompt != "" {
+			name += " (" + p.Prompt + ")"
+		}
+		fmt.Fprintf(w, "%8.0f%% %7d %6d  %s\n", p.Rate()*100, p.Useful, p.Wrong, name)
+	}
+}
+
+// severityCounts renders the non-zero counts by severity, e.g. "high: 2, low: 1".
+func severityCounts(list []findings.Finding) string {
+	counts := findings.CountBySeverity(list)
+	var parts []string
+	for _, sev := range findings.Severities {
+		if counts[sev] > 0 {
+			parts = append(parts, fmt.Sprintf("%s: %d", sev, counts[sev]))
+		}
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,112 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/disconnekt/goreview/internal/findings"
+)
+
+// TemplateData is what a --report-template is executed with.
+type TemplateData struct {
+	Generated time.Time
+	// Run holds the model and decoding parameters of the run.
+	Run RunInfo
+	// Root is the directory Files and Findings paths are relative to.
+	Root  string
+	Files []FileReview
+	// Findings holds every finding of every file, sorted by file and line.
+	Findings []findings.Finding
+	// Counts tallies Findings per severity.
+	Counts     map[findings.Severity]int
+	Severities []findings.Severity
+	Skipped    []SkippedFile
+	Suppressed int
+}
+
+// templateFuncs are available to report templates in addition to the builtins.
+var templateFuncs = template.FuncMap{
+	"upper":    strings.ToUpper,
+	"lower":    strings.ToLower,
+	"join":     strings.Join,
+	"repeat":   strings.Repeat,
+	"trim":     strings.TrimSpace,
+	"replace":  strings.ReplaceAll,
+	"contains": strings.Contains,
+	"indent": func(n int, s string) string {
+		pad := strings.Repeat(" ", n)
+		return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+	},
+	"bySeverity": func(sev string, list []findings.Finding) []findings.Finding {
+		var out []findings.Finding
+		for _, f := range list {
+			if strings.EqualFold(string(f.Severity), sev) {
+				out = append(out, f)
+			}
+		}
+		return out
+	},
+	"date": func(layout string, t time.Time) string { return t.Format(layout) },
+}
+
+// templateFormatter renders the whole report with a user-supplied text/template once
+// all files are reviewed.
+type templateFormatter struct {
+	tmpl    *template.Template
+	root    string
+	run     RunInfo
+	skipped []SkippedFile
+}
+
+func newTemplateFormatter(o Options) (Formatter, error) {
+	if o.Template == "" {
+		return nil, fmt.Errorf("the template format requires --report-template")
+	}
+	data, err := os.ReadFile(o.Template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report template: %w", err)
+	}
+	tmpl, err := template.New(filepath.Base(o.Template)).Funcs(templateFuncs).Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse report template: %w", err)
+	}
+	return &templateFormatter{tmpl: tmpl, root: o.PathRoot, run: o.Run}, nil
+}
+
+func (*templateFormatter) WriteEntry(io.Writer, FileReview) error { return nil }
+
+func (t *templateFormatter) WriteSkipped(_ io.Writer, skipped []SkippedFile) error {
+	t.skipped = skipped
+	return nil
+}
+
+func (t *templateFormatter) Finish(w io.Writer, all []FileReview) error {
+	data := TemplateData{
+		Generated:  time.Now(),
+		Run:        t.run,
+		Root:       t.root,
+		Severities: findings.Severities,
+		Skipped:    t.skipped,
+	}
+	for _, fr := range all {
+		fr.Path = RelativeTo(t.root, fr.Path)
+		fr.Findings = withFile(fr.Findings, fr.Path)
+		data.Files = append(data.Files, fr)
+		data.Findings = append(data.Findings, fr.Findings...)
+		data.Suppressed += fr.Suppressed
+	}
+	sort.SliceStable(data.Files, func(i, j int) bool { return data.Files[i].Path < data.Files[j].Path })
+	findings.Sort(data.Findings)
+	data.Counts = findings.CountBySeverity(data.Findings)
+
+	if err := t.tmpl.Execute(w, data); err != nil {
+		return fmt.Errorf("failed to render report template: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/findings"
+	"github.com/disconnekt/goreview/internal/todo"
+)
+
+// Todos collects the TODO comments of all files, triaged ones by priority first. File
+// paths are made relative to root.
+func Todos(root string, all []FileReview) []todo.Todo {
+	var todos []todo.Todo
+	for _, fr := range all {
+		if fr.DuplicateOf != "" {
+			continue
+		}
+		for _, t := range fr.Todos {
+			t.File = RelativeTo(root, fr.Path)
+			todos = append(todos, t)
+		}
+	}
+	sort.SliceStable(todos, func(i, j int) bool {
+		ri, rj := findings.Severity(todos[i].Priority).Rank(), findings.Severity(todos[j].Priority).Rank()
+		if ri != rj {
+			return ri > rj
+		}
+		if todos[i].File != todos[j].File {
+			return todos[i].File < todos[j].File
+		}
+		return todos[i].Line < todos[j].Line
+	})
+	return todos
+}
+
+// writeTodos renders the TODO inventory; heading receives the count.
+func writeTodos(w io.Writer, heading, root string, all []FileReview) error {
+	todos := Todos(root, all)
+	if len(todos) == 0 {
+		return nil
+	}
+	fmt.Fprintf(w, heading, len(todos))
+	for _, t := range todos {
+		fmt.Fprintf(w, "- %s:%d %s: %s\n", t.File, t.Line, t.Tag, t.Text)
+		if t.Priority != "" {
+			fmt.Fprintf(w, "  [%s] %s\n", strings.ToUpper(t.Priority), t.Assessment)
+		}
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
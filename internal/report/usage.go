@@ -0,0 +1,94 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Usage is the provider usage and response metadata of a review.
+type Usage struct {
+	Requests int `json:"requests"`
+	// ReusedChunks counts the chunks of a large file whose review was taken from an
+	// earlier failed attempt instead of being resent.
+	ReusedChunks     int `json:"reused_chunks,omitempty"`
+	PromptTokens     int `json:"prompt_tokens,omitempty"`
+	CompletionTokens int `json:"completion_tokens,omitempty"`
+	// CachedTokens are the prompt tokens read from the provider's prompt cache.
+	CachedTokens int   `json:"cached_tokens,omitempty"`
+	LatencyMS    int64 `json:"latency_ms"`
+	// ModelVersions are the model versions the provider reported serving.
+	ModelVersions []string `json:"model_versions,omitempty"`
+	// RateLimitRequests and RateLimitTokens are the remaining quota reported by the
+	// provider; for a whole run, the lowest seen.
+	RateLimitRequests *int `json:"ratelimit_remaining_requests,omitempty"`
+	RateLimitTokens   *int `json:"ratelimit_remaining_tokens,omitempty"`
+}
+
+// TotalUsage sums the usage of the reviews, or returns nil when none was recorded.
+// Reused reviews of identical files are counted once.
+func TotalUsage(all []FileReview) *Usage {
+	var total Usage
+	versions := make(map[string]bool)
+	for _, fr := range all {
+		u := fr.Usage
+		if u == nil || fr.DuplicateOf != "" {
+			continue
+		}
+		total.Requests += u.Requests
+		total.ReusedChunks += u.ReusedChunks
+		total.PromptTokens += u.PromptTokens
+		total.CompletionTokens += u.CompletionTokens
+		total.CachedTokens += u.CachedTokens
+		total.LatencyMS += u.LatencyMS
+		for _, v := range u.ModelVersions {
+			versions[v] = true
+		}
+		total.RateLimitRequests = lowest(total.RateLimitRequests, u.RateLimitRequests)
+		total.RateLimitTokens = lowest(total.RateLimitTokens, u.RateLimitTokens)
+	}
+	if total.Requests == 0 && total.ReusedChunks == 0 {
+		return nil
+	}
+	for v := range versions {
+		total.ModelVersions = append(total.ModelVersions, v)
+	}
+	sort.Strings(total.ModelVersions)
+	return &total
+}
+
+func lowest(a, b *int) *int {
+	if a == nil || (b != nil && *b < *a) {
+		return b
+	}
+	return a
+}
+
+// writeUsage renders the usage of a run for the summary.
+func writeUsage(w io.Writer, u *Usage) {
+	fmt.Fprintf(w, "Usage: %d requests, %d prompt + %d completion tokens", u.Requests, u.PromptTokens, u.CompletionTokens)
+	if u.CachedTokens > 0 {
+		fmt.Fprintf(w, " (%d prompt tokens cached)", u.CachedTokens)
+	}
+	if u.Requests > 0 {
+		fmt.Fprintf(w, ", avg latency %dms", u.LatencyMS/int64(u.Requests))
+	}
+	fmt.Fprintln(w)
+	if u.ReusedChunks > 0 {
+		fmt.Fprintf(w, "Chunks reused from failed attempts: %d\n", u.ReusedChunks)
+	}
+	if len(u.ModelVersions) > 0 {
+		fmt.Fprintf(w, "Model versions served: %s\n", strings.Join(u.ModelVersions, ", "))
+	}
+	var quota []string
+	if u.RateLimitRequests != nil {
+		quota = append(quota, fmt.Sprintf("%d requests", *u.RateLimitRequests))
+	}
+	if u.RateLimitTokens != nil {
+		quota = append(quota, fmt.Sprintf("%d tokens", *u.RateLimitTokens))
+	}
+	if len(quota) > 0 {
+		fmt.Fprintf(w, "Lowest remaining rate limit: %s\n", strings.Join(quota, ", "))
+	}
+}
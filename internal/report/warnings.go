@@ -0,0 +1,101 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/disconnekt/goreview/internal/findings"
+)
+
+// warningsOrigin identifies goreview issues in the Jenkins Warnings Next Generation
+// plugin; it matches the id suggested for its "issues" tool.
+const warningsOrigin = "goreview"
+
+type warningsReport struct {
+	Issues []warningsIssue `json:"issues"`
+	Size   int             `json:"size"`
+}
+
+type warningsIssue struct {
+	FileName    string `json:"fileName"`
+	LineStart   int    `json:"lineStart,omitempty"`
+	LineEnd     int    `json:"lineEnd,omitempty"`
+	Severity    string `json:"severity"`
+	Message     string `json:"message"`
+	Category    string `json:"category"`
+	Type        string `json:"type"`
+	PackageName string `json:"packageName,omitempty"`
+	ModuleName  string `json:"moduleName,omitempty"`
+	Origin      string `json:"origin"`
+	OriginName  string `json:"originName"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// warningsFormatter writes the native JSON format of the Jenkins Warnings Next
+// Generation plugin, with file names relative to the workspace so the plugin can link
+// its source views and trend issues across builds.
+type warningsFormatter struct {
+	root string
+}
+
+func (warningsFormatter) WriteEntry(io.Writer, FileReview) error      { return nil }
+func (warningsFormatter) WriteSkipped(io.Writer, []SkippedFile) error { return nil }
+
+func (wf warningsFormatter) Finish(w io.Writer, all []FileReview) error {
+	out := warningsReport{Issues: []warningsIssue{}}
+	for _, fr := range all {
+		file := RelativeTo(wf.root, fr.Path)
+		for _, f := range fr.Findings {
+			ruleID := f.RuleID
+			if ruleID == "" {
+				ruleID = defaultRuleID
+			}
+			issue := warningsIssue{
+				FileName:    file,
+				Severity:    warningsSeverity(f.Severity),
+				Message:     f.Message,
+				Category:    ruleID,
+				Type:        string(f.Severity),
+				ModuleName:  f.Module,
+				Origin:      warningsOrigin,
+				OriginName:  "goreview",
+				Fingerprint: f.Fingerprint(),
+			}
+			if dir := path.Dir(file); dir != "." {
+				issue.PackageName = dir
+			}
+			if f.Line > 0 {
+				issue.LineStart = f.Line
+				issue.LineEnd = f.Line
+				if f.EndLine > f.Line {
+					issue.LineEnd = f.EndLine
+				}
+			}
+			out.Issues = append(out.Issues, issue)
+		}
+	}
+	out.Size = len(out.Issues)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return fmt.Errorf("failed to encode warnings-ng report: %w", err)
+	}
+	return nil
+}
+
+// warningsSeverity maps severities to the plugin's ERROR, HIGH, NORMAL and LOW.
+func warningsSeverity(s findings.Severity) string {
+	switch s {
+	case findings.SeverityCritical:
+		return "ERROR"
+	case findings.SeverityHigh:
+		return "HIGH"
+	case findings.SeverityMedium:
+		return "NORMAL"
+	default:
+		return "LOW"
+	}
+}
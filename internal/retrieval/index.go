@@ -0,0 +1,135 @@
+package retrieval
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// IndexFile is the name of the embedding index inside the state directory.
+const IndexFile = "embeddings.json"
+
+// Index holds one embedding per file, keyed by the path relative to the project root.
+type Index struct {
+	// Model is the embedding model the vectors were computed with.
+	Model string           `json:"model"`
+	Files map[string]Entry `json:"files"`
+}
+
+// Entry is the embedding of one file version.
+type Entry struct {
+	// Hash identifies the content the vector was computed from.
+	Hash   string    `json:"hash"`
+	Vector []float32 `json:"vector"`
+}
+
+// Neighbor is a file similar to a query, with its cosine similarity.
+type Neighbor struct {
+	Path       string
+	Similarity float64
+}
+
+// LoadIndex reads an index; a missing file yields an empty index.
+func LoadIndex(path string) (*Index, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Index{Files: make(map[string]Entry)}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedding index: %w", err)
+	}
+	var ix Index
+	if err := json.Unmarshal(data, &ix); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if ix.Files == nil {
+		ix.Files = make(map[string]Entry)
+	}
+	return &ix, nil
+}
+
+// Save writes the index to path, creating parent directories as needed.
+func (ix *Index) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	data, err := json.Marshal(ix)
+	if err != nil {
+		return fmt.Errorf("failed to encode embedding index: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write embedding index: %w", err)
+	}
+	return nil
+}
+
+// Stale returns the paths whose entry is missing or was computed from other content;
+// hashes maps each current path to its content hash.
+func (ix *Index) Stale(hashes map[string]string) []string {
+	var stale []string
+	for path, hash := range hashes {
+		if e, ok := ix.Files[path]; !ok || e.Hash != hash || len(e.Vector) == 0 {
+			stale = append(stale, path)
+		}
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+// Prune removes the entries for which exists reports false and returns how many were
+// removed.
+func (ix *Index) Prune(exists func(path string) bool) int {
+	removed := 0
+	for path := range ix.Files {
+		if !exists(path) {
+			delete(ix.Files, path)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Nearest returns up to k indexed files most similar to vector among those eligible
+// accepts, skipping files below minSimilarity.
+func (ix *Index) Nearest(vector []float32, k int, eligible func(path string) bool, minSimilarity float64) []Neighbor {
+	var out []Neighbor
+	for path, e := range ix.Files {
+		if !eligible(path) {
+			continue
+		}
+		if sim := cosine(vector, e.Vector); sim >= minSimilarity {
+			out = append(out, Neighbor{Path: path, Similarity: sim})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Similarity != out[j].Similarity {
+			return out[i].Similarity > out[j].Similarity
+		}
+		return out[i].Path < out[j].Path
+	})
+	if len(out) > k {
+		out = out[:k]
+	}
+	return out
+}
+
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,137 @@
+// Package retrieval selects the files of a project most relevant to a question or to
+// the file under review.
+package retrieval
+
+import (
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/disconnekt/goreview/internal/scanner"
+)
+
+// Match is a file with its relevance score.
+type Match struct {
+	File  scanner.FileInfo
+	Score float64
+}
+
+// stopWords are question words that say nothing about where code lives.
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "are": true, "was": true, "how": true, "what": true,
+	"where": true, "when": true, "which": true, "who": true, "why": true, "does": true, "this": true,
+	"that": true, "with": true, "from": true, "into": true, "code": true, "implemented": true,
+	"implement": true, "handled": true, "handle": true, "used": true, "use": true, "there": true,
+	"file": true, "files": true, "function": true, "can": true, "should": true, "any": true,
+}
+
+// Terms splits text into distinct lowercase search terms: words and the parts of
+// camelCase and snake_case identifiers, stemmed and without stop words.
+func Terms(text string) []string {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, t := range tokens(text) {
+		if !seen[t] {
+			seen[t] = true
+			terms = append(terms, t)
+		}
+	}
+	return terms
+}
+
+// tokens returns every term occurrence in text, in order.
+func tokens(text string) []string {
+	var out []string
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		for _, part := range splitIdentifier(word) {
+			if t := stem(strings.ToLower(part)); len(t) >= 3 && !stopWords[t] {
+				out = append(out, t)
+			}
+		}
+	}
+	return out
+}
+
+// splitIdentifier splits camelCase words, keeping acronyms together ("parseHTTPBody"
+// gives parse, HTTP, Body).
+func splitIdentifier(word string) []string {
+	runes := []rune(word)
+	var parts []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		lowerToUpper := unicode.IsLower(runes[i-1]) && unicode.IsUpper(runes[i])
+		acronymEnd := i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i+1])
+		if lowerToUpper || acronymEnd {
+			parts = append(parts, string(runes[start:i]))
+			start = i
+		}
+	}
+	return append(parts, string(runes[start:]))
+}
+
+// stem strips common English suffixes so "retries", "retried" and "retrying" match "retry".
+func stem(t string) string {
+	for _, suffix := range []string{"ies", "ied", "ying", "ing", "ed", "es", "s"} {
+		if len(t) > len(suffix)+3 && strings.HasSuffix(t, suffix) {
+			t = strings.TrimSuffix(t, suffix)
+			if suffix == "ies" || suffix == "ied" || suffix == "ying" {
+				t += "y"
+			}
+			return t
+		}
+	}
+	return t
+}
+
+// Keyword ranks files by how often the terms of query appear in their path relative to
+// root and in their content, weighting rare terms higher (TF-IDF). Files without any
+// term are left out.
+func Keyword(query, root string, files []scanner.FileInfo) []Match {
+	terms := Terms(query)
+	if len(terms) == 0 {
+		return nil
+	}
+	counts := make([]map[string]int, len(files))
+	df := make(map[string]int)
+	for i, f := range files {
+		counts[i] = make(map[string]int)
+		text, _ := f.Text()
+		for _, t := range tokens(text) {
+			counts[i][t]++
+		}
+		path := f.Path
+		if rel, err := filepath.Rel(root, f.Path); err == nil {
+			path = rel
+		}
+		// Only the path inside the project tells files apart
+		for _, t := range Terms(filepath.ToSlash(path)) {
+			// A term in the path counts as much as several mentions in the code
+			counts[i][t] += 5
+		}
+		for _, t := range terms {
+			if counts[i][t] > 0 {
+				df[t]++
+			}
+		}
+	}
+
+	var matches []Match
+	for i, f := range files {
+		score := 0.0
+		for _, t := range terms {
+			if n := counts[i][t]; n > 0 {
+				idf := math.Log(float64(len(files))/float64(df[t])) + 1
+				score += (1 + math.Log(float64(n))) * idf
+			}
+		}
+		if score > 0 {
+			matches = append(matches, Match{File: f, Score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	return matches
+}
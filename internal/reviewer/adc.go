@@ -0,0 +1,235 @@
+package reviewer
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	googleTokenURL   = "https://oauth2.googleapis.com/token"
+	googleScope      = "https://www.googleapis.com/auth/cloud-platform"
+	metadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+)
+
+// googleCredentials is the subset of a service account key or gcloud user credentials
+// file used to obtain access tokens.
+type googleCredentials struct {
+	Type         string `json:"type"`
+	ProjectID    string `json:"project_id"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+	TokenURI     string `json:"token_uri"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+	// QuotaProjectID is set by gcloud for user credentials.
+	QuotaProjectID string `json:"quota_project_id"`
+}
+
+// adcTokenSource fetches and caches OAuth access tokens from Application Default
+// Credentials: GOOGLE_APPLICATION_CREDENTIALS, the gcloud well-known file, or the
+// metadata server when running on Google Cloud.
+type adcTokenSource struct {
+	client *http.Client
+	// creds is nil when tokens come from the metadata server
+	creds *googleCredentials
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// newADCTokenSource locates Application Default Credentials.
+func newADCTokenSource(client *http.Client) (*adcTokenSource, error) {
+	path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if path == "" {
+		if p := wellKnownADCFile(); p != "" {
+			if _, err := os.Stat(p); err == nil {
+				path = p
+			}
+		}
+	}
+	if path == "" {
+		// Fall back to the metadata server; failures surface on the first request
+		return &adcTokenSource{client: client}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Google credentials: %w", err)
+	}
+	var creds googleCredentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("failed to parse Google credentials %s: %w", path, err)
+	}
+	switch creds.Type {
+	case "service_account", "authorized_user":
+	default:
+		return nil, fmt.Errorf("unsupported Google credentials type %q in %s", creds.Type, path)
+	}
+	return &adcTokenSource{client: client, creds: &creds}, nil
+}
+
+// wellKnownADCFile is where `gcloud auth application-default login` stores credentials.
+func wellKnownADCFile() string {
+	if dir := os.Getenv("CLOUDSDK_CONFIG"); dir != "" {
+		return filepath.Join(dir, "application_default_credentials.json")
+	}
+	if appData := os.Getenv("APPDATA"); appData != "" {
+		return filepath.Join(appData, "gcloud", "application_default_credentials.json")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "gcloud", "application_default_credentials.json")
+}
+
+// projectID returns the project the credentials belong to, if known.
+func (s *adcTokenSource) projectID() string {
+	if s.creds == nil {
+		return ""
+	}
+	return firstNonEmpty(s.creds.ProjectID, s.creds.QuotaProjectID)
+}
+
+// Token returns a cached access token, refreshing it shortly before it expires.
+func (s *adcTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && time.Until(s.expires) > time.Minute {
+		return s.token, nil
+	}
+
+	var req *http.Request
+	var err error
+	switch {
+	case s.creds == nil:
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, metadataTokenURL, nil)
+		if err == nil {
+			req.Header.Set("Metadata-Flavor", "Google")
+		}
+	case s.creds.Type == "service_account":
+		var assertion string
+		assertion, err = s.serviceAccountJWT(time.Now())
+		if err == nil {
+			req, err = tokenRequest(ctx, firstNonEmpty(s.creds.TokenURI, googleTokenURL), url.Values{
+				"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+				"assertion":  {assertion},
+			})
+		}
+	default:
+		req, err = tokenRequest(ctx, googleTokenURL, url.Values{
+			"grant_type":    {"refresh_token"},
+			"client_id":     {s.creds.ClientID},
+			"client_secret": {s.creds.ClientSecret},
+			"refresh_token": {s.creds.RefreshToken},
+		})
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to build token request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		if s.creds == nil {
+			return "", fmt.Errorf("no Google credentials found (set GOOGLE_APPLICATION_CREDENTIALS or run `gcloud auth application-default login`): %w", err)
+		}
+		return "", fmt.Errorf("failed to fetch access token: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var out struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return "", fmt.Errorf("failed to decode access token: %w", err)
+	}
+	if out.AccessToken == "" {
+		return "", errors.New("token endpoint returned no access token")
+	}
+	s.token = out.AccessToken
+	s.expires = time.Now().Add(time.Duration(out.ExpiresIn) * time.Second)
+	return s.token, nil
+}
+
+func tokenRequest(ctx context.Context, endpoint string, form url.Values) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req, nil
+}
+
+// serviceAccountJWT builds the RS256-signed assertion exchanged for an access token.
+func (s *adcTokenSource) serviceAccountJWT(now time.Time) (string, error) {
+	key, err := parseRSAKey(s.creds.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": s.creds.PrivateKeyID})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]any{
+		"iss":   s.creds.ClientEmail,
+		"scope": googleScope,
+		"aud":   firstNonEmpty(s.creds.TokenURI, googleTokenURL),
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(nil, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token assertion: %w", err)
+	}
+	return unsigned + "." + enc.EncodeToString(sig), nil
+}
+
+func parseRSAKey(pemKey string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, errors.New("service account private key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse service account private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("service account private key is not an RSA key")
+	}
+	return key, nil
+}
@@ -0,0 +1,101 @@
+package reviewer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// Attempt describes one outbound request made by a provider, successful or not.
+type Attempt struct {
+	// File is the reviewed file the request was made for, when known.
+	File string
+	// RequestID is the ID sent with the request; ProviderRequestID the ID the provider
+	// returned for it, when any.
+	RequestID         string
+	ProviderRequestID string
+	Endpoint          string
+	Model             string
+	// PromptSHA256 is the hex SHA-256 of the message contents, so audits can tell which
+	// code was sent without storing it.
+	PromptSHA256 string
+	RequestBytes int
+	// SentBytes is the size of the body on the wire, smaller than RequestBytes when
+	// compressed; 0 when the provider does not report it.
+	SentBytes int
+	// Status is the HTTP status, or the exit code for the exec provider; 0 when no
+	// response was received.
+	Status           int
+	PromptTokens     int
+	CompletionTokens int
+	// CachedTokens are the prompt tokens read from the provider's prompt cache.
+	CachedTokens int
+	// ModelVersion is the model the provider reported serving the request with.
+	ModelVersion string
+	// RateLimitRequests and RateLimitTokens are the remaining quota reported by the
+	// response headers; nil when not reported.
+	RateLimitRequests *int
+	RateLimitTokens   *int
+	Latency           time.Duration
+	Err               error
+}
+
+// Usage is the token accounting of an OpenAI-compatible response.
+type Usage struct {
+	PromptTokens        int `json:"prompt_tokens"`
+	CompletionTokens    int `json:"completion_tokens"`
+	TotalTokens         int `json:"total_tokens"`
+	PromptTokensDetails *struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"prompt_tokens_details,omitempty"`
+	// CacheReadInputTokens is reported instead by gateways passing Anthropic usage on.
+	CacheReadInputTokens int `json:"cache_read_input_tokens,omitempty"`
+}
+
+// cachedTokens returns the prompt tokens read from the cache.
+func (u *Usage) cachedTokens() int {
+	if u.PromptTokensDetails != nil && u.PromptTokensDetails.CachedTokens > 0 {
+		return u.PromptTokensDetails.CachedTokens
+	}
+	return u.CacheReadInputTokens
+}
+
+// observed is embedded by providers to report their attempts.
+type observed struct {
+	observer func(Attempt)
+}
+
+func (o *observed) setObserver(fn func(Attempt)) {
+	o.observer = fn
+}
+
+func (o *observed) observe(ctx context.Context, a Attempt) {
+	if t, ok := ctx.Value(requestKey{}).(*requestTrace); ok {
+		t.record(a)
+	}
+	if o.observer != nil {
+		a.File, _ = ctx.Value(fileKey{}).(string)
+		a.RequestID = requestID(ctx)
+		o.observer(a)
+	}
+}
+
+// fileKey carries the reviewed file's path in the request context.
+type fileKey struct{}
+
+// newAttempt starts the record of a request for the given messages.
+func newAttempt(request ReviewRequest, body []byte) Attempt {
+	h := sha256.New()
+	for _, m := range request.Messages {
+		h.Write([]byte(m.Role))
+		h.Write([]byte{0})
+		h.Write([]byte(m.Content))
+		h.Write([]byte{0})
+	}
+	return Attempt{
+		Model:        request.Model,
+		PromptSHA256: hex.EncodeToString(h.Sum(nil)),
+		RequestBytes: len(body),
+	}
+}
@@ -0,0 +1,426 @@
+package reviewer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/disconnekt/goreview/internal/config"
+)
+
+// batchQuietPeriod is how long the batch provider waits for further requests before
+// submitting the ones collected so far, when it does not know how many reviews are
+// under way or one of them stopped without a request.
+const batchQuietPeriod = 2 * time.Second
+
+// batchProvider sends requests through an OpenAI-style asynchronous batch API: requests
+// arriving together are uploaded as one JSONL file, submitted as a batch and answered
+// once the batch completes, at a discount but with hours rather than seconds of latency.
+type batchProvider struct {
+	observed
+	api      *openAIProvider
+	endpoint string
+	poll     time.Duration
+
+	mu      sync.Mutex
+	pending []*batchRequest
+	timer   *time.Timer
+	seq     int
+	// issuers counts the requests the reviews under way may still send at once: one
+	// per review, or one per model with --consensus. Once that many are pending, every
+	// review waits for the batch, and it is submitted without waiting for the quiet
+	// period. tracked is false until the reviews are announced.
+	issuers int
+	tracked bool
+}
+
+// batchRequest is a request waiting for its batch.
+type batchRequest struct {
+	id      string
+	ctx     context.Context
+	request ReviewRequest
+	attempt Attempt
+	done    chan batchResult
+}
+
+type batchResult struct {
+	content string
+	err     error
+}
+
+// batchLine is one request of the input file.
+type batchLine struct {
+	CustomID string        `json:"custom_id"`
+	Method   string        `json:"method"`
+	URL      string        `json:"url"`
+	Body     ReviewRequest `json:"body"`
+}
+
+// batchOutput is one line of the output or error file.
+type batchOutput struct {
+	CustomID string `json:"custom_id"`
+	Response *struct {
+		StatusCode int             `json:"status_code"`
+		RequestID  string          `json:"request_id"`
+		Body       json.RawMessage `json:"body"`
+	} `json:"response"`
+	Error *APIError `json:"error"`
+}
+
+// batchJob is the state of a batch as returned by the API.
+type batchJob struct {
+	ID           string `json:"id"`
+	Status       string `json:"status"`
+	OutputFileID string `json:"output_file_id"`
+	ErrorFileID  string `json:"error_file_id"`
+	Errors       *struct {
+		Data []APIError `json:"data"`
+	} `json:"errors"`
+}
+
+func newBatchProvider(cfg *config.Config, provider Provider) (Provider, error) {
+	api, ok := provider.(*openAIProvider)
+	if !ok {
+		return nil, fmt.Errorf("the %s provider does not support --batch", cfg.Provider)
+	}
+	endpoints := cfg.EffectiveAPIURLs()
+	return &batchProvider{api: api, endpoint: endpoints[0], poll: cfg.BatchPollInterval}, nil
+}
+
+// Complete queues the request for the next batch and waits for its result.
+func (p *batchProvider) Complete(ctx context.Context, request ReviewRequest) (string, error) {
+	if p.api.mapModel != nil {
+		request.Model = p.api.mapModel(request.Model)
+	}
+	request.Stream = false
+	if !explicitCache(request.Model) {
+		request.Messages = withoutCachePrefixes(request.Messages)
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	p.mu.Lock()
+	p.seq++
+	r := &batchRequest{
+		id:      "req-" + strconv.Itoa(p.seq),
+		ctx:     ctx,
+		request: request,
+		attempt: newAttempt(request, body),
+		done:    make(chan batchResult, 1),
+	}
+	p.pending = append(p.pending, r)
+	if p.timer == nil {
+		p.timer = time.AfterFunc(batchQuietPeriod, p.flush)
+	} else {
+		p.timer.Reset(batchQuietPeriod)
+	}
+	p.submitIfAllWaiting()
+	p.mu.Unlock()
+
+	select {
+	case res := <-r.done:
+		return res.content, res.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// expect announces n more request issuers.
+func (p *batchProvider) expect(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.issuers += n
+	p.tracked = true
+}
+
+// leave records that n issuers will send no more requests.
+func (p *batchProvider) leave(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.issuers = max(p.issuers-n, 0)
+	p.submitIfAllWaiting()
+}
+
+// submitIfAllWaiting submits the pending requests once every issuer waits for one, so
+// each pass of the reviews forms a single batch. The caller holds p.mu.
+func (p *batchProvider) submitIfAllWaiting() {
+	if !p.tracked || len(p.pending) == 0 || len(p.pending) < p.issuers {
+		return
+	}
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+	list := p.pending
+	p.pending, p.timer = nil, nil
+	go p.run(list)
+}
+
+// flush submits the pending requests as one batch.
+func (p *batchProvider) flush() {
+	p.mu.Lock()
+	list := p.pending
+	p.pending, p.timer = nil, nil
+	p.mu.Unlock()
+	if len(list) > 0 {
+		go p.run(list)
+	}
+}
+
+// run submits a batch, waits for it to finish and hands each request its result.
+func (p *batchProvider) run(list []*batchRequest) {
+	// The batch outlives any single review; it is abandoned once no review waits for it
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		for _, r := range list {
+			select {
+			case <-r.ctx.Done():
+			case <-ctx.Done():
+				return
+			}
+		}
+		cancel()
+	}()
+
+	start := time.Now()
+	job, results, err := p.execute(ctx, list)
+	for _, r := range list {
+		a := r.attempt
+		a.Endpoint = p.endpoint
+		if job != nil {
+			a.Endpoint = "batch:" + job.ID
+		}
+		a.Latency = time.Since(start)
+		res := batchResult{err: err}
+		if err == nil {
+			res = p.result(&a, results[r.id])
+		}
+		a.Err = res.err
+		p.observe(r.ctx, a)
+		r.done <- res
+	}
+}
+
+// execute uploads the requests, creates the batch and polls it until it ends, returning
+// the output lines by custom ID.
+func (p *batchProvider) execute(ctx context.Context, list []*batchRequest) (*batchJob, map[string]batchOutput, error) {
+	u, err := url.Parse(p.endpoint)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid endpoint %s: %w", p.endpoint, err)
+	}
+	var input bytes.Buffer
+	enc := json.NewEncoder(&input)
+	for _, r := range list {
+		if err := enc.Encode(batchLine{CustomID: r.id, Method: http.MethodPost, URL: u.Path, Body: r.request}); err != nil {
+			return nil, nil, fmt.Errorf("failed to encode batch request: %w", err)
+		}
+	}
+	fileID, err := p.upload(ctx, input.Bytes())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var job batchJob
+	create := map[string]string{"input_file_id": fileID, "endpoint": u.Path, "completion_window": "24h"}
+	if err := p.call(ctx, http.MethodPost, "/batches", create, &job); err != nil {
+		return nil, nil, fmt.Errorf("failed to create batch: %w", err)
+	}
+
+	ticker := time.NewTicker(p.poll)
+	defer ticker.Stop()
+	for !batchFinished(job.Status) {
+		select {
+		case <-ctx.Done():
+			// Nobody waits for the results any more; stop paying for them
+			_ = p.call(context.Background(), http.MethodPost, "/batches/"+job.ID+"/cancel", nil, nil)
+			return &job, nil, ctx.Err()
+		case <-ticker.C:
+		}
+		if err := p.call(ctx, http.MethodGet, "/batches/"+job.ID, nil, &job); err != nil {
+			return &job, nil, fmt.Errorf("failed to poll batch %s: %w", job.ID, err)
+		}
+	}
+	if job.Status != "completed" {
+		msg := "batch " + job.ID + " " + job.Status
+		if job.Errors != nil && len(job.Errors.Data) > 0 {
+			msg += ": " + job.Errors.Data[0].Message
+		}
+		return &job, nil, errors.New(msg)
+	}
+
+	results := make(map[string]batchOutput, len(list))
+	for _, id := range []string{job.OutputFileID, job.ErrorFileID} {
+		if id == "" {
+			continue
+		}
+		if err := p.download(ctx, id, results); err != nil {
+			return &job, nil, err
+		}
+	}
+	return &job, results, nil
+}
+
+func batchFinished(status string) bool {
+	switch status {
+	case "completed", "failed", "expired", "cancelled":
+		return true
+	}
+	return false
+}
+
+// result extracts the review of one output line and records it in the attempt.
+func (p *batchProvider) result(a *Attempt, out batchOutput) batchResult {
+	if out.Error != nil {
+		return batchResult{err: fmt.Errorf("batch request failed: %s", out.Error.Message)}
+	}
+	if out.Response == nil {
+		return batchResult{err: errors.New("batch returned no result for the request")}
+	}
+	a.Status = out.Response.StatusCode
+	a.ProviderRequestID = out.Response.RequestID
+	var resp ReviewResponse
+	if err := json.Unmarshal(out.Response.Body, &resp); err != nil {
+		return batchResult{err: fmt.Errorf("failed to decode batch response: %w", err)}
+	}
+	if resp.Usage != nil {
+		a.PromptTokens = resp.Usage.PromptTokens
+		a.CompletionTokens = resp.Usage.CompletionTokens
+		a.CachedTokens = resp.Usage.cachedTokens()
+	}
+	a.ModelVersion = resp.Model
+	if resp.Error != nil {
+		return batchResult{err: fmt.Errorf("API error: %s", resp.Error.Message)}
+	}
+	if out.Response.StatusCode != http.StatusOK {
+		return batchResult{err: &statusError{code: out.Response.StatusCode,
+			msg: fmt.Sprintf("batch request returned status %d", out.Response.StatusCode)}}
+	}
+	if len(resp.Choices) == 0 {
+		return batchResult{err: errors.New("no review choices returned")}
+	}
+	return batchResult{content: resp.Choices[0].Message.Content}
+}
+
+// apiURL returns the URL of an API path next to the chat completions endpoint.
+func (p *batchProvider) apiURL(path string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(p.endpoint, "/"), "/chat/completions") + path
+}
+
+// upload stores the batch input file and returns its ID.
+func (p *batchProvider) upload(ctx context.Context, data []byte) (string, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	if err := mw.WriteField("purpose", "batch"); err != nil {
+		return "", fmt.Errorf("failed to build upload: %w", err)
+	}
+	fw, err := mw.CreateFormFile("file", "aireview-batch.jsonl")
+	if err != nil {
+		return "", fmt.Errorf("failed to build upload: %w", err)
+	}
+	if _, err := fw.Write(data); err != nil {
+		return "", fmt.Errorf("failed to build upload: %w", err)
+	}
+	if err := mw.Close(); err != nil {
+		return "", fmt.Errorf("failed to build upload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL("/files"), &body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	var file struct {
+		ID string `json:"id"`
+	}
+	if err := p.do(req, &file); err != nil {
+		return "", fmt.Errorf("failed to upload batch input: %w", err)
+	}
+	return file.ID, nil
+}
+
+// download reads an output or error file into results by custom ID.
+func (p *batchProvider) download(ctx context.Context, fileID string, results map[string]batchOutput) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.apiURL("/files/"+fileID+"/content"), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	var data bytes.Buffer
+	if err := p.do(req, &data); err != nil {
+		return fmt.Errorf("failed to download batch results: %w", err)
+	}
+	sc := bufio.NewScanner(&data)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		var out batchOutput
+		if err := json.Unmarshal(sc.Bytes(), &out); err != nil {
+			return fmt.Errorf("failed to decode batch results: %w", err)
+		}
+		results[out.CustomID] = out
+	}
+	return sc.Err()
+}
+
+// call sends a JSON request to an API path and decodes the JSON response into out
+// when not nil.
+func (p *batchProvider) call(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, p.apiURL(path), body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return p.do(req, out)
+}
+
+// do authorizes and sends a request. A *bytes.Buffer out receives the raw body, any
+// other non-nil out the decoded JSON.
+func (p *batchProvider) do(req *http.Request, out any) error {
+	_, key := p.api.keys.pick()
+	if err := p.api.authorize(req.Context(), req, key); err != nil {
+		return err
+	}
+	resp, err := p.api.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var apiErr ReviewResponse
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error != nil {
+			return &statusError{code: resp.StatusCode, msg: fmt.Sprintf("status %d: %s", resp.StatusCode, apiErr.Error.Message)}
+		}
+		return &statusError{code: resp.StatusCode, msg: fmt.Sprintf("status %d: %s", resp.StatusCode, resp.Status)}
+	}
+	switch out := out.(type) {
+	case nil:
+		return nil
+	case *bytes.Buffer:
+		_, err := io.Copy(out, resp.Body)
+		return err
+	default:
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+}
@@ -0,0 +1,184 @@
+package reviewer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/disconnekt/goreview/internal/config"
+)
+
+// bedrockProvider calls the AWS Bedrock Converse API with SigV4-signed requests.
+// The configured model is the Bedrock model ID, e.g. "anthropic.claude-3-5-sonnet-20240620-v1:0".
+type bedrockProvider struct {
+	observed
+	client   *http.Client
+	endpoint string
+	region   string
+	creds    awsCredentials
+}
+
+type converseRequest struct {
+	System          []converseContent `json:"system,omitempty"`
+	Messages        []converseMessage `json:"messages"`
+	InferenceConfig inferenceConfig   `json:"inferenceConfig"`
+}
+
+type converseMessage struct {
+	Role    string            `json:"role"`
+	Content []converseContent `json:"content"`
+}
+
+type converseContent struct {
+	Text string `json:"text,omitempty"`
+	// CachePoint ends a prefix the model caches.
+	CachePoint *cachePoint `json:"cachePoint,omitempty"`
+}
+
+type cachePoint struct {
+	Type string `json:"type"`
+}
+
+type inferenceConfig struct {
+	MaxTokens   int     `json:"maxTokens,omitempty"`
+	Temperature float64 `json:"temperature"`
+}
+
+type converseResponse struct {
+	Output struct {
+		Message converseMessage `json:"message"`
+	} `json:"output"`
+	StopReason string `json:"stopReason"`
+	Usage      struct {
+		InputTokens           int `json:"inputTokens"`
+		OutputTokens          int `json:"outputTokens"`
+		CacheReadInputTokens  int `json:"cacheReadInputTokens"`
+		CacheWriteInputTokens int `json:"cacheWriteInputTokens"`
+	} `json:"usage"`
+}
+
+func newBedrockProvider(cfg *config.Config) (Provider, error) {
+	region := firstNonEmpty(cfg.AWSRegion, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"))
+	if region == "" {
+		return nil, errors.New("the bedrock provider requires --aws-region or AWS_REGION")
+	}
+	creds, err := loadAWSCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	// A custom --url (e.g. a VPC endpoint) replaces the regional endpoint
+	endpoint := "https://bedrock-runtime." + region + ".amazonaws.com"
+	if cfg.APIURL != config.DefaultAPIURL {
+		endpoint = strings.TrimSuffix(cfg.APIURL, "/")
+	}
+	return &bedrockProvider{
+		client:   &http.Client{Timeout: cfg.RequestTimeout},
+		endpoint: endpoint,
+		region:   region,
+		creds:    creds,
+	}, nil
+}
+
+func (p *bedrockProvider) Complete(ctx context.Context, request ReviewRequest) (review string, err error) {
+	body := converseRequest{
+		InferenceConfig: inferenceConfig{MaxTokens: request.MaxTokens, Temperature: request.Temperature},
+	}
+	for _, m := range request.Messages {
+		content := []converseContent{{Text: m.Content}}
+		if m.CachePrefix > 0 && m.CachePrefix < len(m.Content) {
+			content = []converseContent{
+				{Text: m.Content[:m.CachePrefix]},
+				{CachePoint: &cachePoint{Type: "default"}},
+				{Text: m.Content[m.CachePrefix:]},
+			}
+		} else if m.CachePrefix > 0 {
+			content = append(content, converseContent{CachePoint: &cachePoint{Type: "default"}})
+		}
+		if m.Role == "system" {
+			body.System = append(body.System, content...)
+			continue
+		}
+		body.Messages = append(body.Messages, converseMessage{Role: m.Role, Content: content})
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	u, err := url.Parse(p.endpoint + "/model/" + awsEscape(request.Model) + "/converse")
+	if err != nil {
+		return "", fmt.Errorf("invalid bedrock endpoint: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "aireview/1.0")
+	setRequestID(ctx, req)
+	signV4(req, payload, p.creds, p.region, "bedrock", time.Now())
+
+	attempt := newAttempt(request, payload)
+	attempt.Endpoint = p.endpoint
+	start := time.Now()
+	defer func() {
+		attempt.Latency = time.Since(start)
+		attempt.Err = err
+		p.observe(ctx, attempt)
+	}()
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+	attempt.Status = resp.StatusCode
+	attempt.readResponseHeaders(resp.Header)
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return "", fmt.Errorf("bedrock returned status %d: %s", resp.StatusCode, apiErr.Message)
+		}
+		return "", fmt.Errorf("bedrock returned status %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	var out converseResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	// Converse counts cached prompt tokens apart from the input tokens
+	attempt.PromptTokens = out.Usage.InputTokens + out.Usage.CacheReadInputTokens + out.Usage.CacheWriteInputTokens
+	attempt.CachedTokens = out.Usage.CacheReadInputTokens
+	attempt.CompletionTokens = out.Usage.OutputTokens
+	var text strings.Builder
+	for _, c := range out.Output.Message.Content {
+		text.WriteString(c.Text)
+	}
+	if text.Len() == 0 {
+		return "", fmt.Errorf("no review content returned (stop reason %q)", out.StopReason)
+	}
+	return text.String(), nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
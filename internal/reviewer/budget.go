@@ -0,0 +1,50 @@
+package reviewer
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// TruncationMarker ends a review cut by TruncateReview.
+const TruncationMarker = "[review truncated: longer than --max-review-chars]"
+
+// charsPerWord converts the character budget to words for the prompt; English prose
+// averages about six characters per word including the space.
+const charsPerWord = 6
+
+// lengthBudget asks the model to keep its review within maxChars, so truncation rarely
+// has to cut anything.
+func lengthBudget(maxChars int) string {
+	words := maxChars / charsPerWord
+	if words < 20 {
+		words = 20
+	}
+	return fmt.Sprintf("Keep the whole review under %d words. If there is more to report, "+
+		"list the most severe findings first and leave out minor ones.", words)
+}
+
+// TruncateReview cuts a review longer than maxChars characters at the last line break
+// (or space) within the budget and appends TruncationMarker, so no finding is cut in
+// half. A maxChars of 0 or less leaves the review unchanged.
+func TruncateReview(review string, maxChars int) (string, bool) {
+	if maxChars <= 0 || utf8.RuneCountInString(review) <= maxChars {
+		return review, false
+	}
+	end, n := 0, 0
+	for i := range review {
+		if n == maxChars {
+			end = i
+			break
+		}
+		n++
+	}
+	cut := review[:end]
+	half := maxChars / 2
+	if i := strings.LastIndexByte(cut, '\n'); i >= 0 && utf8.RuneCountInString(cut[:i]) > half {
+		cut = cut[:i]
+	} else if i := strings.LastIndexByte(cut, ' '); i >= 0 && utf8.RuneCountInString(cut[:i]) > half {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " \n") + "\n\n" + TruncationMarker + "\n", true
+}
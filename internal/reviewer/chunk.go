@@ -0,0 +1,156 @@
+package reviewer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/repocontext"
+)
+
+// minChunkTokens is the smallest chunk derived from a model's context window, for
+// models whose window is mostly taken by the reply and the repository context.
+const minChunkTokens = 1024
+
+// lineOverhead approximates the characters numberCode adds to each line: its number,
+// the separator and the line break.
+const lineOverhead = 8
+
+// ChunkChars returns how many characters of code a review with opts sends in one
+// request: --chunk-tokens, or else half of what the context window of the model leaves
+// after its reply and the repository context, which keeps room for the prompt and for
+// the draft review sent back by verification passes. With --consensus or a triage
+// model, the smallest of the models counts.
+func (s *Service) ChunkChars(opts Options) int {
+	if s.config.ChunkTokens > 0 {
+		return s.config.ChunkTokens * repocontext.CharsPerToken
+	}
+	models := []string{s.config.Model}
+	if opts.Model != "" {
+		models[0] = opts.Model
+	}
+	if s.config.Consensus {
+		models = s.config.ConsensusModels
+	}
+	if s.config.TriageModel != "" {
+		models = append(models[:len(models):len(models)], s.config.TriageModel)
+	}
+	tokens := 0
+	for _, model := range models {
+		info := s.config.ModelInfo(model)
+		t := max((info.ContextWindow-info.MaxOutput-s.config.ContextBudget)/2, minChunkTokens)
+		if tokens == 0 || t < tokens {
+			tokens = t
+		}
+	}
+	return tokens * repocontext.CharsPerToken
+}
+
+// Chunk splits the lines of code, or of excerpt when one is given, into excerpts of at
+// most maxChars characters of numbered code each, to be reviewed one request at a time.
+// It cuts between top-level declarations where it can, and within one only when the
+// declaration alone is over the budget. Code within the budget is returned as a single
+// chunk with the excerpt unchanged, so a nil excerpt still sends the whole file.
+func Chunk(code string, excerpt []LineRange, maxChars int) [][]LineRange {
+	lines := strings.Split(strings.TrimSuffix(code, "\n"), "\n")
+	var nums []int
+	if len(excerpt) == 0 {
+		for n := 1; n <= len(lines); n++ {
+			nums = append(nums, n)
+		}
+	} else {
+		for _, r := range excerpt {
+			for n := max(r.First, 1); n <= min(r.Last, len(lines)); n++ {
+				nums = append(nums, n)
+			}
+		}
+	}
+	cost := func(n int) int { return len(lines[n-1]) + lineOverhead }
+	total := 0
+	for _, n := range nums {
+		total += cost(n)
+	}
+	if maxChars <= 0 || total <= maxChars {
+		return [][]LineRange{excerpt}
+	}
+
+	var chunks [][]LineRange
+	for start := 0; start < len(nums); {
+		// Take lines up to the budget, always at least one
+		end, size := start, 0
+		for end < len(nums) && (end == start || size+cost(nums[end]) <= maxChars) {
+			size += cost(nums[end])
+			end++
+		}
+		if end < len(nums) {
+			// Back up to the last declaration boundary past half of the budget
+			taken := size
+			for cut := end; cut > start; cut-- {
+				if taken <= maxChars/2 {
+					break
+				}
+				if declStart(lines, nums[cut]) {
+					end = cut
+					break
+				}
+				taken -= cost(nums[cut-1])
+			}
+		}
+		chunks = append(chunks, lineRanges(nums[start:end]))
+		start = end
+	}
+	return chunks
+}
+
+// declStart reports whether line n starts a top-level declaration or its doc comment:
+// it starts at column 0 and follows a blank line or the closing brace or parenthesis
+// of the declaration before.
+func declStart(lines []string, n int) bool {
+	if n < 2 {
+		return true
+	}
+	line, prev := lines[n-1], strings.TrimRight(lines[n-2], " \t\r")
+	if line == "" || line[0] == ' ' || line[0] == '\t' || line[0] == '}' || line[0] == ')' {
+		return false
+	}
+	return prev == "" || prev == "}" || prev == ")"
+}
+
+// lineRanges turns ascending line numbers into ranges of consecutive lines.
+func lineRanges(nums []int) []LineRange {
+	var ranges []LineRange
+	for _, n := range nums {
+		if k := len(ranges) - 1; k >= 0 && ranges[k].Last == n-1 {
+			ranges[k].Last = n
+			continue
+		}
+		ranges = append(ranges, LineRange{First: n, Last: n})
+	}
+	return ranges
+}
+
+// MergeChunks combines the results of reviewing a file in the chunks returned by Chunk
+// into the result for the file. The review of each chunk is headed by the lines it
+// covers; chunks the triage model screened out add nothing.
+func MergeChunks(results []*Result, chunks [][]LineRange) *Result {
+	if len(results) == 1 {
+		return results[0]
+	}
+	merged := &Result{RequestID: results[0].RequestID, Prefiltered: true}
+	var parts []string
+	for i, r := range results {
+		ranges := chunks[i]
+		if review := strings.TrimSpace(r.Review); review != "" {
+			parts = append(parts, fmt.Sprintf("Lines %d-%d:\n\n%s", ranges[0].First, ranges[len(ranges)-1].Last, review))
+		}
+		merged.Findings = append(merged.Findings, r.Findings...)
+		merged.Truncated = merged.Truncated || r.Truncated
+		merged.Prefiltered = merged.Prefiltered && r.Prefiltered
+		merged.ProviderRequestIDs = append(merged.ProviderRequestIDs, r.ProviderRequestIDs...)
+		merged.Metadata.Merge(r.Metadata)
+	}
+	merged.Review = strings.Join(parts, "\n\n")
+	if len(parts) > 0 {
+		merged.Review += "\n"
+	}
+	return merged
+}
@@ -0,0 +1,112 @@
+package reviewer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/config"
+)
+
+// embeddingBatch is how many inputs are sent per embeddings request.
+const embeddingBatch = 32
+
+// Embedder computes embeddings through the OpenAI-compatible /embeddings endpoint
+// next to the configured chat completions endpoint.
+type Embedder struct {
+	provider *openAIProvider
+	endpoint string
+	model    string
+}
+
+// NewEmbedder returns an embedder for the configured endpoint and --embedding-model.
+// Only providers speaking the OpenAI API support embeddings.
+func NewEmbedder(cfg *config.Config) (*Embedder, error) {
+	provider, err := NewProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+	p, ok := provider.(*openAIProvider)
+	if !ok {
+		return nil, fmt.Errorf("the %s provider does not support embeddings", cfg.Provider)
+	}
+	return &Embedder{provider: p, endpoint: embeddingsURL(p.endpoints[0]), model: cfg.EmbeddingModel}, nil
+}
+
+// Model is the embedding model; vectors of different models are not comparable.
+func (e *Embedder) Model() string {
+	return e.model
+}
+
+// embeddingsURL derives the embeddings URL from a chat completions endpoint.
+func embeddingsURL(endpoint string) string {
+	base := strings.TrimSuffix(strings.TrimSuffix(endpoint, "/"), "/chat/completions")
+	return base + "/embeddings"
+}
+
+// Embed returns one vector per input, in order.
+func (e *Embedder) Embed(ctx context.Context, inputs []string) ([][]float32, error) {
+	out := make([][]float32, 0, len(inputs))
+	for start := 0; start < len(inputs); start += embeddingBatch {
+		batch := inputs[start:min(start+embeddingBatch, len(inputs))]
+		vectors, err := e.embedBatch(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, vectors...)
+	}
+	return out, nil
+}
+
+func (e *Embedder) embedBatch(ctx context.Context, inputs []string) ([][]float32, error) {
+	payload, err := json.Marshal(map[string]any{"model": e.model, "input": inputs})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	_, key := e.provider.keys.pick()
+	if err := e.provider.authorize(ctx, req, key); err != nil {
+		return nil, err
+	}
+
+	resp, err := e.provider.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, &statusError{code: resp.StatusCode, msg: fmt.Sprintf("embeddings returned status %d: %s",
+			resp.StatusCode, strings.TrimSpace(string(body)))}
+	}
+
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode embeddings: %w", err)
+	}
+	if len(result.Data) != len(inputs) {
+		return nil, fmt.Errorf("embeddings returned %d vectors for %d inputs", len(result.Data), len(inputs))
+	}
+	vectors := make([][]float32, len(inputs))
+	for _, d := range result.Data {
+		if d.Index < 0 || d.Index >= len(inputs) {
+			return nil, fmt.Errorf("embeddings returned an out-of-range index %d", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, nil
+}
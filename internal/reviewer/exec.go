@@ -0,0 +1,131 @@
+package reviewer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/disconnekt/goreview/internal/config"
+)
+
+// ExecResponse is what an exec provider writes to stdout: the review text, or an
+// error message when the request failed.
+type ExecResponse struct {
+	Content string `json:"content"`
+	Error   string `json:"error,omitempty"`
+}
+
+// execProvider runs an external command per request. The command reads one
+// ReviewRequest as JSON on stdin and writes one ExecResponse as JSON on stdout, which
+// lets users plug in gateways or transports goreview does not support itself.
+type execProvider struct {
+	observed
+	name    string
+	args    []string
+	timeout time.Duration
+}
+
+func newExecProvider(cfg *config.Config) (Provider, error) {
+	fields, err := splitCommand(cfg.ProviderCommand)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --provider-command: %w", err)
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("the exec provider requires --provider-command")
+	}
+	return &execProvider{name: fields[0], args: fields[1:], timeout: cfg.RequestTimeout}, nil
+}
+
+// splitCommand splits a command line into arguments on whitespace. Single or double
+// quotes group words into one argument; backslashes are kept as is so Windows paths
+// need no escaping.
+func splitCommand(line string) ([]string, error) {
+	var args []string
+	var b strings.Builder
+	inArg := false
+	var quote rune
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				b.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, b.String())
+				b.Reset()
+				inArg = false
+			}
+		default:
+			b.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inArg {
+		args = append(args, b.String())
+	}
+	return args, nil
+}
+
+func (p *execProvider) Complete(ctx context.Context, request ReviewRequest) (review string, err error) {
+	// The command protocol has string message content only
+	request.Messages = withoutCachePrefixes(request.Messages)
+	input, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	attempt := newAttempt(request, input)
+	attempt.Endpoint = "exec:" + p.name
+	start := time.Now()
+	defer func() {
+		attempt.Latency = time.Since(start)
+		attempt.Err = err
+		p.observe(ctx, attempt)
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.name, p.args...)
+	if id := requestID(ctx); id != "" {
+		cmd.Env = append(os.Environ(), "AIREVIEW_REQUEST_ID="+id)
+	}
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	attempt.Status = cmd.ProcessState.ExitCode()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("provider command failed: %w: %s", err, msg)
+		}
+		return "", fmt.Errorf("provider command failed: %w", err)
+	}
+
+	var response ExecResponse
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		return "", fmt.Errorf("failed to decode provider command output: %w", err)
+	}
+	if response.Error != "" {
+		return "", fmt.Errorf("provider error: %s", response.Error)
+	}
+	if strings.TrimSpace(response.Content) == "" {
+		return "", errors.New("provider command returned no content")
+	}
+	return response.Content, nil
+}
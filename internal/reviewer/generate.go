@@ -0,0 +1,67 @@
+package reviewer
+
+import (
+	"context"
+)
+
+// Generate sends a task other than a review, such as drafting tests, to the model and
+// returns its reply. The repository context is prepended to the system prompt, but the
+// review profile, rules and finding format are not.
+func (s *Service) Generate(ctx context.Context, system, user string, opts Options) (string, error) {
+	if opts.File != "" {
+		ctx = context.WithValue(ctx, fileKey{}, opts.File)
+	}
+	model := s.config.Model
+	if opts.Model != "" {
+		model = opts.Model
+	}
+	if s.context != "" {
+		system = s.context + "\n\n" + system
+	}
+	shared := len(system)
+	if opts.Prompt != "" {
+		system += "\n\n" + opts.Prompt
+	}
+	return s.complete(ctx, model, []Message{
+		{Role: "system", Content: system, CachePrefix: s.cachePrefix(shared)},
+		{Role: "user", Content: user},
+	})
+}
+
+// Thread is a conversation with the model, such as follow-up questions about a finding.
+// Every question is sent with the earlier messages so answers build on each other.
+type Thread struct {
+	service *Service
+	model   string
+	system  string
+	opening string
+	// Messages holds the questions and answers so far, alternating user and assistant.
+	Messages []Message
+}
+
+// NewThread starts a conversation with a system prompt and an opening message that
+// sets out the subject, e.g. a finding and its code. Messages continues an earlier
+// conversation and may be nil.
+func (s *Service) NewThread(system, opening string, messages []Message) *Thread {
+	return &Thread{
+		service:  s,
+		model:    s.config.Model,
+		system:   system,
+		opening:  opening,
+		Messages: messages,
+	}
+}
+
+// Ask sends a question with the conversation so far and records the answer.
+func (t *Thread) Ask(ctx context.Context, question string) (string, error) {
+	turns := append(t.Messages[:len(t.Messages):len(t.Messages)], Message{Role: "user", Content: question})
+	messages := append([]Message{{Role: "system", Content: t.system}}, turns...)
+	// The opening is part of the first question so user and assistant turns alternate
+	messages[1].Content = t.opening + "\n\n" + messages[1].Content
+	answer, err := t.service.complete(ctx, t.model, messages)
+	if err != nil {
+		return "", err
+	}
+	t.Messages = append(turns, Message{Role: "assistant", Content: answer})
+	return answer, nil
+}
@@ -0,0 +1,103 @@
+package reviewer
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// KeyUsage counts how one API key was used during a run.
+type KeyUsage struct {
+	// Key is the masked key, e.g. "...a1b2".
+	Key         string
+	Requests    int
+	RateLimited int
+	Rejected    bool
+}
+
+// keyPool spreads requests round-robin over several API keys. Keys rejected with 401
+// are taken out of rotation; a 429 moves the request on to the next key.
+type keyPool struct {
+	mu    sync.Mutex
+	keys  []string
+	usage []KeyUsage
+	next  int
+}
+
+func newKeyPool(keys []string) *keyPool {
+	if len(keys) == 0 {
+		return nil
+	}
+	p := &keyPool{keys: keys, usage: make([]KeyUsage, len(keys))}
+	for i, k := range keys {
+		p.usage[i].Key = maskKey(k)
+	}
+	return p
+}
+
+// size is the number of keys; a nil pool has none.
+func (p *keyPool) size() int {
+	if p == nil {
+		return 0
+	}
+	return len(p.keys)
+}
+
+// pick returns the index and value of the next key in rotation, preferring keys that
+// have not been rejected. A nil pool returns -1 and no key.
+func (p *keyPool) pick() (int, string) {
+	if p == nil {
+		return -1, ""
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for range p.keys {
+		i := p.next
+		p.next = (p.next + 1) % len(p.keys)
+		if !p.usage[i].Rejected {
+			p.usage[i].Requests++
+			return i, p.keys[i]
+		}
+	}
+	// Every key was rejected; keep trying so the error reaches the user
+	i := p.next
+	p.next = (p.next + 1) % len(p.keys)
+	p.usage[i].Requests++
+	return i, p.keys[i]
+}
+
+// record notes the HTTP status a key received.
+func (p *keyPool) record(i, status int) {
+	if p == nil || i < 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch status {
+	case 429:
+		p.usage[i].RateLimited++
+	case 401:
+		if !p.usage[i].Rejected && len(p.keys) > 1 {
+			fmt.Fprintf(os.Stderr, "Warning: API key %s was rejected (401); rotating to the remaining keys\n", p.usage[i].Key)
+		}
+		p.usage[i].Rejected = true
+	}
+}
+
+// snapshot returns a copy of the per-key usage.
+func (p *keyPool) snapshot() []KeyUsage {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]KeyUsage(nil), p.usage...)
+}
+
+// maskKey keeps only the last four characters of a key for display.
+func maskKey(key string) string {
+	if len(key) <= 4 {
+		return "..."
+	}
+	return "..." + key[len(key)-4:]
+}
@@ -0,0 +1,92 @@
+package reviewer
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// rateLimitHeaders name the response headers reporting the remaining request and token
+// quota, in order of preference.
+var (
+	rateLimitRequestHeaders = []string{"X-Ratelimit-Remaining-Requests", "Anthropic-Ratelimit-Requests-Remaining"}
+	rateLimitTokenHeaders   = []string{"X-Ratelimit-Remaining-Tokens", "Anthropic-Ratelimit-Tokens-Remaining"}
+)
+
+// Metadata sums up the responses to the requests made for one review.
+type Metadata struct {
+	Requests int
+	// Reused counts the chunks of a file whose review was taken from an earlier failed
+	// attempt instead of being resent.
+	Reused           int
+	PromptTokens     int
+	CompletionTokens int
+	// CachedTokens are the prompt tokens read from the provider's prompt cache.
+	CachedTokens int
+	Latency      time.Duration
+	// ModelVersions are the model versions the provider reported serving, which may be
+	// more specific than the requested models, e.g. "gpt-4o-2024-08-06".
+	ModelVersions []string
+	// RateLimitRequests and RateLimitTokens are the remaining quota reported with the
+	// latest response that had it; nil when never reported.
+	RateLimitRequests *int
+	RateLimitTokens   *int
+}
+
+// add accounts for one attempt.
+func (m *Metadata) add(a Attempt) {
+	m.Requests++
+	m.PromptTokens += a.PromptTokens
+	m.CompletionTokens += a.CompletionTokens
+	m.CachedTokens += a.CachedTokens
+	m.Latency += a.Latency
+	if a.ModelVersion != "" && !slices.Contains(m.ModelVersions, a.ModelVersion) {
+		m.ModelVersions = append(m.ModelVersions, a.ModelVersion)
+	}
+	if a.RateLimitRequests != nil {
+		m.RateLimitRequests = a.RateLimitRequests
+	}
+	if a.RateLimitTokens != nil {
+		m.RateLimitTokens = a.RateLimitTokens
+	}
+}
+
+// Merge adds the metadata of another review of the same file, such as one of its chunks.
+// The rate limits of o replace those of m when o reported them.
+func (m *Metadata) Merge(o Metadata) {
+	m.Requests += o.Requests
+	m.Reused += o.Reused
+	m.PromptTokens += o.PromptTokens
+	m.CompletionTokens += o.CompletionTokens
+	m.CachedTokens += o.CachedTokens
+	m.Latency += o.Latency
+	for _, v := range o.ModelVersions {
+		if !slices.Contains(m.ModelVersions, v) {
+			m.ModelVersions = append(m.ModelVersions, v)
+		}
+	}
+	if o.RateLimitRequests != nil {
+		m.RateLimitRequests = o.RateLimitRequests
+	}
+	if o.RateLimitTokens != nil {
+		m.RateLimitTokens = o.RateLimitTokens
+	}
+}
+
+// readResponseHeaders records the provider metadata of a response in the attempt.
+func (a *Attempt) readResponseHeaders(h http.Header) {
+	a.ProviderRequestID = providerRequestID(h)
+	a.RateLimitRequests = headerInt(h, rateLimitRequestHeaders)
+	a.RateLimitTokens = headerInt(h, rateLimitTokenHeaders)
+}
+
+// headerInt returns the first of the named headers holding an integer, or nil.
+func headerInt(h http.Header, names []string) *int {
+	for _, name := range names {
+		if n, err := strconv.Atoi(h.Get(name)); err == nil {
+			return &n
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package reviewer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/config"
+)
+
+// EndpointModels is the model listing of one endpoint.
+type EndpointModels struct {
+	Endpoint string
+	Models   []string
+	// Requested is the configured model as the endpoint names it, e.g. with the vendor
+	// prefix a gateway preset adds.
+	Requested string
+	Err       error
+}
+
+// Has reports whether the endpoint serves its requested model.
+func (e EndpointModels) Has() bool {
+	for _, m := range e.Models {
+		if m == e.Requested {
+			return true
+		}
+	}
+	return false
+}
+
+// ListModels asks every endpoint of the configured provider which models it serves.
+// Only providers speaking the OpenAI API support listing.
+func ListModels(ctx context.Context, cfg *config.Config) ([]EndpointModels, error) {
+	provider, err := NewProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+	p, ok := provider.(*openAIProvider)
+	if !ok {
+		return nil, fmt.Errorf("the %s provider does not support listing models", cfg.Provider)
+	}
+	requested := cfg.Model
+	if p.mapModel != nil {
+		requested = p.mapModel(requested)
+	}
+	out := make([]EndpointModels, 0, len(p.endpoints))
+	for _, ep := range p.endpoints {
+		models, err := p.listModels(ctx, ep)
+		out = append(out, EndpointModels{Endpoint: ep, Models: models, Requested: requested, Err: err})
+	}
+	return out, nil
+}
+
+// modelsURL derives the models listing URL from a chat completions endpoint.
+func modelsURL(endpoint string) string {
+	base := strings.TrimSuffix(strings.TrimSuffix(endpoint, "/"), "/chat/completions")
+	return base + "/models"
+}
+
+func (p *openAIProvider) listModels(ctx context.Context, endpoint string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, modelsURL(endpoint), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	_, key := p.keys.pick()
+	if err := p.authorize(ctx, req, key); err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &statusError{code: resp.StatusCode, msg: fmt.Sprintf("models listing returned status %d: %s", resp.StatusCode, resp.Status)}
+	}
+
+	var listing struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&listing); err != nil {
+		return nil, fmt.Errorf("failed to decode models listing: %w", err)
+	}
+	models := make([]string, 0, len(listing.Data))
+	for _, m := range listing.Data {
+		models = append(models, m.ID)
+	}
+	sort.Strings(models)
+	return models, nil
+}
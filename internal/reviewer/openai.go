@@ -0,0 +1,252 @@
+package reviewer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/disconnekt/goreview/internal/config"
+)
+
+// openAIProvider talks to OpenAI-compatible chat completion endpoints, spreading
+// requests round-robin across them with failover.
+type openAIProvider struct {
+	observed
+	config *config.Config
+	client *http.Client
+	// endpoints contains the effective list of API endpoints to use
+	endpoints []string
+	// rrCounter is used for round-robin selection across endpoints
+	rrCounter uint64
+	// headers are extra request headers set by gateway presets
+	headers map[string]string
+	// keys are the API keys requests rotate through
+	keys *keyPool
+	// token returns a bearer token per request, replacing the static API key when set
+	token func(context.Context) (string, error)
+	// mapModel translates configured model names for gateway presets when set
+	mapModel func(string) string
+}
+
+func newOpenAIProvider(cfg *config.Config) (Provider, error) {
+	return &openAIProvider{
+		config: cfg,
+		client: &http.Client{
+			Timeout: cfg.RequestTimeout,
+		},
+		endpoints: cfg.EffectiveAPIURLs(),
+		keys:      newKeyPool(cfg.EffectiveAPIKeys()),
+	}, nil
+}
+
+// statusError is a non-200 response from an endpoint.
+type statusError struct {
+	code int
+	msg  string
+}
+
+func (e *statusError) Error() string { return e.msg }
+
+// StatusCode returns the HTTP status of the endpoint response that caused err, or 0
+// when err did not come from a non-200 response.
+func StatusCode(err error) int {
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code
+	}
+	return 0
+}
+
+// KeyUsage reports per-key request counts when several API keys are configured.
+func (p *openAIProvider) KeyUsage() []KeyUsage {
+	return p.keys.snapshot()
+}
+
+// Complete tries endpoints in round-robin order for failover.
+func (p *openAIProvider) Complete(ctx context.Context, request ReviewRequest) (string, error) {
+	if p.mapModel != nil {
+		request.Model = p.mapModel(request.Model)
+	}
+	if !explicitCache(request.Model) {
+		request.Messages = withoutCachePrefixes(request.Messages)
+	}
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	// Try multiple endpoints starting from a round-robin index for failover
+	eps := p.endpoints
+	if len(eps) == 0 {
+		eps = []string{p.config.APIURL}
+	}
+	start := int((atomic.AddUint64(&p.rrCounter, 1) - 1) % uint64(len(eps)))
+	var lastErr error
+	for i := 0; i < len(eps); i++ {
+		ep := eps[(start+i)%len(eps)]
+		review, err := p.attemptWithKeys(ctx, ep, newAttempt(request, requestBody), requestBody)
+		if err == nil {
+			return review, nil
+		}
+		lastErr = fmt.Errorf("endpoint %s failed: %w", ep, err)
+	}
+	if lastErr != nil {
+		return "", fmt.Errorf("all %d endpoints failed; last error: %w", len(eps), lastErr)
+	}
+	return "", fmt.Errorf("no endpoints configured")
+}
+
+// attemptWithKeys sends the request to one endpoint, moving on to the next API key
+// when a key is rate limited (429) or rejected (401).
+func (p *openAIProvider) attemptWithKeys(ctx context.Context, endpoint string, attempt Attempt, requestBody []byte) (string, error) {
+	tries := max(p.keys.size(), 1)
+	var err error
+	for t := 0; t < tries; t++ {
+		i, key := p.keys.pick()
+		var review string
+		review, err = p.attemptRequest(ctx, endpoint, attempt, key, requestBody)
+		if err == nil {
+			return review, nil
+		}
+		var se *statusError
+		if !errors.As(err, &se) || (se.code != http.StatusTooManyRequests && se.code != http.StatusUnauthorized) {
+			return "", err
+		}
+		p.keys.record(i, se.code)
+	}
+	return "", err
+}
+
+// attemptRequest performs a single HTTP request to the given endpoint and reports it
+// to the observer.
+func (p *openAIProvider) attemptRequest(ctx context.Context, endpoint string, attempt Attempt, apiKey string, requestBody []byte) (review string, err error) {
+	model := attempt.Model
+	attempt.Endpoint = endpoint
+	start := time.Now()
+	defer func() {
+		attempt.Latency = time.Since(start)
+		attempt.Err = err
+		p.observe(ctx, attempt)
+	}()
+
+	body, encoding := requestBody, ""
+	if p.config.Compresses(endpoint) && len(requestBody) >= minGzipBytes {
+		if body, err = gzipBody(requestBody); err != nil {
+			return "", err
+		}
+		encoding = "gzip"
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+	attempt.SentBytes = len(body)
+	setRequestID(ctx, req)
+	if err := p.authorize(ctx, req, apiKey); err != nil {
+		return "", err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+	attempt.Status = resp.StatusCode
+	attempt.readResponseHeaders(resp.Header)
+
+	if resp.StatusCode != http.StatusOK {
+		var msg string
+		switch resp.StatusCode {
+		case http.StatusBadRequest:
+			msg = fmt.Sprintf("bad request (400): invalid request format or unsupported model '%s'", model)
+		case http.StatusUnauthorized:
+			msg = "authentication failed (401): check your API key"
+		case http.StatusForbidden:
+			msg = "access forbidden (403): insufficient permissions or invalid API key"
+		case http.StatusNotFound:
+			msg = fmt.Sprintf("model not found (404): check if model '%s' exists and you have access to it", model)
+		case http.StatusTooManyRequests:
+			msg = "rate limit exceeded (429): too many requests, please wait and try again"
+		case http.StatusInternalServerError:
+			msg = "server error (500): API service temporarily unavailable"
+		default:
+			msg = fmt.Sprintf("API returned status %d: %s", resp.StatusCode, resp.Status)
+		}
+		return "", &statusError{code: resp.StatusCode, msg: msg}
+	}
+
+	var reviewResponse ReviewResponse
+	if err := json.NewDecoder(resp.Body).Decode(&reviewResponse); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	if u := reviewResponse.Usage; u != nil {
+		attempt.PromptTokens = u.PromptTokens
+		attempt.CompletionTokens = u.CompletionTokens
+		attempt.CachedTokens = u.cachedTokens()
+	}
+	attempt.ModelVersion = reviewResponse.Model
+
+	if reviewResponse.Error != nil {
+		return "", fmt.Errorf("API error: %s", reviewResponse.Error.Message)
+	}
+
+	if len(reviewResponse.Choices) == 0 {
+		return "", fmt.Errorf("no review choices returned")
+	}
+
+	return reviewResponse.Choices[0].Message.Content, nil
+}
+
+// explicitCache reports whether a model caches prompts only at cache_control markers,
+// as Claude models do behind OpenAI-compatible gateways. Others, such as OpenAI's,
+// cache repeated prompt prefixes by themselves and may reject array content.
+func explicitCache(model string) bool {
+	return strings.Contains(strings.ToLower(model), "claude")
+}
+
+// minGzipBytes is the request size below which compression is not worth the gateway's
+// extra work.
+const minGzipBytes = 1024
+
+// gzipBody compresses a request body.
+func gzipBody(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to compress request: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress request: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// authorize sets the identification, credential and preset headers of a request.
+func (p *openAIProvider) authorize(ctx context.Context, req *http.Request, apiKey string) error {
+	req.Header.Set("User-Agent", "aireview/1.0")
+	if p.token != nil {
+		token, err := p.token(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get access token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	for k, v := range p.headers {
+		req.Header.Set(k, v)
+	}
+	return nil
+}
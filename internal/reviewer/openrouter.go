@@ -0,0 +1,58 @@
+package reviewer
+
+import (
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/config"
+)
+
+// openRouterHeaders identify goreview in OpenRouter's app rankings and usage pages.
+var openRouterHeaders = map[string]string{
+	"HTTP-Referer": "https://github.com/disconnekt/goreview",
+	"X-Title":      "goreview",
+}
+
+// openRouterVendors maps model name prefixes to the vendor namespace OpenRouter
+// expects, so "gpt-4o" can be used instead of "openai/gpt-4o".
+var openRouterVendors = []struct{ prefix, vendor string }{
+	{"gpt-", "openai"},
+	{"o1", "openai"},
+	{"o3", "openai"},
+	{"o4", "openai"},
+	{"claude-", "anthropic"},
+	{"gemini-", "google"},
+	{"gemma-", "google"},
+	{"llama-", "meta-llama"},
+	{"mistral-", "mistralai"},
+	{"mixtral-", "mistralai"},
+	{"codestral-", "mistralai"},
+	{"devstral-", "mistralai"},
+	{"deepseek-", "deepseek"},
+	{"qwen", "qwen"},
+}
+
+func newOpenRouterProvider(cfg *config.Config) (Provider, error) {
+	p, err := newOpenAIProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+	op := p.(*openAIProvider)
+	op.headers = openRouterHeaders
+	op.mapModel = openRouterModel
+	return op, nil
+}
+
+// openRouterModel prefixes bare model names with their OpenRouter vendor namespace.
+// Names that already contain a namespace, or that are not recognized, pass through.
+func openRouterModel(model string) string {
+	if strings.Contains(model, "/") {
+		return model
+	}
+	lower := strings.ToLower(model)
+	for _, v := range openRouterVendors {
+		if strings.HasPrefix(lower, v.prefix) {
+			return v.vendor + "/" + model
+		}
+	}
+	return model
+}
@@ -0,0 +1,44 @@
+package reviewer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/disconnekt/goreview/internal/config"
+)
+
+// Provider sends a chat completion request to a model backend and returns the
+// assistant's reply.
+type Provider interface {
+	Complete(ctx context.Context, req ReviewRequest) (string, error)
+}
+
+// providers maps --provider names to their constructors.
+var providers = map[string]func(*config.Config) (Provider, error){
+	"openai":     newOpenAIProvider,
+	"openrouter": newOpenRouterProvider,
+	"litellm":    newOpenAIProvider,
+	"bedrock":    newBedrockProvider,
+	"vertex":     newVertexProvider,
+	"exec":       newExecProvider,
+}
+
+// NewProvider returns the provider selected by cfg.Provider.
+func NewProvider(cfg *config.Config) (Provider, error) {
+	newProvider, ok := providers[cfg.Provider]
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q (available: %s)", cfg.Provider, ProviderNames())
+	}
+	return newProvider(cfg)
+}
+
+// ProviderNames lists the available provider names, sorted.
+func ProviderNames() []string {
+	names := make([]string, 0, len(providers))
+	for n := range providers {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,114 @@
+package reviewer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// RequestIDHeader carries the ID of a review with each of its requests, so provider
+// and proxy logs can be correlated with aireview's.
+const RequestIDHeader = "X-Request-ID"
+
+// providerRequestHeaders are the response headers in which providers return the ID
+// they assigned to a request, in order of preference.
+var providerRequestHeaders = []string{
+	"X-Request-Id",     // OpenAI, Groq, Mistral and most gateways
+	"Request-Id",       // Anthropic
+	"X-Amzn-Requestid", // Bedrock
+	"Apim-Request-Id",  // Azure OpenAI
+	"X-Ms-Request-Id",
+}
+
+// NewRequestID returns a random ID for correlating the requests of one review.
+func NewRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// requestKey carries the request trace of a review in the request context.
+type requestKey struct{}
+
+// requestTrace collects the provider request IDs and metadata of the requests made
+// for one review.
+type requestTrace struct {
+	id string
+
+	mu          sync.Mutex
+	providerIDs []string
+	meta        Metadata
+}
+
+func (t *requestTrace) record(a Attempt) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.meta.add(a)
+	// Skip absent IDs and our own ID echoed back
+	if a.ProviderRequestID != "" && a.ProviderRequestID != t.id {
+		t.providerIDs = append(t.providerIDs, a.ProviderRequestID)
+	}
+}
+
+func (t *requestTrace) metadata() Metadata {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	m := t.meta
+	m.ModelVersions = slices.Clone(m.ModelVersions)
+	return m
+}
+
+func (t *requestTrace) provider() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.providerIDs...)
+}
+
+// requestID returns the review's request ID from the context, or "" outside a review.
+func requestID(ctx context.Context) string {
+	if t, ok := ctx.Value(requestKey{}).(*requestTrace); ok {
+		return t.id
+	}
+	return ""
+}
+
+// setRequestID adds the review's request ID to the headers of an outbound request.
+func setRequestID(ctx context.Context, req *http.Request) {
+	if id := requestID(ctx); id != "" {
+		req.Header.Set(RequestIDHeader, id)
+	}
+}
+
+// providerRequestID returns the ID the provider assigned to a request, or "".
+func providerRequestID(h http.Header) string {
+	for _, name := range providerRequestHeaders {
+		if v := h.Get(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// RequestError is a failed review annotated with the IDs to quote in support tickets.
+type RequestError struct {
+	RequestID          string
+	ProviderRequestIDs []string
+	Err                error
+}
+
+func (e *RequestError) Error() string {
+	msg := fmt.Sprintf("%v (request ID %s", e.Err, e.RequestID)
+	if len(e.ProviderRequestIDs) > 0 {
+		msg += ", provider request IDs " + strings.Join(e.ProviderRequestIDs, ", ")
+	}
+	return msg + ")"
+}
+
+func (e *RequestError) Unwrap() error { return e.Err }
@@ -1,32 +1,90 @@
 package reviewer
 
 import (
-    "bytes"
-    "context"
-    "encoding/json"
-    "fmt"
-    "net/http"
-    "strings"
-    "sync/atomic"
-
-    "github.com/disconnekt/goreview/internal/config"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/disconnekt/goreview/internal/config"
+	"github.com/disconnekt/goreview/internal/findings"
+	"github.com/disconnekt/goreview/internal/languages"
+	"github.com/disconnekt/goreview/internal/profiles"
+	"github.com/disconnekt/goreview/internal/repocontext"
+	"github.com/disconnekt/goreview/internal/rules"
+	"github.com/disconnekt/goreview/internal/scanner"
 )
 
+// minReplyTokens is the smallest reply a prompt must leave room for in the model's
+// context window; larger prompts are rejected without being sent.
+const minReplyTokens = 1024
+
 type ReviewRequest struct {
 	Model       string    `json:"model"`
 	Messages    []Message `json:"messages"`
 	MaxTokens   int       `json:"max_tokens,omitempty"`
-	Temperature float64   `json:"temperature,omitempty"`
-	Stream      bool      `json:"stream,omitempty"`
+	Temperature float64   `json:"temperature"`
+	// Seed is sent by providers whose API supports it; 0 omits it.
+	Seed   int64 `json:"seed,omitempty"`
+	Stream bool  `json:"stream,omitempty"`
 }
 
 type Message struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
+	// CachePrefix is the length of the start of Content shared by the requests of a run,
+	// which providers mark as cacheable; 0 marks nothing.
+	CachePrefix int `json:"-"`
+}
+
+// textPart is a part of an array message content.
+type textPart struct {
+	Type         string        `json:"type"`
+	Text         string        `json:"text"`
+	CacheControl *cacheControl `json:"cache_control,omitempty"`
+}
+
+type cacheControl struct {
+	Type string `json:"type"`
+}
+
+// MarshalJSON sends a message with a cache prefix as two text parts, the first carrying
+// Anthropic's cache_control marker, and any other message with plain string content.
+func (m Message) MarshalJSON() ([]byte, error) {
+	if m.CachePrefix <= 0 || m.CachePrefix > len(m.Content) {
+		type plain Message
+		return json.Marshal(plain(m))
+	}
+	parts := []textPart{{Type: "text", Text: m.Content[:m.CachePrefix], CacheControl: &cacheControl{Type: "ephemeral"}}}
+	if rest := m.Content[m.CachePrefix:]; rest != "" {
+		parts = append(parts, textPart{Type: "text", Text: rest})
+	}
+	return json.Marshal(struct {
+		Role    string     `json:"role"`
+		Content []textPart `json:"content"`
+	}{m.Role, parts})
+}
+
+// withoutCachePrefixes returns messages without cache prefixes, for backends that take
+// string content only.
+func withoutCachePrefixes(messages []Message) []Message {
+	out := make([]Message, len(messages))
+	for i, m := range messages {
+		m.CachePrefix = 0
+		out[i] = m
+	}
+	return out
 }
 
 type ReviewResponse struct {
+	// Model is the model version that served the request, when reported.
+	Model   string    `json:"model,omitempty"`
 	Choices []Choice  `json:"choices"`
+	Usage   *Usage    `json:"usage,omitempty"`
 	Error   *APIError `json:"error,omitempty"`
 }
 
@@ -41,142 +99,435 @@ type APIError struct {
 }
 
 type Service struct {
-    config *config.Config
-    client *http.Client
-    // endpoints contains the effective list of API endpoints to use
-    endpoints []string
-    // rrCounter is used for round-robin selection across endpoints
-    rrCounter uint64
+	config *config.Config
+	// provider sends chat completion requests to the model backend
+	provider Provider
+	// profile focuses the review on one concern
+	profile profiles.Profile
+	// rules are project conventions appended to the system prompt
+	rules []rules.Rule
+	// context is repository context prepended to the system prompt
+	context string
+	// observers are notified of every outbound request
+	observers []func(Attempt)
+	// triageProvider serves --triage-model
+	triageProvider Provider
+}
+
+func NewService(cfg *config.Config) (*Service, error) {
+	profile, err := profiles.Get(cfg.Profile)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := NewProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var triageProvider Provider
+	if cfg.TriageModel != "" {
+		// Screening every file waits on its answer, so it skips the batch API
+		if triageProvider, err = newTriageProvider(cfg, provider); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Batch {
+		if provider, err = newBatchProvider(cfg, provider); err != nil {
+			return nil, err
+		}
+	}
+	return &Service{
+		config:         cfg,
+		provider:       provider,
+		profile:        profile,
+		triageProvider: triageProvider,
+	}, nil
+}
+
+// ExpectReviews tells a --batch service that n more reviews are about to start, so a
+// batch is submitted as soon as all of them wait for it. Each started review must end in
+// ReviewCode or SkipReview. Without --batch it does nothing.
+func (s *Service) ExpectReviews(n int) {
+	if b, ok := s.provider.(*batchProvider); ok {
+		b.expect(n * s.issuersPerReview())
+	}
+}
+
+// SkipReview tells a --batch service that one of the reviews announced by ExpectReviews
+// will not run.
+func (s *Service) SkipReview() {
+	s.leaveBatch(s.issuersPerReview())
+}
+
+// issuersPerReview is how many requests a review may wait for at once.
+func (s *Service) issuersPerReview() int {
+	if s.config.Consensus {
+		return len(s.config.ConsensusModels)
+	}
+	return 1
+}
+
+// leaveBatch records that n request issuers of a review are done.
+func (s *Service) leaveBatch(n int) {
+	if b, ok := s.provider.(*batchProvider); ok {
+		b.leave(n)
+	}
+}
+
+// KeyUsage reports per-key usage when the provider rotates over several API keys.
+func (s *Service) KeyUsage() []KeyUsage {
+	if r, ok := s.provider.(interface{ KeyUsage() []KeyUsage }); ok {
+		return r.KeyUsage()
+	}
+	return nil
+}
+
+// AddObserver registers fn to be called after every outbound request, e.g. to write
+// an audit log or collect statistics. It must be called before reviews start.
+func (s *Service) AddObserver(fn func(Attempt)) {
+	s.observers = append(s.observers, fn)
+	observers := s.observers
+	for _, p := range []Provider{s.provider, s.triageProvider} {
+		if o, ok := p.(interface{ setObserver(func(Attempt)) }); ok {
+			o.setObserver(func(a Attempt) {
+				for _, fn := range observers {
+					fn(a)
+				}
+			})
+		}
+	}
+}
+
+// SetRules configures project rules that every review is checked against.
+func (s *Service) SetRules(r []rules.Rule) {
+	s.rules = r
+}
+
+// SetContext sets repository context, such as project documentation, that is
+// prepended to the system prompt.
+func (s *Service) SetContext(section string) {
+	s.context = section
+}
+
+// Result is the outcome of reviewing a single piece of code.
+type Result struct {
+	Review   string
+	Findings []findings.Finding
+	// Truncated reports that the review exceeded --max-review-chars and was cut.
+	Truncated bool
+	// RequestID is the ID sent with every request of the review; ProviderRequestIDs are
+	// the IDs the provider returned for them.
+	RequestID          string
+	ProviderRequestIDs []string
+	// Metadata sums up the usage and response metadata of the review's requests.
+	Metadata Metadata
+	// Prefiltered reports that the triage model found the code not worth a review, so
+	// the review model did not see it.
+	Prefiltered bool
+}
+
+// Options customizes a single review, e.g. with per-module overrides.
+type Options struct {
+	// Model overrides the configured model when set.
+	Model string
+	// Prompt is appended to the system prompt when set.
+	Prompt string
+	// File is the path of the reviewed file, recorded with each request for audits.
+	File string
+	// Language selects the language guidance added to the prompt, e.g. "go".
+	Language string
+	// RequestID correlates the requests of the review; a random one is used when empty.
+	RequestID string
+	// Excerpt, when set, limits the code sent to the model to these lines, which keep
+	// their numbers in the file.
+	Excerpt []LineRange
 }
 
-func NewService(cfg *config.Config) *Service {
-    return &Service{
-        config: cfg,
-        client: &http.Client{
-            Timeout: cfg.RequestTimeout,
-        },
-        endpoints: cfg.EffectiveAPIURLs(),
-    }
+// LineRange is the lines First through Last of a file, counting from 1.
+type LineRange struct {
+	First, Last int
 }
 
-func (s *Service) ReviewCode(ctx context.Context, code string) (string, error) {
+func (s *Service) ReviewCode(ctx context.Context, code string, opts Options) (*Result, error) {
 	if len(code) > int(s.config.MaxFileSize) {
-		return "", fmt.Errorf("file size exceeds maximum allowed size of %d bytes", s.config.MaxFileSize)
+		s.SkipReview()
+		return nil, fmt.Errorf("file size exceeds maximum allowed size of %d bytes", s.config.MaxFileSize)
 	}
 
 	// Validate content to prevent API issues
 	if err := s.validateContent(code); err != nil {
-		return "", fmt.Errorf("content validation failed: %w", err)
+		s.SkipReview()
+		return nil, fmt.Errorf("content validation failed: %w", err)
+	}
+
+	if opts.File != "" {
+		ctx = context.WithValue(ctx, fileKey{}, opts.File)
+	}
+	id := opts.RequestID
+	if id == "" {
+		id = NewRequestID()
+	}
+	trace := &requestTrace{id: id}
+	result, err := s.review(context.WithValue(ctx, requestKey{}, trace), code, opts)
+	if err != nil {
+		return nil, &RequestError{RequestID: id, ProviderRequestIDs: trace.provider(), Err: err}
+	}
+	result.RequestID, result.ProviderRequestIDs = id, trace.provider()
+	result.Metadata = trace.metadata()
+	return result, nil
+}
+
+// review runs a single-model or consensus review of validated code, unless the triage
+// model screens it out.
+func (s *Service) review(ctx context.Context, code string, opts Options) (*Result, error) {
+	if s.config.TriageModel != "" && !s.triage(ctx, code, opts) {
+		s.SkipReview()
+		return &Result{Prefiltered: true}, nil
+	}
+	if s.config.Consensus {
+		return s.reviewConsensus(ctx, code, opts)
+	}
+
+	model := s.config.Model
+	if opts.Model != "" {
+		model = opts.Model
+	}
+	review, err := s.reviewWithModel(ctx, model, code, opts)
+	s.leaveBatch(1)
+	if err != nil {
+		return nil, err
+	}
+	review, truncated := TruncateReview(review, s.config.MaxReviewChars)
+	return &Result{Review: review, Findings: findings.Parse(review), Truncated: truncated}, nil
+}
+
+// reviewWithModel runs the initial review and any verification passes against one model.
+func (s *Service) reviewWithModel(ctx context.Context, model, code string, opts Options) (string, error) {
+	numbered := numberCode(code, opts.Excerpt)
+	systemPrompt := s.getSystemPrompt()
+	shared := len(systemPrompt)
+	if section := s.languagePrompt(opts.Language); section != "" {
+		systemPrompt += "\n\n" + section
+	}
+	if opts.Prompt != "" {
+		systemPrompt += "\n\n" + opts.Prompt
+	}
+	review, err := s.complete(ctx, model, []Message{
+		{
+			Role:        "system",
+			Content:     systemPrompt,
+			CachePrefix: s.cachePrefix(shared),
+		},
+		{
+			Role:    "user",
+			Content: numbered,
+		},
+	})
+	if err != nil {
+		return "", err
 	}
 
-	request := ReviewRequest{
-		Model: s.config.Model,
-		Messages: []Message{
+	// Additional passes ask the model to verify its own findings against the code
+	for pass := 2; pass <= s.config.Passes; pass++ {
+		verified, err := s.complete(ctx, model, []Message{
 			{
 				Role:    "system",
-				Content: s.getSystemPrompt(),
+				Content: verificationPrompt,
 			},
 			{
 				Role:    "user",
-				Content: code,
-		},
-		},
-		MaxTokens:   4000,
-		Temperature: 0.1,
-		Stream:      false,
-	}
-	requestBody, err := json.Marshal(request)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+				Content: fmt.Sprintf("Code:\n```%s\n%s\n```\n\nDraft review:\n%s", fence(opts.Language), numbered, review),
+			},
+		})
+		if err != nil {
+			return "", fmt.Errorf("verification pass %d failed: %w", pass, err)
+		}
+		review = verified
 	}
 
-	// Try multiple endpoints starting from a round-robin index for failover
-	eps := s.endpoints
-	if len(eps) == 0 {
-		eps = []string{s.config.APIURL}
+	return review, nil
+}
+
+// reviewConsensus sends the code to every consensus model and merges their findings,
+// marking which ones were reported by more than one model.
+func (s *Service) reviewConsensus(ctx context.Context, code string, opts Options) (*Result, error) {
+	models := s.config.ConsensusModels
+	reviews := make([]string, len(models))
+	errs := make([]error, len(models))
+
+	var wg sync.WaitGroup
+	for i, model := range models {
+		wg.Add(1)
+		go func(i int, model string) {
+			defer wg.Done()
+			reviews[i], errs[i] = s.reviewWithModel(ctx, model, code, opts)
+			s.leaveBatch(1)
+		}(i, model)
 	}
-	start := int((atomic.AddUint64(&s.rrCounter, 1) - 1) % uint64(len(eps)))
-	var lastErr error
-	for i := 0; i < len(eps); i++ {
-		ep := eps[(start+i)%len(eps)]
-		review, err := s.attemptRequest(ctx, ep, requestBody)
-		if err == nil {
-			return review, nil
+	wg.Wait()
+
+	byModel := make(map[string][]findings.Finding, len(models))
+	var failed []string
+	truncated := false
+	for i, model := range models {
+		if errs[i] != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", model, errs[i]))
+			continue
 		}
-		lastErr = fmt.Errorf("endpoint %s failed: %w", ep, err)
+		review, cut := TruncateReview(reviews[i], s.config.MaxReviewChars)
+		truncated = truncated || cut
+		byModel[model] = findings.Parse(review)
 	}
-	if lastErr != nil {
-		return "", fmt.Errorf("all %d endpoints failed; last error: %w", len(eps), lastErr)
+	if len(byModel) == 0 {
+		return nil, fmt.Errorf("all consensus models failed: %s", strings.Join(failed, "; "))
 	}
-	return "", fmt.Errorf("no endpoints configured")
-}
 
-func (s *Service) getSystemPrompt() string {
-	return `You are a very experienced senior developer. Analyze the following code and provide recommendations on:
-	- Security vulnerabilities and best practices
-	- Performance optimizations and efficiency improvements
-	- Code correctness and potential bugs
-	- Code readability and maintainability
-	- Clean architecture principles
-	- Go-specific best practices
-
-	Provide only actionable, specific, and important recommendations. Be concise and focus on real issues.`
+	merged := findings.Merge(byModel)
+	review := findings.ConsensusMarkdown(merged, len(models))
+	if len(failed) > 0 {
+		review += "\n_Models that failed: " + strings.Join(failed, "; ") + "_\n"
+	}
+	return &Result{Review: review, Findings: merged, Truncated: truncated}, nil
 }
 
-// attemptRequest performs a single HTTP request to the given endpoint
-func (s *Service) attemptRequest(ctx context.Context, endpoint string, requestBody []byte) (string, error) {
-	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(requestBody))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+// NumberLines prefixes each line with its number, counting from first, so the model can
+// reference lines precisely.
+func NumberLines(code string, first int) string {
+	lines := strings.Split(code, "\n")
+	width := len(strconv.Itoa(first + len(lines) - 1))
+	var b strings.Builder
+	b.Grow(len(code) + len(lines)*(width+2))
+	for i, line := range lines {
+		fmt.Fprintf(&b, "%*d| %s\n", width, first+i, line)
 	}
+	return b.String()
+}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "aireview/1.0")
-	if s.config.APIKey != "" {
-		req.Header.Set("Authorization", "Bearer "+s.config.APIKey)
+// numberCode numbers the lines of code, or of the excerpt of it when one is given, with
+// a "..." line wherever lines are left out.
+func numberCode(code string, excerpt []LineRange) string {
+	if len(excerpt) == 0 {
+		return NumberLines(code, 1)
 	}
-
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		switch resp.StatusCode {
-		case http.StatusBadRequest:
-			return "", fmt.Errorf("bad request (400): invalid request format or unsupported model '%s'", s.config.Model)
-		case http.StatusUnauthorized:
-			return "", fmt.Errorf("authentication failed (401): check your API key")
-		case http.StatusForbidden:
-			return "", fmt.Errorf("access forbidden (403): insufficient permissions or invalid API key")
-		case http.StatusNotFound:
-			return "", fmt.Errorf("model not found (404): check if model '%s' exists and you have access to it", s.config.Model)
-		case http.StatusTooManyRequests:
-			return "", fmt.Errorf("rate limit exceeded (429): too many requests, please wait and try again")
-		case http.StatusInternalServerError:
-			return "", fmt.Errorf("server error (500): API service temporarily unavailable")
-		default:
-			return "", fmt.Errorf("API returned status %d: %s", resp.StatusCode, resp.Status)
+	lines := strings.Split(strings.TrimSuffix(code, "\n"), "\n")
+	width := len(strconv.Itoa(excerpt[len(excerpt)-1].Last))
+	var b strings.Builder
+	next := 1
+	for _, r := range excerpt {
+		first, last := max(r.First, 1), min(r.Last, len(lines))
+		if first > last {
+			continue
+		}
+		if first > next {
+			fmt.Fprintf(&b, "%*s| ...\n", width, "")
 		}
+		for n := first; n <= last; n++ {
+			fmt.Fprintf(&b, "%*d| %s\n", width, n, lines[n-1])
+		}
+		next = last + 1
+	}
+	if next <= len(lines) {
+		fmt.Fprintf(&b, "%*s| ...\n", width, "")
 	}
+	return b.String()
+}
+
+// complete sends a chat completion request through the configured provider, limited to
+// what the model's context window allows.
+func (s *Service) complete(ctx context.Context, model string, messages []Message) (string, error) {
+	return s.completeWith(ctx, s.provider, model, messages)
+}
 
-	var reviewResponse ReviewResponse
-	if err := json.NewDecoder(resp.Body).Decode(&reviewResponse); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+// completeWith is complete through the given provider.
+func (s *Service) completeWith(ctx context.Context, provider Provider, model string, messages []Message) (string, error) {
+	maxTokens, err := s.replyLimit(model, messages)
+	if err != nil {
+		return "", err
 	}
+	temperature, seed := s.config.Sampling()
+	return provider.Complete(ctx, ReviewRequest{
+		Model:       model,
+		Messages:    messages,
+		MaxTokens:   maxTokens,
+		Temperature: temperature,
+		Seed:        seed,
+		Stream:      false,
+	})
+}
 
-	if reviewResponse.Error != nil {
-		return "", fmt.Errorf("API error: %s", reviewResponse.Error.Message)
+// cachePrefix returns the cache prefix of a system prompt whose first n bytes are shared
+// by the run's requests, or 0 without --prompt-cache.
+func (s *Service) cachePrefix(n int) int {
+	if !s.config.PromptCache {
+		return 0
 	}
+	return n
+}
 
-	if len(reviewResponse.Choices) == 0 {
-		return "", fmt.Errorf("no review choices returned")
+// replyLimit returns the completion limit of a request: the model's output limit, or
+// what the prompt leaves of its context window when that is less.
+func (s *Service) replyLimit(model string, messages []Message) (int, error) {
+	info := s.config.ModelInfo(model)
+	chars := 0
+	for _, m := range messages {
+		chars += len(m.Content)
 	}
+	prompt := chars / repocontext.CharsPerToken
+	limit := min(info.MaxOutput, info.ContextWindow-prompt)
+	if limit < min(minReplyTokens, info.MaxOutput) {
+		return 0, fmt.Errorf("prompt of about %d tokens is too large for the %d-token context window of %s", prompt, info.ContextWindow, model)
+	}
+	return limit, nil
+}
 
-	return reviewResponse.Choices[0].Message.Content, nil
+// PromptID identifies the review instructions of the service for comparing the
+// precision of prompts: the profile and a hash of the system prompt without the
+// repository context, which differs per project. extra is a prompt appended for every
+// file, such as the organization prompt.
+func (s *Service) PromptID(extra string) string {
+	instructions := *s
+	instructions.context = ""
+	sum := sha256.Sum256([]byte(instructions.getSystemPrompt() + "\n\n" + extra))
+	return s.profile.Name + "@" + hex.EncodeToString(sum[:4])
 }
 
+func (s *Service) getSystemPrompt() string {
+	prompt := baseSystemPrompt
+	if s.context != "" {
+		prompt = s.context + "\n\n" + prompt
+	}
+	if s.profile.Prompt != "" {
+		prompt += "\n\n" + s.profile.Prompt
+	}
+	prompt += "\n\n" + findings.FormatInstructions
+	if s.config.MaxReviewChars > 0 {
+		prompt += "\n\n" + lengthBudget(s.config.MaxReviewChars)
+	}
+	if section := rules.PromptSection(s.rules); section != "" {
+		prompt += "\n\n" + section
+	}
+	return prompt
+}
+
+const baseSystemPrompt = `You are a very experienced senior developer. Analyze the following code and provide recommendations on:
+	- Security vulnerabilities and best practices
+	- Performance optimizations and efficiency improvements
+	- Code correctness and potential bugs
+	- Code readability and maintainability
+	- Clean architecture principles
+	- Idiomatic use of the code's language
+
+	The code is prefixed with line numbers ("N| ") for reference; they are not part of the source.
+	Provide only actionable, specific, and important recommendations. Be concise and focus on real issues.`
+
+// verificationPrompt drives the self-critique passes enabled by --passes.
+const verificationPrompt = `You are verifying a draft code review written by another reviewer. For each finding in the draft:
+	- Check it against the actual code. Drop findings that reference code, identifiers, or behavior not present in the code.
+	- Drop findings that are speculative, duplicated, or not actionable.
+	- Keep correct findings, fixing line references or details where the draft was inaccurate.
+
+	Reply with the corrected review only, in the same format as the draft, without commentary about the verification itself.
+	If no findings survive, reply with "No significant issues found."`
+
 // validateContent validates code content to prevent API issues
 func (s *Service) validateContent(code string) error {
 	// Check for empty content
@@ -184,40 +535,28 @@ func (s *Service) validateContent(code string) error {
 		return fmt.Errorf("code content is empty")
 	}
 
-	// Check for extremely large content that might cause API issues
-	if len(code) > 100000 { // 100KB threshold for API compatibility
-		return fmt.Errorf("code content too large (%d bytes), may cause API issues", len(code))
-	}
-
 	// Check for binary content or non-text content
-	if !s.isTextContent(code) {
+	if !scanner.IsTextContent(code) {
 		return fmt.Errorf("content appears to be binary or non-text")
 	}
 
 	return nil
 }
 
-// isTextContent checks if content is valid text
-func (s *Service) isTextContent(content string) bool {
-	// Check for null bytes which indicate binary content
-	if strings.Contains(content, "\x00") {
-		return false
-	}
-
-	// Check if content is mostly printable characters
-	printableCount := 0
-	totalCount := 0
-	for _, r := range content {
-		totalCount++
-		if r >= 32 && r <= 126 || r == '\t' || r == '\n' || r == '\r' {
-			printableCount++
-		}
+// languagePrompt returns the guidance for a language: the prompt configured under
+// languages, or the built-in one.
+func (s *Service) languagePrompt(name string) string {
+	if lc, ok := s.config.Languages[name]; ok {
+		return lc.Prompt
 	}
+	l, _ := languages.Get(name)
+	return l.Prompt
+}
 
-	// Require at least 95% printable characters
-	if totalCount > 0 && float64(printableCount)/float64(totalCount) < 0.95 {
-		return false
+// fence is the Markdown code block info string of a language.
+func fence(name string) string {
+	if l, ok := languages.Get(name); ok {
+		return l.Fence
 	}
-
-	return true
+	return ""
 }
@@ -0,0 +1,46 @@
+package reviewer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/config"
+)
+
+// triagePrompt asks the --triage-model whether a file is worth the review model.
+const triagePrompt = `You are screening code before a detailed review by a more capable reviewer. Decide whether the code likely contains bugs, security vulnerabilities, performance problems or other issues worth that review.
+	The code is prefixed with line numbers ("N| ") for reference; they are not part of the source.
+	Reply with exactly one word: REVIEW if it likely does or you are unsure, SKIP if the code is routine and unlikely to have significant issues.`
+
+// newTriageProvider returns the provider of --triage-model: an OpenAI-compatible
+// provider for --triage-url, or else provider itself.
+func newTriageProvider(cfg *config.Config, provider Provider) (Provider, error) {
+	if cfg.TriageURL == "" {
+		return provider, nil
+	}
+	tcfg := *cfg
+	tcfg.Provider = "openai"
+	tcfg.APIURL, tcfg.APIURLs = cfg.TriageURL, nil
+	// Keys of the review endpoints are not sent to another host
+	tcfg.APIKey, tcfg.APIKeys = "", nil
+	return newOpenAIProvider(&tcfg)
+}
+
+// triage asks the triage model whether code needs a review. Failed requests and unclear
+// replies count as needing one, so the screening never drops a file by accident.
+func (s *Service) triage(ctx context.Context, code string, opts Options) bool {
+	system := triagePrompt
+	if s.profile.Prompt != "" {
+		system += "\n\nThe detailed review focuses on:\n" + s.profile.Prompt
+	}
+	reply, err := s.completeWith(ctx, s.triageProvider, s.config.TriageModel, []Message{
+		{Role: "system", Content: system},
+		{Role: "user", Content: fmt.Sprintf("```%s\n%s```", fence(opts.Language), numberCode(code, opts.Excerpt))},
+	})
+	if err != nil {
+		return true
+	}
+	verdict := strings.ToUpper(reply)
+	return !strings.Contains(verdict, "SKIP") || strings.Contains(verdict, "REVIEW")
+}
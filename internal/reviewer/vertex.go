@@ -0,0 +1,53 @@
+package reviewer
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/config"
+)
+
+// newVertexProvider targets the OpenAI-compatible chat completions endpoint of Vertex
+// AI, authenticating with Application Default Credentials instead of an API key.
+func newVertexProvider(cfg *config.Config) (Provider, error) {
+	client := &http.Client{Timeout: cfg.RequestTimeout}
+	tokens, err := newADCTokenSource(client)
+	if err != nil {
+		return nil, err
+	}
+
+	project := firstNonEmpty(cfg.GCPProject, os.Getenv("GOOGLE_CLOUD_PROJECT"), tokens.projectID())
+	if project == "" {
+		return nil, errors.New("the vertex provider requires --gcp-project or GOOGLE_CLOUD_PROJECT")
+	}
+	region := firstNonEmpty(cfg.GCPRegion, os.Getenv("GOOGLE_CLOUD_REGION"), "us-central1")
+
+	endpoint := cfg.APIURL
+	if endpoint == config.DefaultAPIURL {
+		host := region + "-aiplatform.googleapis.com"
+		if region == "global" {
+			host = "aiplatform.googleapis.com"
+		}
+		endpoint = fmt.Sprintf("https://%s/v1beta1/projects/%s/locations/%s/endpoints/openapi/chat/completions",
+			host, project, region)
+	}
+
+	return &openAIProvider{
+		config:    cfg,
+		client:    client,
+		endpoints: []string{endpoint},
+		token:     tokens.Token,
+		mapModel:  vertexModel,
+	}, nil
+}
+
+// vertexModel prefixes bare model names with the "google/" publisher Vertex expects.
+func vertexModel(model string) string {
+	if strings.Contains(model, "/") {
+		return model
+	}
+	return "google/" + model
+}
@@ -0,0 +1,84 @@
+// Package risk scores files by how likely they are to need attention, combining review
+// findings, complexity, churn and size.
+package risk
+
+import (
+	"math"
+	"sort"
+
+	"github.com/disconnekt/goreview/internal/findings"
+)
+
+// Weights of the factors in the score; they add up to 1.
+const (
+	findingsWeight   = 0.4
+	complexityWeight = 0.25
+	churnWeight      = 0.25
+	sizeWeight       = 0.1
+)
+
+// severityWeight is what a finding of each severity adds to the findings factor.
+var severityWeight = map[findings.Severity]int{
+	findings.SeverityCritical: 10,
+	findings.SeverityHigh:     5,
+	findings.SeverityMedium:   2,
+	findings.SeverityLow:      1,
+}
+
+// File holds the factors of one file and its score.
+type File struct {
+	Path string `json:"path"`
+	// Findings is the severity-weighted number of findings.
+	Findings int `json:"findings"`
+	// Complexity is the summed cyclomatic complexity of the file's functions beyond one
+	// per function, so a file of many trivial functions stays low.
+	Complexity int `json:"complexity"`
+	// Churn is the number of lines added and deleted recently.
+	Churn int   `json:"churn"`
+	Size  int64 `json:"size"`
+	// Score ranges from 0 to 100 and is relative to the other files of the run.
+	Score float64 `json:"score"`
+}
+
+// FindingsWeight returns the severity-weighted number of findings.
+func FindingsWeight(list []findings.Finding) int {
+	w := 0
+	for _, f := range list {
+		w += severityWeight[f.Severity]
+	}
+	return w
+}
+
+// Rank scores the files and sorts them riskiest first. Each factor is scaled
+// logarithmically against its largest value among the files, so one outlier does not
+// flatten the others.
+func Rank(files []File) {
+	var maxFindings, maxComplexity, maxChurn, maxSize float64
+	for _, f := range files {
+		maxFindings = math.Max(maxFindings, float64(f.Findings))
+		maxComplexity = math.Max(maxComplexity, float64(f.Complexity))
+		maxChurn = math.Max(maxChurn, float64(f.Churn))
+		maxSize = math.Max(maxSize, float64(f.Size))
+	}
+	for i := range files {
+		f := &files[i]
+		score := findingsWeight*scale(float64(f.Findings), maxFindings) +
+			complexityWeight*scale(float64(f.Complexity), maxComplexity) +
+			churnWeight*scale(float64(f.Churn), maxChurn) +
+			sizeWeight*scale(float64(f.Size), maxSize)
+		f.Score = math.Round(score*1000) / 10
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].Score != files[j].Score {
+			return files[i].Score > files[j].Score
+		}
+		return files[i].Path < files[j].Path
+	})
+}
+
+func scale(v, max float64) float64 {
+	if max <= 0 {
+		return 0
+	}
+	return math.Log1p(v) / math.Log1p(max)
+}
@@ -0,0 +1,124 @@
+// Package routing picks the model that reviews a file from declared routes, such as
+// "files over 50KB go to a large-context model", to balance review quality and cost.
+package routing
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/languages"
+	"github.com/disconnekt/goreview/internal/profiles"
+	"github.com/disconnekt/goreview/internal/scanner"
+)
+
+// Route sends the files matching all of its conditions to Model. A route without
+// conditions matches every file.
+type Route struct {
+	// Name identifies the route in logs and errors.
+	Name string `yaml:"name,omitempty"`
+	// Model reviews the matching files.
+	Model string `yaml:"model"`
+	// Paths lists globs of the project-relative paths the route applies to; "**"
+	// matches any number of directories and a pattern without a slash the base name.
+	Paths []string `yaml:"paths,omitempty"`
+	// Languages lists the languages the route applies to, e.g. "go".
+	Languages []string `yaml:"languages,omitempty"`
+	// MinSize and MaxSize bound the file size in bytes; 0 means no bound.
+	MinSize int64 `yaml:"min_size,omitempty"`
+	MaxSize int64 `yaml:"max_size,omitempty"`
+	// Profiles lists the review profiles the route applies to, e.g. "security".
+	Profiles []string `yaml:"profiles,omitempty"`
+}
+
+// File holds what routes are matched against.
+type File struct {
+	// Path is relative to the project root, slash-separated.
+	Path     string
+	Language string
+	Size     int64
+	Profile  string
+}
+
+// label names the route in messages: its name or its position.
+func (r Route) label(i int) string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return fmt.Sprintf("#%d", i+1)
+}
+
+// Validate checks the route at index i of the routes.
+func (r Route) Validate(i int) error {
+	if r.Model == "" {
+		return fmt.Errorf("route %s has no model", r.label(i))
+	}
+	if r.MinSize < 0 || r.MaxSize < 0 {
+		return fmt.Errorf("route %s: sizes must not be negative", r.label(i))
+	}
+	if r.MaxSize > 0 && r.MinSize > r.MaxSize {
+		return fmt.Errorf("route %s: min_size is larger than max_size", r.label(i))
+	}
+	for _, p := range r.Paths {
+		if _, err := path.Match(strings.ReplaceAll(p, "**", "*"), ""); err != nil {
+			return fmt.Errorf("route %s: invalid pattern %q: %w", r.label(i), p, err)
+		}
+	}
+	for _, name := range r.Languages {
+		if _, ok := languages.Get(name); !ok {
+			return fmt.Errorf("route %s: unknown language %q (available: %s)", r.label(i), name, strings.Join(languages.Names(), ", "))
+		}
+	}
+	for _, name := range r.Profiles {
+		if _, err := profiles.Get(name); err != nil {
+			return fmt.Errorf("route %s: %w", r.label(i), err)
+		}
+	}
+	return nil
+}
+
+// Validate checks every route.
+func Validate(routes []Route) error {
+	var errs []error
+	for i, r := range routes {
+		if err := r.Validate(i); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Matches reports whether the file meets all conditions of the route.
+func (r Route) Matches(f File) bool {
+	switch {
+	case r.MinSize > 0 && f.Size < r.MinSize:
+		return false
+	case r.MaxSize > 0 && f.Size > r.MaxSize:
+		return false
+	case len(r.Languages) > 0 && !slices.Contains(r.Languages, f.Language):
+		return false
+	case len(r.Profiles) > 0 && !slices.Contains(r.Profiles, f.Profile):
+		return false
+	}
+	if len(r.Paths) == 0 {
+		return true
+	}
+	for _, p := range r.Paths {
+		if scanner.MatchGlob(p, f.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Select returns the first route matching the file.
+func Select(routes []Route, f File) (Route, bool) {
+	for _, r := range routes {
+		if r.Matches(f) {
+			return r, true
+		}
+	}
+	return Route{}, false
+}
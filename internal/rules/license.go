@@ -0,0 +1,100 @@
+package rules
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// License configures the license profile. It is read from the "license" key of a YAML
+// rules file.
+type License struct {
+	// Header is the text every file must carry in its leading comments; {{year}} matches
+	// a year or a range of years.
+	Header string `yaml:"header"`
+	// Project is the SPDX identifier of the project's license.
+	Project string `yaml:"project"`
+	// Allowed lists SPDX identifiers of licenses whose code may be copied into the project.
+	Allowed []string `yaml:"allowed"`
+	// Exclude holds glob patterns of files exempt from the header check.
+	Exclude []string `yaml:"exclude"`
+
+	header *regexp.Regexp
+}
+
+// LoadLicense reads the license section of a YAML rules file. It returns nil when the
+// file is Markdown or has no license section.
+func LoadLicense(path string) (*License, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+	default:
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules file: %w", err)
+	}
+	var f struct {
+		License *License `yaml:"license"`
+	}
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse rules file %s: %w", path, err)
+	}
+	if f.License == nil {
+		return nil, nil
+	}
+	if header := normalizeComment(f.License.Header); header != "" {
+		pattern := strings.ReplaceAll(regexp.QuoteMeta(header), regexp.QuoteMeta("{{year}}"), `\d{4}(?:\s*-\s*\d{4})?`)
+		f.License.header = regexp.MustCompile(pattern)
+	}
+	return f.License, nil
+}
+
+// generatedPattern is the marker of generated Go files, which are exempt from the header check.
+var generatedPattern = regexp.MustCompile(`(?m)^// Code generated .* DO NOT EDIT\.$`)
+
+// HasHeader reports whether the comments before the package clause of src contain the
+// required header. Files are compliant when no header is configured or they are generated.
+func (l *License) HasHeader(src string) bool {
+	if l.header == nil || generatedPattern.MatchString(src) {
+		return true
+	}
+	var leading []string
+	for _, line := range strings.Split(src, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "package ") {
+			break
+		}
+		leading = append(leading, line)
+	}
+	return l.header.MatchString(normalizeComment(strings.Join(leading, "\n")))
+}
+
+// PromptSection describes the license policy to the model.
+func (l *License) PromptSection() string {
+	var b strings.Builder
+	b.WriteString("License policy:\n")
+	if l.Project != "" {
+		fmt.Fprintf(&b, "- The project is licensed under %s.\n", l.Project)
+	}
+	if len(l.Allowed) > 0 {
+		fmt.Fprintf(&b, "- Code copied from elsewhere may only come from projects under: %s.\n", strings.Join(l.Allowed, ", "))
+	}
+	if l.Header != "" {
+		fmt.Fprintf(&b, "- Every file must carry this header (checked separately):\n%s\n", strings.TrimSpace(l.Header))
+	}
+	return b.String()
+}
+
+// commentMarker matches the comment syntax at the start of a line.
+var commentMarker = regexp.MustCompile(`(?m)^[ \t]*(?://|/\*+|\*+/|\*)?`)
+
+// normalizeComment strips comment markers and collapses whitespace.
+func normalizeComment(s string) string {
+	s = commentMarker.ReplaceAllString(s, "")
+	s = strings.ReplaceAll(s, "*/", "")
+	return strings.Join(strings.Fields(s), " ")
+}
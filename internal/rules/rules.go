@@ -0,0 +1,166 @@
+package rules
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rule is a single project convention that reviews are checked against.
+type Rule struct {
+	ID          string `yaml:"id"`
+	Description string `yaml:"description"`
+}
+
+// File is the YAML layout of a rules file.
+type File struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// DefaultPaths lists the rules files looked up (in order) inside the project directory.
+var DefaultPaths = []string{
+	filepath.Join(".aireview", "rules.yaml"),
+	filepath.Join(".aireview", "rules.yml"),
+	filepath.Join(".aireview", "rules.md"),
+}
+
+// Discover returns the first default rules file present in projectPath, or "" if none exists.
+func Discover(projectPath string) string {
+	for _, p := range DefaultPaths {
+		candidate := filepath.Join(projectPath, p)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// Load reads rules from a YAML (.yaml/.yml) or Markdown (.md) file.
+func Load(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules file: %w", err)
+	}
+
+	var rules []Rule
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var f File
+		if err := yaml.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("failed to parse rules file %s: %w", path, err)
+		}
+		rules = f.Rules
+	default:
+		rules = parseMarkdown(string(data))
+	}
+
+	return normalize(rules)
+}
+
+// markdownIDPattern matches an optional explicit rule ID at the start of a bullet,
+// e.g. "SQL-1: all SQL must use squirrel" or "**SQL-1** all SQL must use squirrel".
+var markdownIDPattern = regexp.MustCompile(`^(?:\*\*([A-Z][A-Z0-9_-]*)\*\*:?|([A-Z][A-Z0-9_-]*):)\s+(.+)$`)
+
+// parseMarkdown treats every top-level bullet or numbered item as a rule.
+// Continuation lines indented below an item are appended to it.
+func parseMarkdown(content string) []Rule {
+	var rules []Rule
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		text, isItem := stripListMarker(line)
+		if !isItem {
+			if len(rules) > 0 && line != trimmed {
+				rules[len(rules)-1].Description += " " + trimmed
+			}
+			continue
+		}
+
+		rule := Rule{Description: text}
+		if m := markdownIDPattern.FindStringSubmatch(text); m != nil {
+			rule.ID = m[1] + m[2]
+			rule.Description = m[3]
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// stripListMarker returns the item text if line is a top-level list item.
+func stripListMarker(line string) (string, bool) {
+	if line != strings.TrimLeft(line, " \t") {
+		return "", false
+	}
+	for _, marker := range []string{"- ", "* ", "+ "} {
+		if strings.HasPrefix(line, marker) {
+			return strings.TrimSpace(line[len(marker):]), true
+		}
+	}
+	if i := strings.IndexAny(line, ".)"); i > 0 && i+1 < len(line) && line[i+1] == ' ' {
+		for _, r := range line[:i] {
+			if r < '0' || r > '9' {
+				return "", false
+			}
+		}
+		return strings.TrimSpace(line[i+2:]), true
+	}
+	return "", false
+}
+
+// normalize assigns IDs to rules that lack one and rejects duplicates. Generated IDs
+// skip any ID a rule declares itself.
+func normalize(rules []Rule) ([]Rule, error) {
+	seen := make(map[string]bool, len(rules))
+	out := make([]Rule, 0, len(rules))
+	for _, r := range rules {
+		r.ID = strings.TrimSpace(r.ID)
+		r.Description = strings.TrimSpace(r.Description)
+		if r.Description == "" {
+			continue
+		}
+		if r.ID != "" {
+			if seen[r.ID] {
+				return nil, fmt.Errorf("duplicate rule ID %q", r.ID)
+			}
+			seen[r.ID] = true
+		}
+		out = append(out, r)
+	}
+
+	next := 1
+	for i := range out {
+		if out[i].ID != "" {
+			continue
+		}
+		for seen[fmt.Sprintf("R%d", next)] {
+			next++
+		}
+		out[i].ID = fmt.Sprintf("R%d", next)
+		seen[out[i].ID] = true
+		next++
+	}
+	return out, nil
+}
+
+// PromptSection renders rules as an addendum to the system prompt.
+func PromptSection(rules []Rule) string {
+	if len(rules) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("Project-specific rules. Check the code against each of them, and when a finding violates a rule, ")
+	b.WriteString("reference it by its ID in square brackets (for example [")
+	b.WriteString(rules[0].ID)
+	b.WriteString("]):\n")
+	for _, r := range rules {
+		fmt.Fprintf(&b, "- [%s] %s\n", r.ID, r.Description)
+	}
+	return b.String()
+}
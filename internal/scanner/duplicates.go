@@ -0,0 +1,231 @@
+package scanner
+
+import (
+	"errors"
+	goscanner "go/scanner"
+	"go/token"
+	"hash/fnv"
+	"path/filepath"
+	"sort"
+)
+
+// maxShingleOccurrences bounds how many places of one token sequence are kept as seeds,
+// so boilerplate repeated all over a repository does not make detection quadratic.
+// Matches are extended token by token from a seed, so the bound does not cut them short.
+const maxShingleOccurrences = 8
+
+// maxIndexedShingles bounds the memory of the shingle index. Windows past it are still
+// compared with the indexed ones, but not with each other.
+const maxIndexedShingles = 1 << 20
+
+// Block is a range of lines of a file.
+type Block struct {
+	Path      string
+	StartLine int
+	EndLine   int
+}
+
+// Duplicate is a pair of near-identical blocks: the same tokens, with identifiers
+// consistently renamed and literals allowed to differ.
+type Duplicate struct {
+	A, B   Block
+	Tokens int
+}
+
+type shingleToken struct {
+	// hash identifies the token with identifiers and literals of a kind alike
+	hash uint64
+	// name numbers the identifier, or is 0 for other tokens
+	name int32
+	line int
+}
+
+type shingleLoc struct {
+	file, pos int
+}
+
+// FindDuplicates finds blocks of at least minTokens tokens that occur in more than one
+// place of the Go files, largest first. Windows (shingles) of minTokens tokens with the
+// same hash seed a match, which is extended over the tokens around it and kept where
+// the identifiers of one copy map one-to-one to those of the other. It also reports
+// whether the index filled up, leaving later windows uncompared with each other. Files
+// that cannot be read are left out, and the returned error lists them.
+func FindDuplicates(files []FileInfo, minTokens int) ([]Duplicate, bool, error) {
+	if minTokens <= 0 {
+		return nil, false, nil
+	}
+	var paths []string
+	var tokens [][]shingleToken
+	var errs []error
+	names := make(map[string]int32)
+	for _, f := range files {
+		if filepath.Ext(f.Path) != ".go" {
+			continue
+		}
+		text, err := f.Text()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		paths = append(paths, f.Path)
+		tokens = append(tokens, normalizedTokens(text, names))
+	}
+
+	// Polynomial rolling hash over the token hashes of each window
+	const base = 1099511628211
+	var pow uint64 = 1
+	for i := 1; i < minTokens; i++ {
+		pow *= base
+	}
+
+	type runKey struct {
+		a, b, delta int
+	}
+	index := make(map[uint64][]shingleLoc)
+	indexed := 0
+	// covered holds the last window of each pair of files and offset matched so far
+	covered := make(map[runKey]int)
+	var dups []Duplicate
+
+	for f, toks := range tokens {
+		if len(toks) < minTokens {
+			continue
+		}
+		var h uint64
+		for i := 0; i < minTokens; i++ {
+			h = h*base + toks[i].hash
+		}
+		for i := 0; ; i++ {
+			for _, loc := range index[h] {
+				k := runKey{a: loc.file, b: f, delta: i - loc.pos}
+				if end, ok := covered[k]; ok && i <= end {
+					continue
+				}
+				a, b, n := extendMatch(tokens[loc.file], toks, loc.pos, i, loc.file == f)
+				if n < minTokens {
+					continue
+				}
+				covered[k] = b + n - minTokens
+				for _, seg := range renamedSegments(tokens[loc.file][a:a+n], toks[b:b+n], minTokens) {
+					ta, tb := tokens[loc.file][a+seg[0]:a+seg[1]], toks[b+seg[0]:b+seg[1]]
+					dups = append(dups, Duplicate{
+						A:      Block{Path: paths[loc.file], StartLine: ta[0].line, EndLine: ta[len(ta)-1].line},
+						B:      Block{Path: paths[f], StartLine: tb[0].line, EndLine: tb[len(tb)-1].line},
+						Tokens: len(ta),
+					})
+				}
+			}
+			if len(index[h]) < maxShingleOccurrences && indexed < maxIndexedShingles {
+				index[h] = append(index[h], shingleLoc{file: f, pos: i})
+				indexed++
+			}
+			if i+minTokens >= len(toks) {
+				break
+			}
+			h = (h-toks[i].hash*pow)*base + toks[i+minTokens].hash
+		}
+	}
+
+	sort.Slice(dups, func(i, j int) bool {
+		if dups[i].Tokens != dups[j].Tokens {
+			return dups[i].Tokens > dups[j].Tokens
+		}
+		if dups[i].A.Path != dups[j].A.Path {
+			return dups[i].A.Path < dups[j].A.Path
+		}
+		return dups[i].A.StartLine < dups[j].A.StartLine
+	})
+	return dups, indexed >= maxIndexedShingles, errors.Join(errs...)
+}
+
+// extendMatch grows the match of the windows at a and b, a before b when both are in
+// the same file, over the equal tokens before and after them. It returns the start of
+// each copy and the number of tokens matched, which is 0 when the seed windows differ
+// despite their hashes. Copies in the same file do not overlap.
+func extendMatch(ta, tb []shingleToken, a, b int, sameFile bool) (int, int, int) {
+	for a > 0 && b > 0 && ta[a-1].hash == tb[b-1].hash {
+		a--
+		b--
+	}
+	n := 0
+	for a+n < len(ta) && b+n < len(tb) && ta[a+n].hash == tb[b+n].hash {
+		if sameFile && a+n >= b {
+			break
+		}
+		n++
+	}
+	return a, b, n
+}
+
+// renamedSegments splits two equal token sequences where their identifiers stop
+// mapping one-to-one, and returns the segments of at least minTokens tokens as
+// [start, end) offsets. Each segment starts a fresh mapping.
+func renamedSegments(ta, tb []shingleToken, minTokens int) [][2]int {
+	var segments [][2]int
+	start := 0
+	aToB := make(map[int32]int32)
+	bToA := make(map[int32]int32)
+	for i := 0; i < len(ta); i++ {
+		na, nb := ta[i].name, tb[i].name
+		if na == 0 {
+			continue
+		}
+		mb, okA := aToB[na]
+		ma, okB := bToA[nb]
+		if (!okA && !okB) || (okA && okB && mb == nb && ma == na) {
+			aToB[na], bToA[nb] = nb, na
+			continue
+		}
+		if i-start >= minTokens {
+			segments = append(segments, [2]int{start, i})
+		}
+		start = i
+		aToB = map[int32]int32{na: nb}
+		bToA = map[int32]int32{nb: na}
+	}
+	if len(ta)-start >= minTokens {
+		segments = append(segments, [2]int{start, len(ta)})
+	}
+	return segments
+}
+
+var periodHash = func() uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(token.PERIOD.String()))
+	return h.Sum64()
+}()
+
+// normalizedTokens reads Go source as tokens where every identifier and every literal
+// of a kind hash the same, so renamed copies still match; identifiers are numbered by
+// name in names to check the renaming later. Identifiers after a period keep their name.
+// Comments are dropped.
+func normalizedTokens(src string, names map[string]int32) []shingleToken {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", -1, len(src))
+	var s goscanner.Scanner
+	s.Init(file, []byte(src), nil, 0)
+	var toks []shingleToken
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		h := fnv.New64a()
+		h.Write([]byte(tok.String()))
+		// Selected fields and methods are not renamed with the variables: copies that
+		// call other methods or set other fields do different things
+		selector := tok == token.IDENT && len(toks) > 0 && toks[len(toks)-1].hash == periodHash
+		if selector {
+			h.Write([]byte(lit))
+		}
+		t := shingleToken{hash: h.Sum64(), line: file.Line(pos)}
+		if tok == token.IDENT && !selector {
+			if _, ok := names[lit]; !ok {
+				names[lit] = int32(len(names) + 1)
+			}
+			t.name = names[lit]
+		}
+		toks = append(toks, t)
+	}
+	return toks
+}
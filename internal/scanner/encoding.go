@@ -0,0 +1,86 @@
+package scanner
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// NormalizeText converts file content to UTF-8 with "\n" line endings: BOMs are
+// stripped, UTF-16 (with or without BOM) is transcoded and invalid UTF-8 is read as
+// Latin-1. Files authored on Windows would otherwise be rejected as binary.
+func NormalizeText(data []byte) string {
+	var text string
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		text = string(data[len(bomUTF8):])
+	case bytes.HasPrefix(data, bomUTF16LE):
+		text = decodeUTF16(data[len(bomUTF16LE):], false)
+	case bytes.HasPrefix(data, bomUTF16BE):
+		text = decodeUTF16(data[len(bomUTF16BE):], true)
+	default:
+		if bigEndian, ok := looksUTF16(data); ok {
+			text = decodeUTF16(data, bigEndian)
+		} else if utf8.Valid(data) {
+			text = string(data)
+		} else {
+			text = decodeLatin1(data)
+		}
+	}
+
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.ReplaceAll(text, "\r", "\n")
+}
+
+// looksUTF16 detects BOM-less UTF-16 by the zero high bytes of ASCII characters,
+// which land on even offsets for big endian and odd offsets for little endian.
+func looksUTF16(data []byte) (bigEndian, ok bool) {
+	if len(data) < 4 || len(data)%2 != 0 {
+		return false, false
+	}
+	var even, odd int
+	for i := 0; i < len(data); i += 2 {
+		if data[i] == 0 {
+			even++
+		}
+		if data[i+1] == 0 {
+			odd++
+		}
+	}
+	// Require most code units to be ASCII and the other half to be free of zeros
+	half := len(data) / 2
+	switch {
+	case even*10 >= half*7 && odd == 0:
+		return true, true
+	case odd*10 >= half*7 && even == 0:
+		return false, true
+	}
+	return false, false
+}
+
+func decodeUTF16(data []byte, bigEndian bool) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+		} else {
+			units[i] = uint16(data[2*i+1])<<8 | uint16(data[2*i])
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+func decodeLatin1(data []byte) string {
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
@@ -0,0 +1,88 @@
+package scanner
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Module is a Go module found inside the scanned tree.
+type Module struct {
+	// Dir is the absolute directory containing go.mod.
+	Dir string
+	// Path is the module path declared in go.mod.
+	Path string
+}
+
+// FindModules returns every Go module under root, deepest directories first so the
+// first module containing a file is the one it belongs to.
+func (s *Scanner) FindModules(root string) ([]Module, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var modules []Module
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			// The file walk records unreadable directories as skipped; leave them out here
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && s.shouldSkipDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() == "go.mod" {
+			modules = append(modules, Module{Dir: filepath.Dir(path), Path: modulePath(path)})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(modules, func(i, j int) bool {
+		return len(modules[i].Dir) > len(modules[j].Dir)
+	})
+	return modules, nil
+}
+
+// ModuleFor returns the deepest module containing path, or nil.
+func ModuleFor(modules []Module, path string) *Module {
+	for i := range modules {
+		dir := modules[i].Dir
+		if path == dir || strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			return &modules[i]
+		}
+	}
+	return nil
+}
+
+// modulePath reads the module directive from a go.mod file.
+func modulePath(goMod string) string {
+	f, err := os.Open(goMod)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if rest, ok := strings.CutPrefix(line, "module"); ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+			return strings.Trim(strings.TrimSpace(rest), `"`)
+		}
+	}
+	return ""
+}
@@ -1,85 +1,758 @@
 package scanner
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 )
 
 type FileInfo struct {
-	Path    string
-	Size    int64
+	Path string
+	Size int64
+	// Content is the file text normalized by NormalizeText; empty when Lazy. Use Text
+	// to get it either way.
 	Content string
+	// Lazy reports that the scan left the content on disk to stay within its memory
+	// limit; Text reads it again.
+	Lazy bool
+	// Module is the directory of the Go module the file belongs to, relative to the
+	// scanned root ("." for the root module, empty when no go.mod was found).
+	Module string
+	// ModulePath is the module path declared in the module's go.mod.
+	ModulePath string
+	// Hash is the hex SHA-256 of Content, used to review identical files only once.
+	Hash string
+	// Repo is the directory of the git submodule or nested repository the file belongs
+	// to, relative to the scanned root; empty for the project's own repository.
+	Repo string
+	// source is the real path the content is read from.
+	source string
+}
+
+// Text returns the normalized content of the file, reading it from disk when the scan
+// left it there.
+func (f FileInfo) Text() (string, error) {
+	if !f.Lazy {
+		return f.Content, nil
+	}
+	data, err := os.ReadFile(f.source)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", f.Path, err)
+	}
+	return NormalizeText(data), nil
 }
 
 type Scanner struct {
 	maxFileSize int64
+	// excludes are glob patterns, relative to the scanned root, of files to skip
+	excludes []string
+	// followSymlinks enables descending into symlinked directories and reading symlinked files
+	followSymlinks bool
+	// includeSubmodules enables descending into git submodules and nested repositories
+	includeSubmodules bool
+	// workers is the number of concurrent directory and file reads (0 = by CPU count)
+	workers int
+	// module restricts scans to the files of one module directory, relative to the
+	// scanned root ("" = all modules)
+	module  string
+	skipped []SkippedFile
+	// maxFiles and maxTotalBytes cap what a scan collects (0 = unlimited)
+	maxFiles      int
+	maxTotalBytes int64
+	// collected counts files and bytes accepted so far; truncated what was left out by the caps
+	collectedFiles, truncatedFiles int
+	collectedBytes, truncatedBytes int64
+	// maxMemory caps the content held in memory (0 = unlimited); held is the content
+	// kept so far and lazy the number of files left on disk
+	maxMemory int64
+	held      int64
+	lazy      int
 }
+
 func NewScanner(maxFileSize int64) *Scanner {
 	return &Scanner{
 		maxFileSize: maxFileSize,
 	}
 }
 
+// SetFollowSymlinks controls whether symlinks are followed (with cycle detection) or skipped.
+func (s *Scanner) SetFollowSymlinks(follow bool) {
+	s.followSymlinks = follow
+}
+
+// SetIncludeSubmodules controls whether git submodules and nested repositories below
+// the scanned directory are scanned or skipped.
+func (s *Scanner) SetIncludeSubmodules(include bool) {
+	s.includeSubmodules = include
+}
+
+// SetWorkers sets how many directories and files a scan reads at once; zero or less
+// uses the number of CPUs, and at least four.
+func (s *Scanner) SetWorkers(n int) {
+	s.workers = n
+}
+
+func (s *Scanner) workerCount() int {
+	if s.workers > 0 {
+		return s.workers
+	}
+	// Reads mostly wait on the disk, so a few workers pay off even on one CPU
+	return max(runtime.NumCPU(), 4)
+}
+
+// SetModule restricts scans to the files of the Go module in dir, given relative to
+// the scanned root in slash form ("." for the root module). Files of other modules are
+// left out before the limits set with SetLimits apply. An empty dir scans every module.
+func (s *Scanner) SetModule(dir string) {
+	s.module = dir
+}
+
+// SetLimits caps the number of files and their total size collected by a scan.
+// Zero disables a limit.
+func (s *Scanner) SetLimits(maxFiles int, maxTotalBytes int64) {
+	s.maxFiles = maxFiles
+	s.maxTotalBytes = maxTotalBytes
+}
+
+// SetMaxMemory caps the bytes of file content a scan keeps in memory. Files past the
+// cap are still scanned, but their content is read again when needed. Zero disables
+// the cap.
+func (s *Scanner) SetMaxMemory(bytes int64) {
+	s.maxMemory = bytes
+}
+
+// Lazy reports how many files were left on disk because of the memory cap.
+func (s *Scanner) Lazy() int {
+	return s.lazy
+}
+
+// Truncated reports how many files, and how many bytes, were left out because a
+// limit set with SetLimits was reached.
+func (s *Scanner) Truncated() (files int, bytes int64) {
+	return s.truncatedFiles, s.truncatedBytes
+}
+
+// overLimit reports whether accepting a file of the given size would exceed a limit.
+func (s *Scanner) overLimit(size int64) bool {
+	if s.maxFiles > 0 && s.collectedFiles >= s.maxFiles {
+		return true
+	}
+	return s.maxTotalBytes > 0 && s.collectedBytes+size > s.maxTotalBytes
+}
+
+// SetExcludes configures glob patterns (see MatchGlob) of files to skip.
+func (s *Scanner) SetExcludes(patterns []string) {
+	s.excludes = patterns
+}
+
+// ScanGoFiles scans dirPath, or the module set with SetModule below it, and returns the
+// reviewable files sorted by path.
 func (s *Scanner) ScanGoFiles(dirPath string) ([]FileInfo, error) {
-	var files []FileInfo
+	return collectAll(s.ScanStream(context.Background(), dirPath, nil))
+}
+
+// ScanStream scans like ScanGoFiles, or like ScanWorkspace when members lists the
+// go.work member modules, but sends each file on the returned channel as soon as it is
+// read, in the same order. The channel is unbuffered and the scan reads only a window
+// of files ahead of the one it sends, so a busy receiver holds back the reads. The
+// channel is closed when the scan ends or ctx is done; wait then returns the error that
+// ended the scan, if any, and Skipped, Truncated and Lazy are complete.
+func (s *Scanner) ScanStream(ctx context.Context, dirPath string, members []string) (files <-chan FileInfo, wait func() error) {
+	out := make(chan FileInfo)
+	done := make(chan struct{})
+	var err error
+	go func() {
+		defer close(done)
+		defer close(out)
+		err = s.scanDirs(ctx, dirPath, members, func(f FileInfo) bool {
+			select {
+			case out <- f:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+	return out, func() error {
+		<-done
+		return err
+	}
+}
+
+// collectAll receives the files of a scan into a slice.
+func collectAll(files <-chan FileInfo, wait func() error) ([]FileInfo, error) {
+	var list []FileInfo
+	for f := range files {
+		list = append(list, f)
+	}
+	if err := wait(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// scanDirs scans dirPath, or the workspace members below it, and passes the files to
+// emit in path order per directory. A file below two members is passed once. It stops
+// when emit returns false.
+func (s *Scanner) scanDirs(ctx context.Context, dirPath string, members []string, emit func(FileInfo) bool) error {
+	root, err := absPath(dirPath)
+	if err != nil {
+		return err
+	}
+	dirs := members
+	if members == nil {
+		dir := root
+		if s.module != "" {
+			// Start at the module; when there is no such directory the walk finds nothing
+			// to collect and callers report the module as missing
+			moduleDir := filepath.Join(root, filepath.FromSlash(s.module))
+			if info, err := os.Stat(moduleDir); err == nil && info.IsDir() {
+				dir = moduleDir
+			}
+		}
+		dirs = []string{dir}
+	}
+
+	seen := make(map[string]bool)
+	for _, member := range dirs {
+		dir := member
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(root, filepath.FromSlash(member))
+		}
+		w, err := s.walk(root, dir)
+		if err != nil {
+			if members != nil {
+				return fmt.Errorf("failed to scan workspace module %s: %w", member, err)
+			}
+			return err
+		}
+		complete := w.collect(ctx.Done(), func(f FileInfo) bool {
+			if seen[f.Path] {
+				return true
+			}
+			seen[f.Path] = true
+			return emit(f)
+		})
+		if !complete {
+			return ctx.Err()
+		}
+	}
+	return nil
+}
 
+func absPath(dirPath string) (string, error) {
 	cleanPath := filepath.Clean(dirPath)
 	if !filepath.IsAbs(cleanPath) {
 		abs, err := filepath.Abs(cleanPath)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get absolute path: %w", err)
+			return "", fmt.Errorf("failed to get absolute path: %w", err)
 		}
 		cleanPath = abs
 	}
+	return cleanPath, nil
+}
 
-	err := filepath.Walk(cleanPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
+// walk lists dir with a pool of workers and returns the walker holding the candidate
+// files found, which are not read yet.
+func (s *Scanner) walk(root, dir string) (*walker, error) {
+	modules, err := s.FindModules(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect modules: %w", err)
+	}
+
+	w := &walker{
+		scanner: s,
+		root:    root,
+		modules: modules,
+		visited: make(map[string]bool),
+	}
+	w.cond = sync.NewCond(&w.mu)
+	if real, err := filepath.EvalSymlinks(dir); err == nil {
+		w.visited[real] = true
+	}
+	if err := w.dir(dir, dir); err != nil {
+		return nil, err
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < s.workerCount(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.work()
+		}()
+	}
+	wg.Wait()
+	return w, nil
+}
+
+// walker holds the state of one scan: the queue of directories and files left to
+// process, symlinked directories already entered (for cycle detection), the candidate
+// files found and the nested repositories entered. mu guards the walker and the
+// counters of the scanner.
+type walker struct {
+	scanner *Scanner
+	root    string
+	modules []Module
+
+	mu         sync.Mutex
+	cond       *sync.Cond
+	queue      []task
+	pending    int
+	visited    map[string]bool
+	candidates []task
+	repos      []string
+}
+
+// task is a directory to list, or a file to read when info is set. path is the real
+// path and shown the path reported, which differ below a followed symlink.
+type task struct {
+	path, shown string
+	info        os.FileInfo
+}
+
+// push queues a task for the workers.
+func (w *walker) push(t task) {
+	w.mu.Lock()
+	w.queue = append(w.queue, t)
+	w.pending++
+	w.mu.Unlock()
+	w.cond.Signal()
+}
+
+// work runs queued tasks until none is queued or running.
+func (w *walker) work() {
+	for {
+		w.mu.Lock()
+		for len(w.queue) == 0 && w.pending > 0 {
+			w.cond.Wait()
+		}
+		if w.pending == 0 {
+			w.mu.Unlock()
+			return
 		}
+		// Taking the newest task walks depth-first, which keeps the queue short
+		t := w.queue[len(w.queue)-1]
+		w.queue = w.queue[:len(w.queue)-1]
+		w.mu.Unlock()
+
+		if t.info != nil {
+			w.candidate(t)
+		} else if err := w.dir(t.path, t.shown); err != nil {
+			w.skip(t.shown, fmt.Sprintf("unreadable: %v", err))
+		}
+
+		w.mu.Lock()
+		w.pending--
+		done := w.pending == 0
+		w.mu.Unlock()
+		if done {
+			w.cond.Broadcast()
+		}
+	}
+}
 
-		if info.IsDir() {
-			if s.shouldSkipDir(info.Name()) {
-				return filepath.SkipDir
+// skip records a skipped file of the scan.
+func (w *walker) skip(path, reason string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.scanner.skip(path, reason)
+}
+
+// dir lists the real directory path, reported as shown, and queues its files and
+// subdirectories.
+func (w *walker) dir(path, shown string) error {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	// Entries are queued last first, so a single worker takes them in lexical order
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		p, s := filepath.Join(path, e.Name()), filepath.Join(shown, e.Name())
+		switch {
+		case e.Type()&fs.ModeSymlink != 0:
+			w.symlink(p, s)
+		case e.IsDir():
+			if !w.scanner.shouldSkipDir(e.Name()) && !w.otherModule(p) && !w.nestedRepo(p, s) {
+				w.push(task{path: p, shown: s})
 			}
-			return nil
+		case e.Type().IsRegular() && isCandidate(e.Name()):
+			info, err := e.Info()
+			if err != nil {
+				w.skip(s, fmt.Sprintf("unreadable: %v", err))
+				continue
+			}
+			w.push(task{path: p, shown: s, info: info})
 		}
+	}
+	return nil
+}
 
-		if !strings.HasSuffix(info.Name(), ".go") {
-			return nil
+// otherModule reports whether a directory is the root of a module other than the one
+// set with SetModule, and holds no directory of that module.
+func (w *walker) otherModule(path string) bool {
+	if w.scanner.module == "" {
+		return false
+	}
+	selected := filepath.Join(w.root, filepath.FromSlash(w.scanner.module))
+	if path == selected || strings.HasPrefix(selected, path+string(filepath.Separator)) {
+		return false
+	}
+	for _, m := range w.modules {
+		if m.Dir == path {
+			return true
 		}
+	}
+	return false
+}
+
+// nestedRepo reports whether a directory is a git submodule or nested repository to
+// skip. Included ones are recorded so the files below them are marked as theirs.
+func (w *walker) nestedRepo(path, shown string) bool {
+	info, err := os.Lstat(filepath.Join(path, ".git"))
+	if err != nil {
+		return false
+	}
+	// Submodules have a .git file pointing into the parent's .git/modules
+	kind := "nested git repository"
+	if !info.IsDir() {
+		kind = "git submodule"
+	}
+	if !w.scanner.includeSubmodules {
+		w.skip(shown, kind+" (use --include-submodules to include)")
+		return true
+	}
+	w.mu.Lock()
+	w.repos = append(w.repos, shown)
+	w.mu.Unlock()
+	return false
+}
 
-		if strings.HasSuffix(info.Name(), "_test.go") {
-			return nil
+// symlink skips the link unless following is enabled, in which case it queues linked
+// directories once and linked files.
+func (w *walker) symlink(path, shown string) {
+	if !w.scanner.followSymlinks {
+		if isCandidate(filepath.Base(path)) || isDirLink(path) {
+			w.skip(shown, "symlink (use --follow-symlinks to include)")
 		}
+		return
+	}
+
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		w.skip(shown, fmt.Sprintf("broken symlink: %v", err))
+		return
+	}
+	info, err := os.Stat(real)
+	if err != nil {
+		w.skip(shown, fmt.Sprintf("unreadable symlink target: %v", err))
+		return
+	}
 
-		// Skip generated files that may cause API issues
-		if s.isGeneratedFile(path, info.Name()) {
-			fmt.Printf("Skipping generated file: %s\n", path)
-			return nil
+	if info.IsDir() {
+		if w.scanner.shouldSkipDir(filepath.Base(path)) {
+			return
 		}
-		if info.Size() > s.maxFileSize {
-			fmt.Printf("Warning: Skipping file %s (size %d exceeds limit %d)\n",
-				path, info.Size(), s.maxFileSize)
-			return nil
+		w.mu.Lock()
+		entered := w.visited[real]
+		w.visited[real] = true
+		w.mu.Unlock()
+		if entered {
+			w.skip(shown, "symlink cycle or duplicate directory: "+real)
+			return
 		}
+		w.push(task{path: real, shown: shown})
+		return
+	}
+	if info.Mode().IsRegular() && isCandidate(filepath.Base(shown)) {
+		w.push(task{path: real, shown: shown, info: info})
+	}
+}
 
-		content, err := os.ReadFile(path)
-		if err != nil {
-			return fmt.Errorf("failed to read file %s: %w", path, err)
+// candidate applies the file filters to a file task and keeps the file to read.
+func (w *walker) candidate(t task) {
+	s := w.scanner
+	name := filepath.Base(t.shown)
+	if s.isExcluded(w.root, t.shown) {
+		return
+	}
+	if module, _ := w.module(t.shown); s.module != "" && module != s.module {
+		return
+	}
+
+	// Skip generated files that may cause API issues
+	if s.isGeneratedFile(t.shown, name) {
+		w.skip(t.shown, "generated file")
+		return
+	}
+	if t.info.Size() > s.maxFileSize {
+		w.skip(t.shown, fmt.Sprintf("size %d exceeds limit %d (see --max-size)", t.info.Size(), s.maxFileSize))
+		return
+	}
+	w.mu.Lock()
+	w.candidates = append(w.candidates, t)
+	w.mu.Unlock()
+}
+
+// readResult is a candidate file read by collect: its content, or why it is skipped.
+type readResult struct {
+	text, hash string
+	skipped    string
+}
+
+// collect reads the candidate files with the pool of workers and passes them to emit in
+// lexical order. A file reachable twice through symlinks is taken under its first path,
+// and the limits and the memory cap apply in that order, so a tree always yields the
+// same files however the reads interleave. It returns false when stop is closed or emit
+// returns false before every file was passed.
+func (w *walker) collect(stop <-chan struct{}, emit func(FileInfo) bool) bool {
+	s := w.scanner
+	sort.Slice(w.candidates, func(i, j int) bool { return w.candidates[i].shown < w.candidates[j].shown })
+	var candidates []task
+	seen := make(map[string]bool)
+	for _, t := range w.candidates {
+		if real, err := filepath.EvalSymlinks(t.path); err == nil {
+			if seen[real] {
+				continue
+			}
+			seen[real] = true
 		}
+		candidates = append(candidates, t)
+	}
 
-		files = append(files, FileInfo{
-			Path:    path,
-			Size:    info.Size(),
-			Content: string(content),
-		})
+	// Reads run at most a window ahead of the file taken, and files the limits already
+	// leave out are not read
+	results := make([]chan readResult, len(candidates))
+	for i := range results {
+		results[i] = make(chan readResult, 1)
+	}
+	next := make(chan int)
+	window := make(chan struct{}, 2*s.workerCount())
+	quit := make(chan struct{})
+	defer close(quit)
+	go func() {
+		defer close(next)
+		for i, t := range candidates {
+			select {
+			case window <- struct{}{}:
+			case <-quit:
+				return
+			}
+			w.mu.Lock()
+			over := s.overLimit(t.info.Size())
+			w.mu.Unlock()
+			if over {
+				results[i] <- readResult{}
+				continue
+			}
+			select {
+			case next <- i:
+			case <-quit:
+				return
+			}
+		}
+	}()
+	for i := 0; i < s.workerCount(); i++ {
+		go func() {
+			for i := range next {
+				results[i] <- read(candidates[i].path)
+			}
+		}()
+	}
 
-		return nil
-	})
+	for i, t := range candidates {
+		var res readResult
+		select {
+		case res = <-results[i]:
+		case <-stop:
+			return false
+		}
+		<-window
+		size := t.info.Size()
+		w.mu.Lock()
+		// Past a limit, keep going only to count what was left out
+		if s.overLimit(size) {
+			s.truncatedFiles++
+			s.truncatedBytes += size
+			w.mu.Unlock()
+			continue
+		}
+		if res.skipped != "" {
+			s.skip(t.shown, res.skipped)
+			w.mu.Unlock()
+			continue
+		}
+		s.collectedFiles++
+		s.collectedBytes += size
+		module, modulePath := w.module(t.shown)
+		fi := FileInfo{
+			Path:       t.shown,
+			Size:       size,
+			Content:    res.text,
+			Hash:       res.hash,
+			Module:     module,
+			ModulePath: modulePath,
+			source:     t.path,
+		}
+		if s.maxMemory > 0 && s.held+int64(len(res.text)) > s.maxMemory {
+			fi.Content, fi.Lazy = "", true
+			s.lazy++
+		} else {
+			s.held += int64(len(res.text))
+		}
+		// The innermost repository holding the file wins
+		for _, r := range w.repos {
+			if strings.HasPrefix(t.shown, r+string(filepath.Separator)) {
+				if rel, err := filepath.Rel(w.root, r); err == nil && len(rel) > len(fi.Repo) {
+					fi.Repo = filepath.ToSlash(rel)
+				}
+			}
+		}
+		w.mu.Unlock()
+		if !emit(fi) {
+			return false
+		}
+	}
+	return true
+}
 
-	return files, err
+// read reads a candidate file. Read errors and unreviewable content are reported as the
+// reason to skip the file instead of aborting the scan.
+func read(path string) readResult {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return readResult{skipped: fmt.Sprintf("unreadable: %v", err)}
+	}
+	text := NormalizeText(content)
+	if reason := unreviewable(text); reason != "" {
+		return readResult{skipped: reason}
+	}
+	sum := sha256.Sum256([]byte(text))
+	return readResult{text: text, hash: hex.EncodeToString(sum[:])}
+}
+
+// module returns the directory, relative to the scanned root, and the path of the
+// module a file belongs to, or empty strings when no go.mod was found.
+func (w *walker) module(shown string) (dir, path string) {
+	m := ModuleFor(w.modules, shown)
+	if m == nil {
+		return "", ""
+	}
+	if rel, err := filepath.Rel(w.root, m.Dir); err == nil {
+		dir = filepath.ToSlash(rel)
+	}
+	return dir, m.Path
+}
+
+// isCandidate reports whether a file name is a reviewable Go source file.
+func isCandidate(name string) bool {
+	return strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go")
+}
+
+func isDirLink(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// unreviewable returns why content is not worth sending for review, or "" when it is.
+func unreviewable(content string) string {
+	if !IsTextContent(content) {
+		return "binary or non-text content"
+	}
+	if isMinified(content) {
+		return "minified or one-line generated content"
+	}
+	return ""
+}
+
+// IsTextContent reports whether content is mostly printable text.
+func IsTextContent(content string) bool {
+	// Check for null bytes which indicate binary content
+	if strings.Contains(content, "\x00") {
+		return false
+	}
+
+	// Check if content is mostly printable characters
+	printableCount := 0
+	totalCount := 0
+	for _, r := range content {
+		totalCount++
+		if r >= 32 && r <= 126 || r == '\t' || r == '\n' || r == '\r' {
+			printableCount++
+		}
+	}
+
+	// Require at least 95% printable characters
+	if totalCount > 0 && float64(printableCount)/float64(totalCount) < 0.95 {
+		return false
+	}
+
+	return true
+}
+
+// Thresholds for isMinified. gofmt'd code stays far below them; embedded data blobs
+// and minified generated code do not.
+const (
+	minifiedMinSize     = 2048
+	minifiedLongestLine = 2000
+	minifiedAverageLine = 300
+)
+
+// isMinified reports whether content looks like a minified or one-line generated blob
+// that would only waste tokens.
+func isMinified(content string) bool {
+	if len(content) < minifiedMinSize {
+		return false
+	}
+	lines := strings.Split(content, "\n")
+	longest := 0
+	for _, line := range lines {
+		longest = max(longest, len(line))
+	}
+	return longest > minifiedLongestLine || len(content)/len(lines) > minifiedAverageLine
+}
+
+// SkippedFile is a file the scanner could not or would not include.
+type SkippedFile struct {
+	Path   string
+	Reason string
+}
+
+// Skipped returns the files skipped so far with the reason, e.g. generated, binary or
+// unreadable files and symlinks, sorted by path.
+func (s *Scanner) Skipped() []SkippedFile {
+	sort.SliceStable(s.skipped, func(i, j int) bool { return s.skipped[i].Path < s.skipped[j].Path })
+	return s.skipped
+}
+
+func (s *Scanner) skip(path, reason string) {
+	s.skipped = append(s.skipped, SkippedFile{Path: path, Reason: reason})
+}
+
+// isExcluded reports whether path matches one of the exclude patterns.
+func (s *Scanner) isExcluded(root, path string) bool {
+	if len(s.excludes) == 0 {
+		return false
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range s.excludes {
+		if MatchGlob(pattern, rel) {
+			return true
+		}
+	}
+	return false
 }
 
 func (s *Scanner) shouldSkipDir(dirName string) bool {
@@ -116,16 +789,16 @@ func (s *Scanner) isGeneratedFile(filePath, fileName string) bool {
 	if strings.HasSuffix(fileName, ".pb.go") {
 		return true
 	}
-	
+
 	// Skip other common generated files
 	if strings.HasSuffix(fileName, "_generated.go") {
 		return true
 	}
-	
+
 	// Check for generated file markers in the file content
 	if strings.Contains(filePath, "/generated/") {
 		return true
 	}
-	
+
 	return false
 }
@@ -0,0 +1,68 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WorkspaceMembers returns the module directories listed by "use" directives in
+// root/go.work, or nil when there is no go.work file.
+func WorkspaceMembers(root string) ([]string, error) {
+	f, err := os.Open(filepath.Join(root, "go.work"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open go.work: %w", err)
+	}
+	defer f.Close()
+
+	var members []string
+	inBlock := false
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case inBlock && line == ")":
+			inBlock = false
+		case inBlock:
+			members = append(members, strings.Trim(line, `"`))
+		case line == "use (" || line == "use(":
+			inBlock = true
+		case strings.HasPrefix(line, "use "):
+			members = append(members, strings.Trim(strings.TrimSpace(line[len("use "):]), `"`))
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read go.work: %w", err)
+	}
+	return members, nil
+}
+
+// ScanWorkspace scans every member module of root/go.work. Files are reported once
+// even when member directories nest. ok is false when root has no go.work, in which
+// case nothing is scanned.
+func (s *Scanner) ScanWorkspace(dirPath string) (files []FileInfo, members []string, ok bool, err error) {
+	root, err := absPath(dirPath)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	members, err = WorkspaceMembers(root)
+	if err != nil || members == nil {
+		return nil, nil, false, err
+	}
+	files, err = collectAll(s.ScanStream(context.Background(), root, members))
+	if err != nil {
+		return nil, nil, true, err
+	}
+	return files, members, true, nil
+}
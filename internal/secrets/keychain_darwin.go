@@ -0,0 +1,18 @@
+package secrets
+
+import (
+	"context"
+	"os/exec"
+)
+
+const keychainName = "macOS Keychain"
+
+// keychainLookup reads a generic password with the security tool, which also handles
+// the keychain unlock prompt.
+func keychainLookup(ctx context.Context, service, account string) (string, error) {
+	args := []string{"find-generic-password", "-s", service, "-w"}
+	if account != "" {
+		args = append(args, "-a", account)
+	}
+	return run(exec.CommandContext(ctx, "security", args...))
+}
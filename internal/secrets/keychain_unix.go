@@ -0,0 +1,20 @@
+//go:build !darwin && !windows
+
+package secrets
+
+import (
+	"context"
+	"os/exec"
+)
+
+const keychainName = "Secret Service (libsecret)"
+
+// keychainLookup queries the Secret Service through secret-tool, matching items stored
+// with `secret-tool store --label=... service SERVICE [account ACCOUNT]`.
+func keychainLookup(ctx context.Context, service, account string) (string, error) {
+	args := []string{"lookup", "service", service}
+	if account != "" {
+		args = append(args, "account", account)
+	}
+	return run(exec.CommandContext(ctx, "secret-tool", args...))
+}
@@ -0,0 +1,70 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"unicode/utf16"
+	"unsafe"
+)
+
+const keychainName = "Windows Credential Manager"
+
+const credTypeGeneric = 1
+
+var (
+	advapi32     = syscall.NewLazyDLL("advapi32.dll")
+	procCredRead = advapi32.NewProc("CredReadW")
+	procCredFree = advapi32.NewProc("CredFree")
+)
+
+// credential mirrors the Win32 CREDENTIALW structure.
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// keychainLookup reads the generic credential whose target name is the service (or
+// "service/account" when an account is given), as created with
+// `cmdkey /generic:SERVICE /user:... /pass:...`.
+func keychainLookup(_ context.Context, service, account string) (string, error) {
+	target := service
+	if account != "" {
+		target += "/" + account
+	}
+	name, err := syscall.UTF16PtrFromString(target)
+	if err != nil {
+		return "", err
+	}
+
+	var cred *credential
+	r, _, callErr := procCredRead.Call(uintptr(unsafe.Pointer(name)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if r == 0 {
+		return "", callErr
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+
+	if cred.CredentialBlobSize == 0 || cred.CredentialBlob == nil {
+		return "", errors.New("credential has no secret")
+	}
+	blob := unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)
+	// cmdkey and the Control Panel store UTF-16; other tools store raw bytes
+	if len(blob) >= 2 && len(blob)%2 == 0 && blob[1] == 0 {
+		units := make([]uint16, len(blob)/2)
+		for i := range units {
+			units[i] = uint16(blob[2*i]) | uint16(blob[2*i+1])<<8
+		}
+		return string(utf16.Decode(units)), nil
+	}
+	return string(blob), nil
+}
@@ -0,0 +1,69 @@
+// Package secrets resolves API keys from external secret stores so they never have to
+// appear in shell history, config files or environment dumps.
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// commandTimeout bounds how long a secret command (which may prompt for a hardware
+// token or SSO login) may take.
+const commandTimeout = 2 * time.Minute
+
+// FromCommand runs command through the system shell and returns its trimmed stdout,
+// e.g. for `vault kv get -field=key secret/aireview` or `op read op://vault/item/key`.
+func FromCommand(ctx context.Context, command string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	return run(cmd)
+}
+
+// FromKeychain reads a secret from the OS keychain: the macOS Keychain, the Windows
+// Credential Manager, or the Secret Service (libsecret) elsewhere. item is the service
+// name, optionally followed by "/account".
+func FromKeychain(ctx context.Context, item string) (string, error) {
+	service, account, _ := strings.Cut(item, "/")
+	if service == "" {
+		return "", errors.New("keychain item needs a service name")
+	}
+	secret, err := keychainLookup(ctx, service, account)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %q from the %s: %w", item, keychainName, err)
+	}
+	if secret == "" {
+		return "", fmt.Errorf("keychain item %q is empty", item)
+	}
+	return secret, nil
+}
+
+// run executes cmd and returns its trimmed stdout, including stderr in errors.
+func run(cmd *exec.Cmd) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	secret := strings.TrimSpace(stdout.String())
+	if secret == "" {
+		return "", errors.New("command printed no secret")
+	}
+	return secret, nil
+}
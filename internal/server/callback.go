@@ -0,0 +1,163 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// Callback delivery settings: each attempt times out after callbackTimeout, and failed
+// attempts are retried after a doubling delay.
+const (
+	callbackTimeout  = 30 * time.Second
+	callbackAttempts = 4
+	callbackBackoff  = 5 * time.Second
+)
+
+// reservedNetworks are the non-public networks the IP methods of callbackAllowed do
+// not cover.
+var reservedNetworks = []*net.IPNet{
+	mustCIDR("0.0.0.0/8"),
+	mustCIDR("100.64.0.0/10"), // carrier-grade NAT, used for internal addresses by some clouds
+	mustCIDR("192.0.0.0/24"),
+	mustCIDR("198.18.0.0/15"),
+	mustCIDR("240.0.0.0/4"),
+}
+
+func mustCIDR(s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// callbackClient returns the client callbacks are sent with. It only connects to
+// public addresses and those in allowed, checked on the resolved address so that a
+// host name cannot lead it to an internal service, and it does not follow redirects.
+// Callbacks do not go through HTTP_PROXY, which would hide the address.
+func callbackClient(allowed []*net.IPNet) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !callbackAllowed(ip, allowed) {
+				return fmt.Errorf("callback address %s is not public and not allowed", host)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: callbackTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// callbackAllowed reports whether a callback may connect to ip: a public address or
+// one in allowed.
+func callbackAllowed(ip net.IP, allowed []*net.IPNet) bool {
+	for _, n := range allowed {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsLinkLocalMulticast() {
+		return false
+	}
+	for _, n := range reservedNetworks {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// SignatureHeader carries the HMAC-SHA256 of a callback body, keyed with the server's
+// callback secret, as "sha256=<hex>".
+const SignatureHeader = "X-Aireview-Signature"
+
+// deliver POSTs the finished job to its callback URL, retrying failures, and records
+// the outcome in the job.
+func (s *Server) deliver(ctx context.Context, job *Job) {
+	s.mu.Lock()
+	view := *job
+	s.mu.Unlock()
+	body, err := json.Marshal(view)
+	if err != nil {
+		s.update(job, func(j *Job) { j.CallbackError = err.Error() })
+		return
+	}
+
+	delay := callbackBackoff
+	for attempt := 1; ; attempt++ {
+		err = s.post(ctx, view.CallbackURL, body)
+		if err == nil {
+			s.update(job, func(j *Job) {
+				now := time.Now().UTC()
+				j.CallbackDeliveredAt, j.CallbackError = &now, ""
+			})
+			return
+		}
+		s.update(job, func(j *Job) { j.CallbackError = err.Error() })
+		if attempt == callbackAttempts {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func (s *Server) post(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create callback request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "aireview/1.0")
+	if s.opts.CallbackSecret != "" {
+		req.Header.Set(SignatureHeader, Sign(s.opts.CallbackSecret, body))
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send callback: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		return fmt.Errorf("callback returned status %d; redirects are not followed", resp.StatusCode)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("callback returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the SignatureHeader value of a callback body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
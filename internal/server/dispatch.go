@@ -0,0 +1,407 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/disconnekt/goreview/internal/report"
+)
+
+// Dispatch settings: a lease request waits up to leaseWait for a shard, and a shard
+// whose lease expired maxShardAttempts times fails instead of being queued again.
+const (
+	leaseWait        = 25 * time.Second
+	maxShardAttempts = 3
+)
+
+// Shard is a part of a job's files, reviewed by a worker.
+type Shard struct {
+	ID string `json:"id"`
+	// Request holds the shard's files and the job's format, profile and model.
+	Request Request `json:"request"`
+	// LeaseSeconds is how long the lease lasts without a heartbeat.
+	LeaseSeconds int `json:"lease_seconds"`
+}
+
+// ShardResult is a worker's result of a shard: the reviews of its files, by path
+// relative to the project root, also when some files failed.
+type ShardResult struct {
+	Files  []report.FileReview `json:"files"`
+	Tokens int                 `json:"tokens"`
+	Error  string              `json:"error,omitempty"`
+}
+
+// Heartbeat extends the lease of a shard and reports its progress.
+type Heartbeat struct {
+	FilesDone int `json:"files_done"`
+}
+
+// DispatcherOptions configures a Dispatcher.
+type DispatcherOptions struct {
+	// Token, when set, is the bearer token workers must present.
+	Token string
+	// LeaseTimeout is how long a worker may hold a shard without a heartbeat before
+	// it is queued for another worker.
+	LeaseTimeout time.Duration
+	// MaxBytes bounds the size of a shard result.
+	MaxBytes int64
+	// Queue holds the shards waiting for a worker; nil means an in-memory queue.
+	Queue ShardQueue
+}
+
+// ShardQueue holds the shards waiting for a worker, in the order they are leased. The
+// dispatcher keeps the leases and results itself and calls the queue with its lock
+// held, so an implementation needs no locking of its own but must not block for long.
+type ShardQueue interface {
+	// Push adds a shard at the back of the queue, or at the front when it is leased
+	// again after its lease expired.
+	Push(shard Shard, front bool) error
+	// Pop removes the shard at the front and returns it; ok is false when the queue is
+	// empty.
+	Pop() (shard Shard, ok bool, err error)
+	// Remove drops the shards with the given IDs that are still queued.
+	Remove(ids ...string) error
+}
+
+// memoryQueue is the default ShardQueue.
+type memoryQueue struct {
+	shards []Shard
+}
+
+func (q *memoryQueue) Push(shard Shard, front bool) error {
+	if front {
+		q.shards = append([]Shard{shard}, q.shards...)
+	} else {
+		q.shards = append(q.shards, shard)
+	}
+	return nil
+}
+
+func (q *memoryQueue) Pop() (Shard, bool, error) {
+	if len(q.shards) == 0 {
+		return Shard{}, false, nil
+	}
+	shard := q.shards[0]
+	q.shards = q.shards[1:]
+	return shard, true, nil
+}
+
+func (q *memoryQueue) Remove(ids ...string) error {
+	gone := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		gone[id] = true
+	}
+	kept := q.shards[:0]
+	for _, shard := range q.shards {
+		if !gone[shard.ID] {
+			kept = append(kept, shard)
+		}
+	}
+	q.shards = kept
+	return nil
+}
+
+// Dispatcher splits jobs into shards that workers lease over HTTP, so the files of a
+// job are reviewed on several machines at once.
+type Dispatcher struct {
+	opts DispatcherOptions
+
+	mu    sync.Mutex
+	queue ShardQueue
+	// shards holds the shards of the running jobs, queued or leased, by ID.
+	shards  map[string]*shardState
+	leased  map[string]*shardState
+	changed chan struct{}
+}
+
+type shardState struct {
+	shard     Shard
+	deadline  time.Time
+	attempts  int
+	filesDone int
+	result    *ShardResult
+}
+
+// NewDispatcher returns a dispatcher without shards.
+func NewDispatcher(opts DispatcherOptions) *Dispatcher {
+	queue := opts.Queue
+	if queue == nil {
+		queue = &memoryQueue{}
+	}
+	return &Dispatcher{
+		opts:   opts,
+		queue:  queue,
+		shards: make(map[string]*shardState),
+		leased: make(map[string]*shardState),
+	}
+}
+
+// Run is the Runner body of a coordinator: it splits the files of req into shards of
+// size files, waits for workers to review them and returns the reviews of all files
+// and the tokens used. Files a shard did not return a review for, because it failed or
+// its lease expired too often, are listed with the error. Shards still queued or leased
+// when ctx ends are withdrawn.
+func (d *Dispatcher) Run(ctx context.Context, req Request, size int, progress Progress) ([]report.FileReview, int, error) {
+	var shards []*shardState
+	for start := 0; start < len(req.Files); start += size {
+		part := req
+		part.Files = req.Files[start:min(start+size, len(req.Files))]
+		part.CallbackURL = ""
+		shards = append(shards, &shardState{shard: Shard{
+			ID:           strings.Replace(newJobID(), "job-", "shard-", 1),
+			Request:      part,
+			LeaseSeconds: int(d.opts.LeaseTimeout / time.Second),
+		}})
+	}
+	defer d.withdraw(shards)
+	d.mu.Lock()
+	for _, st := range shards {
+		if err := d.queue.Push(st.shard, false); err != nil {
+			d.mu.Unlock()
+			return nil, 0, fmt.Errorf("failed to queue shard %s: %w", st.shard.ID, err)
+		}
+		d.shards[st.shard.ID] = st
+	}
+	d.notify()
+	d.mu.Unlock()
+
+	reported := make([]bool, len(shards))
+	for {
+		d.mu.Lock()
+		d.expire(time.Now())
+		filesDone, finished := 0, 0
+		var results []*ShardResult
+		for i, st := range shards {
+			filesDone += st.filesDone
+			if st.result != nil {
+				finished++
+				if !reported[i] {
+					reported[i] = true
+					results = append(results, st.result)
+				}
+			}
+		}
+		changed := d.watch()
+		d.mu.Unlock()
+
+		for _, res := range results {
+			for _, fr := range res.Files {
+				progress.Findings(fr.Path, fr.Findings)
+			}
+		}
+		progress.Files(min(filesDone, len(req.Files)), len(req.Files))
+		if finished == len(shards) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, 0, ctx.Err()
+		case <-changed:
+		case <-time.After(d.opts.LeaseTimeout / 4):
+		}
+	}
+
+	var all []report.FileReview
+	var tokens int
+	var errs []error
+	for _, st := range shards {
+		all = append(all, st.result.Files...)
+		tokens += st.result.Tokens
+		if st.result.Error != "" {
+			errs = append(errs, fmt.Errorf("%s: %s", st.shard.ID, st.result.Error))
+		}
+		all = append(all, unreviewed(st)...)
+	}
+	return all, tokens, errors.Join(errs...)
+}
+
+// unreviewed returns errored entries for the files of a finished shard that its result
+// has no review for.
+func unreviewed(st *shardState) []report.FileReview {
+	reviewed := make(map[string]bool, len(st.result.Files))
+	for _, fr := range st.result.Files {
+		reviewed[fr.Path] = true
+	}
+	reason := st.result.Error
+	if reason == "" {
+		reason = "the worker returned no review"
+	}
+	var out []report.FileReview
+	for _, f := range st.shard.Request.Files {
+		if !reviewed[f.Path] {
+			out = append(out, report.FileReview{Path: f.Path, Size: int64(len(f.Content)), Error: reason})
+		}
+	}
+	return out
+}
+
+// withdraw removes the shards from the queue and the leases. A shard the queue fails
+// to remove is skipped when it is leased.
+func (d *Dispatcher) withdraw(shards []*shardState) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	ids := make([]string, 0, len(shards))
+	for _, st := range shards {
+		ids = append(ids, st.shard.ID)
+		delete(d.shards, st.shard.ID)
+		delete(d.leased, st.shard.ID)
+	}
+	_ = d.queue.Remove(ids...)
+}
+
+// expire queues shards whose lease ran out again, ahead of the others, or fails them
+// after maxShardAttempts leases. The caller holds d.mu.
+func (d *Dispatcher) expire(now time.Time) {
+	for id, st := range d.leased {
+		if now.Before(st.deadline) {
+			continue
+		}
+		delete(d.leased, id)
+		st.filesDone = 0
+		if st.attempts >= maxShardAttempts {
+			st.result = &ShardResult{Error: fmt.Sprintf("lease expired %d times", st.attempts)}
+			continue
+		}
+		if err := d.queue.Push(st.shard, true); err != nil {
+			st.result = &ShardResult{Error: fmt.Sprintf("failed to queue the shard again: %v", err)}
+			continue
+		}
+		d.notify()
+	}
+}
+
+// watch returns a channel closed at the next change of the shards. The caller holds d.mu.
+func (d *Dispatcher) watch() <-chan struct{} {
+	if d.changed == nil {
+		d.changed = make(chan struct{})
+	}
+	return d.changed
+}
+
+// notify wakes the watchers of the shards. The caller holds d.mu.
+func (d *Dispatcher) notify() {
+	if d.changed != nil {
+		close(d.changed)
+		d.changed = nil
+	}
+}
+
+// Handler serves the worker API:
+//
+//	POST /v1/worker/lease                lease a shard; 204 when none is queued
+//	POST /v1/worker/shards/{id}/heartbeat extend the lease with a Heartbeat
+//	POST /v1/worker/shards/{id}          finish the shard with a ShardResult
+//
+// A shard whose lease was lost answers 410, telling the worker to drop it.
+func (d *Dispatcher) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if d.opts.Token != "" && !tokenEqual(d.opts.Token, bearerToken(r)) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			httpError(w, http.StatusUnauthorized, "invalid worker token")
+			return
+		}
+		if r.Method != http.MethodPost {
+			httpError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		path := strings.TrimPrefix(r.URL.Path, "/v1/worker/")
+		switch {
+		case path == "lease":
+			d.lease(w, r)
+		case strings.HasPrefix(path, "shards/"):
+			id, heartbeat := strings.CutSuffix(strings.TrimPrefix(path, "shards/"), "/heartbeat")
+			d.report(w, r, id, heartbeat)
+		default:
+			httpError(w, http.StatusNotFound, "not found")
+		}
+	})
+}
+
+func (d *Dispatcher) lease(w http.ResponseWriter, r *http.Request) {
+	timeout := time.NewTimer(leaseWait)
+	defer timeout.Stop()
+	for {
+		d.mu.Lock()
+		d.expire(time.Now())
+		st, err := d.pop()
+		if err != nil {
+			d.mu.Unlock()
+			httpError(w, http.StatusServiceUnavailable, "failed to lease a shard: "+err.Error())
+			return
+		}
+		if st != nil {
+			st.attempts++
+			st.deadline = time.Now().Add(d.opts.LeaseTimeout)
+			d.leased[st.shard.ID] = st
+			shard := st.shard
+			d.mu.Unlock()
+			writeJSON(w, http.StatusOK, shard)
+			return
+		}
+		changed := d.watch()
+		d.mu.Unlock()
+		select {
+		case <-r.Context().Done():
+			return
+		case <-timeout.C:
+			w.WriteHeader(http.StatusNoContent)
+			return
+		case <-changed:
+		}
+	}
+}
+
+// pop returns the next queued shard of a running job, or nil when there is none. The
+// caller holds d.mu.
+func (d *Dispatcher) pop() (*shardState, error) {
+	for {
+		shard, ok, err := d.queue.Pop()
+		if err != nil || !ok {
+			return nil, err
+		}
+		// Shards of a job that ended meanwhile are dropped
+		if st := d.shards[shard.ID]; st != nil {
+			return st, nil
+		}
+	}
+}
+
+func (d *Dispatcher) report(w http.ResponseWriter, r *http.Request, id string, heartbeat bool) {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, d.opts.MaxBytes))
+	var hb Heartbeat
+	var res ShardResult
+	var err error
+	if heartbeat {
+		err = dec.Decode(&hb)
+	} else {
+		err = dec.Decode(&res)
+	}
+	if err != nil {
+		httpError(w, http.StatusBadRequest, "invalid shard report: "+err.Error())
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	st, ok := d.leased[id]
+	if !ok {
+		httpError(w, http.StatusGone, "shard is not leased")
+		return
+	}
+	if heartbeat {
+		st.deadline = time.Now().Add(d.opts.LeaseTimeout)
+		st.filesDone = hb.FilesDone
+	} else {
+		delete(d.leased, id)
+		st.filesDone = len(st.shard.Request.Files)
+		st.result = &res
+	}
+	d.notify()
+	w.WriteHeader(http.StatusNoContent)
+}
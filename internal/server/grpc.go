@@ -0,0 +1,159 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	reviewv1 "github.com/disconnekt/goreview/api/review/v1"
+)
+
+// GRPCServer returns a gRPC server offering the ReviewService of api/review/v1 over
+// the server's jobs, with the same tenants, limits and queue as the REST API.
+func (s *Server) GRPCServer() *grpc.Server {
+	g := grpc.NewServer(grpc.MaxRecvMsgSize(int(s.opts.MaxBytes)))
+	reviewv1.RegisterReviewServiceServer(g, &grpcService{s: s})
+	return g
+}
+
+type grpcService struct {
+	reviewv1.UnimplementedReviewServiceServer
+	s *Server
+}
+
+// tenant returns the tenant of the bearer token in the call's authorization metadata.
+func (g *grpcService) tenant(ctx context.Context) (*Tenant, error) {
+	var token string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get("authorization"); len(v) > 0 {
+			token, _ = strings.CutPrefix(v[0], "Bearer ")
+		}
+	}
+	t, err := g.s.tenantOf(token)
+	return t, grpcError(err)
+}
+
+func (g *grpcService) SubmitReview(ctx context.Context, in *reviewv1.SubmitReviewRequest) (*reviewv1.Job, error) {
+	tenant, err := g.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req := Request{
+		Format:      in.GetFormat(),
+		Profile:     in.GetProfile(),
+		Model:       in.GetModel(),
+		CallbackURL: in.GetCallbackUrl(),
+	}
+	for _, f := range in.GetFiles() {
+		req.Files = append(req.Files, File{Path: f.GetPath(), Content: f.GetContent()})
+	}
+	job, err := g.s.submitJob(tenant, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return jobMessage(job), nil
+}
+
+func (g *grpcService) GetJob(ctx context.Context, in *reviewv1.GetJobRequest) (*reviewv1.Job, error) {
+	tenant, err := g.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	job, err := g.s.job(in.GetId(), tenant)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	msg := jobMessage(job)
+	// The REST API carries reports of other formats as JSON strings
+	var text string
+	if json.Unmarshal(job.Report, &text) == nil {
+		msg.Report = []byte(text)
+	} else {
+		msg.Report = job.Report
+	}
+	return msg, nil
+}
+
+func (g *grpcService) StreamFindings(in *reviewv1.StreamFindingsRequest, stream reviewv1.ReviewService_StreamFindingsServer) error {
+	tenant, err := g.tenant(stream.Context())
+	if err != nil {
+		return err
+	}
+	err = g.s.watchFindings(stream.Context(), in.GetId(), tenant, func(ff FileFindings) error {
+		msg := &reviewv1.FileFindings{File: ff.File}
+		for _, f := range ff.Findings {
+			msg.Findings = append(msg.Findings, &reviewv1.Finding{
+				File:     f.File,
+				Module:   f.Module,
+				Line:     int32(f.Line),
+				EndLine:  int32(f.EndLine),
+				Severity: string(f.Severity),
+				RuleId:   f.RuleID,
+				Message:  f.Message,
+				Models:   f.Models,
+				Owners:   f.Owners,
+			})
+		}
+		return stream.Send(msg)
+	})
+	return grpcError(err)
+}
+
+func jobMessage(job Job) *reviewv1.Job {
+	return &reviewv1.Job{
+		Id:          job.ID,
+		Status:      job.Status,
+		SubmittedAt: timestamppb.New(job.SubmittedAt),
+		StartedAt:   timestamp(job.StartedAt),
+		FinishedAt:  timestamp(job.FinishedAt),
+		FilesTotal:  int32(job.FilesTotal),
+		FilesDone:   int32(job.FilesDone),
+		Format:      job.Format,
+		Error:       job.Error,
+		Tenant:      job.Tenant,
+		Tokens:      int64(job.Tokens),
+	}
+}
+
+func timestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+// grpcError converts the errors of the job operations to gRPC statuses.
+func grpcError(err error) error {
+	var op *opError
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	case !errors.As(err, &op):
+		return err
+	}
+	code := codes.Internal
+	switch op.status {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		code = codes.Unavailable
+	}
+	return status.Error(code, op.msg)
+}
@@ -0,0 +1,797 @@
+// Package server accepts review jobs over HTTP and gRPC and runs them one after
+// another in the background, so large reviews can be submitted and polled instead of
+// held open.
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/disconnekt/goreview/internal/findings"
+)
+
+// Job statuses.
+const (
+	Queued  = "queued"
+	Running = "running"
+	Done    = "done"
+	Failed  = "failed"
+)
+
+// defaultRetention is how long finished jobs stay queryable by default.
+const defaultRetention = 24 * time.Hour
+
+// File is a file submitted for review, by path relative to the project root.
+type File struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// Request is the body of a job submission.
+type Request struct {
+	Files []File `json:"files"`
+	// Format is the report format, "json" by default.
+	Format string `json:"format,omitempty"`
+	// Profile and Model override the server's settings for the job when set, if the
+	// server allows them; see Options.
+	Profile string `json:"profile,omitempty"`
+	Model   string `json:"model,omitempty"`
+	// CallbackURL receives the finished job, report or failure included, as a POST.
+	CallbackURL string `json:"callback_url,omitempty"`
+}
+
+// Validate checks that the request has files with clean relative paths.
+func (r Request) Validate() error {
+	if len(r.Files) == 0 {
+		return errors.New("no files submitted")
+	}
+	seen := make(map[string]bool, len(r.Files))
+	for _, f := range r.Files {
+		p := f.Path
+		if p == "" || path.IsAbs(p) || path.Clean(p) != p || p == ".." || strings.HasPrefix(p, "../") {
+			return fmt.Errorf("invalid file path %q: must be a clean relative path", p)
+		}
+		if seen[p] {
+			return fmt.Errorf("duplicate file path %q", p)
+		}
+		seen[p] = true
+	}
+	if r.CallbackURL != "" {
+		u, err := url.Parse(r.CallbackURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid callback URL %q: must be an http or https URL", r.CallbackURL)
+		}
+	}
+	return nil
+}
+
+// Job is the state of a submitted review.
+type Job struct {
+	ID          string     `json:"id"`
+	Status      string     `json:"status"`
+	SubmittedAt time.Time  `json:"submitted_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	// FilesTotal and FilesDone count the files to review and reviewed so far.
+	FilesTotal int    `json:"files_total"`
+	FilesDone  int    `json:"files_done"`
+	Format     string `json:"format"`
+	Error      string `json:"error,omitempty"`
+	// Report is the finished report: a JSON document for the json format, otherwise a
+	// JSON string. It is only included when a single job is requested.
+	Report json.RawMessage `json:"report,omitempty"`
+	// CallbackURL is notified once the job finishes; CallbackDeliveredAt and
+	// CallbackError record the outcome.
+	CallbackURL         string     `json:"callback_url,omitempty"`
+	CallbackDeliveredAt *time.Time `json:"callback_delivered_at,omitempty"`
+	CallbackError       string     `json:"callback_error,omitempty"`
+	// Tenant is the name of the tenant that submitted the job, when tenants are set up.
+	Tenant string `json:"tenant,omitempty"`
+	// Tokens counts the prompt and completion tokens the review used.
+	Tokens int `json:"tokens,omitempty"`
+
+	request Request
+	// findings are the findings of the reviewed files, in the order they finished.
+	findings []FileFindings
+	// changed is closed at the next change of the job, see watch.
+	changed chan struct{}
+}
+
+// FileFindings are the findings of a reviewed file, by path relative to the project root.
+type FileFindings struct {
+	File     string             `json:"file"`
+	Findings []findings.Finding `json:"findings"`
+}
+
+// Outcome is what a Runner returns: the report and the tokens used to write it.
+type Outcome struct {
+	Report []byte
+	Tokens int
+}
+
+// Progress is told about a running job as its files are reviewed.
+type Progress interface {
+	// Files reports how many of the job's files have been reviewed.
+	Files(done, total int)
+	// Findings reports the findings of a reviewed file.
+	Findings(file string, list []findings.Finding)
+}
+
+// Runner reviews the files of a job, reporting progress as files finish. A failed
+// review may still return the report of the files that succeeded.
+type Runner func(ctx context.Context, req Request, progress Progress) (Outcome, error)
+
+// Options configures a Server.
+type Options struct {
+	// QueueSize bounds the number of jobs waiting to run.
+	QueueSize int
+	// MaxBytes bounds the size of a job submission.
+	MaxBytes int64
+	// CallbackSecret, when set, signs callbacks with an HMAC-SHA256 of the body.
+	CallbackSecret string
+	// CallbackNetworks lists the non-public networks callbacks may go to. Callbacks to
+	// loopback, private, link-local and other non-public addresses are refused otherwise.
+	CallbackNetworks []*net.IPNet
+	// Tenants, when set, requires every request to carry a tenant's token and
+	// enforces the tenants' limits.
+	Tenants *Tenants
+	// Store, when set, keeps the jobs and the tenants' usage across restarts; see
+	// Restore. It is a PostgreSQL URL (postgres://...), a SQLite database
+	// (sqlite:<file>) or a directory of JSON files.
+	Store string
+	// Retention is how long finished jobs stay queryable, 24 hours by default.
+	Retention time.Duration
+	// DrainTimeout is how long the running job may go on once Work stops taking jobs;
+	// it is then cancelled. With a store it runs again after a restart.
+	DrainTimeout time.Duration
+	// Profiles lists the profiles a request may choose, any when empty. Models lists the
+	// models a request may choose; when empty a request may not choose one.
+	Profiles []string
+	Models   []string
+	// Reload, when set, reloads the server's configuration on POST /v1/admin/reload.
+	Reload func() error
+	// Logf reports problems that do not fail a request, such as a failed write to the
+	// store.
+	Logf func(format string, args ...any)
+}
+
+// Server queues jobs and runs them one at a time with its Runner. It is safe for
+// concurrent use.
+type Server struct {
+	run    Runner
+	opts   Options
+	queue  chan *Job
+	client *http.Client
+	store  jobStore
+	writer *storeWriter
+
+	mu    sync.Mutex
+	jobs  map[string]*Job
+	usage map[string]*usage
+}
+
+// New returns a server running jobs with run. It fails when the store cannot be
+// opened.
+func New(run Runner, opts Options) (*Server, error) {
+	if opts.Retention <= 0 {
+		opts.Retention = defaultRetention
+	}
+	if opts.Logf == nil {
+		opts.Logf = func(string, ...any) {}
+	}
+	s := &Server{
+		run:    run,
+		opts:   opts,
+		queue:  make(chan *Job, opts.QueueSize),
+		client: callbackClient(opts.CallbackNetworks),
+		jobs:   make(map[string]*Job),
+		usage:  make(map[string]*usage),
+	}
+	if opts.Store != "" {
+		store, err := openStore(opts.Store)
+		if err != nil {
+			return nil, err
+		}
+		s.store, s.writer = store, newStoreWriter(store, opts.Logf)
+	}
+	return s, nil
+}
+
+// Close waits for the pending writes to the store and closes it.
+func (s *Server) Close() error {
+	if s.store == nil {
+		return nil
+	}
+	s.writer.flush()
+	return s.store.close()
+}
+
+// Restore loads the jobs and usage of the store and queues the jobs that had not
+// finished again, in the order they were submitted. It returns the number of requeued
+// jobs and is called before Work.
+func (s *Server) Restore() (int, error) {
+	if s.store == nil {
+		return 0, nil
+	}
+	days, err := s.store.loadUsage()
+	if err != nil {
+		return 0, err
+	}
+	stored, err := s.store.load()
+	if err != nil {
+		return 0, err
+	}
+	sort.Slice(stored, func(i, j int) bool { return stored[i].SubmittedAt.Before(stored[j].SubmittedAt) })
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, list := range days {
+		u := s.tenantUsage(name)
+		for _, d := range list {
+			*u.day(d.Date) = d
+		}
+	}
+	requeued := 0
+	for _, rec := range stored {
+		job := rec.Job
+		s.jobs[job.ID] = &job
+		if job.FinishedAt != nil {
+			job.findings = rec.Findings
+			continue
+		}
+		// Interrupted jobs start over
+		job.Status, job.StartedAt, job.FilesDone = Queued, nil, 0
+		if rec.Request != nil {
+			job.request = *rec.Request
+			select {
+			case s.queue <- &job:
+				requeued++
+				if job.Tenant != "" {
+					s.tenantUsage(job.Tenant).active++
+				}
+				s.save(&job)
+				continue
+			default:
+			}
+		}
+		now := time.Now().UTC()
+		job.Status, job.Error, job.FinishedAt = Failed, "job could not be resumed after a restart", &now
+		s.save(&job)
+	}
+	s.prune()
+	return requeued, nil
+}
+
+// Work runs queued jobs until ctx ends. The running job is not tied to ctx: it may
+// finish within DrainTimeout, and Work returns once it has.
+func (s *Server) Work(ctx context.Context) {
+	jobCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+	stop := context.AfterFunc(ctx, func() {
+		time.AfterFunc(s.opts.DrainTimeout, cancel)
+	})
+	defer stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-s.queue:
+			if ctx.Err() != nil {
+				// Left queued: the store queues it again on restart
+				return
+			}
+			s.runJob(jobCtx, job)
+		}
+	}
+}
+
+func (s *Server) runJob(ctx context.Context, job *Job) {
+	var refused string
+	s.update(job, func(j *Job) {
+		now := time.Now().UTC()
+		j.Status, j.StartedAt = Running, &now
+		// The budget may have run out while the job was queued
+		if t := s.tenant(j.Tenant); t != nil {
+			refused = s.tenantUsage(t.Name).overBudget(t)
+		}
+	})
+	var out Outcome
+	err := errors.New(refused)
+	if refused == "" {
+		out, err = s.run(ctx, job.request, jobProgress{s: s, job: job})
+	}
+	if ctx.Err() != nil && s.store != nil {
+		// Cut off by a stop: the stored job is still running, so Restore queues it again
+		s.opts.Logf("job %s was stopped before it finished; it runs again after a restart\n", job.ID)
+		return
+	}
+	s.update(job, func(j *Job) {
+		now := time.Now().UTC()
+		j.FinishedAt = &now
+		j.request = Request{} // the sources are no longer needed
+		j.Status = Done
+		j.Tokens = out.Tokens
+		if err != nil {
+			j.Status, j.Error = Failed, err.Error()
+		}
+		if j.Tenant != "" {
+			u := s.tenantUsage(j.Tenant)
+			u.active--
+			d := u.day(now.Format(time.DateOnly))
+			d.Jobs++
+			d.Files += j.FilesDone
+			d.Tokens += out.Tokens
+			if err != nil {
+				d.Failed++
+			}
+			s.saveUsage()
+		}
+		if len(out.Report) == 0 {
+			return
+		}
+		if j.Format == "json" && json.Valid(out.Report) {
+			j.Report = out.Report
+		} else {
+			j.Report, _ = json.Marshal(string(out.Report))
+		}
+	})
+	if job.CallbackURL != "" {
+		// Delivery retries must not hold up the next job
+		go s.deliver(ctx, job)
+	}
+}
+
+// jobProgress records the progress of a running job. Progress is not stored: an
+// interrupted job starts over anyway.
+type jobProgress struct {
+	s   *Server
+	job *Job
+}
+
+func (p jobProgress) Files(done, total int) {
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	p.job.FilesDone, p.job.FilesTotal = done, total
+	p.job.notify()
+}
+
+func (p jobProgress) Findings(file string, list []findings.Finding) {
+	p.s.mu.Lock()
+	defer p.s.mu.Unlock()
+	p.job.findings = append(p.job.findings, FileFindings{File: file, Findings: list})
+	p.job.notify()
+}
+
+// update changes the job and writes it to the store.
+func (s *Server) update(job *Job, fn func(*Job)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(job)
+	job.notify()
+	s.save(job)
+}
+
+// watch returns a channel closed at the next change of the job. The caller holds s.mu.
+func (j *Job) watch() <-chan struct{} {
+	if j.changed == nil {
+		j.changed = make(chan struct{})
+	}
+	return j.changed
+}
+
+// notify wakes the watchers of the job. The caller holds s.mu.
+func (j *Job) notify() {
+	if j.changed != nil {
+		close(j.changed)
+		j.changed = nil
+	}
+}
+
+// save queues the job for the store, if any; a finished job's sources are dropped
+// from it. The caller holds s.mu, which only covers taking the snapshot: encoding and
+// writing happen in the background.
+func (s *Server) save(job *Job) {
+	if s.store == nil {
+		return
+	}
+	if job.FinishedAt != nil {
+		s.writer.finished(record(job))
+		return
+	}
+	s.writer.job(record(job), nil)
+}
+
+// saveNew queues a new job with its sources for the store, if any. The caller holds s.mu.
+func (s *Server) saveNew(job *Job) {
+	if s.store == nil {
+		return
+	}
+	req := job.request
+	s.writer.job(record(job), &req)
+}
+
+// record returns a snapshot of the job for the store. The findings are shared with the
+// job, which only ever appends to them.
+func record(job *Job) storedJob {
+	rec := storedJob{Job: *job, Findings: job.findings[:len(job.findings):len(job.findings)]}
+	rec.request, rec.findings, rec.changed = Request{}, nil, nil
+	return rec
+}
+
+// saveUsage queues the tenants' usage for the store, if any. The caller holds s.mu.
+func (s *Server) saveUsage() {
+	if s.store == nil {
+		return
+	}
+	days := make(map[string][]DayUsage, len(s.usage))
+	for name, u := range s.usage {
+		for _, d := range u.days {
+			days[name] = append(days[name], *d)
+		}
+	}
+	s.writer.saveUsage(days)
+}
+
+// Handler serves the job API:
+//
+//	POST /v1/jobs         submit a Request; responds 202 with the queued job
+//	GET  /v1/jobs         list the jobs without their reports
+//	GET  /v1/jobs/{id}    the job with its report once done
+//	GET  /v1/admin/usage  the usage of every tenant
+//	POST /v1/admin/reload reload the configuration
+//
+// With tenants, the job endpoints take a tenant's token as a bearer token and only
+// show the tenant's own jobs, and the admin endpoints take the admin token.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/jobs", func(w http.ResponseWriter, r *http.Request) {
+		tenant, ok := s.authenticate(w, r)
+		if !ok {
+			return
+		}
+		switch r.Method {
+		case http.MethodPost:
+			s.submit(w, r, tenant)
+		case http.MethodGet:
+			s.list(w, tenant)
+		default:
+			httpError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	})
+	mux.HandleFunc("/v1/jobs/", func(w http.ResponseWriter, r *http.Request) {
+		tenant, ok := s.authenticate(w, r)
+		if !ok {
+			return
+		}
+		if r.Method != http.MethodGet {
+			httpError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		s.get(w, strings.TrimPrefix(r.URL.Path, "/v1/jobs/"), tenant)
+	})
+	mux.HandleFunc("/v1/admin/usage", func(w http.ResponseWriter, r *http.Request) {
+		if !s.authenticateAdmin(w, r) {
+			return
+		}
+		if r.Method != http.MethodGet {
+			httpError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		s.usageReport(w)
+	})
+	mux.HandleFunc("/v1/admin/reload", func(w http.ResponseWriter, r *http.Request) {
+		if !s.authenticateAdmin(w, r) {
+			return
+		}
+		if r.Method != http.MethodPost {
+			httpError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		if s.opts.Reload == nil {
+			httpError(w, http.StatusNotFound, "reload is not supported")
+			return
+		}
+		if err := s.opts.Reload(); err != nil {
+			httpError(w, http.StatusInternalServerError, "reload failed: "+err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "reloaded"})
+	})
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return mux
+}
+
+func (s *Server) submit(w http.ResponseWriter, r *http.Request, tenant *Tenant) {
+	var req Request
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.opts.MaxBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		httpError(w, http.StatusBadRequest, "invalid job: "+err.Error())
+		return
+	}
+	view, err := s.submitJob(tenant, req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Location", "/v1/jobs/"+view.ID)
+	writeJSON(w, http.StatusAccepted, view)
+}
+
+// opError is a failed job operation with the HTTP status it maps to.
+type opError struct {
+	status int
+	msg    string
+}
+
+func (e *opError) Error() string { return e.msg }
+
+// submitJob validates and queues a job of tenant, which is nil without tenants.
+func (s *Server) submitJob(tenant *Tenant, req Request) (Job, error) {
+	if err := req.Validate(); err != nil {
+		return Job{}, &opError{http.StatusBadRequest, "invalid job: " + err.Error()}
+	}
+	if req.Profile != "" && len(s.opts.Profiles) > 0 && !slices.Contains(s.opts.Profiles, req.Profile) {
+		return Job{}, &opError{http.StatusBadRequest, fmt.Sprintf("invalid job: profile %q is not allowed", req.Profile)}
+	}
+	if req.Model != "" && !slices.Contains(s.opts.Models, req.Model) {
+		return Job{}, &opError{http.StatusBadRequest, fmt.Sprintf("invalid job: model %q is not allowed", req.Model)}
+	}
+	if req.Format == "" {
+		req.Format = "json"
+	}
+
+	job := &Job{
+		ID:          newJobID(),
+		Status:      Queued,
+		SubmittedAt: time.Now().UTC(),
+		FilesTotal:  len(req.Files),
+		Format:      req.Format,
+		CallbackURL: req.CallbackURL,
+		request:     req,
+	}
+	s.mu.Lock()
+	s.prune()
+	if tenant != nil {
+		job.Tenant = tenant.Name
+		u := s.tenantUsage(tenant.Name)
+		refused := u.overBudget(tenant)
+		if refused == "" && tenant.MaxQueuedJobs > 0 && u.active >= tenant.MaxQueuedJobs {
+			refused = fmt.Sprintf("tenant %s already has %d queued or running jobs", tenant.Name, u.active)
+		}
+		if refused != "" {
+			s.mu.Unlock()
+			return Job{}, &opError{http.StatusTooManyRequests, refused}
+		}
+	}
+	select {
+	case s.queue <- job:
+		s.jobs[job.ID] = job
+		if tenant != nil {
+			s.tenantUsage(tenant.Name).active++
+		}
+		s.saveNew(job)
+	default:
+		s.mu.Unlock()
+		return Job{}, &opError{http.StatusServiceUnavailable, "job queue is full"}
+	}
+	view := *job
+	s.mu.Unlock()
+	return view, nil
+}
+
+func (s *Server) list(w http.ResponseWriter, tenant *Tenant) {
+	s.mu.Lock()
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		if !owns(tenant, j) {
+			continue
+		}
+		view := *j
+		view.Report = nil
+		jobs = append(jobs, view)
+	}
+	s.mu.Unlock()
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].SubmittedAt.Before(jobs[j].SubmittedAt) })
+	writeJSON(w, http.StatusOK, map[string][]Job{"jobs": jobs})
+}
+
+func (s *Server) get(w http.ResponseWriter, id string, tenant *Tenant) {
+	view, err := s.job(id, tenant)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, view)
+}
+
+// job returns the job id of tenant, which is nil without tenants.
+func (s *Server) job(id string, tenant *Tenant) (Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok || !owns(tenant, job) {
+		return Job{}, &opError{http.StatusNotFound, "job not found"}
+	}
+	return *job, nil
+}
+
+// watchFindings sends the findings of the job id of tenant file by file, those of
+// files reviewed before included, until the job finishes or ctx ends.
+func (s *Server) watchFindings(ctx context.Context, id string, tenant *Tenant, send func(FileFindings) error) error {
+	sent := 0
+	for {
+		s.mu.Lock()
+		job, ok := s.jobs[id]
+		if !ok || !owns(tenant, job) {
+			s.mu.Unlock()
+			return &opError{http.StatusNotFound, "job not found"}
+		}
+		pending := job.findings[sent:]
+		finished := job.FinishedAt != nil
+		changed := job.watch()
+		s.mu.Unlock()
+
+		for _, ff := range pending {
+			if err := send(ff); err != nil {
+				return err
+			}
+		}
+		sent += len(pending)
+		if finished {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// authenticate returns the tenant of the request's bearer token, answering 401 when
+// tenants are set up and the token matches none. Without tenants it returns nil.
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request) (*Tenant, bool) {
+	t, err := s.tenantOf(bearerToken(r))
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, err)
+		return nil, false
+	}
+	return t, true
+}
+
+// tenantOf returns the tenant owning token, or nil without tenants.
+func (s *Server) tenantOf(token string) (*Tenant, error) {
+	tenants := s.tenants()
+	if tenants == nil {
+		return nil, nil
+	}
+	t := tenants.find(token)
+	if t == nil {
+		return nil, &opError{http.StatusUnauthorized, "invalid or missing token"}
+	}
+	return t, nil
+}
+
+// authenticateAdmin checks the admin token of the request, answering 401 when it does
+// not match. Without tenants the admin endpoints are as open as the others.
+func (s *Server) authenticateAdmin(w http.ResponseWriter, r *http.Request) bool {
+	tenants := s.tenants()
+	if tenants == nil || tokenEqual(tenants.AdminToken, bearerToken(r)) {
+		return true
+	}
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	httpError(w, http.StatusUnauthorized, "invalid admin token")
+	return false
+}
+
+func (s *Server) tenants() *Tenants {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.opts.Tenants
+}
+
+// SetTenants replaces the tenants, for instance after their file changed. Queued jobs
+// of removed tenants still run.
+func (s *Server) SetTenants(t *Tenants) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.opts.Tenants = t
+}
+
+// owns reports whether the job is visible to tenant; without tenants all jobs are.
+func owns(tenant *Tenant, job *Job) bool {
+	return tenant == nil || job.Tenant == tenant.Name
+}
+
+// tenant returns the tenant named name, or nil. The caller holds s.mu.
+func (s *Server) tenant(name string) *Tenant {
+	if s.opts.Tenants == nil || name == "" {
+		return nil
+	}
+	for i := range s.opts.Tenants.Tenants {
+		if t := &s.opts.Tenants.Tenants[i]; t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// tenantUsage returns the usage of the tenant named name. The caller holds s.mu.
+func (s *Server) tenantUsage(name string) *usage {
+	u := s.usage[name]
+	if u == nil {
+		u = &usage{}
+		s.usage[name] = u
+	}
+	return u
+}
+
+func (s *Server) usageReport(w http.ResponseWriter) {
+	s.mu.Lock()
+	tenants := []TenantUsage{}
+	if s.opts.Tenants != nil {
+		for i := range s.opts.Tenants.Tenants {
+			t := &s.opts.Tenants.Tenants[i]
+			tenants = append(tenants, s.tenantUsage(t.Name).report(t))
+		}
+	}
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string][]TenantUsage{"tenants": tenants})
+}
+
+// prune forgets jobs finished longer than the retention ago. The caller holds s.mu.
+func (s *Server) prune() {
+	cutoff := time.Now().Add(-s.opts.Retention)
+	for id, j := range s.jobs {
+		if j.FinishedAt != nil && j.FinishedAt.Before(cutoff) {
+			delete(s.jobs, id)
+			if s.store != nil {
+				s.writer.remove(id)
+			}
+		}
+	}
+}
+
+func newJobID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return "job-" + hex.EncodeToString(b)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func httpError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var op *opError
+	if errors.As(err, &op) {
+		status = op.status
+	}
+	httpError(w, status, err.Error())
+}
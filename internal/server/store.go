@@ -0,0 +1,327 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// usageFile is the file of a directory store holding the tenants' usage.
+const usageFile = "usage.json"
+
+// sourcesSuffix ends the names of the files of a directory store holding the sources
+// of unfinished jobs.
+const sourcesSuffix = ".sources.json"
+
+// jobStore keeps jobs and the tenants' usage across restarts. The sources of a job are
+// kept apart from its record: they are written once when the job is queued and dropped
+// when it finishes, while the record is written again at every change.
+type jobStore interface {
+	saveJob(rec storedJob) error
+	saveSources(id string, req Request) error
+	dropSources(id string) error
+	// remove drops the record of a job and its sources.
+	remove(id string) error
+	// load returns the stored jobs, with the sources of the unfinished ones.
+	load() ([]storedJob, error)
+	saveUsage(days map[string][]DayUsage) error
+	loadUsage() (map[string][]DayUsage, error)
+	close() error
+}
+
+// storedJob is the record of a job in the store. Request is only set by load, for
+// unfinished jobs whose sources were kept, so they can run again.
+type storedJob struct {
+	Job
+	Findings []FileFindings `json:"findings,omitempty"`
+	Request  *Request       `json:"-"`
+}
+
+// openStore opens the store named by spec: a PostgreSQL URL (postgres://...), a SQLite
+// database (sqlite:<file>) or else a directory of JSON files.
+func openStore(spec string) (jobStore, error) {
+	switch {
+	case strings.HasPrefix(spec, "postgres://") || strings.HasPrefix(spec, "postgresql://"):
+		return openSQLStore("postgres", spec)
+	case strings.HasPrefix(spec, "sqlite:"):
+		return openSQLStore("sqlite", strings.TrimPrefix(spec, "sqlite:"))
+	}
+	return &dirStore{dir: spec}, nil
+}
+
+// dirStore keeps each job as a JSON file in a directory, next to a file with its
+// sources while it is unfinished.
+type dirStore struct {
+	dir string
+}
+
+func (st *dirStore) saveJob(rec storedJob) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode job %s: %w", rec.ID, err)
+	}
+	return st.write(rec.ID+".json", data)
+}
+
+func (st *dirStore) saveSources(id string, req Request) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to encode the sources of job %s: %w", id, err)
+	}
+	return st.write(id+sourcesSuffix, data)
+}
+
+func (st *dirStore) dropSources(id string) error {
+	if err := os.Remove(filepath.Join(st.dir, id+sourcesSuffix)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove the sources of job %s: %w", id, err)
+	}
+	return nil
+}
+
+func (st *dirStore) remove(id string) error {
+	if err := os.Remove(filepath.Join(st.dir, id+".json")); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove job %s: %w", id, err)
+	}
+	return st.dropSources(id)
+}
+
+// load returns the stored jobs. A missing directory yields no jobs.
+func (st *dirStore) load() ([]storedJob, error) {
+	entries, err := os.ReadDir(st.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job store: %w", err)
+	}
+	var jobs []storedJob
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, "job-") || filepath.Ext(name) != ".json" || strings.HasSuffix(name, sourcesSuffix) {
+			continue
+		}
+		var rec storedJob
+		if err := st.read(name, &rec); err != nil {
+			return nil, err
+		}
+		if rec.FinishedAt == nil {
+			var req Request
+			err := st.read(rec.ID+sourcesSuffix, &req)
+			switch {
+			case err == nil:
+				rec.Request = &req
+			case !errors.Is(err, os.ErrNotExist):
+				return nil, err
+			}
+		}
+		jobs = append(jobs, rec)
+	}
+	return jobs, nil
+}
+
+func (st *dirStore) saveUsage(days map[string][]DayUsage) error {
+	data, err := json.Marshal(days)
+	if err != nil {
+		return fmt.Errorf("failed to encode usage: %w", err)
+	}
+	return st.write(usageFile, data)
+}
+
+// loadUsage returns the stored days of usage by tenant.
+func (st *dirStore) loadUsage() (map[string][]DayUsage, error) {
+	var days map[string][]DayUsage
+	if err := st.read(usageFile, &days); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return days, nil
+}
+
+func (st *dirStore) close() error { return nil }
+
+// read decodes the file name of the store into v. A missing file yields an error
+// matching os.ErrNotExist.
+func (st *dirStore) read(name string, v any) error {
+	data, err := os.ReadFile(filepath.Join(st.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read job store: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s of the job store: %w", name, err)
+	}
+	return nil
+}
+
+// write replaces name atomically, so a crash never leaves a truncated file.
+func (st *dirStore) write(name string, data []byte) error {
+	if err := os.MkdirAll(st.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create job store: %w", err)
+	}
+	tmp, err := os.CreateTemp(st.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(st.dir, name)); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// storeWrite is a pending write of a job: its latest record, or its removal.
+type storeWrite struct {
+	rec *storedJob
+	// sources are written before the record; drop removes them after it.
+	sources *Request
+	drop    bool
+	removed bool
+}
+
+// storeWriter writes to the store in the background, so the server's lock is never
+// held for encoding or I/O. The server queues snapshots taken under its lock; the
+// latest record of a job replaces an older one not yet written.
+type storeWriter struct {
+	store jobStore
+	logf  func(format string, args ...any)
+
+	mu      sync.Mutex
+	pending map[string]*storeWrite
+	order   []string
+	usage   map[string][]DayUsage
+	wake    chan struct{}
+	idle    *sync.Cond
+	busy    bool
+}
+
+func newStoreWriter(store jobStore, logf func(string, ...any)) *storeWriter {
+	w := &storeWriter{
+		store:   store,
+		logf:    logf,
+		pending: make(map[string]*storeWrite),
+		wake:    make(chan struct{}, 1),
+	}
+	w.idle = sync.NewCond(&w.mu)
+	go w.run()
+	return w
+}
+
+// job queues the record of a job, with its sources when they are to be written.
+func (w *storeWriter) job(rec storedJob, sources *Request) {
+	w.queue(rec.ID, func(p *storeWrite) {
+		p.rec, p.removed = &rec, false
+		if sources != nil {
+			p.sources, p.drop = sources, false
+		}
+	})
+}
+
+// finished queues the final record of a job and the removal of its sources.
+func (w *storeWriter) finished(rec storedJob) {
+	w.queue(rec.ID, func(p *storeWrite) {
+		p.rec, p.sources, p.drop = &rec, nil, true
+	})
+}
+
+// remove queues the removal of a job.
+func (w *storeWriter) remove(id string) {
+	w.queue(id, func(p *storeWrite) {
+		*p = storeWrite{removed: true}
+	})
+}
+
+// saveUsage queues the tenants' usage.
+func (w *storeWriter) saveUsage(days map[string][]DayUsage) {
+	w.mu.Lock()
+	w.usage = days
+	w.mu.Unlock()
+	w.signal()
+}
+
+func (w *storeWriter) queue(id string, fn func(*storeWrite)) {
+	w.mu.Lock()
+	p := w.pending[id]
+	if p == nil {
+		p = &storeWrite{}
+		w.pending[id] = p
+		w.order = append(w.order, id)
+	}
+	fn(p)
+	w.mu.Unlock()
+	w.signal()
+}
+
+func (w *storeWriter) signal() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (w *storeWriter) run() {
+	for range w.wake {
+		for {
+			w.mu.Lock()
+			pending, order, usage := w.pending, w.order, w.usage
+			if len(order) == 0 && usage == nil {
+				w.busy = false
+				w.idle.Broadcast()
+				w.mu.Unlock()
+				break
+			}
+			w.pending, w.order, w.usage = make(map[string]*storeWrite), nil, nil
+			w.busy = true
+			w.mu.Unlock()
+
+			for _, id := range order {
+				if err := w.write(id, pending[id]); err != nil {
+					w.logf("%v\n", err)
+				}
+			}
+			if usage != nil {
+				if err := w.store.saveUsage(usage); err != nil {
+					w.logf("%v\n", err)
+				}
+			}
+		}
+	}
+}
+
+func (w *storeWriter) write(id string, p *storeWrite) error {
+	if p.removed {
+		return w.store.remove(id)
+	}
+	if p.sources != nil {
+		if err := w.store.saveSources(id, *p.sources); err != nil {
+			return err
+		}
+	}
+	if err := w.store.saveJob(*p.rec); err != nil {
+		return err
+	}
+	if p.drop {
+		return w.store.dropSources(id)
+	}
+	return nil
+}
+
+// flush waits until the queued writes are done.
+func (w *storeWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for w.busy || len(w.order) > 0 || w.usage != nil {
+		w.idle.Wait()
+	}
+}
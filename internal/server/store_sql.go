@@ -0,0 +1,165 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+// sqlSchema creates the tables of a SQL store. Records, sources and usage are JSON
+// documents; the statements run on both PostgreSQL and SQLite.
+var sqlSchema = []string{
+	`CREATE TABLE IF NOT EXISTS jobs (id TEXT PRIMARY KEY, record TEXT NOT NULL)`,
+	`CREATE TABLE IF NOT EXISTS job_sources (id TEXT PRIMARY KEY, request TEXT NOT NULL)`,
+	`CREATE TABLE IF NOT EXISTS tenant_usage (tenant TEXT PRIMARY KEY, days TEXT NOT NULL)`,
+}
+
+// sqlStore keeps jobs in a PostgreSQL or SQLite database.
+type sqlStore struct {
+	db *sql.DB
+}
+
+func openSQLStore(driver, dsn string) (*sqlStore, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open job store: %w", err)
+	}
+	if driver == "sqlite" {
+		// SQLite allows one writer; a single connection avoids "database is locked"
+		db.SetMaxOpenConns(1)
+	}
+	for _, stmt := range sqlSchema {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to set up job store: %w", err)
+		}
+	}
+	return &sqlStore{db: db}, nil
+}
+
+func (st *sqlStore) saveJob(rec storedJob) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode job %s: %w", rec.ID, err)
+	}
+	_, err = st.db.Exec(`INSERT INTO jobs (id, record) VALUES ($1, $2)
+		ON CONFLICT (id) DO UPDATE SET record = excluded.record`, rec.ID, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to store job %s: %w", rec.ID, err)
+	}
+	return nil
+}
+
+func (st *sqlStore) saveSources(id string, req Request) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to encode the sources of job %s: %w", id, err)
+	}
+	_, err = st.db.Exec(`INSERT INTO job_sources (id, request) VALUES ($1, $2)
+		ON CONFLICT (id) DO UPDATE SET request = excluded.request`, id, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to store the sources of job %s: %w", id, err)
+	}
+	return nil
+}
+
+func (st *sqlStore) dropSources(id string) error {
+	if _, err := st.db.Exec(`DELETE FROM job_sources WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to remove the sources of job %s: %w", id, err)
+	}
+	return nil
+}
+
+func (st *sqlStore) remove(id string) error {
+	if _, err := st.db.Exec(`DELETE FROM jobs WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to remove job %s: %w", id, err)
+	}
+	return st.dropSources(id)
+}
+
+func (st *sqlStore) load() ([]storedJob, error) {
+	rows, err := st.db.Query(`SELECT j.record, s.request FROM jobs j
+		LEFT JOIN job_sources s ON s.id = j.id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job store: %w", err)
+	}
+	defer rows.Close()
+	var jobs []storedJob
+	for rows.Next() {
+		var record string
+		var request sql.NullString
+		if err := rows.Scan(&record, &request); err != nil {
+			return nil, fmt.Errorf("failed to read job store: %w", err)
+		}
+		var rec storedJob
+		if err := json.Unmarshal([]byte(record), &rec); err != nil {
+			return nil, fmt.Errorf("failed to parse stored job: %w", err)
+		}
+		if request.Valid && rec.FinishedAt == nil {
+			var req Request
+			if err := json.Unmarshal([]byte(request.String), &req); err != nil {
+				return nil, fmt.Errorf("failed to parse the sources of job %s: %w", rec.ID, err)
+			}
+			rec.Request = &req
+		}
+		jobs = append(jobs, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read job store: %w", err)
+	}
+	return jobs, nil
+}
+
+func (st *sqlStore) saveUsage(days map[string][]DayUsage) error {
+	tx, err := st.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to store usage: %w", err)
+	}
+	defer tx.Rollback()
+	for tenant, list := range days {
+		data, err := json.Marshal(list)
+		if err != nil {
+			return fmt.Errorf("failed to encode usage: %w", err)
+		}
+		_, err = tx.Exec(`INSERT INTO tenant_usage (tenant, days) VALUES ($1, $2)
+			ON CONFLICT (tenant) DO UPDATE SET days = excluded.days`, tenant, string(data))
+		if err != nil {
+			return fmt.Errorf("failed to store usage: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to store usage: %w", err)
+	}
+	return nil
+}
+
+func (st *sqlStore) loadUsage() (map[string][]DayUsage, error) {
+	rows, err := st.db.Query(`SELECT tenant, days FROM tenant_usage`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage: %w", err)
+	}
+	defer rows.Close()
+	days := make(map[string][]DayUsage)
+	for rows.Next() {
+		var tenant, data string
+		if err := rows.Scan(&tenant, &data); err != nil {
+			return nil, fmt.Errorf("failed to read usage: %w", err)
+		}
+		var list []DayUsage
+		if err := json.Unmarshal([]byte(data), &list); err != nil {
+			return nil, fmt.Errorf("failed to parse usage of tenant %s: %w", tenant, err)
+		}
+		days[tenant] = list
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read usage: %w", err)
+	}
+	return days, nil
+}
+
+func (st *sqlStore) close() error {
+	return st.db.Close()
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// usageDays is how many days of usage the server keeps per tenant.
+const usageDays = 30
+
+// Tenant is a client of the server with its own token and limits.
+type Tenant struct {
+	Name  string `yaml:"name"`
+	Token string `yaml:"token"`
+	// MaxQueuedJobs bounds the tenant's queued and running jobs; 0 means no limit. It is
+	// a quota on the shared queue, not a concurrency limit: the server runs one job at a
+	// time whatever the tenant.
+	MaxQueuedJobs int `yaml:"max_queued_jobs,omitempty"`
+	// DailyTokens bounds the prompt and completion tokens the tenant's jobs may use per
+	// UTC day; 0 means no limit. A job that starts under the budget runs to completion.
+	DailyTokens int `yaml:"daily_tokens,omitempty"`
+}
+
+// Tenants is the tenants file of the server.
+type Tenants struct {
+	// AdminToken grants access to the admin endpoints.
+	AdminToken string   `yaml:"admin_token,omitempty"`
+	Tenants    []Tenant `yaml:"tenants"`
+}
+
+// LoadTenants parses a tenants file. Tokens written as "$NAME" are read from the
+// environment variable NAME.
+func LoadTenants(path string) (*Tenants, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tenants file: %w", err)
+	}
+	defer f.Close()
+	var t Tenants
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(&t); err != nil {
+		return nil, fmt.Errorf("failed to parse tenants file %s: %w", path, err)
+	}
+	t.AdminToken = expandToken(t.AdminToken)
+	for i := range t.Tenants {
+		t.Tenants[i].Token = expandToken(t.Tenants[i].Token)
+	}
+	if err := t.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid tenants file %s: %w", path, err)
+	}
+	return &t, nil
+}
+
+func expandToken(token string) string {
+	if name, ok := strings.CutPrefix(token, "$"); ok {
+		return os.Getenv(name)
+	}
+	return token
+}
+
+// Validate checks that tenants have unique names and tokens and non-negative limits.
+func (t *Tenants) Validate() error {
+	if len(t.Tenants) == 0 {
+		return errors.New("no tenants")
+	}
+	names := make(map[string]bool)
+	tokens := map[string]bool{t.AdminToken: t.AdminToken != ""}
+	for _, tn := range t.Tenants {
+		switch {
+		case tn.Name == "":
+			return errors.New("tenant without name")
+		case names[tn.Name]:
+			return fmt.Errorf("duplicate tenant %s", tn.Name)
+		case tn.Token == "":
+			return fmt.Errorf("tenant %s has no token", tn.Name)
+		case tokens[tn.Token]:
+			return fmt.Errorf("tenant %s reuses another token", tn.Name)
+		case tn.MaxQueuedJobs < 0 || tn.DailyTokens < 0:
+			return fmt.Errorf("tenant %s has negative limits", tn.Name)
+		}
+		names[tn.Name], tokens[tn.Token] = true, true
+	}
+	return nil
+}
+
+// find returns the tenant owning token, or nil.
+func (t *Tenants) find(token string) *Tenant {
+	for i := range t.Tenants {
+		if tokenEqual(t.Tenants[i].Token, token) {
+			return &t.Tenants[i]
+		}
+	}
+	return nil
+}
+
+func tokenEqual(want, got string) bool {
+	return want != "" && subtle.ConstantTimeCompare([]byte(want), []byte(got)) == 1
+}
+
+// bearerToken returns the token of the Authorization header.
+func bearerToken(r *http.Request) string {
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return token
+}
+
+// DayUsage is a tenant's usage on one UTC day.
+type DayUsage struct {
+	Date   string `json:"date"`
+	Jobs   int    `json:"jobs"`
+	Failed int    `json:"failed"`
+	Files  int    `json:"files"`
+	Tokens int    `json:"tokens"`
+}
+
+// TenantUsage is the usage of a tenant as reported by the admin endpoint.
+type TenantUsage struct {
+	Name          string     `json:"name"`
+	ActiveJobs    int        `json:"active_jobs"`
+	MaxQueuedJobs int        `json:"max_queued_jobs,omitempty"`
+	DailyTokens   int        `json:"daily_tokens,omitempty"`
+	Days          []DayUsage `json:"days"`
+}
+
+// usage accounts the jobs of one tenant. It is guarded by the server's mutex.
+type usage struct {
+	active int
+	days   map[string]*DayUsage
+}
+
+func today() string {
+	return time.Now().UTC().Format(time.DateOnly)
+}
+
+// day returns the usage of date, creating it and forgetting days beyond usageDays.
+func (u *usage) day(date string) *DayUsage {
+	if u.days == nil {
+		u.days = make(map[string]*DayUsage)
+	}
+	d := u.days[date]
+	if d == nil {
+		d = &DayUsage{Date: date}
+		u.days[date] = d
+		cutoff := time.Now().UTC().AddDate(0, 0, -usageDays).Format(time.DateOnly)
+		for k := range u.days {
+			if k < cutoff {
+				delete(u.days, k)
+			}
+		}
+	}
+	return d
+}
+
+// overBudget reports why the tenant may not start another job, or "".
+func (u *usage) overBudget(t *Tenant) string {
+	if t.DailyTokens > 0 {
+		if used := u.day(today()).Tokens; used >= t.DailyTokens {
+			return fmt.Sprintf("daily token budget of tenant %s exhausted (%d of %d)", t.Name, used, t.DailyTokens)
+		}
+	}
+	return ""
+}
+
+func (u *usage) report(t *Tenant) TenantUsage {
+	out := TenantUsage{Name: t.Name, ActiveJobs: u.active, MaxQueuedJobs: t.MaxQueuedJobs, DailyTokens: t.DailyTokens, Days: []DayUsage{}}
+	for _, d := range u.days {
+		out.Days = append(out.Days, *d)
+	}
+	sort.Slice(out.Days, func(i, j int) bool { return out.Days[i].Date > out.Days[j].Date })
+	return out
+}
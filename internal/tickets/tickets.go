@@ -0,0 +1,150 @@
+package tickets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/disconnekt/goreview/internal/findings"
+)
+
+// LabelPrefix starts the label that ties a ticket to a finding fingerprint, for
+// trackers that identify tickets by label.
+const LabelPrefix = "aireview-"
+
+var markerPattern = regexp.MustCompile(`<!-- goreview:ticket fp=([0-9a-f]+) -->`)
+
+// Marker returns the hidden marker that ties a Markdown ticket body to a finding, for
+// trackers that identify tickets by body.
+func Marker(fingerprint string) string {
+	return fmt.Sprintf("<!-- goreview:ticket fp=%s -->", fingerprint)
+}
+
+// ParseMarkers returns the fingerprints marked in a ticket body; consolidated tickets
+// carry several.
+func ParseMarkers(body string) []string {
+	var fps []string
+	for _, m := range markerPattern.FindAllStringSubmatch(body, -1) {
+		fps = append(fps, m[1])
+	}
+	return fps
+}
+
+// excerptContext is the number of lines shown around the finding's line.
+const excerptContext = 5
+
+// Ticket is an issue goreview wants to exist in the tracker for a finding.
+type Ticket struct {
+	Finding findings.Finding
+	// Excerpt holds the numbered code lines around the finding, if available.
+	Excerpt string
+}
+
+// Label returns the label identifying the ticket of the finding, so reruns find it.
+func (t Ticket) Label() string {
+	return LabelPrefix + t.Finding.Fingerprint()
+}
+
+// Title returns a one-line summary of the finding for the ticket title.
+func (t Ticket) Title() string {
+	msg := strings.Join(strings.Fields(t.Finding.Message), " ")
+	if len(msg) > 100 {
+		msg = strings.ToValidUTF8(msg[:100], "") + "..."
+	}
+	return fmt.Sprintf("[%s] %s: %s", strings.ToUpper(string(t.Finding.Severity)), t.Finding.Location(), msg)
+}
+
+// Tracker abstracts the issue tracker tickets are filed in.
+type Tracker interface {
+	// Existing returns the fingerprints, out of the given ones, that have a ticket.
+	Existing(ctx context.Context, fingerprints []string) (map[string]bool, error)
+	// Create files the ticket and returns its key or URL.
+	Create(ctx context.Context, t Ticket) (string, error)
+}
+
+// Consolidator is implemented by trackers that can file several findings as one ticket.
+type Consolidator interface {
+	CreateConsolidated(ctx context.Context, ts []Ticket) (string, error)
+}
+
+// Stats summarizes a Sync.
+type Stats struct {
+	// Created holds the keys or URLs of the new tickets.
+	Created []string
+	// Filed counts the findings the new tickets cover.
+	Filed    int
+	Existing int
+}
+
+// Sync files a ticket for every finding that does not have one yet, or a single
+// ticket for all of them when consolidate is set.
+func Sync(ctx context.Context, tracker Tracker, want []Ticket, consolidate bool) (Stats, error) {
+	var stats Stats
+	fps := make([]string, len(want))
+	for i, t := range want {
+		fps[i] = t.Finding.Fingerprint()
+	}
+	existing, err := tracker.Existing(ctx, fps)
+	if err != nil {
+		return stats, err
+	}
+	var fresh []Ticket
+	for _, t := range want {
+		fp := t.Finding.Fingerprint()
+		if existing[fp] {
+			stats.Existing++
+			continue
+		}
+		// Guard against the same finding twice in one run
+		existing[fp] = true
+		fresh = append(fresh, t)
+	}
+	if len(fresh) == 0 {
+		return stats, nil
+	}
+
+	if consolidate {
+		c, ok := tracker.(Consolidator)
+		if !ok {
+			return stats, errors.New("the tracker does not support consolidated tickets")
+		}
+		key, err := c.CreateConsolidated(ctx, fresh)
+		if err != nil {
+			return stats, err
+		}
+		stats.Created = append(stats.Created, key)
+		stats.Filed = len(fresh)
+		return stats, nil
+	}
+	for _, t := range fresh {
+		key, err := tracker.Create(ctx, t)
+		if err != nil {
+			return stats, err
+		}
+		stats.Created = append(stats.Created, key)
+		stats.Filed++
+	}
+	return stats, nil
+}
+
+// Excerpt returns the numbered lines of content around line, marking the line itself.
+func Excerpt(content string, line int) string {
+	if line <= 0 {
+		return ""
+	}
+	lines := strings.Split(content, "\n")
+	if line > len(lines) {
+		return ""
+	}
+	var b strings.Builder
+	for n := max(line-excerptContext, 1); n <= min(line+excerptContext, len(lines)); n++ {
+		marker := " "
+		if n == line {
+			marker = ">"
+		}
+		fmt.Fprintf(&b, "%s %4d| %s\n", marker, n, lines[n-1])
+	}
+	return b.String()
+}
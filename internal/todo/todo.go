@@ -0,0 +1,93 @@
+// Package todo finds TODO, FIXME, HACK and XXX comments in source files.
+package todo
+
+import (
+	goscanner "go/scanner"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// tagPattern matches a TODO-style marker at the start of comment text, with an optional
+// "(owner)" and colon.
+var tagPattern = regexp.MustCompile(`^\s*(TODO|FIXME|HACK|XXX)\b(?:\([^)]*\))?:?\s*(.*)$`)
+
+// linePattern finds comments in the lines of files other than Go: a comment marker
+// starting the line, or "//" or "#" after code and a space.
+var linePattern = regexp.MustCompile(`^\s*(?://|#|/\*|<!--|--|;|\*)|\s(?://|#)`)
+
+// Todo is a TODO, FIXME, HACK or XXX comment.
+type Todo struct {
+	// File is set when TODOs of several files are listed together.
+	File string `json:"file,omitempty"`
+	Line int    `json:"line"`
+	Tag  string `json:"tag"`
+	Text string `json:"text"`
+	// Priority and Assessment are the model's judgment when TODOs are triaged; Priority
+	// is a severity name.
+	Priority   string `json:"priority,omitempty"`
+	Assessment string `json:"assessment,omitempty"`
+}
+
+// Find returns the TODO-style comments of the source of the file at path, in line
+// order. Go files are tokenized, so markers in strings are not taken for comments;
+// other files are read line by line.
+func Find(path, src string) []Todo {
+	if filepath.Ext(path) == ".go" {
+		return findGo(src)
+	}
+	var todos []Todo
+	for i, line := range strings.Split(src, "\n") {
+		loc := linePattern.FindStringIndex(line)
+		if loc == nil {
+			continue
+		}
+		if t, ok := parse(line[loc[1]:], i+1); ok {
+			todos = append(todos, t)
+		}
+	}
+	return todos
+}
+
+// findGo returns the TODOs starting a line of the comments of Go source.
+func findGo(src string) []Todo {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", -1, len(src))
+	var s goscanner.Scanner
+	s.Init(file, []byte(src), nil, goscanner.ScanComments)
+	var todos []Todo
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok != token.COMMENT {
+			continue
+		}
+		first := file.Line(pos)
+		if text, ok := strings.CutPrefix(lit, "//"); ok {
+			if t, ok := parse(text, first); ok {
+				todos = append(todos, t)
+			}
+			continue
+		}
+		for i, line := range strings.Split(strings.TrimPrefix(lit, "/*"), "\n") {
+			line = strings.TrimPrefix(strings.TrimSpace(line), "*")
+			if t, ok := parse(line, first+i); ok {
+				todos = append(todos, t)
+			}
+		}
+	}
+	return todos
+}
+
+// parse reads a TODO from the text following a comment marker.
+func parse(text string, line int) (Todo, bool) {
+	m := tagPattern.FindStringSubmatch(text)
+	if m == nil {
+		return Todo{}, false
+	}
+	body := strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(strings.TrimSpace(m[2]), "*/"), "-->"))
+	return Todo{Line: line, Tag: m[1], Text: strings.TrimSpace(body)}, true
+}
@@ -0,0 +1,156 @@
+package transcript
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/disconnekt/goreview/internal/findings"
+	"github.com/disconnekt/goreview/internal/reviewer"
+)
+
+// FileName is the name of the transcript file inside the state directory.
+const FileName = "threads.json"
+
+// Thread is the follow-up conversation about one finding.
+type Thread struct {
+	Fingerprint string             `json:"fingerprint"`
+	Finding     findings.Finding   `json:"finding"`
+	Model       string             `json:"model"`
+	Messages    []reviewer.Message `json:"messages"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+// Store holds the conversations of all findings, persisted as JSON.
+type Store struct {
+	path    string
+	threads map[string]Thread
+}
+
+type fileLayout struct {
+	Threads []Thread `json:"threads"`
+}
+
+// Load reads the transcripts at path. A missing file yields an empty store.
+func Load(path string) (*Store, error) {
+	s := &Store{path: path, threads: make(map[string]Thread)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transcripts: %w", err)
+	}
+	var layout fileLayout
+	if err := json.Unmarshal(data, &layout); err != nil {
+		return nil, fmt.Errorf("failed to parse transcripts %s: %w", path, err)
+	}
+	for _, t := range layout.Threads {
+		s.threads[t.Fingerprint] = t
+	}
+	return s, nil
+}
+
+// Save writes the store back to disk.
+func (s *Store) Save() error {
+	data, err := json.MarshalIndent(fileLayout{Threads: s.All()}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode transcripts: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create transcript directory: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write transcripts: %w", err)
+	}
+	return nil
+}
+
+// Record stores the conversation about the finding, replacing the earlier one.
+func (s *Store) Record(f findings.Finding, model string, messages []reviewer.Message) {
+	s.threads[f.Fingerprint()] = Thread{
+		Fingerprint: f.Fingerprint(),
+		Finding:     f,
+		Model:       model,
+		Messages:    messages,
+		UpdatedAt:   time.Now().UTC(),
+	}
+}
+
+// Messages returns the conversation about the finding so far, if any.
+func (s *Store) Messages(f findings.Finding) []reviewer.Message {
+	return s.threads[f.Fingerprint()].Messages
+}
+
+// All returns every conversation, ordered by file and line.
+func (s *Store) All() []Thread {
+	threads := make([]Thread, 0, len(s.threads))
+	for _, t := range s.threads {
+		threads = append(threads, t)
+	}
+	sort.Slice(threads, func(i, j int) bool {
+		a, b := threads[i].Finding, threads[j].Finding
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return threads[i].Fingerprint < threads[j].Fingerprint
+	})
+	return threads
+}
+
+// Select returns the conversations whose fingerprint starts with, or whose location
+// (file or file:line) equals, one of the given selectors.
+func (s *Store) Select(selectors []string) []Thread {
+	var selected []Thread
+	for _, t := range s.All() {
+		for _, sel := range selectors {
+			if strings.HasPrefix(t.Fingerprint, sel) || t.Finding.File == sel || t.Finding.Location() == sel {
+				selected = append(selected, t)
+				break
+			}
+		}
+	}
+	return selected
+}
+
+// WriteMarkdown renders the conversations as Markdown, one section per finding.
+func WriteMarkdown(w io.Writer, threads []Thread) error {
+	var b strings.Builder
+	for i, t := range threads {
+		if i > 0 {
+			b.WriteString("\n---\n\n")
+		}
+		f := t.Finding
+		fmt.Fprintf(&b, "## [%s] %s\n\n", strings.ToUpper(string(f.Severity)), f.Location())
+		if f.RuleID != "" {
+			fmt.Fprintf(&b, "Rule: `%s`  \n", f.RuleID)
+		}
+		fmt.Fprintf(&b, "Fingerprint: `%s`  \nModel: %s  \nUpdated: %s\n\n> %s\n",
+			t.Fingerprint, t.Model, t.UpdatedAt.Format(time.RFC3339), f.Message)
+		for _, m := range t.Messages {
+			speaker := "Question"
+			if m.Role == "assistant" {
+				speaker = "Answer"
+			}
+			fmt.Fprintf(&b, "\n**%s:**\n\n%s\n", speaker, strings.TrimSpace(m.Content))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON renders the conversations as an indented JSON array.
+func WriteJSON(w io.Writer, threads []Thread) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(threads)
+}
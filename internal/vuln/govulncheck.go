@@ -0,0 +1,125 @@
+// Package vuln reads govulncheck results and maps reachable vulnerabilities to the
+// source files that call them.
+package vuln
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Report is the parsed output of "govulncheck -json".
+type Report struct {
+	// Entries maps OSV IDs to their advisories.
+	Entries  map[string]Entry
+	Findings []Finding
+}
+
+// Entry is the part of an OSV advisory used in prompts.
+type Entry struct {
+	ID      string   `json:"id"`
+	Aliases []string `json:"aliases"`
+	Summary string   `json:"summary"`
+	Details string   `json:"details"`
+}
+
+// Finding is one vulnerability govulncheck found. Trace runs from the vulnerable
+// symbol to the entry point in the scanned module.
+type Finding struct {
+	OSV          string  `json:"osv"`
+	FixedVersion string  `json:"fixed_version"`
+	Trace        []Frame `json:"trace"`
+}
+
+// Frame is one step of a call trace.
+type Frame struct {
+	Module   string    `json:"module"`
+	Version  string    `json:"version"`
+	Package  string    `json:"package"`
+	Function string    `json:"function"`
+	Receiver string    `json:"receiver"`
+	Position *Position `json:"position"`
+}
+
+// Position locates a frame in source code.
+type Position struct {
+	Filename string `json:"filename"`
+	Line     int    `json:"line"`
+}
+
+// Symbol renders the frame as pkg.Func or pkg.Recv.Method.
+func (f Frame) Symbol() string {
+	name := f.Function
+	if f.Receiver != "" {
+		name = strings.TrimPrefix(f.Receiver, "*") + "." + name
+	}
+	if name == "" {
+		return f.Package
+	}
+	return f.Package + "." + name
+}
+
+// Reachable reports whether the finding is at symbol level, i.e. the scanned code
+// calls the vulnerable function rather than only importing its package or module.
+func (f Finding) Reachable() bool {
+	return len(f.Trace) > 0 && f.Trace[0].Function != ""
+}
+
+// message is one value of the govulncheck JSON stream.
+type message struct {
+	OSV     *Entry   `json:"osv"`
+	Finding *Finding `json:"finding"`
+}
+
+// Parse reads the stream of JSON values written by "govulncheck -json".
+func Parse(r io.Reader) (*Report, error) {
+	rep := &Report{Entries: make(map[string]Entry)}
+	dec := json.NewDecoder(r)
+	for {
+		var m message
+		if err := dec.Decode(&m); errors.Is(err, io.EOF) {
+			return rep, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse govulncheck output: %w", err)
+		}
+		if m.OSV != nil {
+			rep.Entries[m.OSV.ID] = *m.OSV
+		}
+		if m.Finding != nil {
+			rep.Findings = append(rep.Findings, *m.Finding)
+		}
+	}
+}
+
+// Load parses a saved "govulncheck -json" report.
+func Load(path string) (*Report, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open govulncheck report: %w", err)
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Run executes "govulncheck -json ./..." in the module directory dir.
+func Run(ctx context.Context, dir string) (*Report, error) {
+	if _, err := exec.LookPath("govulncheck"); err != nil {
+		return nil, errors.New("govulncheck not found in PATH; install it with " +
+			"\"go install golang.org/x/vuln/cmd/govulncheck@latest\" or pass a saved report with --govulncheck")
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "govulncheck", "-json", "./...")
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("govulncheck failed in %s: %w: %s", dir, err, strings.TrimSpace(stderr.String()))
+	}
+	return Parse(&stdout)
+}